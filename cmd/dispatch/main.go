@@ -79,12 +79,7 @@ func main() {
 	b.SetupSubscriptions()
 
 	// Backlog scorer
-	backlogScorer := scoring.NewBacklogScorer(scoring.BacklogWeightSet{
-		BusinessImpact:      cfg.Scoring.BacklogWeights.BusinessImpact,
-		DependencyReadiness: cfg.Scoring.BacklogWeights.DependencyReadiness,
-		Urgency:             cfg.Scoring.BacklogWeights.Urgency,
-		CostEfficiency:      cfg.Scoring.BacklogWeights.CostEfficiency,
-	})
+	backlogScorer := scoring.NewConfiguredBacklogScorer(cfg.Scoring)
 
 	// API server
 	router := api.NewRouter(db, hermesClient, warrenClient, forgeClient, b, backlogScorer, cfg, cfg.Server.AdminToken, logger)
@@ -22,13 +22,34 @@ func NewAdminHandler(s store.Store, w warren.Client, f forge.Client, b *broker.B
 	return &AdminHandler{store: s, warren: w, forge: f, broker: b}
 }
 
+// StatsResponse is the task statistics plus per-group fair-share usage and
+// per-owner deadline attainment.
+type StatsResponse struct {
+	*store.TaskStats
+	FairShare []broker.ShareUsage `json:"fair_share"`
+	SLA       []*store.OwnerSLA   `json:"sla"`
+}
+
 func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.store.GetStats(r.Context())
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
-	writeJSON(w, http.StatusOK, stats)
+	usage, err := h.broker.FairShareUsage(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	sla, err := h.store.GetSLAStats(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if sla == nil {
+		sla = []*store.OwnerSLA{}
+	}
+	writeJSON(w, http.StatusOK, StatsResponse{TaskStats: stats, FairShare: usage, SLA: sla})
 }
 
 type AgentInfo struct {
@@ -37,6 +58,11 @@ type AgentInfo struct {
 	Capabilities []string `json:"capabilities,omitempty"`
 	ActiveTasks  int      `json:"active_tasks"`
 	Drained      bool     `json:"drained"`
+	// MaxConcurrent is the agent's resolved concurrency limit, or nil for
+	// agents Forge doesn't know. Zero means unlimited.
+	MaxConcurrent *int `json:"max_concurrent,omitempty"`
+	// Failures counts the agent's recorded attempts and failures by class.
+	Failures *store.AgentFailureStats `json:"failures,omitempty"`
 }
 
 func (h *AdminHandler) Agents(w http.ResponseWriter, r *http.Request) {
@@ -52,16 +78,25 @@ func (h *AdminHandler) Agents(w http.ResponseWriter, r *http.Request) {
 		capMap[p.Name] = p.Capabilities
 	}
 
+	limits, _ := h.broker.AgentLimits(r.Context())
+
 	var infos []AgentInfo
 	for _, a := range agents {
 		running, _ := h.store.GetActiveTasksForAgent(r.Context(), a.Name)
-		infos = append(infos, AgentInfo{
+		info := AgentInfo{
 			Name:         a.Name,
 			Status:       a.Status,
 			Capabilities: capMap[a.Name],
 			ActiveTasks:  len(running),
 			Drained:      h.broker.IsDrained(a.Name),
-		})
+		}
+		if n, ok := limits[a.Name]; ok {
+			info.MaxConcurrent = &n
+		}
+		if stats, err := h.store.GetAgentFailureStats(r.Context(), a.Name); err == nil && stats != nil && stats.Attempts > 0 {
+			info.Failures = stats
+		}
+		infos = append(infos, info)
 	}
 
 	writeJSON(w, http.StatusOK, infos)
@@ -1,10 +1,12 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
@@ -33,6 +35,52 @@ func TestStatsEndpoint_ReturnsStats(t *testing.T) {
 	}
 }
 
+func TestStatsEndpoint_ReportsFairShareUsage(t *testing.T) {
+	router, ms := setupTestRouter()
+	ctx := context.Background()
+	for _, spec := range []struct {
+		owner  string
+		status store.TaskStatus
+	}{
+		{"alice", store.StatusPending},
+		{"alice", store.StatusPending},
+		{"alice", store.StatusInProgress},
+		{"bob", store.StatusPending},
+		{"bob", store.StatusAssigned},
+		{"bob", store.StatusAssigned},
+		{"bob", store.StatusCompleted},
+	} {
+		_ = ms.CreateTask(ctx, &store.Task{Title: "t", Owner: spec.owner, Status: spec.status})
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/stats", nil)
+	req.Header.Set("X-Agent-ID", "test-agent")
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp StatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	if len(resp.FairShare) != 2 {
+		t.Fatalf("expected 2 owner groups, got %+v", resp.FairShare)
+	}
+	alice, bob := resp.FairShare[0], resp.FairShare[1]
+	if alice.Group != "alice" || alice.Pending != 2 || alice.InFlight != 1 {
+		t.Errorf("unexpected alice usage: %+v", alice)
+	}
+	if bob.Group != "bob" || bob.Pending != 1 || bob.InFlight != 2 {
+		t.Errorf("unexpected bob usage: %+v", bob)
+	}
+	if alice.Share != 0.5 || bob.Usage < 0.66 || bob.Usage > 0.67 {
+		t.Errorf("expected equal shares and bob using 2/3, got %+v", resp.FairShare)
+	}
+}
+
 func TestAgentsEndpoint_AggregatesInfo(t *testing.T) {
 	router, _ := setupTestRouter()
 
@@ -93,3 +141,34 @@ func TestDrainEndpoint(t *testing.T) {
 		t.Errorf("expected agent 'test-agent', got '%s'", resp["agent"])
 	}
 }
+
+func TestStatsEndpoint_ReportsSLAAttainment(t *testing.T) {
+	router, ms := setupTestRouter()
+	ctx := context.Background()
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	onTime, late := now.Add(-2*time.Hour), now.Add(-30*time.Minute)
+	for _, task := range []*store.Task{
+		{Owner: "alice", Status: store.StatusCompleted, Deadline: &past, CompletedAt: &onTime},
+		{Owner: "alice", Status: store.StatusCompleted, Deadline: &past, CompletedAt: &late},
+		{Owner: "alice", Status: store.StatusInProgress, Deadline: &past},
+		{Owner: "alice", Status: store.StatusPending, Deadline: &future}, // not yet due
+		{Owner: "alice", Status: store.StatusCompleted, CompletedAt: &late},
+	} {
+		task.Title = "t"
+		_ = ms.CreateTask(ctx, task)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/stats", ""))
+	var resp StatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	if len(resp.SLA) != 1 {
+		t.Fatalf("expected one owner, got %+v", resp.SLA)
+	}
+	if sla := resp.SLA[0]; sla.Owner != "alice" || sla.Met != 1 || sla.Missed != 2 {
+		t.Errorf("unexpected attainment: %+v", sla)
+	}
+}
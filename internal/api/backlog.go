@@ -2,8 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -11,6 +14,8 @@ import (
 
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hierarchy"
+	"github.com/MikeSquared-Agency/Dispatch/internal/orchestrator"
 	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
@@ -19,11 +24,12 @@ type BacklogHandler struct {
 	store        store.Store
 	hermes       hermes.Client
 	scorer       *scoring.BacklogScorer
+	orch         *orchestrator.Orchestrator
 	modelRouting config.ModelRoutingConfig
 }
 
-func NewBacklogHandler(s store.Store, h hermes.Client, bs *scoring.BacklogScorer, modelRouting config.ModelRoutingConfig) *BacklogHandler {
-	return &BacklogHandler{store: s, hermes: h, scorer: bs, modelRouting: modelRouting}
+func NewBacklogHandler(s store.Store, h hermes.Client, bs *scoring.BacklogScorer, orch *orchestrator.Orchestrator, modelRouting config.ModelRoutingConfig) *BacklogHandler {
+	return &BacklogHandler{store: s, hermes: h, scorer: bs, orch: orch, modelRouting: modelRouting}
 }
 
 type BacklogNextItem struct {
@@ -46,6 +52,7 @@ type CreateBacklogItemRequest struct {
 	Urgency         *float64               `json:"urgency,omitempty"`
 	EstimatedTokens *int64                 `json:"estimated_tokens,omitempty"`
 	EffortEstimate  string                 `json:"effort_estimate,omitempty"`
+	DueDate         *time.Time             `json:"due_date,omitempty"`
 	Labels          []string               `json:"labels,omitempty"`
 	OneWayDoor      bool                   `json:"one_way_door"`
 	Source          string                 `json:"source,omitempty"`
@@ -74,6 +81,7 @@ func (h *BacklogHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Impact:          req.Impact,
 		EstimatedTokens: req.EstimatedTokens,
 		EffortEstimate:  req.EffortEstimate,
+		DueDate:         req.DueDate,
 		Labels:          req.Labels,
 		OneWayDoor:      req.OneWayDoor,
 		Source:          req.Source,
@@ -127,43 +135,138 @@ func (h *BacklogHandler) Create(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, item)
 }
 
-// List handles GET /api/v1/backlog
+// List handles GET /api/v1/backlog. Besides the basic filters it supports
+// full-text search, rich filters and sort orders, and keyset pagination: a
+// full page sets X-Next-Cursor, which ?cursor= continues from.
 func (h *BacklogHandler) List(w http.ResponseWriter, r *http.Request) {
-	filter := store.BacklogFilter{
-		Domain:     r.URL.Query().Get("domain"),
-		AssignedTo: r.URL.Query().Get("assigned_to"),
-		ItemType:   r.URL.Query().Get("item_type"),
+	filter, err := parseBacklogFilter(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
 	}
-	if s := r.URL.Query().Get("status"); s != "" {
+
+	items, err := h.store.ListBacklogItems(r.Context(), filter)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if items == nil {
+		items = []*store.BacklogItem{}
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if len(items) == limit {
+		w.Header().Set("X-Next-Cursor", store.NewBacklogCursor(filter.Sort, items[len(items)-1]).Encode())
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// parseBacklogFilter reads a backlog list filter from query parameters.
+func parseBacklogFilter(q url.Values) (store.BacklogFilter, error) {
+	filter := store.BacklogFilter{
+		Domain:     q.Get("domain"),
+		AssignedTo: q.Get("assigned_to"),
+		ItemType:   q.Get("item_type"),
+		Query:      q.Get("q"),
+		ModelTier:  q.Get("model_tier"),
+		Sort:       q.Get("sort"),
+	}
+	if s := q.Get("status"); s != "" {
 		status := store.BacklogStatus(s)
 		filter.Status = &status
 	}
-	if s := r.URL.Query().Get("parent_id"); s != "" {
+	if s := q.Get("parent_id"); s != "" {
 		pid, err := uuid.Parse(s)
 		if err == nil {
 			filter.ParentID = &pid
 		}
 	}
-	if s := r.URL.Query().Get("limit"); s != "" {
+	if s := q.Get("limit"); s != "" {
 		if n, err := strconv.Atoi(s); err == nil {
 			filter.Limit = n
 		}
 	}
-	if s := r.URL.Query().Get("offset"); s != "" {
+	if s := q.Get("offset"); s != "" {
 		if n, err := strconv.Atoi(s); err == nil {
 			filter.Offset = n
 		}
 	}
 
-	items, err := h.store.ListBacklogItems(r.Context(), filter)
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-		return
+	if s := q.Get("labels"); s != "" {
+		for _, l := range strings.Split(s, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				filter.Labels = append(filter.Labels, l)
+			}
+		}
 	}
-	if items == nil {
-		items = []*store.BacklogItem{}
+	switch q.Get("labels_match") {
+	case "", "any":
+	case "all":
+		filter.AllLabels = true
+	default:
+		return filter, fmt.Errorf("labels_match must be any or all")
 	}
-	writeJSON(w, http.StatusOK, items)
+
+	for name, dst := range map[string]**float64{"min_score": &filter.MinScore, "max_score": &filter.MaxScore} {
+		if s := q.Get(name); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*dst = &v
+		}
+	}
+	for name, dst := range map[string]**bool{"one_way_door": &filter.OneWayDoor, "has_blockers": &filter.HasBlockers} {
+		if s := q.Get(name); s != "" {
+			v, err := strconv.ParseBool(s)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*dst = &v
+		}
+	}
+	for name, dst := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+		"updated_after":  &filter.UpdatedAfter,
+		"updated_before": &filter.UpdatedBefore,
+	} {
+		if s := q.Get(name); s != "" {
+			v, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			*dst = &v
+		}
+	}
+
+	if !store.ValidBacklogSort(filter.Sort) {
+		return filter, fmt.Errorf("sort must be priority, created, updated, due_date or relevance")
+	}
+	if s := q.Get("cursor"); s != "" {
+		c, err := store.DecodeBacklogCursor(s)
+		if err != nil {
+			return filter, err
+		}
+		if filter.Sort == "" {
+			filter.Sort = c.Sort
+		}
+		if c.Sort != filter.Sort {
+			return filter, fmt.Errorf("cursor is for sort %s", c.Sort)
+		}
+		filter.Cursor = c
+	}
+	// A search lists its best matches first unless asked otherwise.
+	if filter.Sort == "" && filter.Query != "" {
+		filter.Sort = store.BacklogSortRelevance
+	}
+	if filter.Sort == store.BacklogSortRelevance && filter.Query == "" {
+		return filter, fmt.Errorf("sort relevance needs q")
+	}
+	return filter, nil
 }
 
 // backlogItemToTask converts a BacklogItem to a Task for model tier derivation
@@ -241,13 +344,19 @@ func (h *BacklogHandler) Next(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
-// Get handles GET /api/v1/backlog/{id}
+// Get handles GET /api/v1/backlog/{id}. With at, an RFC 3339 timestamp, it
+// returns the item as it was at that time.
 func (h *BacklogHandler) Get(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
 		return
 	}
+	at, ok := parseAt(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at must be an RFC 3339 timestamp"})
+		return
+	}
 
 	item, err := h.store.GetBacklogItem(r.Context(), id)
 	if err != nil {
@@ -258,9 +367,81 @@ func (h *BacklogHandler) Get(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "item not found"})
 		return
 	}
+	if at != nil {
+		item, err = asOf(r, h.store, store.EntityBacklogItem, id, item, *at)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if item == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "item did not exist at that time"})
+			return
+		}
+	}
 	writeJSON(w, http.StatusOK, item)
 }
 
+// Tree handles GET /api/v1/backlog/{id}/tree. It returns the item with its
+// descendants nested by parent, each rolled up over the work beneath it.
+func (h *BacklogHandler) Tree(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+
+	subtree, err := h.store.ListBacklogSubtree(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	var root *store.BacklogItem
+	descendants := make([]*store.BacklogItem, 0, len(subtree))
+	for _, item := range subtree {
+		if item.ID == id {
+			root = item
+		} else {
+			descendants = append(descendants, item)
+		}
+	}
+	if root == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "item not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hierarchy.Build(root, descendants))
+}
+
+// ScoreHistory handles GET /api/v1/backlog/{id}/score-history. It returns
+// the item's recorded score changes, newest first.
+func (h *BacklogHandler) ScoreHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	history, err := h.store.ListScoreHistory(r.Context(), id, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if history == nil {
+		history = []*store.BacklogScoreChange{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
 // Update handles PATCH /api/v1/backlog/{id}
 func (h *BacklogHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
@@ -305,6 +486,20 @@ func (h *BacklogHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if v, ok := patch["urgency"].(float64); ok {
 		item.Urgency = &v
 	}
+	if v, ok := patch["due_date"]; ok {
+		if v == nil {
+			item.DueDate = nil
+		} else {
+			s, _ := v.(string)
+			due, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "due_date must be an RFC 3339 timestamp"})
+				return
+			}
+			item.DueDate = &due
+		}
+	}
+	previous := item.Status
 	if v, ok := patch["status"].(string); ok {
 		item.Status = store.BacklogStatus(v)
 	}
@@ -351,6 +546,11 @@ func (h *BacklogHandler) Update(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// Release or cancel the items blocked by it once it is closed
+	if isClosed(item.Status) && !isClosed(previous) {
+		h.orch.ItemClosed(r.Context(), item)
+	}
+
 	writeJSON(w, http.StatusOK, item)
 }
 
@@ -382,6 +582,9 @@ func (h *BacklogHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// Release or cancel the items this one was blocking
+	h.orch.ItemClosed(r.Context(), item)
+
 	writeJSON(w, http.StatusOK, item)
 }
 
@@ -548,9 +751,6 @@ func (h *BacklogHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Resolve dependencies where this item is the blocker
-	_ = h.store.ResolveDependenciesForBlocker(r.Context(), id)
-
 	if h.hermes != nil {
 		_ = h.hermes.Publish(hermes.SubjectBacklogCompleted(item.ID.String()), hermes.BacklogItemEvent{
 			ItemID: item.ID.String(),
@@ -559,6 +759,10 @@ func (h *BacklogHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// Resolve dependencies where this item is the blocker and release the
+	// items it was holding up
+	h.orch.ItemClosed(r.Context(), item)
+
 	writeJSON(w, http.StatusOK, item)
 }
 
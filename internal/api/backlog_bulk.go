@@ -0,0 +1,434 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// maxBulkItems bounds the items one bulk operation may touch.
+const maxBulkItems = 500
+
+// Bulk backlog actions.
+const (
+	bulkSetStatus = "set_status"
+	bulkAssign    = "assign"
+	bulkRelabel   = "relabel"
+	bulkSetTier   = "set_tier"
+	bulkPark      = "park"
+	bulkCancel    = "cancel"
+	bulkReparent  = "reparent"
+)
+
+// bulkOverrideTypes maps each bulk action to the override type recorded for
+// the items it changes.
+var bulkOverrideTypes = map[string]string{
+	bulkSetStatus: "status",
+	bulkAssign:    "reassign",
+	bulkRelabel:   "labels",
+	bulkSetTier:   "model_tier",
+	bulkPark:      "status",
+	bulkCancel:    "status",
+	bulkReparent:  "parent",
+}
+
+// BulkBacklogRequest applies one action to the items selected by IDs or by
+// a filter using the backlog list parameters.
+type BulkBacklogRequest struct {
+	IDs    []string          `json:"ids,omitempty"`
+	Filter map[string]string `json:"filter,omitempty"`
+	Action string            `json:"action"`
+
+	Status       string   `json:"status,omitempty"`        // set_status
+	AssignedTo   *string  `json:"assigned_to,omitempty"`   // assign; empty unassigns
+	Labels       []string `json:"labels,omitempty"`        // relabel: replaces the labels
+	AddLabels    []string `json:"add_labels,omitempty"`    // relabel
+	RemoveLabels []string `json:"remove_labels,omitempty"` // relabel
+	ModelTier    string   `json:"model_tier,omitempty"`    // set_tier
+	ParentID     *string  `json:"parent_id,omitempty"`     // reparent; empty detaches
+
+	DryRun       bool   `json:"dry_run"`
+	Reason       string `json:"reason,omitempty"`
+	OverriddenBy string `json:"overridden_by"`
+}
+
+// BulkBacklogResult is the outcome of a bulk action for one item.
+type BulkBacklogResult struct {
+	ItemID        string `json:"item_id"`
+	OK            bool   `json:"ok"`
+	Changed       bool   `json:"changed"`
+	PreviousValue string `json:"previous_value,omitempty"`
+	NewValue      string `json:"new_value,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type BulkBacklogResponse struct {
+	Action  string              `json:"action"`
+	DryRun  bool                `json:"dry_run"`
+	Applied bool                `json:"applied"`
+	Matched int                 `json:"matched"`
+	Changed int                 `json:"changed"`
+	Results []BulkBacklogResult `json:"results"`
+}
+
+// Bulk handles POST /api/v1/backlog/bulk. Every selected item is validated
+// first; if any fails, nothing is applied and the response is 422. Otherwise
+// the changed items and their overrides are written in one transaction and
+// summarised in a single event. With dry_run the results are returned
+// without applying anything.
+func (h *BacklogHandler) Bulk(w http.ResponseWriter, r *http.Request) {
+	var req BulkBacklogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if _, ok := bulkOverrideTypes[req.Action]; !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "action must be set_status, assign, relabel, set_tier, park, cancel or reparent"})
+		return
+	}
+	if req.OverriddenBy == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "overridden_by required"})
+		return
+	}
+	if err := h.validateBulkRequest(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	ids, items, err := h.selectBulkItems(r, &req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var parent *store.BacklogItem
+	var ancestors map[uuid.UUID]bool
+	if req.Action == bulkReparent && *req.ParentID != "" {
+		parent, ancestors, err = h.loadAncestors(r, *req.ParentID)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	resp := BulkBacklogResponse{Action: req.Action, DryRun: req.DryRun, Matched: len(items), Results: []BulkBacklogResult{}}
+	var changed []*store.BacklogItem
+	var overrides []*store.DispatchOverride
+	var previous []store.BacklogStatus
+	failed := false
+	for _, id := range ids {
+		res := BulkBacklogResult{ItemID: id.String()}
+		item, ok := items[id]
+		if !ok {
+			res.Error = "item not found"
+			failed = true
+			resp.Results = append(resp.Results, res)
+			continue
+		}
+
+		// Work on a copy so a dry run or a rejected batch leaves the item
+		// as it was read.
+		updated := *item
+		updated.Labels = append([]string(nil), item.Labels...)
+		prev, next, err := h.applyBulkAction(&updated, &req, parent, ancestors)
+		if err != nil {
+			res.Error = err.Error()
+			failed = true
+			resp.Results = append(resp.Results, res)
+			continue
+		}
+		res.OK = true
+		res.PreviousValue, res.NewValue = prev, next
+		res.Changed = prev != next
+		resp.Results = append(resp.Results, res)
+		if !res.Changed {
+			continue
+		}
+
+		itemID := updated.ID
+		changed = append(changed, &updated)
+		previous = append(previous, item.Status)
+		overrides = append(overrides, &store.DispatchOverride{
+			BacklogItemID: &itemID,
+			OverrideType:  bulkOverrideTypes[req.Action],
+			PreviousValue: prev,
+			NewValue:      next,
+			Reason:        req.Reason,
+			OverriddenBy:  req.OverriddenBy,
+		})
+	}
+	resp.Changed = len(changed)
+
+	if failed {
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if req.DryRun || len(changed) == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if h.scorer != nil {
+		medianTokens, _ := h.store.GetMedianEstimatedTokens(r.Context())
+		for _, item := range changed {
+			hasBlockers, _ := h.store.HasUnresolvedBlockers(r.Context(), item.ID)
+			score := h.scorer.ScoreItem(item, hasBlockers, medianTokens)
+			item.PriorityScore = &score
+		}
+	}
+
+	if err := h.store.BulkUpdateBacklogItems(r.Context(), changed, overrides); err != nil {
+		if errors.Is(err, store.ErrBacklogItemChanged) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp.Applied = true
+
+	if h.hermes != nil {
+		event := hermes.BacklogBulkAppliedEvent{
+			Action:       req.Action,
+			ItemIDs:      make([]string, 0, len(changed)),
+			ItemsChanged: len(changed),
+			OverriddenBy: req.OverriddenBy,
+			Reason:       req.Reason,
+		}
+		for _, item := range changed {
+			event.ItemIDs = append(event.ItemIDs, item.ID.String())
+		}
+		_ = h.hermes.Publish(hermes.SubjectBacklogBulkApplied(), event)
+	}
+
+	// Release or cancel the items blocked by anything this closed
+	for i, item := range changed {
+		if isClosed(item.Status) && !isClosed(previous[i]) {
+			h.orch.ItemClosed(r.Context(), item)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateBulkRequest checks the action's arguments before any item is
+// loaded.
+func (h *BacklogHandler) validateBulkRequest(req *BulkBacklogRequest) error {
+	switch req.Action {
+	case bulkSetStatus:
+		if !store.ValidBacklogStatus(store.BacklogStatus(req.Status)) {
+			return fmt.Errorf("set_status requires a valid status")
+		}
+	case bulkAssign:
+		if req.AssignedTo == nil {
+			return fmt.Errorf("assign requires assigned_to")
+		}
+	case bulkRelabel:
+		if req.Labels == nil && len(req.AddLabels) == 0 && len(req.RemoveLabels) == 0 {
+			return fmt.Errorf("relabel requires labels, add_labels or remove_labels")
+		}
+	case bulkSetTier:
+		if !h.knownTier(req.ModelTier) {
+			return fmt.Errorf("set_tier requires a configured model_tier")
+		}
+	case bulkReparent:
+		if req.ParentID == nil {
+			return fmt.Errorf("reparent requires parent_id")
+		}
+	}
+	return nil
+}
+
+// knownTier reports whether tier is one of the configured model tiers. Any
+// tier is accepted when none are configured.
+func (h *BacklogHandler) knownTier(tier string) bool {
+	if tier == "" {
+		return false
+	}
+	if len(h.modelRouting.Tiers) == 0 {
+		return true
+	}
+	for _, t := range h.modelRouting.Tiers {
+		if t.Name == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// selectBulkItems loads the items a bulk request selects, by IDs or by
+// filter, in order. Unknown IDs are returned without an item.
+func (h *BacklogHandler) selectBulkItems(r *http.Request, req *BulkBacklogRequest) ([]uuid.UUID, map[uuid.UUID]*store.BacklogItem, error) {
+	if (len(req.IDs) == 0) == (len(req.Filter) == 0) {
+		return nil, nil, fmt.Errorf("exactly one of ids or filter required")
+	}
+
+	var ids []uuid.UUID
+	items := make(map[uuid.UUID]*store.BacklogItem)
+	if len(req.IDs) > 0 {
+		if len(req.IDs) > maxBulkItems {
+			return nil, nil, fmt.Errorf("at most %d items per bulk operation", maxBulkItems)
+		}
+		for _, s := range req.IDs {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid id %q", s)
+			}
+			if _, seen := items[id]; seen {
+				continue
+			}
+			item, err := h.store.GetBacklogItem(r.Context(), id)
+			if err != nil {
+				return nil, nil, err
+			}
+			ids = append(ids, id)
+			items[id] = item
+		}
+		for id, item := range items {
+			if item == nil {
+				delete(items, id)
+			}
+		}
+		return ids, items, nil
+	}
+
+	q := url.Values{}
+	for k, v := range req.Filter {
+		q.Set(k, v)
+	}
+	filter, err := parseBacklogFilter(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter.Limit = maxBulkItems + 1
+	filter.Offset = 0
+	filter.Cursor = nil
+	list, err := h.store.ListBacklogItems(r.Context(), filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(list) > maxBulkItems {
+		return nil, nil, fmt.Errorf("filter selects more than %d items", maxBulkItems)
+	}
+	for _, item := range list {
+		ids = append(ids, item.ID)
+		items[item.ID] = item
+	}
+	return ids, items, nil
+}
+
+// loadAncestors loads the new parent for a reparent along with the IDs of
+// the parent and everything above it. Moving any of those under the parent
+// would create a cycle.
+func (h *BacklogHandler) loadAncestors(r *http.Request, parentID string) (*store.BacklogItem, map[uuid.UUID]bool, error) {
+	id, err := uuid.Parse(parentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid parent_id")
+	}
+	parent, err := h.store.GetBacklogItem(r.Context(), id)
+	if err != nil || parent == nil {
+		return nil, nil, fmt.Errorf("parent not found")
+	}
+	ancestors := map[uuid.UUID]bool{parent.ID: true}
+	for cur := parent; cur.ParentID != nil && !ancestors[*cur.ParentID]; {
+		ancestors[*cur.ParentID] = true
+		next, err := h.store.GetBacklogItem(r.Context(), *cur.ParentID)
+		if err != nil || next == nil {
+			break
+		}
+		cur = next
+	}
+	return parent, ancestors, nil
+}
+
+// applyBulkAction applies req's action to item and returns the affected
+// value before and after. The item is unchanged when the two are equal.
+func (h *BacklogHandler) applyBulkAction(item *store.BacklogItem, req *BulkBacklogRequest, parent *store.BacklogItem, ancestors map[uuid.UUID]bool) (string, string, error) {
+	switch req.Action {
+	case bulkSetStatus:
+		prev := string(item.Status)
+		item.Status = store.BacklogStatus(req.Status)
+		return prev, req.Status, nil
+
+	case bulkAssign:
+		prev := item.AssignedTo
+		item.AssignedTo = *req.AssignedTo
+		return prev, item.AssignedTo, nil
+
+	case bulkRelabel:
+		prev := strings.Join(item.Labels, ",")
+		item.Labels = relabel(item.Labels, req.Labels, req.AddLabels, req.RemoveLabels)
+		return prev, strings.Join(item.Labels, ","), nil
+
+	case bulkSetTier:
+		prev := item.ModelTier
+		item.ModelTier = req.ModelTier
+		return prev, item.ModelTier, nil
+
+	case bulkPark:
+		prev := string(item.Status)
+		if isClosed(item.Status) {
+			return prev, prev, fmt.Errorf("item is %s", item.Status)
+		}
+		item.Status = store.BacklogStatusBacklog
+		return prev, string(item.Status), nil
+
+	case bulkCancel:
+		prev := string(item.Status)
+		if item.Status == store.BacklogStatusDone {
+			return prev, prev, fmt.Errorf("item is done")
+		}
+		item.Status = store.BacklogStatusCancelled
+		return prev, string(item.Status), nil
+
+	case bulkReparent:
+		prev := ""
+		if item.ParentID != nil {
+			prev = item.ParentID.String()
+		}
+		if parent == nil {
+			item.ParentID = nil
+			return prev, "", nil
+		}
+		if ancestors[item.ID] {
+			return prev, prev, fmt.Errorf("item cannot move under itself or its own descendant")
+		}
+		item.ParentID = &parent.ID
+		return prev, parent.ID.String(), nil
+	}
+	return "", "", fmt.Errorf("unknown action %s", req.Action)
+}
+
+// relabel replaces labels when set is non-nil, then adds and removes
+// labels, keeping the existing order.
+func relabel(labels, set, add, remove []string) []string {
+	if set != nil {
+		labels = set
+	}
+	drop := make(map[string]bool, len(remove))
+	for _, l := range remove {
+		drop[l] = true
+	}
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, l := range append(append([]string(nil), labels...), add...) {
+		if l == "" || drop[l] || seen[l] {
+			continue
+		}
+		seen[l] = true
+		out = append(out, l)
+	}
+	return out
+}
+
+func isClosed(s store.BacklogStatus) bool {
+	return s == store.BacklogStatusDone || s == store.BacklogStatusCancelled
+}
@@ -0,0 +1,516 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/backlogio"
+	"github.com/MikeSquared-Agency/Dispatch/internal/depgraph"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// exportPageSize is how many items an export reads at a time.
+const exportPageSize = 500
+
+// maxImportItems bounds the records one import may contain, and
+// maxImportBytes the size of its body.
+const (
+	maxImportItems = 2000
+	maxImportBytes = 10 << 20
+)
+
+// Import actions.
+const (
+	importCreate    = "create"
+	importUpdate    = "update"
+	importUnchanged = "unchanged"
+)
+
+// BacklogImportResult is what an import does with one record. Row is the
+// record's position in the file, from 1.
+type BacklogImportResult struct {
+	Row        int      `json:"row"`
+	ItemID     string   `json:"item_id"`
+	ExternalID string   `json:"external_id,omitempty"`
+	Action     string   `json:"action"`
+	Fields     []string `json:"fields,omitempty"` // the fields an update changes
+}
+
+// BacklogImportConflict is a record that stops an import from applying.
+type BacklogImportConflict struct {
+	Row        int    `json:"row"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+type BacklogImportResponse struct {
+	Format       string                  `json:"format"`
+	DryRun       bool                    `json:"dry_run"`
+	Applied      bool                    `json:"applied"`
+	Created      int                     `json:"created"`
+	Updated      int                     `json:"updated"`
+	Unchanged    int                     `json:"unchanged"`
+	Dependencies int                     `json:"dependencies"`
+	Results      []BacklogImportResult   `json:"results"`
+	Conflicts    []BacklogImportConflict `json:"conflicts"`
+}
+
+// Export handles GET /api/v1/backlog/export. It writes every item matching
+// the backlog list filters, with its parent, blockers and the gates of its
+// current stage, as JSON (the default), CSV or a Markdown TODO list.
+func (h *BacklogHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = backlogio.FormatJSON
+	}
+	if !backlogio.ValidFormat(format) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json, csv or md"})
+		return
+	}
+	filter, err := parseBacklogFilter(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	items, err := h.exportItems(r, filter)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	deps, err := h.store.ListDependencies(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Parents and blockers outside the export are looked up for their
+	// references.
+	refs := make(map[uuid.UUID]string, len(items))
+	for _, item := range items {
+		refs[item.ID] = backlogio.Ref(item)
+	}
+	blockedBy := make(map[uuid.UUID][]string, len(items))
+	for _, item := range items {
+		blockedBy[item.ID] = nil
+	}
+	ref := func(id uuid.UUID) string {
+		if s, ok := refs[id]; ok {
+			return s
+		}
+		s := id.String()
+		if item, err := h.store.GetBacklogItem(r.Context(), id); err == nil && item != nil {
+			s = backlogio.Ref(item)
+		}
+		refs[id] = s
+		return s
+	}
+	for _, d := range deps {
+		if _, ok := blockedBy[d.BlockedID]; ok {
+			blockedBy[d.BlockedID] = append(blockedBy[d.BlockedID], ref(d.BlockerID))
+		}
+	}
+
+	records := make([]backlogio.Record, 0, len(items))
+	for _, item := range items {
+		parent := ""
+		if item.ParentID != nil {
+			parent = ref(*item.ParentID)
+		}
+		var gates []store.GateCriterion
+		if item.CurrentStage != "" {
+			gates, err = h.store.GetGateStatus(r.Context(), item.ID, item.CurrentStage)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		records = append(records, backlogio.NewRecord(item, parent, blockedBy[item.ID], gates))
+	}
+
+	w.Header().Set("Content-Type", backlogio.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="backlog.%s"`, format))
+	w.WriteHeader(http.StatusOK)
+	_ = backlogio.Write(format, w, records)
+}
+
+// exportItems lists every item matching filter, a page at a time. The
+// filter's limit and offset are ignored.
+func (h *BacklogHandler) exportItems(r *http.Request, filter store.BacklogFilter) ([]*store.BacklogItem, error) {
+	filter.Limit, filter.Offset, filter.Cursor = exportPageSize, 0, nil
+	var items []*store.BacklogItem
+	for {
+		page, err := h.store.ListBacklogItems(r.Context(), filter)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		if len(page) < exportPageSize {
+			return items, nil
+		}
+		filter.Cursor = store.NewBacklogCursor(filter.Sort, page[len(page)-1])
+	}
+}
+
+// Import handles POST /api/v1/backlog/import. The body is a file in the
+// format given by ?format= (json by default). Each record updates the item
+// with its external_id, else its id, or creates a new item. The whole file
+// is checked first; any conflict stops it from applying, with the
+// conflicts reported and a 409. Otherwise the items and their dependencies
+// are written in one transaction. With ?dry_run=true the plan is returned
+// without applying anything, and ?force=true updates items that changed
+// since the record's updated_at.
+func (h *BacklogHandler) Import(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = backlogio.FormatJSON
+	}
+	if !backlogio.ValidFormat(format) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json, csv or md"})
+		return
+	}
+	var dryRun, force bool
+	for name, dst := range map[string]*bool{"dry_run": &dryRun, "force": &force} {
+		if s := q.Get(name); s != "" {
+			v, err := strconv.ParseBool(s)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid %s", name)})
+				return
+			}
+			*dst = v
+		}
+	}
+
+	records, err := backlogio.Read(format, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(records) > maxImportItems {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("at most %d items per import", maxImportItems)})
+		return
+	}
+
+	resp := BacklogImportResponse{Format: format, DryRun: dryRun, Results: []BacklogImportResult{}, Conflicts: []BacklogImportConflict{}}
+	plan, err := h.planImport(r, records, force, &resp)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(resp.Conflicts) > 0 {
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	if dryRun || len(plan.Create)+len(plan.Update)+len(plan.Dependencies) == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if err := h.store.ImportBacklog(r.Context(), &plan.BacklogImport); err != nil {
+		if errors.Is(err, store.ErrBacklogItemChanged) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp.Applied = true
+
+	if h.hermes != nil {
+		event := hermes.BacklogImportedEvent{
+			Format:       format,
+			ItemIDs:      make([]string, 0, len(plan.Create)+len(plan.Update)),
+			ItemsCreated: len(plan.Create),
+			ItemsUpdated: len(plan.Update),
+			Dependencies: len(plan.Dependencies),
+		}
+		for _, item := range append(append([]*store.BacklogItem(nil), plan.Create...), plan.Update...) {
+			event.ItemIDs = append(event.ItemIDs, item.ID.String())
+		}
+		_ = h.hermes.Publish(hermes.SubjectBacklogImported(), event)
+	}
+
+	// Release or cancel the items blocked by anything this closed
+	for _, item := range plan.Update {
+		if isClosed(item.Status) && !isClosed(plan.previous[item.ID]) {
+			h.orch.ItemClosed(r.Context(), item)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// importPlan is an import ready to apply, with the status each updated
+// item had before.
+type importPlan struct {
+	store.BacklogImport
+	previous map[uuid.UUID]store.BacklogStatus
+}
+
+// importRow is one record matched to the item it becomes.
+type importRow struct {
+	rec      backlogio.Record
+	existing *store.BacklogItem // nil for a new item
+	item     *store.BacklogItem
+	blocked  bool // the import adds an unresolved blocker
+}
+
+// planImport matches records to items, resolves their parents and
+// blockers, and fills resp with what the import does and any conflicts.
+func (h *BacklogHandler) planImport(r *http.Request, records []backlogio.Record, force bool, resp *BacklogImportResponse) (*importPlan, error) {
+	ctx := r.Context()
+	conflict := func(i int, format string, args ...interface{}) {
+		resp.Conflicts = append(resp.Conflicts, BacklogImportConflict{Row: i + 1, ExternalID: records[i].ExternalID, Error: fmt.Sprintf(format, args...)})
+	}
+
+	// Match each record to the item it updates, or a new one. Records
+	// refer to each other by external ID or ID.
+	rows := make([]*importRow, len(records))
+	byRef := make(map[string]*importRow)
+	for i, rec := range records {
+		var existing *store.BacklogItem
+		var err error
+		if rec.ExternalID != "" {
+			if existing, err = h.store.GetBacklogItemByExternalID(ctx, rec.ExternalID); err != nil {
+				return nil, err
+			}
+		}
+		id := uuid.New()
+		if rec.ID != "" {
+			if id, err = uuid.Parse(rec.ID); err != nil {
+				conflict(i, "invalid id %q", rec.ID)
+				continue
+			}
+			if existing != nil && existing.ID != id {
+				conflict(i, "external_id %s belongs to item %s", rec.ExternalID, existing.ID)
+				continue
+			}
+			if existing == nil {
+				if existing, err = h.store.GetBacklogItem(ctx, id); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		row := &importRow{rec: rec, existing: existing}
+		if existing != nil {
+			if rec.UpdatedAt != nil && existing.UpdatedAt.After(*rec.UpdatedAt) && !force {
+				conflict(i, "item %s was updated at %s, after this record", existing.ID, existing.UpdatedAt.Format(time.RFC3339))
+				continue
+			}
+			item := *existing
+			row.item = &item
+		} else {
+			if rec.Title == "" {
+				conflict(i, "title required")
+				continue
+			}
+			row.item = &store.BacklogItem{
+				ID:           id,
+				ItemType:     "task",
+				Status:       store.BacklogStatusBacklog,
+				Source:       "import",
+				ScoresSource: "manual",
+			}
+		}
+		if err := backlogio.Apply(row.item, rec); err != nil {
+			conflict(i, "%v", err)
+			continue
+		}
+		if !store.ValidBacklogStatus(row.item.Status) {
+			conflict(i, "invalid status %q", rec.Status)
+			continue
+		}
+
+		keys := []string{row.item.ID.String()}
+		if row.item.ExternalID != "" {
+			keys = append(keys, row.item.ExternalID)
+		}
+		duplicate := false
+		for _, k := range keys {
+			if _, ok := byRef[k]; ok {
+				conflict(i, "%s appears more than once", k)
+				duplicate = true
+			}
+		}
+		if duplicate {
+			continue
+		}
+		for _, k := range keys {
+			byRef[k] = row
+		}
+		rows[i] = row
+	}
+
+	// lookup finds the item a reference is to, as the import leaves it.
+	lookup := func(ref string) (*store.BacklogItem, error) {
+		if row, ok := byRef[ref]; ok {
+			return row.item, nil
+		}
+		item, err := h.store.GetBacklogItemByExternalID(ctx, ref)
+		if err != nil || item != nil {
+			return item, err
+		}
+		if id, err := uuid.Parse(ref); err == nil {
+			return h.store.GetBacklogItem(ctx, id)
+		}
+		return nil, nil
+	}
+
+	for i, row := range rows {
+		if row == nil || row.rec.Parent == "" {
+			continue
+		}
+		parent, err := lookup(row.rec.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			conflict(i, "unknown parent %s", row.rec.Parent)
+			continue
+		}
+		row.item.ParentID = &parent.ID
+	}
+	for i, row := range rows {
+		if row == nil || row.rec.Parent == "" || row.item.ParentID == nil {
+			continue
+		}
+		seen := map[uuid.UUID]bool{row.item.ID: true}
+		for id := row.item.ParentID; id != nil; {
+			if seen[*id] {
+				conflict(i, "parent %s would create a cycle", row.rec.Parent)
+				break
+			}
+			seen[*id] = true
+			p, err := lookup(id.String())
+			if err != nil {
+				return nil, err
+			}
+			if p == nil {
+				break
+			}
+			id = p.ParentID
+		}
+	}
+
+	plan := &importPlan{previous: make(map[uuid.UUID]store.BacklogStatus)}
+	deps, err := h.store.ListDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[[2]uuid.UUID]bool, len(deps))
+	for _, d := range deps {
+		exists[[2]uuid.UUID{d.BlockerID, d.BlockedID}] = true
+	}
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		for _, ref := range row.rec.BlockedBy {
+			blocker, err := lookup(ref)
+			if err != nil {
+				return nil, err
+			}
+			if blocker == nil {
+				conflict(i, "unknown blocker %s", ref)
+				continue
+			}
+			key := [2]uuid.UUID{blocker.ID, row.item.ID}
+			if exists[key] {
+				continue
+			}
+			if cycle := depgraph.FindCycle(deps, blocker.ID, row.item.ID); cycle != nil {
+				conflict(i, "blocker %s would create a dependency cycle", ref)
+				continue
+			}
+			dep := &store.BacklogDependency{BlockerID: blocker.ID, BlockedID: row.item.ID}
+			if isClosed(blocker.Status) {
+				now := time.Now()
+				dep.ResolvedAt = &now
+			} else {
+				row.blocked = true
+			}
+			deps = append(deps, dep)
+			exists[key] = true
+			plan.Dependencies = append(plan.Dependencies, dep)
+		}
+	}
+
+	var medianTokens int64
+	if h.scorer != nil {
+		medianTokens, _ = h.store.GetMedianEstimatedTokens(ctx)
+	}
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		res := BacklogImportResult{Row: i + 1, ItemID: row.item.ID.String(), ExternalID: row.item.ExternalID}
+		hasBlockers := row.blocked
+		if row.existing == nil {
+			res.Action = importCreate
+			plan.Create = append(plan.Create, row.item)
+		} else if c := store.NewChangeRecord(ctx, store.EntityBacklogItem, row.item.ID, store.ChangeUpdated, row.existing, row.item); c == nil {
+			res.Action = importUnchanged
+			resp.Results = append(resp.Results, res)
+			continue
+		} else {
+			res.Action = importUpdate
+			for f := range c.Changes {
+				res.Fields = append(res.Fields, f)
+			}
+			sort.Strings(res.Fields)
+			plan.Update = append(plan.Update, row.item)
+			plan.previous[row.item.ID] = row.existing.Status
+			if !hasBlockers {
+				hasBlockers, _ = h.store.HasUnresolvedBlockers(ctx, row.item.ID)
+			}
+		}
+		if h.scorer != nil {
+			score := h.scorer.ScoreItem(row.item, hasBlockers, medianTokens)
+			row.item.PriorityScore = &score
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	plan.Create = parentsFirst(plan.Create)
+
+	resp.Created, resp.Updated = len(plan.Create), len(plan.Update)
+	resp.Unchanged = len(resp.Results) - resp.Created - resp.Updated
+	resp.Dependencies = len(plan.Dependencies)
+	return plan, nil
+}
+
+// parentsFirst orders items so that each comes after its parent when the
+// parent is among them.
+func parentsFirst(items []*store.BacklogItem) []*store.BacklogItem {
+	byID := make(map[uuid.UUID]*store.BacklogItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	added := make(map[uuid.UUID]bool, len(items))
+	out := make([]*store.BacklogItem, 0, len(items))
+	var add func(item *store.BacklogItem)
+	add = func(item *store.BacklogItem) {
+		if added[item.ID] {
+			return
+		}
+		added[item.ID] = true
+		if item.ParentID != nil {
+			if parent, ok := byID[*item.ParentID]; ok {
+				add(parent)
+			}
+		}
+		out = append(out, item)
+	}
+	for _, item := range items {
+		add(item)
+	}
+	return out
+}
@@ -8,13 +8,16 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/MikeSquared-Agency/Dispatch/internal/backlogio"
 	"github.com/MikeSquared-Agency/Dispatch/internal/broker"
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hierarchy"
 	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
@@ -27,6 +30,12 @@ type backlogMockStore struct {
 	overrides    []*store.DispatchOverride
 	autoEvents   []*store.AutonomyEvent
 	stageGates   map[uuid.UUID]map[string][]store.GateCriterion
+	scores       []*store.BacklogScoreChange
+	lastFilter   store.BacklogFilter
+	history      []*store.ChangeRecord
+	saved        map[uuid.UUID]store.BacklogItem // last write of each item, to diff updates against
+	iterations   map[uuid.UUID]*store.Iteration
+	committed    map[uuid.UUID][]*store.IterationItem // by iteration
 }
 
 func newBacklogMockStore() *backlogMockStore {
@@ -35,17 +44,50 @@ func newBacklogMockStore() *backlogMockStore {
 		backlogItems: make(map[uuid.UUID]*store.BacklogItem),
 		deps:         make(map[uuid.UUID]*store.BacklogDependency),
 		stageGates:   make(map[uuid.UUID]map[string][]store.GateCriterion),
+		saved:        make(map[uuid.UUID]store.BacklogItem),
+		iterations:   make(map[uuid.UUID]*store.Iteration),
+		committed:    make(map[uuid.UUID][]*store.IterationItem),
 	}
 }
 
-func (m *backlogMockStore) CreateBacklogItem(_ context.Context, item *store.BacklogItem) error {
+func (m *backlogMockStore) CreateBacklogItem(ctx context.Context, item *store.BacklogItem) error {
 	item.ID = uuid.New()
 	item.CreatedAt = time.Now()
 	item.UpdatedAt = time.Now()
 	m.backlogItems[item.ID] = item
+	m.recordChange(ctx, store.ChangeCreated, nil, item)
 	return nil
 }
 
+// recordChange records item's change since its last write, as the store
+// does.
+func (m *backlogMockStore) recordChange(ctx context.Context, action string, before, item *store.BacklogItem) {
+	if c := store.NewChangeRecord(ctx, store.EntityBacklogItem, item.ID, action, before, item); c != nil {
+		c.ID = uuid.New()
+		c.CreatedAt = time.Now()
+		m.history = append(m.history, c)
+	}
+	m.saved[item.ID] = *item
+}
+
+func (m *backlogMockStore) ListChangeHistory(_ context.Context, filter store.ChangeHistoryFilter) ([]*store.ChangeRecord, error) {
+	var out []*store.ChangeRecord
+	for i := len(m.history) - 1; i >= 0; i-- {
+		c := m.history[i]
+		if c.EntityType != filter.EntityType || c.EntityID != filter.EntityID {
+			continue
+		}
+		if filter.Since != nil && !c.CreatedAt.After(*filter.Since) {
+			continue
+		}
+		out = append(out, c)
+		if len(out) == filter.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
 func (m *backlogMockStore) GetBacklogItem(_ context.Context, id uuid.UUID) (*store.BacklogItem, error) {
 	item, ok := m.backlogItems[id]
 	if !ok {
@@ -54,7 +96,43 @@ func (m *backlogMockStore) GetBacklogItem(_ context.Context, id uuid.UUID) (*sto
 	return item, nil
 }
 
+func (m *backlogMockStore) GetBacklogItemByExternalID(_ context.Context, externalID string) (*store.BacklogItem, error) {
+	for _, item := range m.backlogItems {
+		if item.ExternalID == externalID {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *backlogMockStore) GetBacklogItems(_ context.Context, ids []uuid.UUID) ([]*store.BacklogItem, error) {
+	var out []*store.BacklogItem
+	for _, id := range ids {
+		if item, ok := m.backlogItems[id]; ok {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (m *backlogMockStore) ListBacklogSubtree(_ context.Context, rootID uuid.UUID) ([]*store.BacklogItem, error) {
+	root, ok := m.backlogItems[rootID]
+	if !ok {
+		return nil, nil
+	}
+	out := []*store.BacklogItem{root}
+	for i := 0; i < len(out); i++ {
+		for _, item := range m.backlogItems {
+			if item.ParentID != nil && *item.ParentID == out[i].ID {
+				out = append(out, item)
+			}
+		}
+	}
+	return out, nil
+}
+
 func (m *backlogMockStore) ListBacklogItems(_ context.Context, filter store.BacklogFilter) ([]*store.BacklogItem, error) {
+	m.lastFilter = filter
 	var out []*store.BacklogItem
 	for _, item := range m.backlogItems {
 		if filter.Status != nil && item.Status != *filter.Status {
@@ -69,13 +147,30 @@ func (m *backlogMockStore) ListBacklogItems(_ context.Context, filter store.Back
 		if filter.ItemType != "" && item.ItemType != filter.ItemType {
 			continue
 		}
+		if filter.ParentID != nil && (item.ParentID == nil || *item.ParentID != *filter.ParentID) {
+			continue
+		}
 		out = append(out, item)
+		if len(out) == filter.Limit {
+			break
+		}
 	}
 	return out, nil
 }
 
-func (m *backlogMockStore) UpdateBacklogItem(_ context.Context, item *store.BacklogItem) error {
+func (m *backlogMockStore) UpdateBacklogItem(ctx context.Context, item *store.BacklogItem) error {
+	before := m.saved[item.ID]
 	m.backlogItems[item.ID] = item
+	m.recordChange(ctx, store.ChangeUpdated, &before, item)
+	return nil
+}
+
+// UpdateBacklogItemScore writes the score without a change record, as the
+// store does.
+func (m *backlogMockStore) UpdateBacklogItemScore(_ context.Context, item *store.BacklogItem, score float64) error {
+	item.PriorityScore = &score
+	m.backlogItems[item.ID] = item
+	m.saved[item.ID] = *item
 	return nil
 }
 
@@ -92,6 +187,23 @@ func (m *backlogMockStore) GetNextBacklogItems(_ context.Context, limit int) ([]
 	return out, nil
 }
 
+func (m *backlogMockStore) CreateScoreChange(_ context.Context, c *store.BacklogScoreChange) error {
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	m.scores = append(m.scores, c)
+	return nil
+}
+
+func (m *backlogMockStore) ListScoreHistory(_ context.Context, itemID uuid.UUID, limit int) ([]*store.BacklogScoreChange, error) {
+	var out []*store.BacklogScoreChange
+	for i := len(m.scores) - 1; i >= 0 && len(out) < limit; i-- {
+		if m.scores[i].BacklogItemID == itemID {
+			out = append(out, m.scores[i])
+		}
+	}
+	return out, nil
+}
+
 func (m *backlogMockStore) CreateDependency(_ context.Context, dep *store.BacklogDependency) error {
 	dep.ID = uuid.New()
 	dep.CreatedAt = time.Now()
@@ -99,6 +211,14 @@ func (m *backlogMockStore) CreateDependency(_ context.Context, dep *store.Backlo
 	return nil
 }
 
+func (m *backlogMockStore) CreateDependencyChecked(ctx context.Context, dep *store.BacklogDependency, check store.DependencyCheck) error {
+	existing, _ := m.ListDependencies(ctx)
+	if err := check(existing); err != nil {
+		return err
+	}
+	return m.CreateDependency(ctx, dep)
+}
+
 func (m *backlogMockStore) DeleteDependency(_ context.Context, id uuid.UUID) error {
 	delete(m.deps, id)
 	return nil
@@ -114,6 +234,71 @@ func (m *backlogMockStore) GetDependenciesForItem(_ context.Context, itemID uuid
 	return out, nil
 }
 
+func (m *backlogMockStore) HasUnresolvedBlockers(_ context.Context, itemID uuid.UUID) (bool, error) {
+	for _, d := range m.deps {
+		if d.BlockedID == itemID && d.ResolvedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *backlogMockStore) ListBlockedBacklogItemIDs(_ context.Context) (map[uuid.UUID]bool, error) {
+	blocked := make(map[uuid.UUID]bool)
+	for _, d := range m.deps {
+		if d.ResolvedAt == nil {
+			blocked[d.BlockedID] = true
+		}
+	}
+	return blocked, nil
+}
+
+func (m *backlogMockStore) ResolveDependenciesForBlocker(_ context.Context, blockerID uuid.UUID) error {
+	now := time.Now()
+	for _, d := range m.deps {
+		if d.BlockerID == blockerID && d.ResolvedAt == nil {
+			d.ResolvedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *backlogMockStore) CountBacklogChildren(_ context.Context, parentID uuid.UUID) (store.ChildCounts, error) {
+	var c store.ChildCounts
+	for _, item := range m.backlogItems {
+		if item.ParentID == nil || *item.ParentID != parentID {
+			continue
+		}
+		switch item.Status {
+		case store.BacklogStatusDone:
+			c.Done++
+		case store.BacklogStatusCancelled:
+			c.Cancelled++
+		default:
+			c.Open++
+		}
+	}
+	return c, nil
+}
+
+func (m *backlogMockStore) ListOpenBacklogItems(_ context.Context) ([]*store.BacklogItem, error) {
+	var out []*store.BacklogItem
+	for _, item := range m.backlogItems {
+		if item.Status != store.BacklogStatusDone && item.Status != store.BacklogStatusCancelled {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (m *backlogMockStore) ListDependencies(_ context.Context) ([]*store.BacklogDependency, error) {
+	var out []*store.BacklogDependency
+	for _, d := range m.deps {
+		out = append(out, d)
+	}
+	return out, nil
+}
+
 func (m *backlogMockStore) CreateOverride(_ context.Context, o *store.DispatchOverride) error {
 	o.ID = uuid.New()
 	o.CreatedAt = time.Now()
@@ -252,13 +437,153 @@ func (m *backlogMockStore) BacklogDiscoveryComplete(_ context.Context, itemID uu
 	if tierFn != nil {
 		item.ModelTier = tierFn(item)
 	}
-	return &store.BacklogDiscoveryCompleteResult{
+	result := &store.BacklogDiscoveryCompleteResult{
 		Item:      item,
 		ModelTier: item.ModelTier,
-	}, nil
+	}
+	for _, sub := range req.Subtasks {
+		subtask := &store.BacklogItem{
+			Title:           sub.Title,
+			ItemType:        "task",
+			ParentID:        &itemID,
+			EstimatedTokens: sub.EstimatedTokens,
+			Status:          store.BacklogStatusBacklog,
+			Source:          "discovery",
+		}
+		_ = m.CreateBacklogItem(context.Background(), subtask)
+		result.CreatedSubtasks = append(result.CreatedSubtasks, subtask)
+	}
+	return result, nil
+}
+
+func (m *backlogMockStore) BulkUpdateBacklogItems(_ context.Context, items []*store.BacklogItem, overrides []*store.DispatchOverride) error {
+	for _, item := range items {
+		item.UpdatedAt = time.Now()
+		m.backlogItems[item.ID] = item
+	}
+	for _, o := range overrides {
+		_ = m.CreateOverride(context.Background(), o)
+	}
+	return nil
+}
+
+func (m *backlogMockStore) ImportBacklog(ctx context.Context, imp *store.BacklogImport) error {
+	for _, item := range imp.Create {
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+		}
+		item.CreatedAt = time.Now()
+		item.UpdatedAt = time.Now()
+		m.backlogItems[item.ID] = item
+		m.recordChange(ctx, store.ChangeCreated, nil, item)
+	}
+	for _, item := range imp.Update {
+		before := m.saved[item.ID]
+		item.UpdatedAt = time.Now()
+		m.backlogItems[item.ID] = item
+		m.recordChange(ctx, store.ChangeUpdated, &before, item)
+	}
+	for _, d := range imp.Dependencies {
+		_ = m.CreateDependency(ctx, d)
+	}
+	return nil
+}
+
+func (m *backlogMockStore) CreateIteration(_ context.Context, it *store.Iteration) error {
+	it.ID = uuid.New()
+	it.CreatedAt = time.Now()
+	it.UpdatedAt = time.Now()
+	m.iterations[it.ID] = it
+	return nil
+}
+
+func (m *backlogMockStore) GetIteration(_ context.Context, id uuid.UUID) (*store.Iteration, error) {
+	return m.iterations[id], nil
+}
+
+func (m *backlogMockStore) ListIterations(_ context.Context) ([]*store.Iteration, error) {
+	var out []*store.Iteration
+	for _, it := range m.iterations {
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+func (m *backlogMockStore) DeleteIteration(_ context.Context, id uuid.UUID) error {
+	delete(m.iterations, id)
+	delete(m.committed, id)
+	return nil
 }
 
-func setupBacklogTestRouter() (http.Handler, *backlogMockStore) {
+func (m *backlogMockStore) CommitIterationItems(ctx context.Context, items []*store.IterationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	elsewhere, _ := m.ListOpenCommitments(ctx, items[0].IterationID)
+	for _, item := range items {
+		if other := elsewhere[item.BacklogItemID]; other != nil {
+			return &store.IterationConflictError{ItemID: item.BacklogItemID, Iteration: other}
+		}
+	}
+	for _, item := range items {
+		found := false
+		for _, c := range m.committed[item.IterationID] {
+			if c.BacklogItemID == item.BacklogItemID {
+				*item = *c
+				found = true
+			}
+		}
+		if !found {
+			item.CommittedAt = time.Now()
+			c := *item
+			m.committed[item.IterationID] = append(m.committed[item.IterationID], &c)
+		}
+	}
+	return nil
+}
+
+func (m *backlogMockStore) UncommitIterationItems(_ context.Context, iterationID uuid.UUID, itemIDs []uuid.UUID) error {
+	var kept []*store.IterationItem
+	for _, c := range m.committed[iterationID] {
+		drop := false
+		for _, id := range itemIDs {
+			drop = drop || c.BacklogItemID == id
+		}
+		if !drop {
+			kept = append(kept, c)
+		}
+	}
+	m.committed[iterationID] = kept
+	return nil
+}
+
+func (m *backlogMockStore) ListIterationItems(_ context.Context, iterationID uuid.UUID) ([]*store.IterationItem, error) {
+	var out []*store.IterationItem
+	for _, c := range m.committed[iterationID] {
+		if item := m.backlogItems[c.BacklogItemID]; item != nil {
+			c.Item = item
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *backlogMockStore) ListOpenCommitments(_ context.Context, exceptID uuid.UUID) (map[uuid.UUID]*store.Iteration, error) {
+	now := time.Now()
+	out := make(map[uuid.UUID]*store.Iteration)
+	for id, committed := range m.committed {
+		it := m.iterations[id]
+		if id == exceptID || it == nil || !it.Open(now) {
+			continue
+		}
+		for _, c := range committed {
+			out[c.BacklogItemID] = it
+		}
+	}
+	return out, nil
+}
+
+func setupBacklogTestRouter(opts ...func(*config.Config)) (http.Handler, *backlogMockStore) {
 	ms := newBacklogMockStore()
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 	cfg := &config.Config{
@@ -295,6 +620,9 @@ func setupBacklogTestRouter() (http.Handler, *backlogMockStore) {
 			},
 		},
 	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	b := broker.New(ms, &mockHermes{}, &mockWarren{}, &mockForge{}, nil, cfg, logger)
 	bs := scoring.NewBacklogScorer(scoring.DefaultBacklogWeights())
 	router := NewRouter(ms, &mockHermes{}, &mockWarren{}, &mockForge{}, b, bs, cfg, "test-admin-token", logger)
@@ -429,6 +757,76 @@ func TestListBacklogItemsFilterByStatus(t *testing.T) {
 	}
 }
 
+func TestListBacklogItemsFilters(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	for i := 0; i < 3; i++ {
+		_ = ms.CreateBacklogItem(context.Background(), &store.BacklogItem{Title: "item", ItemType: "task", Status: store.BacklogStatusReady})
+	}
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/backlog?"+query, nil)
+		req.Header.Set("X-Agent-ID", "test-agent")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := list("q=auth+-oauth&labels=backend,%20security&labels_match=all&model_tier=premium" +
+		"&min_score=0.5&one_way_door=false&has_blockers=true&created_after=2026-01-01T00:00:00Z&sort=updated&limit=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	f := ms.lastFilter
+	if f.Query != "auth -oauth" || len(f.Labels) != 2 || f.Labels[1] != "security" || !f.AllLabels || f.ModelTier != "premium" {
+		t.Errorf("unexpected text and label filters: %+v", f)
+	}
+	if f.MinScore == nil || *f.MinScore != 0.5 || f.MaxScore != nil || f.OneWayDoor == nil || *f.OneWayDoor || f.HasBlockers == nil || !*f.HasBlockers {
+		t.Errorf("unexpected score and flag filters: %+v", f)
+	}
+	if f.CreatedAfter == nil || f.CreatedAfter.Year() != 2026 || f.Sort != store.BacklogSortUpdated {
+		t.Errorf("unexpected date filter or sort: %+v", f)
+	}
+
+	// A full page returns a cursor that continues the same sort.
+	cursor := w.Header().Get("X-Next-Cursor")
+	if cursor == "" {
+		t.Fatal("expected a next cursor for a full page")
+	}
+	if w := list("cursor=" + cursor + "&limit=2"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 following the cursor, got %d: %s", w.Code, w.Body.String())
+	}
+	if c := ms.lastFilter.Cursor; c == nil || c.Sort != store.BacklogSortUpdated || ms.lastFilter.Sort != store.BacklogSortUpdated {
+		t.Errorf("expected the cursor to carry the updated sort, got %+v", ms.lastFilter)
+	}
+	if w := list("limit=5"); w.Header().Get("X-Next-Cursor") != "" {
+		t.Error("expected no cursor for the last page")
+	}
+
+	// A search without a sort lists by relevance, and its cursor carries
+	// the last item's rank.
+	rank := 0.25
+	for _, item := range ms.backlogItems {
+		item.SearchRank = &rank
+	}
+	w = list("q=auth&limit=2")
+	if ms.lastFilter.Sort != store.BacklogSortRelevance {
+		t.Errorf("expected a search to sort by relevance, got %q", ms.lastFilter.Sort)
+	}
+	c, err := store.DecodeBacklogCursor(w.Header().Get("X-Next-Cursor"))
+	if err != nil || c.Sort != store.BacklogSortRelevance || c.Rank == nil || *c.Rank != rank {
+		t.Errorf("expected a relevance cursor with the rank, got %+v (%v)", c, err)
+	}
+
+	for _, query := range []string{
+		"labels_match=some", "min_score=high", "has_blockers=maybe", "updated_before=yesterday",
+		"sort=title", "cursor=garbage", "sort=created&cursor=" + cursor, "sort=relevance",
+	} {
+		if w := list(query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
 func TestGetBacklogItem(t *testing.T) {
 	router, ms := setupBacklogTestRouter()
 
@@ -494,6 +892,113 @@ func TestUpdateBacklogItem(t *testing.T) {
 	}
 }
 
+func TestBacklogDueDateAndScoreHistory(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+
+	body := `{"title":"Ship before launch","due_date":"2026-11-01T09:00:00Z"}`
+	req := httptest.NewRequest("POST", "/api/v1/backlog", bytes.NewBufferString(body))
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var item store.BacklogItem
+	_ = json.NewDecoder(w.Body).Decode(&item)
+	if item.DueDate == nil || !item.DueDate.Equal(time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the due date stored, got %v", item.DueDate)
+	}
+
+	patch := func(body string) int {
+		req := httptest.NewRequest("PATCH", "/api/v1/backlog/"+item.ID.String(), bytes.NewBufferString(body))
+		req.Header.Set("X-Agent-ID", "test-agent")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := patch(`{"due_date":"next week"}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid due date, got %d", code)
+	}
+	if code := patch(`{"due_date":null}`); code != http.StatusOK || ms.backlogItems[item.ID].DueDate != nil {
+		t.Errorf("expected a null due date to clear it, got %d and %v", code, ms.backlogItems[item.ID].DueDate)
+	}
+
+	prev := 0.4
+	_ = ms.CreateScoreChange(context.Background(), &store.BacklogScoreChange{BacklogItemID: item.ID, PreviousScore: &prev, NewScore: 0.5, Reason: "rescore"})
+	_ = ms.CreateScoreChange(context.Background(), &store.BacklogScoreChange{BacklogItemID: uuid.New(), NewScore: 0.9, Reason: "rescore"})
+
+	req = httptest.NewRequest("GET", "/api/v1/backlog/"+item.ID.String()+"/score-history", nil)
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var history []store.BacklogScoreChange
+	_ = json.NewDecoder(w.Body).Decode(&history)
+	if len(history) != 1 || history[0].NewScore != 0.5 || *history[0].PreviousScore != 0.4 {
+		t.Errorf("expected the item's one score change, got %+v", history)
+	}
+}
+
+func TestBacklogHistory(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	item := &store.BacklogItem{Title: "Draft", ItemType: "task", Status: store.BacklogStatusBacklog}
+	_ = ms.CreateBacklogItem(context.Background(), item)
+	beforeEdit := time.Now()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Agent-ID", "alice")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("PATCH", "/api/v1/backlog/"+item.ID.String(), `{"title":"Final","assigned_to":"bob"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := do("GET", "/api/v1/backlog/"+item.ID.String()+"/history", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var history []store.ChangeRecord
+	_ = json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history) != 2 || history[0].Action != store.ChangeUpdated || history[1].Action != store.ChangeCreated {
+		t.Fatalf("expected the update then the creation, got %+v", history)
+	}
+	edit := history[0]
+	if edit.Actor != "alice" || history[1].Actor != store.SystemActor {
+		t.Errorf("expected the edit by alice and the creation by the system, got %s and %s", edit.Actor, history[1].Actor)
+	}
+	if fc := edit.Changes["title"]; string(fc.Old) != `"Draft"` || string(fc.New) != `"Final"` {
+		t.Errorf("unexpected title change: %s -> %s", fc.Old, fc.New)
+	}
+	// The update also rescored the item
+	if _, ok := edit.Changes["assigned_to"]; !ok || len(edit.Changes) != 3 || edit.Changes["priority_score"].New == nil {
+		t.Errorf("expected title, assigned_to and priority_score changed, got %v", edit.Changes)
+	}
+
+	// The item as it was before the edit
+	w = do("GET", "/api/v1/backlog/"+item.ID.String()+"?at="+beforeEdit.UTC().Format(time.RFC3339Nano), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var past store.BacklogItem
+	_ = json.Unmarshal(w.Body.Bytes(), &past)
+	if past.Title != "Draft" || past.AssignedTo != "" || past.PriorityScore != nil || past.ID != item.ID {
+		t.Errorf("expected the draft item, got %+v", past)
+	}
+
+	if w := do("GET", "/api/v1/backlog/"+item.ID.String()+"?at=2000-01-01T00:00:00Z", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before the item existed, got %d", w.Code)
+	}
+	if w := do("GET", "/api/v1/backlog/"+item.ID.String()+"?at=yesterday", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid at, got %d", w.Code)
+	}
+}
+
 func TestDeleteBacklogItem(t *testing.T) {
 	router, ms := setupBacklogTestRouter()
 
@@ -517,6 +1022,297 @@ func TestDeleteBacklogItem(t *testing.T) {
 	}
 }
 
+func TestDeleteBacklogItemDependents(t *testing.T) {
+	tests := []struct {
+		onCancel string
+		want     store.BacklogStatus
+	}{
+		{config.OnCancelResolve, store.BacklogStatusReady},
+		{config.OnCancelCascade, store.BacklogStatusCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.onCancel, func(t *testing.T) {
+			router, ms := setupBacklogTestRouter(func(cfg *config.Config) {
+				cfg.Dependencies.OnCancel = tt.onCancel
+			})
+			ctx := context.Background()
+
+			// A blocks B, which blocks C.
+			a := &store.BacklogItem{Title: "A", ItemType: "task", Status: store.BacklogStatusBacklog}
+			b := &store.BacklogItem{Title: "B", ItemType: "task", Status: store.BacklogStatusBlocked}
+			c := &store.BacklogItem{Title: "C", ItemType: "task", Status: store.BacklogStatusBlocked}
+			for _, item := range []*store.BacklogItem{a, b, c} {
+				_ = ms.CreateBacklogItem(ctx, item)
+			}
+			_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: a.ID, BlockedID: b.ID})
+			_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: b.ID, BlockedID: c.ID})
+
+			req := httptest.NewRequest("DELETE", "/api/v1/backlog/"+a.ID.String(), nil)
+			req.Header.Set("X-Agent-ID", "test-agent")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", w.Code)
+			}
+
+			if got := ms.backlogItems[b.ID].Status; got != tt.want {
+				t.Errorf("expected B %s, got %s", tt.want, got)
+			}
+			wantC := store.BacklogStatusBlocked
+			if tt.onCancel == config.OnCancelCascade {
+				wantC = store.BacklogStatusCancelled
+			}
+			if got := ms.backlogItems[c.ID].Status; got != wantC {
+				t.Errorf("expected C %s, got %s", wantC, got)
+			}
+		})
+	}
+}
+
+func TestBacklogBulk(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	ctx := context.Background()
+
+	epic := &store.BacklogItem{Title: "Epic", ItemType: "epic", Status: store.BacklogStatusReady}
+	_ = ms.CreateBacklogItem(ctx, epic)
+	a := &store.BacklogItem{Title: "A", ItemType: "task", Domain: "infra", Status: store.BacklogStatusReady, Labels: []string{"old"}, ParentID: &epic.ID}
+	b := &store.BacklogItem{Title: "B", ItemType: "task", Domain: "infra", Status: store.BacklogStatusBacklog}
+	blocked := &store.BacklogItem{Title: "Blocked", ItemType: "task", Status: store.BacklogStatusBlocked}
+	for _, item := range []*store.BacklogItem{a, b, blocked} {
+		_ = ms.CreateBacklogItem(ctx, item)
+	}
+	_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: a.ID, BlockedID: blocked.ID})
+
+	bulk := func(body string) (*httptest.ResponseRecorder, BulkBacklogResponse) {
+		req := httptest.NewRequest("POST", "/api/v1/backlog/bulk", bytes.NewBufferString(body))
+		req.Header.Set("X-Agent-ID", "test-agent")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp BulkBacklogResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	// A dry run reports the changes without making them.
+	w, resp := bulk(`{"ids":["` + a.ID.String() + `","` + b.ID.String() + `"],"action":"relabel","add_labels":["groomed"],"remove_labels":["old"],"dry_run":true,"overridden_by":"mike"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Applied || resp.Changed != 2 || resp.Results[0].PreviousValue != "old" || resp.Results[0].NewValue != "groomed" {
+		t.Errorf("unexpected dry run response: %+v", resp)
+	}
+	if len(ms.backlogItems[a.ID].Labels) != 1 || ms.backlogItems[a.ID].Labels[0] != "old" || len(ms.overrides) != 0 {
+		t.Errorf("expected a dry run to leave items and overrides alone")
+	}
+
+	// Moving the epic under its own child fails validation, so nothing moves.
+	w, resp = bulk(`{"ids":["` + b.ID.String() + `","` + epic.ID.String() + `"],"action":"reparent","parent_id":"` + a.ID.String() + `","overridden_by":"mike"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Applied || !resp.Results[0].OK || resp.Results[1].OK || resp.Results[1].Error == "" {
+		t.Errorf("expected only the epic to fail validation: %+v", resp)
+	}
+	if ms.backlogItems[b.ID].ParentID != nil {
+		t.Error("expected a rejected batch to leave B unparented")
+	}
+
+	// Cancelling by filter records an override per item and releases the
+	// items the cancelled ones were blocking.
+	w, resp = bulk(`{"filter":{"domain":"infra"},"action":"cancel","reason":"descoped","overridden_by":"mike"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !resp.Applied || resp.Matched != 2 || resp.Changed != 2 {
+		t.Errorf("unexpected cancel response: %+v", resp)
+	}
+	for _, item := range []*store.BacklogItem{a, b} {
+		if got := ms.backlogItems[item.ID].Status; got != store.BacklogStatusCancelled {
+			t.Errorf("expected %s cancelled, got %s", item.Title, got)
+		}
+	}
+	if len(ms.overrides) != 2 || ms.overrides[0].OverrideType != "status" || ms.overrides[0].Reason != "descoped" || ms.overrides[0].NewValue != "cancelled" {
+		t.Errorf("expected a status override per item, got %+v", ms.overrides)
+	}
+	if got := ms.backlogItems[blocked.ID].Status; got != store.BacklogStatusReady {
+		t.Errorf("expected the blocked item released, got %s", got)
+	}
+
+	for _, body := range []string{
+		`{"ids":["` + a.ID.String() + `"],"filter":{"domain":"infra"},"action":"park","overridden_by":"mike"}`,
+		`{"ids":["` + a.ID.String() + `"],"action":"set_tier","model_tier":"gold","overridden_by":"mike"}`,
+		`{"ids":["` + a.ID.String() + `"],"action":"archive","overridden_by":"mike"}`,
+		`{"ids":["` + a.ID.String() + `"],"action":"park"}`,
+	} {
+		if w, _ := bulk(body); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestBacklogImportExport(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	ctx := context.Background()
+
+	urgency := 0.9
+	epic := &store.BacklogItem{Title: "Epic", ItemType: "epic", Status: store.BacklogStatusReady, ExternalID: "epic-1"}
+	_ = ms.CreateBacklogItem(ctx, epic)
+	a := &store.BacklogItem{Title: "A", ItemType: "task", Domain: "infrastructure", Status: store.BacklogStatusInProgress, ExternalID: "a", ParentID: &epic.ID, Urgency: &urgency}
+	b := &store.BacklogItem{Title: "B", ItemType: "task", Status: store.BacklogStatusBlocked}
+	for _, item := range []*store.BacklogItem{a, b} {
+		_ = ms.CreateBacklogItem(ctx, item)
+	}
+	_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: a.ID, BlockedID: b.ID})
+	_ = ms.InitStages(ctx, a.ID, []string{"build"})
+	_ = ms.CreateGateCriteria(ctx, a.ID, "build", []string{"tests pass"})
+
+	export := func(format string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/backlog/export?format="+format, nil)
+		req.Header.Set("X-Agent-ID", "test-agent")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("export %s: expected 200, got %d: %s", format, w.Code, w.Body.String())
+		}
+		return w
+	}
+	importBody := func(query, body string) (*httptest.ResponseRecorder, BacklogImportResponse) {
+		req := httptest.NewRequest("POST", "/api/v1/backlog/import?"+query, bytes.NewBufferString(body))
+		req.Header.Set("X-Agent-ID", "test-agent")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp BacklogImportResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	// The export refers to parents and blockers by external ID, falling
+	// back to the item ID, and carries the current stage's gates.
+	var records []backlogio.Record
+	if err := json.Unmarshal(export("json").Body.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	byTitle := map[string]backlogio.Record{}
+	for _, rec := range records {
+		byTitle[rec.Title] = rec
+	}
+	if rec := byTitle["A"]; rec.Parent != "epic-1" || rec.CurrentStage != "build" || len(rec.Gates) != 1 || rec.Gates[0].Criterion != "tests pass" {
+		t.Errorf("unexpected record for A: %+v", rec)
+	}
+	if rec := byTitle["B"]; rec.ID != b.ID.String() || len(rec.BlockedBy) != 1 || rec.BlockedBy[0] != "a" {
+		t.Errorf("unexpected record for B: %+v", rec)
+	}
+	if w := export("csv"); !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") || !strings.HasPrefix(w.Body.String(), "id,external_id,title,") {
+		t.Errorf("unexpected csv export: %s", w.Body.String())
+	}
+	if w := export("md"); !strings.Contains(w.Body.String(), "## infrastructure") || !strings.Contains(w.Body.String(), "- [ ] 🔴 A <!-- id: a -->") {
+		t.Errorf("unexpected markdown export:\n%s", w.Body.String())
+	}
+
+	// Re-importing the export with one edit and one new item plans an
+	// update and a create; a dry run applies neither.
+	stale := byTitle["A"]
+	edited := stale
+	edited.Title = "A renamed"
+	file, _ := json.Marshal([]backlogio.Record{
+		byTitle["Epic"], edited, byTitle["B"],
+		{ExternalID: "c", Title: "C", Parent: "epic-1", BlockedBy: []string{"a"}},
+	})
+	w, resp := importBody("dry_run=true", string(file))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Applied || resp.Created != 1 || resp.Updated != 1 || resp.Unchanged != 2 || resp.Dependencies != 1 {
+		t.Errorf("unexpected dry run response: %+v", resp)
+	}
+	if got := resp.Results[1]; got.Action != "update" || len(got.Fields) != 1 || got.Fields[0] != "title" {
+		t.Errorf("unexpected result for A: %+v", got)
+	}
+	if len(ms.backlogItems) != 3 || ms.backlogItems[a.ID].Title != "A" {
+		t.Error("expected a dry run to leave the backlog alone")
+	}
+
+	w, resp = importBody("", string(file))
+	if w.Code != http.StatusOK || !resp.Applied {
+		t.Fatalf("expected the import applied, got %d: %s", w.Code, w.Body.String())
+	}
+	c, _ := ms.GetBacklogItemByExternalID(ctx, "c")
+	if c == nil || c.ParentID == nil || *c.ParentID != epic.ID || c.Source != "import" {
+		t.Fatalf("expected C created under the epic, got %+v", c)
+	}
+	if blocked, _ := ms.HasUnresolvedBlockers(ctx, c.ID); !blocked {
+		t.Error("expected C blocked by A")
+	}
+	if ms.backlogItems[a.ID].Title != "A renamed" {
+		t.Error("expected A renamed")
+	}
+	if history, _ := ms.ListChangeHistory(ctx, store.ChangeHistoryFilter{EntityType: store.EntityBacklogItem, EntityID: a.ID}); len(history) != 2 || history[0].Actor != "test-agent" {
+		t.Errorf("expected the rename recorded as test-agent's, got %+v", history)
+	}
+
+	// Importing a fresh export changes nothing.
+	if w, resp = importBody("", export("json").Body.String()); w.Code != http.StatusOK || resp.Applied || resp.Unchanged != 4 {
+		t.Errorf("expected importing an export to change nothing: %d %+v", w.Code, resp)
+	}
+
+	// Conflicts stop the whole file, and are all reported.
+	negative := -1
+	file, _ = json.Marshal([]backlogio.Record{
+		stale,
+		{ExternalID: "d", Title: "D", BlockedBy: []string{"nope"}},
+		{ExternalID: "d", Title: "D again"},
+		{ExternalID: "a", BlockedBy: []string{"c"}},
+		{Title: "E", Status: "someday"},
+		{Title: "F", StageTemplate: []string{"build"}, CurrentStage: "build", StageIndex: &negative},
+	})
+	w, resp = importBody("", string(file))
+	if w.Code != http.StatusConflict || resp.Applied {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Conflicts) != 6 {
+		t.Errorf("expected 6 conflicts, got %+v", resp.Conflicts)
+	}
+	if d, _ := ms.GetBacklogItemByExternalID(ctx, "d"); d != nil {
+		t.Error("expected nothing applied")
+	}
+
+	// A stale record applies with force, and a TODO list closes items.
+	if w, _ = importBody("force=true", `[`+string(mustJSON(stale))+`]`); w.Code != http.StatusOK || ms.backlogItems[a.ID].Title != "A" {
+		t.Errorf("expected the forced import applied, got %d: %s", w.Code, w.Body.String())
+	}
+	w, resp = importBody("format=md", "## Infrastructure\n- [x] A <!-- id: a -->\n- [ ] 🟠 Written down\n")
+	if w.Code != http.StatusOK || resp.Created != 1 || resp.Updated != 1 {
+		t.Fatalf("unexpected markdown import: %d %s", w.Code, w.Body.String())
+	}
+	if got := ms.backlogItems[a.ID].Status; got != store.BacklogStatusDone {
+		t.Errorf("expected A done, got %s", got)
+	}
+	if blocked, _ := ms.HasUnresolvedBlockers(ctx, c.ID); blocked {
+		t.Error("expected closing A to release C")
+	}
+	todo, _ := ms.GetBacklogItemByExternalID(ctx, "todo-written-down")
+	if todo == nil || todo.Domain != "infrastructure" || todo.Urgency == nil || *todo.Urgency != 0.75 {
+		t.Errorf("unexpected item from the TODO list: %+v", todo)
+	}
+
+	for _, query := range []string{"format=xml", "dry_run=maybe"} {
+		if w, _ := importBody(query, "[]"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
+
+func mustJSON(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
 // --- Lifecycle Transition Tests ---
 
 func TestBacklogStartTransition(t *testing.T) {
@@ -692,6 +1488,154 @@ func TestBacklogComplete(t *testing.T) {
 	}
 }
 
+func TestBacklogCompleteUnblocksDependents(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	ctx := context.Background()
+
+	blocker := &store.BacklogItem{Title: "Blocker", ItemType: "task", Status: store.BacklogStatusInProgress}
+	other := &store.BacklogItem{Title: "Other Blocker", ItemType: "task", Status: store.BacklogStatusInProgress}
+	single := &store.BacklogItem{Title: "Only Blocked By One", ItemType: "task", Status: store.BacklogStatusBlocked}
+	double := &store.BacklogItem{Title: "Blocked By Two", ItemType: "task", Status: store.BacklogStatusBlocked}
+	for _, item := range []*store.BacklogItem{blocker, other, single, double} {
+		_ = ms.CreateBacklogItem(ctx, item)
+	}
+	_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: blocker.ID, BlockedID: single.ID})
+	_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: blocker.ID, BlockedID: double.ID})
+	_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: other.ID, BlockedID: double.ID})
+
+	req := httptest.NewRequest("POST", "/api/v1/backlog/"+blocker.ID.String()+"/complete", nil)
+	req.Header.Set("Authorization", "Bearer test-admin-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if got := ms.backlogItems[single.ID]; got.Status != store.BacklogStatusReady || got.PriorityScore == nil {
+		t.Errorf("expected the unblocked item ready and rescored, got %s (score %v)", got.Status, got.PriorityScore)
+	}
+	if got := ms.backlogItems[double.ID]; got.Status != store.BacklogStatusBlocked {
+		t.Errorf("expected the item with another blocker to stay blocked, got %s", got.Status)
+	}
+	if has, _ := ms.HasUnresolvedBlockers(ctx, single.ID); has {
+		t.Error("expected the completed item's dependencies resolved")
+	}
+}
+
+func TestBacklogUpdateToDoneUnblocksDependents(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	ctx := context.Background()
+
+	blocker := &store.BacklogItem{Title: "Blocker", ItemType: "task", Status: store.BacklogStatusInProgress}
+	blocked := &store.BacklogItem{Title: "Blocked", ItemType: "task", Status: store.BacklogStatusBlocked}
+	for _, item := range []*store.BacklogItem{blocker, blocked} {
+		_ = ms.CreateBacklogItem(ctx, item)
+	}
+	_ = ms.CreateDependency(ctx, &store.BacklogDependency{BlockerID: blocker.ID, BlockedID: blocked.ID})
+
+	req := httptest.NewRequest("PATCH", "/api/v1/backlog/"+blocker.ID.String(), bytes.NewBufferString(`{"status":"done"}`))
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if got := ms.backlogItems[blocked.ID].Status; got != store.BacklogStatusReady {
+		t.Errorf("expected the blocked item released, got %s", got)
+	}
+	if has, _ := ms.HasUnresolvedBlockers(ctx, blocked.ID); has {
+		t.Error("expected the closed item's dependencies resolved")
+	}
+}
+
+func TestBacklogCompleteAutoCompletesParent(t *testing.T) {
+	router, ms := setupBacklogTestRouter(func(cfg *config.Config) {
+		cfg.Hierarchy.AutoComplete = true
+	})
+	ctx := context.Background()
+
+	epic := &store.BacklogItem{Title: "Epic", ItemType: "epic", Status: store.BacklogStatusInProgress}
+	_ = ms.CreateBacklogItem(ctx, epic)
+	story := &store.BacklogItem{Title: "Story", ItemType: "story", Status: store.BacklogStatusInProgress, ParentID: &epic.ID}
+	_ = ms.CreateBacklogItem(ctx, story)
+	first := &store.BacklogItem{Title: "First", ItemType: "task", Status: store.BacklogStatusInProgress, ParentID: &story.ID}
+	second := &store.BacklogItem{Title: "Second", ItemType: "task", Status: store.BacklogStatusInProgress, ParentID: &story.ID}
+	_ = ms.CreateBacklogItem(ctx, first)
+	_ = ms.CreateBacklogItem(ctx, second)
+
+	complete := func(item *store.BacklogItem) {
+		req := httptest.NewRequest("POST", "/api/v1/backlog/"+item.ID.String()+"/complete", nil)
+		req.Header.Set("Authorization", "Bearer test-admin-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	complete(first)
+	if got := ms.backlogItems[story.ID].Status; got != store.BacklogStatusInProgress {
+		t.Errorf("expected the story open with a child left, got %s", got)
+	}
+	complete(second)
+	if got := ms.backlogItems[story.ID].Status; got != store.BacklogStatusDone {
+		t.Errorf("expected the story done with its children, got %s", got)
+	}
+	if got := ms.backlogItems[epic.ID].Status; got != store.BacklogStatusDone {
+		t.Errorf("expected the epic done with its story, got %s", got)
+	}
+}
+
+func TestBacklogTree(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	ctx := context.Background()
+
+	epic := &store.BacklogItem{Title: "Epic", ItemType: "epic", Status: store.BacklogStatusInDiscovery}
+	_ = ms.CreateBacklogItem(ctx, epic)
+	done := &store.BacklogItem{Title: "Already Done", ItemType: "task", Status: store.BacklogStatusDone, ParentID: &epic.ID}
+	_ = ms.CreateBacklogItem(ctx, done)
+
+	// Subtasks created by discovery join the tree.
+	body := `{"subtasks":[{"title":"Discovered","estimated_tokens":3000}]}`
+	req := httptest.NewRequest("PATCH", "/api/v1/backlog/"+epic.ID.String()+"/discovery-complete", bytes.NewBufferString(body))
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/backlog/"+epic.ID.String()+"/tree", nil)
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var tree hierarchy.Node
+	_ = json.NewDecoder(w.Body).Decode(&tree)
+	if tree.Item == nil || tree.Item.ID != epic.ID || len(tree.Children) != 2 {
+		t.Fatalf("expected the epic with two children, got %+v", tree)
+	}
+	r := tree.Rollup
+	if r.Total != 2 || r.Done != 1 || r.PercentByCount != 50 {
+		t.Errorf("expected 1 of 2 done, got %d of %d (%v%%)", r.Done, r.Total, r.PercentByCount)
+	}
+	if r.EstimatedTokens != 3000 || r.WorstStatus != store.BacklogStatusBacklog {
+		t.Errorf("expected 3000 tokens and worst status backlog, got %d and %s", r.EstimatedTokens, r.WorstStatus)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/backlog/"+uuid.New().String()+"/tree", nil)
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing item, got %d", w.Code)
+	}
+}
+
 func TestBacklogCompleteRejectsWrongState(t *testing.T) {
 	router, ms := setupBacklogTestRouter()
 
@@ -874,6 +1818,106 @@ func TestListDependenciesForItem(t *testing.T) {
 	}
 }
 
+func TestCreateDependencyRejectsCycle(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+
+	a := &store.BacklogItem{Title: "A", ItemType: "task", Status: store.BacklogStatusBacklog}
+	b := &store.BacklogItem{Title: "B", ItemType: "task", Status: store.BacklogStatusBacklog}
+	_ = ms.CreateBacklogItem(context.Background(), a)
+	_ = ms.CreateBacklogItem(context.Background(), b)
+	_ = ms.CreateDependency(context.Background(), &store.BacklogDependency{BlockerID: a.ID, BlockedID: b.ID})
+
+	body, _ := json.Marshal(map[string]string{
+		"blocker_id": b.ID.String(),
+		"blocked_id": a.ID.String(),
+	})
+	req := httptest.NewRequest("POST", "/api/v1/backlog/dependencies", bytes.NewReader(body))
+	req.Header.Set("X-Agent-ID", "test-agent")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Cycle []uuid.UUID `json:"cycle"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Cycle) != 3 || resp.Cycle[0] != b.ID || resp.Cycle[1] != a.ID {
+		t.Errorf("expected cycle B, A, B, got %v", resp.Cycle)
+	}
+	if len(ms.deps) != 1 {
+		t.Errorf("expected the cyclic dependency not stored, got %d dependencies", len(ms.deps))
+	}
+}
+
+func TestBacklogGraph(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+
+	// Design blocks the epic's child; another pair of items is unrelated.
+	epic := &store.BacklogItem{Title: "Epic", ItemType: "epic", Status: store.BacklogStatusBacklog, EffortEstimate: "xs"}
+	_ = ms.CreateBacklogItem(context.Background(), epic)
+	child := &store.BacklogItem{Title: "Child", ItemType: "task", Status: store.BacklogStatusBacklog, EffortEstimate: "l", ParentID: &epic.ID}
+	design := &store.BacklogItem{Title: "Design", ItemType: "task", Status: store.BacklogStatusBacklog, EffortEstimate: "m"}
+	other := &store.BacklogItem{Title: "Other", ItemType: "task", Status: store.BacklogStatusBacklog}
+	unrelated := &store.BacklogItem{Title: "Unrelated", ItemType: "task", Status: store.BacklogStatusBacklog}
+	for _, item := range []*store.BacklogItem{child, design, other, unrelated} {
+		_ = ms.CreateBacklogItem(context.Background(), item)
+	}
+	_ = ms.CreateDependency(context.Background(), &store.BacklogDependency{BlockerID: design.ID, BlockedID: child.ID})
+	_ = ms.CreateDependency(context.Background(), &store.BacklogDependency{BlockerID: other.ID, BlockedID: unrelated.ID})
+
+	req := httptest.NewRequest("GET", "/api/v1/backlog/graph", nil)
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var full struct {
+		Nodes []map[string]interface{}  `json:"nodes"`
+		Edges []store.BacklogDependency `json:"edges"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&full)
+	if len(full.Nodes) != 4 || len(full.Edges) != 2 {
+		t.Errorf("expected the 4 items with dependencies and 2 edges, got %d and %d", len(full.Nodes), len(full.Edges))
+	}
+
+	// Rooted at the epic: the epic, its child and what blocks the child.
+	req = httptest.NewRequest("GET", "/api/v1/backlog/graph?root="+epic.ID.String(), nil)
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var sub struct {
+		Nodes        []map[string]interface{}  `json:"nodes"`
+		Edges        []store.BacklogDependency `json:"edges"`
+		HasCycle     bool                      `json:"has_cycle"`
+		CriticalPath struct {
+			Items  []uuid.UUID `json:"items"`
+			Effort float64     `json:"effort"`
+		} `json:"critical_path"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&sub)
+	if len(sub.Nodes) != 3 || len(sub.Edges) != 1 || sub.HasCycle {
+		t.Errorf("expected epic, child and design, got %d nodes, %d edges", len(sub.Nodes), len(sub.Edges))
+	}
+	if len(sub.CriticalPath.Items) != 2 || sub.CriticalPath.Items[0] != design.ID || sub.CriticalPath.Effort != 8 {
+		t.Errorf("expected critical path design -> child with effort 8, got %+v", sub.CriticalPath)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/backlog/graph?format=dot&root="+epic.ID.String(), nil)
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if ct := w.Header().Get("Content-Type"); ct != "text/vnd.graphviz" {
+		t.Errorf("expected a graphviz content type, got %q", ct)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"`+design.ID.String()+`" -> "`+child.ID.String()+`"`)) {
+		t.Errorf("expected the dependency edge in DOT output, got %s", w.Body.String())
+	}
+}
+
 // --- Full Backlog Lifecycle Test ---
 
 func TestFullBacklogLifecycle(t *testing.T) {
@@ -948,6 +1992,97 @@ func TestFullBacklogLifecycle(t *testing.T) {
 	}
 }
 
+// newStageTask puts an in-progress item at the implement stage and gives it a
+// running stage task.
+func newStageTask(ms *backlogMockStore, maxRetries int) (*store.BacklogItem, *store.Task) {
+	item := &store.BacklogItem{
+		ID:            uuid.New(),
+		Title:         "stage work",
+		Status:        store.BacklogStatusInProgress,
+		StageTemplate: []string{"implement", "verify"},
+		CurrentStage:  "implement",
+	}
+	ms.backlogItems[item.ID] = item
+	_ = ms.CreateGateCriteria(context.Background(), item.ID, "implement", []string{"code complete"})
+	task := &store.Task{
+		Title:         "implement: stage work",
+		Owner:         "dispatch",
+		Status:        store.StatusInProgress,
+		AssignedAgent: "scout",
+		BacklogItemID: &item.ID,
+		BacklogStage:  "implement",
+		MaxRetries:    maxRetries,
+		RetryEligible: true,
+	}
+	_ = ms.CreateTask(context.Background(), task)
+	return item, task
+}
+
+func TestCompleteStageTaskOverHTTPSubmitsEvidence(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	item, task := newStageTask(ms, 3)
+
+	body := `{"result":{"evidence":{"code complete":"PR #7"}}}`
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/complete", bytes.NewBufferString(body))
+	req.Header.Set("X-Agent-ID", "scout")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := ms.stageGates[item.ID]["implement"][0].Evidence; got != "PR #7" {
+		t.Errorf("expected the task result submitted as gate evidence, got %q", got)
+	}
+}
+
+func TestFailStageTaskOverHTTPBlocksItem(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	item, task := newStageTask(ms, 0)
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/fail", bytes.NewBufferString(`{"error":"build broken"}`))
+	req.Header.Set("X-Agent-ID", "scout")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ms.backlogItems[item.ID].Status != store.BacklogStatusBlocked {
+		t.Errorf("expected the item blocked once the task is dead-lettered, got %s", ms.backlogItems[item.ID].Status)
+	}
+}
+
+func TestFinishingAFinishedTaskConflicts(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	_, task := newStageTask(ms, 3)
+
+	post := func(action, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/"+action, bytes.NewBufferString(body))
+		req.Header.Set("X-Agent-ID", "scout")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := post("complete", `{"result":{}}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	events := len(ms.events)
+
+	// Completing again or failing the completed task must not rerun the
+	// completion or the retry logic.
+	if w := post("complete", `{"result":{}}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 completing twice, got %d", w.Code)
+	}
+	if w := post("fail", `{"error":"late"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 failing a completed task, got %d", w.Code)
+	}
+	if got := ms.tasks[task.ID]; got.Status != store.StatusCompleted || got.AssignedAgent != "scout" {
+		t.Errorf("expected the task left completed, got %s assigned to %q", got.Status, got.AssignedAgent)
+	}
+	if len(ms.events) != events {
+		t.Errorf("expected no events after completion, got %d more", len(ms.events)-events)
+	}
+}
+
 // --- Override Tests ---
 
 func TestCreateOverrideRequiresAdminToken(t *testing.T) {
@@ -1108,6 +2243,16 @@ func (m *backlogMockStore) UpdateAutonomyConfig(ctx context.Context, tier string
 func (m *backlogMockStore) IncrementConsecutiveApprovals(ctx context.Context, tier string) (int, error) { return 0, nil }
 func (m *backlogMockStore) IncrementConsecutiveCorrections(ctx context.Context, tier string) (int, error) { return 0, nil }
 func (m *backlogMockStore) ResetAutonomyCounters(ctx context.Context, tier string) error { return nil }
-func (m *backlogMockStore) SubmitEvidence(ctx context.Context, itemID uuid.UUID, stage, criterion, evidence, submittedBy string) error { return nil }
+func (m *backlogMockStore) SubmitEvidence(ctx context.Context, itemID uuid.UUID, stage, criterion, evidence, submittedBy string) error {
+	if m.stageGates[itemID] == nil {
+		return nil
+	}
+	for i, c := range m.stageGates[itemID][stage] {
+		if c.Criterion == criterion {
+			m.stageGates[itemID][stage][i].Evidence = evidence
+		}
+	}
+	return nil
+}
 func (m *backlogMockStore) ResetStageToActive(ctx context.Context, itemID uuid.UUID, stage string) error { return nil }
 
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+type BudgetsHandler struct {
+	store store.Store
+}
+
+func NewBudgetsHandler(s store.Store) *BudgetsHandler {
+	return &BudgetsHandler{store: s}
+}
+
+// BudgetStatus is a budget with its consumption in the current period.
+type BudgetStatus struct {
+	*store.Budget
+	PeriodStart time.Time          `json:"period_start"`
+	Usage       *store.BudgetUsage `json:"usage"`
+	Fraction    float64            `json:"fraction"`
+}
+
+type SetBudgetRequest struct {
+	Scope      string   `json:"scope"`
+	Subject    string   `json:"subject"`
+	Period     string   `json:"period"`
+	MaxTasks   *int     `json:"max_tasks,omitempty"`
+	MaxTokens  *int64   `json:"max_tokens,omitempty"`
+	MaxCostUSD *float64 `json:"max_cost_usd,omitempty"`
+	Action     string   `json:"action,omitempty"`
+}
+
+// List handles GET /api/v1/budgets
+func (h *BudgetsHandler) List(w http.ResponseWriter, r *http.Request) {
+	budgets, err := h.store.ListBudgets(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	out := make([]BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		start := b.PeriodStart(now)
+		usage, err := h.store.GetBudgetUsage(r.Context(), b.Scope, b.Subject, start)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		out = append(out, BudgetStatus{Budget: b, PeriodStart: start, Usage: usage, Fraction: b.Fraction(*usage)})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// Set handles PUT /api/v1/budgets. It creates the budget for the scope,
+// subject and period, or replaces its limits and action.
+func (h *BudgetsHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req SetBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.Scope != store.BudgetScopeOwner && req.Scope != store.BudgetScopeSource {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "scope must be owner or source"})
+		return
+	}
+	if req.Subject == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "subject required"})
+		return
+	}
+	if req.Period != store.BudgetPeriodDay && req.Period != store.BudgetPeriodMonth {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "period must be day or month"})
+		return
+	}
+	if req.Action == "" {
+		req.Action = store.BudgetActionReject
+	}
+	switch req.Action {
+	case store.BudgetActionReject, store.BudgetActionQueue, store.BudgetActionDowngrade:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "action must be reject, queue or downgrade"})
+		return
+	}
+	if req.MaxTasks == nil && req.MaxTokens == nil && req.MaxCostUSD == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at least one of max_tasks, max_tokens or max_cost_usd required"})
+		return
+	}
+
+	b := &store.Budget{
+		Scope:      req.Scope,
+		Subject:    req.Subject,
+		Period:     req.Period,
+		MaxTasks:   req.MaxTasks,
+		MaxTokens:  req.MaxTokens,
+		MaxCostUSD: req.MaxCostUSD,
+		Action:     req.Action,
+	}
+	if err := h.store.UpsertBudget(r.Context(), b); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// Delete handles DELETE /api/v1/budgets/{id}
+func (h *BudgetsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+
+	if err := h.store.DeleteBudget(r.Context(), id); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func adminRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("X-Agent-ID", "test-agent")
+	req.Header.Set("Authorization", "Bearer test-token")
+	return req
+}
+
+func TestSetAndListBudgets(t *testing.T) {
+	router, ms := setupTestRouter()
+	_ = ms.CreateTask(context.Background(), &store.Task{Title: "t", Owner: "mike-d"})
+
+	body := `{"scope":"owner","subject":"mike-d","period":"day","max_tasks":4,"action":"queue"}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("PUT", "/api/v1/budgets", body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/budgets", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var budgets []BudgetStatus
+	if err := json.NewDecoder(w.Body).Decode(&budgets); err != nil {
+		t.Fatalf("failed to decode budgets: %v", err)
+	}
+	if len(budgets) != 1 {
+		t.Fatalf("expected 1 budget, got %d", len(budgets))
+	}
+	if budgets[0].Action != store.BudgetActionQueue || budgets[0].Usage.Tasks != 1 || budgets[0].Fraction != 0.25 {
+		t.Errorf("unexpected budget status: %+v usage %+v", budgets[0].Budget, budgets[0].Usage)
+	}
+}
+
+func TestSetBudgetValidation(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	for _, body := range []string{
+		`{"scope":"team","subject":"x","period":"day","max_tasks":1}`,
+		`{"scope":"owner","subject":"","period":"day","max_tasks":1}`,
+		`{"scope":"owner","subject":"x","period":"week","max_tasks":1}`,
+		`{"scope":"owner","subject":"x","period":"day","max_tasks":1,"action":"ignore"}`,
+		`{"scope":"owner","subject":"x","period":"day"}`,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, adminRequest("PUT", "/api/v1/budgets", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestDeleteBudget(t *testing.T) {
+	router, ms := setupTestRouter()
+	max := 1
+	b := &store.Budget{Scope: store.BudgetScopeOwner, Subject: "x", Period: store.BudgetPeriodDay, MaxTasks: &max, Action: store.BudgetActionReject}
+	_ = ms.UpsertBudget(context.Background(), b)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("DELETE", "/api/v1/budgets/"+b.ID.String(), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(ms.budgets) != 0 {
+		t.Errorf("expected budget deleted, got %d", len(ms.budgets))
+	}
+}
+
+func TestCreateTaskRejectedByBudget(t *testing.T) {
+	router, ms := setupTestRouter()
+	max := 1
+	_ = ms.UpsertBudget(context.Background(), &store.Budget{
+		Scope:    store.BudgetScopeOwner,
+		Subject:  "mike-d",
+		Period:   store.BudgetPeriodDay,
+		MaxTasks: &max,
+		Action:   store.BudgetActionReject,
+	})
+
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/tasks", bytes.NewBufferString(`{"title":"t","owner":"mike-d"}`))
+		req.Header.Set("X-Agent-ID", "test-agent")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := create(); w.Code != http.StatusCreated {
+		t.Fatalf("expected first task created, got %d: %s", w.Code, w.Body.String())
+	}
+	w := create()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp["error"] != "budget exceeded" || resp["limits"] != "tasks" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestCreateTaskQueuedBudgetStillCreates(t *testing.T) {
+	router, ms := setupTestRouter()
+	max := 0
+	_ = ms.UpsertBudget(context.Background(), &store.Budget{
+		Scope:    store.BudgetScopeSource,
+		Subject:  "manual",
+		Period:   store.BudgetPeriodMonth,
+		MaxTasks: &max,
+		Action:   store.BudgetActionQueue,
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks", bytes.NewBufferString(`{"title":"t","source":"manual"}`))
+	req.Header.Set("X-Agent-ID", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -2,11 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/MikeSquared-Agency/Dispatch/internal/depgraph"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
 
@@ -23,6 +25,13 @@ type CreateDependencyRequest struct {
 	BlockedID string `json:"blocked_id"`
 }
 
+// cycleError rejects a dependency that would close a cycle.
+type cycleError struct {
+	cycle []uuid.UUID
+}
+
+func (e *cycleError) Error() string { return "dependency would create a cycle" }
+
 // Create handles POST /api/v1/backlog/dependencies
 func (h *DependenciesHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req CreateDependencyRequest
@@ -42,11 +51,33 @@ func (h *DependenciesHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if blockerID == blockedID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "an item cannot block itself"})
+		return
+	}
+
+	// Refuse dependencies that would deadlock items on each other. The
+	// check runs in the store's insert transaction, so concurrent requests
+	// cannot form a cycle between them.
 	dep := &store.BacklogDependency{
 		BlockerID: blockerID,
 		BlockedID: blockedID,
 	}
-	if err := h.store.CreateDependency(r.Context(), dep); err != nil {
+	err = h.store.CreateDependencyChecked(r.Context(), dep, func(existing []*store.BacklogDependency) error {
+		if cycle := depgraph.FindCycle(existing, blockerID, blockedID); cycle != nil {
+			return &cycleError{cycle: cycle}
+		}
+		return nil
+	})
+	var cycleErr *cycleError
+	if errors.As(err, &cycleErr) {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error": cycleErr.Error(),
+			"cycle": cycleErr.cycle,
+		})
+		return
+	}
+	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
@@ -88,3 +119,124 @@ func (h *DependenciesHandler) ListForItem(w http.ResponseWriter, r *http.Request
 	}
 	writeJSON(w, http.StatusOK, deps)
 }
+
+// graphNode is an item in the GET /api/v1/backlog/graph response.
+type graphNode struct {
+	ID              uuid.UUID           `json:"id"`
+	Title           string              `json:"title"`
+	Status          store.BacklogStatus `json:"status"`
+	EffortEstimate  string              `json:"effort_estimate,omitempty"`
+	EstimatedTokens *int64              `json:"estimated_tokens,omitempty"`
+	Effort          float64             `json:"effort"`
+	Critical        bool                `json:"critical"`
+}
+
+// Graph handles GET /api/v1/backlog/graph. It returns the dependency graph,
+// or with ?root= the part of it gating one item, along with its critical
+// path. ?format=dot renders it as Graphviz DOT instead of JSON.
+func (h *DependenciesHandler) Graph(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "dot" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json or dot"})
+		return
+	}
+
+	deps, err := h.store.ListDependencies(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	items := make(map[uuid.UUID]*store.BacklogItem)
+	var root uuid.UUID
+	if v := r.URL.Query().Get("root"); v != "" {
+		root, err = uuid.Parse(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid root"})
+			return
+		}
+		// Load the root's descendants, which gate it without dependencies
+		subtree, err := h.store.ListBacklogSubtree(r.Context(), root)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if len(subtree) == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "item not found"})
+			return
+		}
+		for _, item := range subtree {
+			items[item.ID] = item
+		}
+	}
+	var missing []uuid.UUID
+	for _, d := range deps {
+		for _, id := range []uuid.UUID{d.BlockerID, d.BlockedID} {
+			if _, ok := items[id]; !ok {
+				items[id] = nil
+				missing = append(missing, id)
+			}
+		}
+	}
+	endpoints, err := h.store.GetBacklogItems(r.Context(), missing)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, item := range endpoints {
+		items[item.ID] = item
+	}
+
+	list := make([]*store.BacklogItem, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			list = append(list, item)
+		}
+	}
+	g := depgraph.New(list, deps)
+	if root != uuid.Nil {
+		g = g.Subgraph(root)
+	}
+
+	path, err := g.CriticalPath()
+	hasCycle := errors.Is(err, depgraph.ErrCycle)
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(g.DOT(path)))
+		return
+	}
+
+	critical := make(map[uuid.UUID]bool, len(path.Items))
+	for _, id := range path.Items {
+		critical[id] = true
+	}
+	nodes := make([]graphNode, 0, len(g.Items))
+	for _, id := range g.IDs() {
+		item := g.Items[id]
+		nodes = append(nodes, graphNode{
+			ID:              item.ID,
+			Title:           item.Title,
+			Status:          item.Status,
+			EffortEstimate:  item.EffortEstimate,
+			EstimatedTokens: item.EstimatedTokens,
+			Effort:          depgraph.Effort(item),
+			Critical:        critical[id],
+		})
+	}
+	edges := g.Deps
+	if edges == nil {
+		edges = []*store.BacklogDependency{}
+	}
+
+	resp := map[string]interface{}{
+		"nodes":     nodes,
+		"edges":     edges,
+		"has_cycle": hasCycle,
+	}
+	if !hasCycle {
+		resp["critical_path"] = path
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
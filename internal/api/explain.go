@@ -63,5 +63,51 @@ func (h *ExplainHandler) Explain(w http.ResponseWriter, r *http.Request) {
 		resp["runtime"] = task.Runtime
 	}
 
+	if placement := taskPlacement(task); placement != nil {
+		resp["placement"] = placement
+	}
+
+	// Preemption decisions, both ones this task caused and ones it suffered.
+	events, err := h.store.GetTaskEvents(r.Context(), task.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	var preemptions []*store.TaskEvent
+	for _, e := range events {
+		if e.Event == "preempted" || e.Event == "preemption" {
+			preemptions = append(preemptions, e)
+		}
+	}
+	if len(preemptions) > 0 {
+		resp["preemptions"] = preemptions
+	}
+
 	writeJSON(w, http.StatusOK, resp)
 }
+
+// taskPlacement returns the task's placement constraints and, once it has
+// been scored, how the broker resolved them (including exclusions it added,
+// such as a review task's parent implementer). It is nil when there are none.
+func taskPlacement(task *store.Task) map[string]interface{} {
+	out := make(map[string]interface{})
+	if task.RequiredAgent != "" {
+		out["required_agent"] = task.RequiredAgent
+	}
+	if len(task.PreferredAgents) > 0 {
+		out["preferred_agents"] = task.PreferredAgents
+	}
+	if len(task.ExcludedAgents) > 0 {
+		out["excluded_agents"] = task.ExcludedAgents
+	}
+	if task.AffinityGroup != "" {
+		out["affinity_group"] = task.AffinityGroup
+	}
+	if resolved, ok := task.ScoringFactors["placement"]; ok {
+		out["resolved"] = resolved
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
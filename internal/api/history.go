@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+type HistoryHandler struct {
+	store store.Store
+}
+
+func NewHistoryHandler(s store.Store) *HistoryHandler {
+	return &HistoryHandler{store: s}
+}
+
+// Backlog handles GET /api/v1/backlog/{id}/history
+func (h *HistoryHandler) Backlog(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, store.EntityBacklogItem)
+}
+
+// Task handles GET /api/v1/tasks/{id}/history
+func (h *HistoryHandler) Task(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, store.EntityTask)
+}
+
+// list writes the recorded changes to an entity, newest first.
+func (h *HistoryHandler) list(w http.ResponseWriter, r *http.Request, entityType string) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	history, err := h.store.ListChangeHistory(r.Context(), store.ChangeHistoryFilter{EntityType: entityType, EntityID: id, Limit: limit})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if history == nil {
+		history = []*store.ChangeRecord{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// parseAt reads the at query parameter that asks for a point-in-time view.
+// It returns nil when at is not set, and false when at is not an RFC 3339
+// timestamp.
+func parseAt(r *http.Request) (*time.Time, bool) {
+	s := r.URL.Query().Get("at")
+	if s == "" {
+		return nil, true
+	}
+	at, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, false
+	}
+	return &at, true
+}
+
+// asOf returns current as it was at time at, from the changes recorded
+// since. It returns nil if the entity did not exist yet.
+func asOf[T any](r *http.Request, s store.Store, entityType string, id uuid.UUID, current *T, at time.Time) (*T, error) {
+	changes, err := s.ListChangeHistory(r.Context(), store.ChangeHistoryFilter{EntityType: entityType, EntityID: id, Since: &at})
+	if err != nil {
+		return nil, err
+	}
+	return store.AsOf(current, changes, at)
+}
@@ -0,0 +1,523 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/planning"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+type IterationsHandler struct {
+	store  store.Store
+	hermes hermes.Client
+	cfg    config.IterationsConfig
+}
+
+func NewIterationsHandler(s store.Store, h hermes.Client, cfg config.IterationsConfig) *IterationsHandler {
+	return &IterationsHandler{store: s, hermes: h, cfg: cfg}
+}
+
+type CreateIterationRequest struct {
+	Name         string    `json:"name"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	Capacity     float64   `json:"capacity"`
+	CapacityUnit string    `json:"capacity_unit,omitempty"`
+}
+
+// IterationItemsRequest selects backlog items to commit or uncommit.
+type IterationItemsRequest struct {
+	ItemIDs []string `json:"item_ids"`
+}
+
+// IterationView is an iteration with its committed items. Load is the
+// capacity taken by items that are not cancelled, Completed the part of it
+// that is done, and Remaining the rest.
+type IterationView struct {
+	*store.Iteration
+	Items     []*IterationItemView `json:"items"`
+	Load      float64              `json:"load"`
+	Completed float64              `json:"completed"`
+	Remaining float64              `json:"remaining"`
+}
+
+// IterationItemView is a committed backlog item and the capacity it takes.
+type IterationItemView struct {
+	*store.BacklogItem
+	Cost        float64   `json:"cost"`
+	CommittedBy string    `json:"committed_by"`
+	CommittedAt time.Time `json:"committed_at"`
+}
+
+// IterationPlanItem is an item the planner picked or skipped.
+type IterationPlanItem struct {
+	ItemID        string   `json:"item_id"`
+	Title         string   `json:"title"`
+	Domain        string   `json:"domain,omitempty"`
+	PriorityScore *float64 `json:"priority_score,omitempty"`
+	Cost          float64  `json:"cost"`
+	Reason        string   `json:"reason,omitempty"`
+}
+
+type IterationPlanResponse struct {
+	DryRun   bool                `json:"dry_run"`
+	Applied  bool                `json:"applied"`
+	Capacity float64             `json:"capacity"`
+	Load     float64             `json:"load"`
+	Picked   []IterationPlanItem `json:"picked"`
+	Skipped  []IterationPlanItem `json:"skipped"`
+}
+
+type IterationBurndownResponse struct {
+	IterationID  string                   `json:"iteration_id"`
+	CapacityUnit string                   `json:"capacity_unit"`
+	Points       []planning.BurndownPoint `json:"points"`
+}
+
+// Create handles POST /api/v1/iterations. The capacity unit is tokens or
+// points and defaults to tokens.
+func (h *IterationsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateIterationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name required"})
+		return
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "starts_at and ends_at required"})
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ends_at must be after starts_at"})
+		return
+	}
+	if req.Capacity <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "capacity must be positive"})
+		return
+	}
+	if req.CapacityUnit == "" {
+		req.CapacityUnit = store.CapacityTokens
+	}
+	if req.CapacityUnit != store.CapacityTokens && req.CapacityUnit != store.CapacityPoints {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "capacity_unit must be tokens or points"})
+		return
+	}
+
+	it := &store.Iteration{
+		Name:         req.Name,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		Capacity:     req.Capacity,
+		CapacityUnit: req.CapacityUnit,
+	}
+	if err := h.store.CreateIteration(r.Context(), it); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusCreated, it)
+}
+
+// List handles GET /api/v1/iterations, latest first.
+func (h *IterationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	iterations, err := h.store.ListIterations(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if iterations == nil {
+		iterations = []*store.Iteration{}
+	}
+	writeJSON(w, http.StatusOK, iterations)
+}
+
+// Get handles GET /api/v1/iterations/{id}
+func (h *IterationsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	view, err := h.view(r.Context(), it)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+// Delete handles DELETE /api/v1/iterations/{id}. Its items stay in the
+// backlog.
+func (h *IterationsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteIteration(r.Context(), it.ID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// Plan handles POST /api/v1/iterations/{id}/plan. It fills the capacity
+// left with ready items by priority score, taking an item only once its
+// blockers are done or in the iteration and keeping each domain within
+// iterations.max_domain_share while other domains have items that fit.
+// Items committed to another open iteration are left out. With ?dry_run
+// the plan is returned without committing anything.
+func (h *IterationsHandler) Plan(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	if s := r.URL.Query().Get("dry_run"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid dry_run"})
+			return
+		}
+		dryRun = v
+	}
+	it, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if !it.Open(time.Now()) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "iteration has ended"})
+		return
+	}
+	ctx := r.Context()
+
+	view, err := h.view(ctx, it)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	var committed []*store.BacklogItem
+	for _, item := range view.Items {
+		if item.Status != store.BacklogStatusCancelled {
+			committed = append(committed, item.BacklogItem)
+		}
+	}
+	elsewhere, err := h.store.ListOpenCommitments(ctx, it.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	open, err := h.store.ListOpenBacklogItems(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	var candidates []*store.BacklogItem
+	for _, item := range open {
+		if item.Status == store.BacklogStatusReady && elsewhere[item.ID] == nil {
+			candidates = append(candidates, item)
+		}
+	}
+	deps, err := h.store.ListDependencies(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	medianTokens, _ := h.store.GetMedianEstimatedTokens(ctx)
+
+	opts := planning.Options{
+		Capacity:       it.Capacity,
+		Unit:           it.CapacityUnit,
+		MaxDomainShare: h.cfg.MaxDomainShare,
+		DefaultTokens:  medianTokens,
+	}
+	res := planning.Plan(committed, candidates, deps, opts)
+
+	resp := IterationPlanResponse{
+		DryRun:   dryRun,
+		Capacity: it.Capacity,
+		Load:     res.Load,
+		Picked:   make([]IterationPlanItem, 0, len(res.Items)),
+		Skipped:  make([]IterationPlanItem, 0, len(res.Skipped)),
+	}
+	for _, item := range res.Items {
+		resp.Picked = append(resp.Picked, newIterationPlanItem(item, "", opts))
+	}
+	for _, s := range res.Skipped {
+		resp.Skipped = append(resp.Skipped, newIterationPlanItem(s.Item, s.Reason, opts))
+	}
+	if dryRun || len(res.Items) == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(res.Items))
+	for _, item := range res.Items {
+		ids = append(ids, item.ID)
+	}
+	if err := h.commit(ctx, it, ids, true); err != nil {
+		writeCommitError(w, err)
+		return
+	}
+	resp.Applied = true
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func newIterationPlanItem(item *store.BacklogItem, reason string, opts planning.Options) IterationPlanItem {
+	return IterationPlanItem{
+		ItemID:        item.ID.String(),
+		Title:         item.Title,
+		Domain:        item.Domain,
+		PriorityScore: item.PriorityScore,
+		Cost:          planning.Cost(item, opts.Unit, opts.DefaultTokens),
+		Reason:        reason,
+	}
+}
+
+// Commit handles POST /api/v1/iterations/{id}/commit. Items must be open
+// and not committed to another open iteration; items already in this one
+// are left as they are. Unlike the planner, committing by hand may take
+// the iteration over its capacity.
+func (h *IterationsHandler) Commit(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	ids, ok := decodeIterationItems(w, r)
+	if !ok {
+		return
+	}
+	if !it.Open(time.Now()) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "iteration has ended"})
+		return
+	}
+	ctx := r.Context()
+
+	items, err := h.store.GetBacklogItems(ctx, ids)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	byID := make(map[uuid.UUID]*store.BacklogItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	for _, id := range ids {
+		item := byID[id]
+		if item == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("item %s not found", id)})
+			return
+		}
+		if isClosed(item.Status) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("item %s is %s", id, item.Status)})
+			return
+		}
+	}
+
+	if err := h.commit(ctx, it, ids, false); err != nil {
+		writeCommitError(w, err)
+		return
+	}
+	h.writeView(w, r, it)
+}
+
+// writeCommitError writes a failed commit: 409 for an item committed to
+// another open iteration meanwhile.
+func writeCommitError(w http.ResponseWriter, err error) {
+	var conflict *store.IterationConflictError
+	if errors.As(err, &conflict) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": conflict.Error()})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// Uncommit handles POST /api/v1/iterations/{id}/uncommit. Items not in the
+// iteration are ignored.
+func (h *IterationsHandler) Uncommit(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	ids, ok := decodeIterationItems(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.UncommitIterationItems(r.Context(), it.ID, ids); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if h.hermes != nil {
+		_ = h.hermes.Publish(hermes.SubjectIterationUncommitted(it.ID.String()), hermes.IterationItemsEvent{
+			IterationID: it.ID.String(),
+			ItemIDs:     uuidStrings(ids),
+			Actor:       store.ActorFromContext(r.Context()),
+		})
+	}
+	h.writeView(w, r, it)
+}
+
+// Burndown handles GET /api/v1/iterations/{id}/burndown. It returns the
+// iteration's scope and completed work, in its capacity unit, at its start
+// and each day since, derived from its items' status history.
+func (h *IterationsHandler) Burndown(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	view, err := h.view(ctx, it)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	tracked := make([]planning.Tracked, 0, len(view.Items))
+	for _, item := range view.Items {
+		changes, err := h.store.ListChangeHistory(ctx, store.ChangeHistoryFilter{
+			EntityType: store.EntityBacklogItem,
+			EntityID:   item.ID,
+			Since:      &it.StartsAt,
+		})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		tracked = append(tracked, planning.Tracked{
+			Item:        item.BacklogItem,
+			CommittedAt: item.CommittedAt,
+			Cost:        item.Cost,
+			Changes:     changes,
+		})
+	}
+	points, err := planning.Burndown(it, tracked, time.Now())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, IterationBurndownResponse{
+		IterationID:  it.ID.String(),
+		CapacityUnit: it.CapacityUnit,
+		Points:       points,
+	})
+}
+
+func (h *IterationsHandler) load(w http.ResponseWriter, r *http.Request) (*store.Iteration, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid iteration id"})
+		return nil, false
+	}
+	it, err := h.store.GetIteration(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	if it == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "iteration not found"})
+		return nil, false
+	}
+	return it, true
+}
+
+func decodeIterationItems(w http.ResponseWriter, r *http.Request) ([]uuid.UUID, bool) {
+	var req IterationItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return nil, false
+	}
+	if len(req.ItemIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "item_ids required"})
+		return nil, false
+	}
+	ids := make([]uuid.UUID, 0, len(req.ItemIDs))
+	for _, s := range req.ItemIDs {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid item id %q", s)})
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// commit commits ids to it as the actor in ctx and announces them.
+func (h *IterationsHandler) commit(ctx context.Context, it *store.Iteration, ids []uuid.UUID, planned bool) error {
+	actor := store.ActorFromContext(ctx)
+	items := make([]*store.IterationItem, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, &store.IterationItem{IterationID: it.ID, BacklogItemID: id, CommittedBy: actor})
+	}
+	if err := h.store.CommitIterationItems(ctx, items); err != nil {
+		return err
+	}
+	if h.hermes != nil {
+		_ = h.hermes.Publish(hermes.SubjectIterationCommitted(it.ID.String()), hermes.IterationItemsEvent{
+			IterationID: it.ID.String(),
+			ItemIDs:     uuidStrings(ids),
+			Actor:       actor,
+			Planned:     planned,
+		})
+	}
+	return nil
+}
+
+// view loads the iteration's committed items and sizes them in its unit,
+// with items without estimated tokens sized at the median.
+func (h *IterationsHandler) view(ctx context.Context, it *store.Iteration) (*IterationView, error) {
+	committed, err := h.store.ListIterationItems(ctx, it.ID)
+	if err != nil {
+		return nil, err
+	}
+	medianTokens, _ := h.store.GetMedianEstimatedTokens(ctx)
+
+	view := &IterationView{Iteration: it, Items: []*IterationItemView{}}
+	for _, c := range committed {
+		item := c.Item
+		if item == nil {
+			continue
+		}
+		v := &IterationItemView{
+			BacklogItem: item,
+			Cost:        planning.Cost(item, it.CapacityUnit, medianTokens),
+			CommittedBy: c.CommittedBy,
+			CommittedAt: c.CommittedAt,
+		}
+		view.Items = append(view.Items, v)
+		switch item.Status {
+		case store.BacklogStatusCancelled:
+		case store.BacklogStatusDone:
+			view.Load += v.Cost
+			view.Completed += v.Cost
+		default:
+			view.Load += v.Cost
+		}
+	}
+	view.Remaining = view.Load - view.Completed
+	return view, nil
+}
+
+func (h *IterationsHandler) writeView(w http.ResponseWriter, r *http.Request, it *store.Iteration) {
+	view, err := h.view(r.Context(), it)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+	return out
+}
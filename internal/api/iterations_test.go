@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func addIterationTestItem(ms *backlogMockStore, title, domain, effort string, score float64, status store.BacklogStatus) *store.BacklogItem {
+	item := &store.BacklogItem{
+		ID:             uuid.New(),
+		Title:          title,
+		Domain:         domain,
+		EffortEstimate: effort,
+		PriorityScore:  &score,
+		Status:         status,
+		CreatedAt:      time.Now(),
+	}
+	ms.backlogItems[item.ID] = item
+	ms.saved[item.ID] = *item
+	return item
+}
+
+func createIteration(t *testing.T, router http.Handler, body string) *store.Iteration {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/iterations", body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var it store.Iteration
+	if err := json.NewDecoder(w.Body).Decode(&it); err != nil {
+		t.Fatalf("failed to decode iteration: %v", err)
+	}
+	return &it
+}
+
+// iterationBody returns a request body for an iteration that started an
+// hour ago and runs for a week.
+func iterationBody(name string, capacity float64, unit string) string {
+	start := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	end := time.Now().Add(7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	return fmt.Sprintf(`{"name":%q,"starts_at":%q,"ends_at":%q,"capacity":%v,"capacity_unit":%q}`, name, start, end, capacity, unit)
+}
+
+func itemIDsBody(items ...*store.BacklogItem) string {
+	var req IterationItemsRequest
+	for _, item := range items {
+		req.ItemIDs = append(req.ItemIDs, item.ID.String())
+	}
+	return string(mustJSON(req))
+}
+
+func getIterationView(t *testing.T, router http.Handler, id uuid.UUID) IterationView {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/iterations/"+id.String(), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var view IterationView
+	if err := json.NewDecoder(w.Body).Decode(&view); err != nil {
+		t.Fatalf("failed to decode iteration: %v", err)
+	}
+	return view
+}
+
+func TestCreateIteration(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+
+	it := createIteration(t, router, `{"name":"sprint-1","starts_at":"2026-11-02T00:00:00Z","ends_at":"2026-11-16T00:00:00Z","capacity":2000000}`)
+	if it.CapacityUnit != store.CapacityTokens {
+		t.Errorf("expected default unit tokens, got %q", it.CapacityUnit)
+	}
+	if ms.iterations[it.ID] == nil {
+		t.Error("expected iteration stored")
+	}
+
+	for _, body := range []string{
+		`not json`,
+		`{"starts_at":"2026-11-02T00:00:00Z","ends_at":"2026-11-16T00:00:00Z","capacity":10}`,
+		`{"name":"a","ends_at":"2026-11-16T00:00:00Z","capacity":10}`,
+		`{"name":"a","starts_at":"2026-11-16T00:00:00Z","ends_at":"2026-11-02T00:00:00Z","capacity":10}`,
+		`{"name":"a","starts_at":"2026-11-02T00:00:00Z","ends_at":"2026-11-16T00:00:00Z","capacity":0}`,
+		`{"name":"a","starts_at":"2026-11-02T00:00:00Z","ends_at":"2026-11-16T00:00:00Z","capacity":10,"capacity_unit":"hours"}`,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, adminRequest("POST", "/api/v1/iterations", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestIterationPlan(t *testing.T) {
+	router, ms := setupBacklogTestRouter(func(cfg *config.Config) {
+		cfg.Iterations.MaxDomainShare = 0.5
+	})
+	ops1 := addIterationTestItem(ms, "ops1", "ops", "m", 0.9, store.BacklogStatusReady)
+	ops2 := addIterationTestItem(ms, "ops2", "ops", "m", 0.8, store.BacklogStatusReady)
+	web := addIterationTestItem(ms, "web", "web", "m", 0.2, store.BacklogStatusReady)
+	blocker := addIterationTestItem(ms, "blocker", "web", "s", 0.1, store.BacklogStatusInProgress)
+	blocked := addIterationTestItem(ms, "blocked", "web", "xs", 0.95, store.BacklogStatusReady)
+	addIterationTestItem(ms, "big", "data", "xl", 0.7, store.BacklogStatusReady)
+	_ = ms.CreateDependency(context.Background(), &store.BacklogDependency{BlockerID: blocker.ID, BlockedID: blocked.ID})
+
+	it := createIteration(t, router, iterationBody("sprint", 10, store.CapacityPoints))
+
+	plan := func(dryRun bool) IterationPlanResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, adminRequest("POST", fmt.Sprintf("/api/v1/iterations/%s/plan?dry_run=%t", it.ID, dryRun), ""))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp IterationPlanResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode plan: %v", err)
+		}
+		return resp
+	}
+
+	// ops takes its half, web gets in ahead of ops2, then ops2 takes the
+	// capacity no other domain can use. The xl item does not fit and the
+	// blocked item waits for its blocker.
+	resp := plan(true)
+	var picked []string
+	for _, p := range resp.Picked {
+		picked = append(picked, p.Title)
+	}
+	if fmt.Sprint(picked) != "[ops1 web ops2]" || resp.Load != 9 || resp.Applied {
+		t.Fatalf("unexpected dry run plan: %+v", resp)
+	}
+	reasons := map[string]string{}
+	for _, s := range resp.Skipped {
+		reasons[s.Title] = s.Reason
+	}
+	if len(reasons) != 2 || reasons["blocked"] != "blocked" || reasons["big"] != "capacity" {
+		t.Errorf("unexpected skips: %v", reasons)
+	}
+	if len(ms.committed[it.ID]) != 0 {
+		t.Fatal("dry run committed items")
+	}
+
+	resp = plan(false)
+	if !resp.Applied || len(resp.Picked) != 3 {
+		t.Fatalf("unexpected plan: %+v", resp)
+	}
+	view := getIterationView(t, router, it.ID)
+	if len(view.Items) != 3 || view.Load != 9 || view.Remaining != 9 {
+		t.Errorf("unexpected iteration: load %v, remaining %v, %d items", view.Load, view.Remaining, len(view.Items))
+	}
+	for _, item := range view.Items {
+		if item.CommittedBy != "test-agent" || item.Cost != 3 {
+			t.Errorf("unexpected committed item: %+v", item)
+		}
+	}
+
+	// Items committed to another open iteration are not planned again.
+	other := createIteration(t, router, iterationBody("other", 100, store.CapacityPoints))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/iterations/"+other.ID.String()+"/plan", ""))
+	var otherResp IterationPlanResponse
+	_ = json.NewDecoder(w.Body).Decode(&otherResp)
+	for _, p := range otherResp.Picked {
+		if p.ItemID == ops1.ID.String() || p.ItemID == ops2.ID.String() || p.ItemID == web.ID.String() {
+			t.Errorf("item %s planned into two iterations", p.Title)
+		}
+	}
+}
+
+func TestIterationCommitAndUncommit(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	a := addIterationTestItem(ms, "a", "ops", "m", 0.5, store.BacklogStatusReady)
+	b := addIterationTestItem(ms, "b", "ops", "l", 0.5, store.BacklogStatusInProgress)
+	done := addIterationTestItem(ms, "done", "ops", "s", 0.5, store.BacklogStatusDone)
+
+	it := createIteration(t, router, iterationBody("sprint", 4, store.CapacityPoints))
+	path := "/api/v1/iterations/" + it.ID.String()
+
+	// Committing by hand may go over capacity.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", path+"/commit", itemIDsBody(a, b)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var view IterationView
+	_ = json.NewDecoder(w.Body).Decode(&view)
+	if len(view.Items) != 2 || view.Load != 8 {
+		t.Errorf("unexpected iteration after commit: %+v", view)
+	}
+
+	// Committing again is a no-op.
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", path+"/commit", itemIDsBody(a)))
+	if w.Code != http.StatusOK || len(ms.committed[it.ID]) != 2 {
+		t.Errorf("expected recommit to be a no-op, got %d with %d items", w.Code, len(ms.committed[it.ID]))
+	}
+
+	other := createIteration(t, router, iterationBody("other", 10, store.CapacityPoints))
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"item_ids":[]}`, http.StatusBadRequest},
+		{`{"item_ids":["nope"]}`, http.StatusBadRequest},
+		{itemIDsBody(&store.BacklogItem{ID: uuid.New()}), http.StatusNotFound},
+		{itemIDsBody(done), http.StatusConflict},
+		{itemIDsBody(a), http.StatusConflict}, // in sprint
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, adminRequest("POST", "/api/v1/iterations/"+other.ID.String()+"/commit", tc.body))
+		if w.Code != tc.want {
+			t.Errorf("commit %s: expected %d, got %d: %s", tc.body, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", path+"/uncommit", itemIDsBody(a)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	_ = json.NewDecoder(w.Body).Decode(&view)
+	if len(view.Items) != 1 || view.Items[0].ID != b.ID {
+		t.Errorf("expected only b left, got %+v", view.Items)
+	}
+
+	// Once uncommitted, the item is free for the other iteration.
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/iterations/"+other.ID.String()+"/commit", itemIDsBody(a)))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Ended iterations take no more items.
+	ended := createIteration(t, router, `{"name":"past","starts_at":"2026-01-01T00:00:00Z","ends_at":"2026-01-15T00:00:00Z","capacity":10,"capacity_unit":"points"}`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/iterations/"+ended.ID.String()+"/commit", itemIDsBody(b)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for an ended iteration, got %d", w.Code)
+	}
+}
+
+func TestIterationBurndown(t *testing.T) {
+	router, ms := setupBacklogTestRouter()
+	a := addIterationTestItem(ms, "a", "ops", "m", 0.5, store.BacklogStatusReady)
+	b := addIterationTestItem(ms, "b", "ops", "s", 0.5, store.BacklogStatusReady)
+
+	start := time.Now().Add(-36 * time.Hour).UTC()
+	end := start.Add(72 * time.Hour)
+	it := createIteration(t, router, fmt.Sprintf(`{"name":"sprint","starts_at":%q,"ends_at":%q,"capacity":10,"capacity_unit":"points"}`,
+		start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano)))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/iterations/"+it.ID.String()+"/commit", itemIDsBody(a, b)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	done := *a
+	done.Status = store.BacklogStatusDone
+	_ = ms.UpdateBacklogItem(context.Background(), &done)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/iterations/"+it.ID.String()+"/burndown", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp IterationBurndownResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	// The start, a day in, and now; the items were committed since.
+	if len(resp.Points) != 3 || resp.CapacityUnit != store.CapacityPoints {
+		t.Fatalf("unexpected burndown: %+v", resp)
+	}
+	if p := resp.Points[1]; p.Scope != 0 {
+		t.Errorf("expected no scope before the commit, got %+v", p)
+	}
+	if p := resp.Points[2]; p.Scope != 5 || p.Completed != 3 || p.Remaining != 2 {
+		t.Errorf("unexpected latest point: %+v", p)
+	}
+}
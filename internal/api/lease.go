@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+type LeaseHandler struct {
+	store  store.Store
+	hermes hermes.Client
+	cfg    *config.Config
+}
+
+func NewLeaseHandler(s store.Store, h hermes.Client, cfg *config.Config) *LeaseHandler {
+	return &LeaseHandler{store: s, hermes: h, cfg: cfg}
+}
+
+type ExtendLeaseRequest struct {
+	ExtendSeconds int `json:"extend_seconds,omitempty"`
+}
+
+// Ack handles POST /api/v1/tasks/{id}/ack
+func (h *LeaseHandler) Ack(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadOwnedTask(w, r)
+	if !ok {
+		return
+	}
+
+	agentID := r.Header.Get("X-Agent-ID")
+	if task.Acknowledge(time.Now()) {
+		if err := h.store.UpdateTask(r.Context(), task); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		_ = h.store.CreateTaskEvent(r.Context(), &store.TaskEvent{
+			TaskID:  task.ID,
+			Event:   "acked",
+			AgentID: agentID,
+		})
+		if h.hermes != nil {
+			_ = h.hermes.Publish(hermes.SubjectTaskAcked(task.ID.String()), map[string]interface{}{
+				"task_id":          task.ID.String(),
+				"agent_id":         agentID,
+				"lease_expires_at": task.LeaseExpiresAt,
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"task_id":          task.ID,
+		"acked_at":         task.AckedAt,
+		"lease_expires_at": task.LeaseExpiresAt,
+	})
+}
+
+// ExtendLease handles POST /api/v1/tasks/{id}/lease
+func (h *LeaseHandler) ExtendLease(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadOwnedTask(w, r)
+	if !ok {
+		return
+	}
+
+	var req ExtendLeaseRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+	}
+	if req.ExtendSeconds < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "extend_seconds must be positive"})
+		return
+	}
+
+	now := time.Now()
+	acked := task.Acknowledge(now)
+
+	extend := time.Duration(req.ExtendSeconds) * time.Second
+	if extend == 0 {
+		extend = time.Duration(task.TimeoutSeconds) * time.Second
+	}
+	expires := now.Add(extend)
+
+	// Leases cannot run past the hard deadline (or the task's own timeout,
+	// whichever is longer), measured from when work began.
+	capped := false
+	if hard := h.cfg.HardDeadline(); hard > 0 {
+		if timeout := time.Duration(task.TimeoutSeconds) * time.Second; timeout > hard {
+			hard = timeout
+		}
+		start := task.AckedAt
+		if task.StartedAt != nil {
+			start = task.StartedAt
+		}
+		if limit := start.Add(hard); expires.After(limit) {
+			expires = limit
+			capped = true
+		}
+	}
+	task.LeaseExpiresAt = &expires
+
+	// Past the first ack, extending changes only the lease.
+	save := h.store.UpdateTaskLiveness
+	if acked {
+		save = h.store.UpdateTask
+	}
+	if err := save(r.Context(), task); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	_ = h.store.CreateTaskEvent(r.Context(), &store.TaskEvent{
+		TaskID:  task.ID,
+		Event:   "lease_extended",
+		AgentID: r.Header.Get("X-Agent-ID"),
+		Payload: map[string]interface{}{"lease_expires_at": expires, "capped": capped},
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"task_id":          task.ID,
+		"lease_expires_at": expires,
+		"capped":           capped,
+	})
+}
+
+// loadOwnedTask fetches an active task and checks the caller is its assignee.
+func (h *LeaseHandler) loadOwnedTask(w http.ResponseWriter, r *http.Request) (*store.Task, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid task id"})
+		return nil, false
+	}
+	task, err := h.store.GetTask(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	if task == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return nil, false
+	}
+	if task.Status != store.StatusAssigned && task.Status != store.StatusInProgress {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task must be assigned or in_progress"})
+		return nil, false
+	}
+	if task.AssignedAgent != r.Header.Get("X-Agent-ID") {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "task is assigned to another agent"})
+		return nil, false
+	}
+	return task, true
+}
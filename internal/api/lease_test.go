@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func createAssignedTask(ms *mockStore, agent string) *store.Task {
+	now := time.Now()
+	task := &store.Task{
+		Title:          "lease",
+		Owner:          "system",
+		Status:         store.StatusAssigned,
+		AssignedAgent:  agent,
+		AssignedAt:     &now,
+		TimeoutSeconds: 300,
+		Source:         "manual",
+	}
+	_ = ms.CreateTask(context.TODO(), task)
+	return task
+}
+
+func TestAckTask(t *testing.T) {
+	router, ms := setupTestRouter()
+	task := createAssignedTask(ms, "nova")
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/ack", nil)
+	req.Header.Set("X-Agent-ID", "nova")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	updated := ms.tasks[task.ID]
+	if updated.AckedAt == nil || updated.LeaseExpiresAt == nil {
+		t.Fatal("expected acked_at and lease_expires_at set")
+	}
+	if updated.Status != store.StatusAssigned {
+		t.Errorf("expected ack not to change status, got %s", updated.Status)
+	}
+	if len(ms.events) != 1 || ms.events[0].Event != "acked" {
+		t.Errorf("expected one acked event, got %d", len(ms.events))
+	}
+
+	// A second ack is a no-op.
+	req = httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/ack", nil)
+	req.Header.Set("X-Agent-ID", "nova")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if len(ms.events) != 1 {
+		t.Errorf("expected repeated ack not to log again, got %d events", len(ms.events))
+	}
+}
+
+func TestAckRejectsOtherAgent(t *testing.T) {
+	router, ms := setupTestRouter()
+	task := createAssignedTask(ms, "nova")
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/ack", nil)
+	req.Header.Set("X-Agent-ID", "scout")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestExtendLease(t *testing.T) {
+	router, ms := setupTestRouter()
+	task := createAssignedTask(ms, "nova")
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/lease",
+		bytes.NewBufferString(`{"extend_seconds":900}`))
+	req.Header.Set("X-Agent-ID", "nova")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		LeaseExpiresAt time.Time `json:"lease_expires_at"`
+		Capped         bool      `json:"capped"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if until := time.Until(resp.LeaseExpiresAt); until < 890*time.Second || until > 910*time.Second {
+		t.Errorf("expected lease ~15m out, got %v", until)
+	}
+	if resp.Capped {
+		t.Error("expected lease not capped without a hard deadline")
+	}
+	if ms.tasks[task.ID].AckedAt == nil {
+		t.Error("expected lease extension to count as an ack")
+	}
+}
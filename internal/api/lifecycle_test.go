@@ -15,7 +15,7 @@ import (
 // TestFullTaskLifecycle exercises the complete happy-path:
 // create → get → progress (assigned→in_progress) → complete
 func TestFullTaskLifecycle(t *testing.T) {
-	router, _ := setupTestRouter()
+	router, ms := setupTestRouter()
 
 	// 1. Create task
 	body := `{"title":"E2E Lifecycle","required_capabilities":["research"],"priority":5,"owner":"mike-d"}`
@@ -72,8 +72,8 @@ func TestFullTaskLifecycle(t *testing.T) {
 
 	// 4. Report progress (transitions assigned→in_progress via API)
 	// First manually set to assigned state since we don't have the broker running
-	fetched.Status = store.StatusAssigned
-	fetched.AssignedAgent = "scout"
+	ms.tasks[created.ID].Status = store.StatusAssigned
+	ms.tasks[created.ID].AssignedAgent = "scout"
 
 	req = httptest.NewRequest("POST", "/api/v1/tasks/"+taskID+"/progress", bytes.NewBufferString(`{"progress":0.5,"detail":"halfway"}`))
 	req.Header.Set("X-Agent-ID", "scout")
@@ -169,6 +169,34 @@ func TestTaskFailureLifecycle(t *testing.T) {
 	}
 }
 
+// TestTaskFailureClass verifies the fail endpoint records and validates failure_class
+func TestTaskFailureClass(t *testing.T) {
+	router, ms := setupTestRouter()
+	task := &store.Task{Title: "Classified", Owner: "system", Status: store.StatusInProgress, AssignedAgent: "nova"}
+	_ = ms.CreateTask(context.TODO(), task)
+
+	// The invalid class goes first: once the task has failed, any further
+	// report conflicts.
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"error":"oops","failure_class":"cosmic_rays"}`, http.StatusBadRequest},
+		{`{"error":"rate limited","failure_class":"quota"}`, http.StatusOK},
+	} {
+		req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/fail", bytes.NewBufferString(tc.body))
+		req.Header.Set("X-Agent-ID", "nova")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.body, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if task.FailureClass != store.FailureQuota {
+		t.Errorf("expected failure class quota, got %q", task.FailureClass)
+	}
+}
+
 // TestTaskListFiltering verifies status, owner, and source filters
 func TestTaskListFiltering(t *testing.T) {
 	router, ms := setupTestRouter()
@@ -342,6 +370,136 @@ func TestProgressEndpointOnAlreadyInProgress(t *testing.T) {
 	}
 }
 
+// TestProgressStoresTypedSnapshot verifies the latest progress is kept on the task row.
+func TestProgressStoresTypedSnapshot(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	task := &store.Task{
+		Title:         "Snapshot",
+		Owner:         "system",
+		Status:        store.StatusInProgress,
+		AssignedAgent: "nova",
+		Source:        "manual",
+	}
+	_ = ms.CreateTask(context.TODO(), task)
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/progress",
+		bytes.NewBufferString(`{"percent":40,"stage":"tests","message":"running suite","eta_seconds":120}`))
+	req.Header.Set("X-Agent-ID", "nova")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/tasks/"+task.ID.String(), nil)
+	req.Header.Set("X-Agent-ID", "nova")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var fetched store.Task
+	_ = json.NewDecoder(w.Body).Decode(&fetched)
+	if fetched.Progress == nil {
+		t.Fatal("expected progress snapshot on task")
+	}
+	if fetched.Progress.Percent == nil || *fetched.Progress.Percent != 40 {
+		t.Errorf("expected percent 40, got %v", fetched.Progress.Percent)
+	}
+	if fetched.Progress.Stage != "tests" || fetched.Progress.Message != "running suite" {
+		t.Errorf("unexpected snapshot %+v", fetched.Progress)
+	}
+	if fetched.Progress.ETASeconds == nil || *fetched.Progress.ETASeconds != 120 {
+		t.Errorf("expected eta 120, got %v", fetched.Progress.ETASeconds)
+	}
+	if fetched.LastHeartbeatAt == nil {
+		t.Error("expected progress to count as a heartbeat")
+	}
+}
+
+// TestHeartbeatEndpoint verifies heartbeats update liveness without logging events.
+func TestHeartbeatEndpoint(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	task := &store.Task{
+		Title:         "Heartbeat",
+		Owner:         "system",
+		Status:        store.StatusInProgress,
+		AssignedAgent: "nova",
+		Source:        "manual",
+	}
+	_ = ms.CreateTask(context.TODO(), task)
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/heartbeat", nil)
+	req.Header.Set("X-Agent-ID", "nova")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ms.tasks[task.ID].LastHeartbeatAt == nil {
+		t.Error("expected last_heartbeat_at set")
+	}
+	if len(ms.events) != 0 {
+		t.Errorf("expected no events for heartbeat, got %d", len(ms.events))
+	}
+
+	// The first heartbeat acked the task; later ones write only liveness.
+	if ms.livenessWrites != 0 {
+		t.Errorf("expected the acking heartbeat to be a full write, got %d liveness writes", ms.livenessWrites)
+	}
+	req = httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/heartbeat", nil)
+	req.Header.Set("X-Agent-ID", "nova")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if ms.livenessWrites != 1 {
+		t.Errorf("expected a later heartbeat to write only liveness, got %d liveness writes", ms.livenessWrites)
+	}
+}
+
+// TestHeartbeatRejectsTerminalTask verifies heartbeats on finished tasks conflict.
+func TestHeartbeatRejectsTerminalTask(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	task := &store.Task{Title: "Done", Owner: "system", Status: store.StatusCompleted, Source: "manual"}
+	_ = ms.CreateTask(context.TODO(), task)
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/heartbeat", nil)
+	req.Header.Set("X-Agent-ID", "nova")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+func TestProgressRejectsTerminalTask(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	for _, status := range []store.TaskStatus{store.StatusCompleted, store.StatusFailed, store.StatusTimedOut} {
+		task := &store.Task{Title: "Finished", Owner: "system", Status: status, AssignedAgent: "nova", Source: "manual"}
+		_ = ms.CreateTask(context.TODO(), task)
+
+		req := httptest.NewRequest("POST", "/api/v1/tasks/"+task.ID.String()+"/progress",
+			bytes.NewBufferString(`{"percent":50}`))
+		req.Header.Set("X-Agent-ID", "nova")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: expected 409, got %d", status, w.Code)
+		}
+		if events, _ := ms.GetTaskEvents(context.TODO(), task.ID); len(events) != 0 {
+			t.Errorf("%s: expected no progress event, got %d", status, len(events))
+		}
+		if ms.tasks[task.ID].Progress != nil || ms.tasks[task.ID].LastHeartbeatAt != nil {
+			t.Errorf("%s: expected the task left unchanged", status)
+		}
+	}
+}
+
 // TestDiscoveryComplete exercises the discovery-complete endpoint.
 func TestDiscoveryComplete(t *testing.T) {
 	router, ms := setupTestRouter()
@@ -497,3 +655,71 @@ func TestExplainIncludesModelRouting(t *testing.T) {
 		t.Errorf("expected runtime in explain, got %v", resp["runtime"])
 	}
 }
+
+// TestExplainIncludesPreemptions verifies preemption decisions are explained.
+func TestExplainIncludesPreemptions(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	task := &store.Task{Title: "Preempted", Owner: "system", Status: store.StatusPending, Preemptible: true}
+	_ = ms.CreateTask(context.TODO(), task)
+	_ = ms.CreateTaskEvent(context.TODO(), &store.TaskEvent{
+		TaskID:  task.ID,
+		Event:   "preempted",
+		AgentID: "lily",
+		Payload: map[string]interface{}{"reason": "urgent work"},
+	})
+	_ = ms.CreateTaskEvent(context.TODO(), &store.TaskEvent{TaskID: task.ID, Event: "assigned"})
+
+	req := httptest.NewRequest("GET", "/api/v1/scoring/explain/"+task.ID.String(), nil)
+	req.Header.Set("X-Agent-ID", "test")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Preemptions []store.TaskEvent `json:"preemptions"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Preemptions) != 1 || resp.Preemptions[0].Event != "preempted" || resp.Preemptions[0].AgentID != "lily" {
+		t.Errorf("expected one preempted event in explain, got %+v", resp.Preemptions)
+	}
+}
+
+// TestExplainIncludesPlacement verifies placement constraints and their
+// resolution are explained.
+func TestExplainIncludesPlacement(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks", bytes.NewBufferString(
+		`{"title":"Review","required_capabilities":["review"],"excluded_agents":["kai"],"affinity_group":"pr-42"}`))
+	req.Header.Set("X-Agent-ID", "test")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var task store.Task
+	_ = json.NewDecoder(w.Body).Decode(&task)
+	stored := ms.tasks[task.ID]
+	stored.ScoringFactors = map[string]interface{}{
+		"placement": map[string]interface{}{"excluded": map[string]interface{}{"lily": "implemented parent task"}},
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/scoring/explain/"+task.ID.String(), nil)
+	req.Header.Set("X-Agent-ID", "test")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp struct {
+		Placement map[string]interface{} `json:"placement"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Placement["affinity_group"] != "pr-42" || resp.Placement["resolved"] == nil {
+		t.Errorf("expected placement with affinity group and resolution, got %v", resp.Placement)
+	}
+	if excluded, _ := resp.Placement["excluded_agents"].([]interface{}); len(excluded) != 1 || excluded[0] != "kai" {
+		t.Errorf("expected excluded_agents [kai], got %v", resp.Placement["excluded_agents"])
+	}
+}
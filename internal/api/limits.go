@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+type LimitsHandler struct {
+	store store.Store
+}
+
+func NewLimitsHandler(s store.Store) *LimitsHandler {
+	return &LimitsHandler{store: s}
+}
+
+type SetAgentLimitRequest struct {
+	MaxConcurrent *int `json:"max_concurrent"`
+}
+
+// List handles GET /api/v1/agents/limits
+func (h *LimitsHandler) List(w http.ResponseWriter, r *http.Request) {
+	limits, err := h.store.ListAgentLimits(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if limits == nil {
+		limits = []*store.AgentLimit{}
+	}
+	writeJSON(w, http.StatusOK, limits)
+}
+
+// Set handles PUT /api/v1/agents/{id}/limit. The override replaces the
+// persona's max_concurrent and the configured default; zero means unlimited.
+func (h *LimitsHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req SetAgentLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.MaxConcurrent == nil || *req.MaxConcurrent < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "max_concurrent must be zero or greater"})
+		return
+	}
+
+	l := &store.AgentLimit{AgentSlug: chi.URLParam(r, "id"), MaxConcurrent: *req.MaxConcurrent}
+	if err := h.store.SetAgentLimit(r.Context(), l); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+// Delete handles DELETE /api/v1/agents/{id}/limit
+func (h *LimitsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.DeleteAgentLimit(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func TestSetAndListAgentLimits(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("PUT", "/api/v1/agents/test/limit", `{"max_concurrent":5}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ms.agentLimits["test"] != 5 {
+		t.Errorf("expected override 5 stored, got %v", ms.agentLimits)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/agents/limits", ""))
+	var limits []store.AgentLimit
+	if err := json.NewDecoder(w.Body).Decode(&limits); err != nil {
+		t.Fatalf("failed to decode limits: %v", err)
+	}
+	if len(limits) != 1 || limits[0].AgentSlug != "test" || limits[0].MaxConcurrent != 5 {
+		t.Errorf("unexpected limits: %+v", limits)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/agents", ""))
+	var agents []AgentInfo
+	if err := json.NewDecoder(w.Body).Decode(&agents); err != nil {
+		t.Fatalf("failed to decode agents: %v", err)
+	}
+	if len(agents) != 1 || agents[0].MaxConcurrent == nil || *agents[0].MaxConcurrent != 5 {
+		t.Errorf("expected agent max_concurrent 5, got %+v", agents)
+	}
+}
+
+func TestSetAgentLimitValidation(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	for _, body := range []string{`{}`, `{"max_concurrent":-1}`, `not json`} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, adminRequest("PUT", "/api/v1/agents/test/limit", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestDeleteAgentLimit(t *testing.T) {
+	router, ms := setupTestRouter()
+	ms.agentLimits = map[string]int{"test": 2}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("DELETE", "/api/v1/agents/test/limit", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(ms.agentLimits) != 0 {
+		t.Errorf("expected override deleted, got %v", ms.agentLimits)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
 
 func AgentIDMiddleware(next http.Handler) http.Handler {
@@ -18,6 +20,17 @@ func AgentIDMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// ActorMiddleware records the caller's X-Agent-ID as the actor of the
+// backlog and task changes made while serving the request.
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if agentID := r.Header.Get("X-Agent-ID"); agentID != "" {
+			r = r.WithContext(store.WithActor(r.Context(), agentID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func AdminAuthMiddleware(token string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
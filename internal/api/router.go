@@ -12,6 +12,7 @@ import (
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
 	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/orchestrator"
 	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 	"github.com/MikeSquared-Agency/Dispatch/internal/warren"
@@ -24,15 +25,24 @@ func NewRouter(s store.Store, h hermes.Client, w warren.Client, f forge.Client,
 	r.Use(chiMiddleware.RequestID)
 	r.Use(RequestLogger(logger))
 	r.Use(RateLimitMiddleware(120))
+	r.Use(ActorMiddleware)
 
-	tasks := NewTasksHandler(s, h, cfg.ModelRouting)
+	tasks := NewTasksHandler(s, h, b, cfg.ModelRouting)
 	admin := NewAdminHandler(s, w, f, b)
 	explain := NewExplainHandler(s)
-	backlog := NewBacklogHandler(s, h, bs, cfg.ModelRouting)
-	stages := NewStagesHandler(s, h, cfg)
+	timeline := NewTimelineHandler(s)
+	history := NewHistoryHandler(s)
+	leases := NewLeaseHandler(s, h, cfg)
+	orch := orchestrator.New(s, h, cfg, logger)
+	backlog := NewBacklogHandler(s, h, bs, orch, cfg.ModelRouting)
+	stages := NewStagesHandler(s, h, cfg, orch)
 	deps := NewDependenciesHandler(s)
 	overrides := NewOverridesHandler(s, h)
 	autonomy := NewAutonomyHandler(s)
+	budgets := NewBudgetsHandler(s)
+	limits := NewLimitsHandler(s)
+	schedules := NewSchedulesHandler(s)
+	iterations := NewIterationsHandler(s, h, cfg.Iterations)
 
 	// Health and identity endpoints
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -65,7 +75,13 @@ func NewRouter(s store.Store, h hermes.Client, w warren.Client, f forge.Client,
 			r.Post("/tasks/{id}/complete", tasks.Complete)
 			r.Post("/tasks/{id}/fail", tasks.Fail)
 			r.Post("/tasks/{id}/progress", tasks.Progress)
+			r.Post("/tasks/{id}/heartbeat", tasks.Heartbeat)
+			r.Post("/tasks/{id}/ack", leases.Ack)
+			r.Post("/tasks/{id}/lease", leases.ExtendLease)
 			r.Patch("/tasks/{id}/discovery-complete", tasks.DiscoveryComplete)
+			r.Get("/tasks/{id}/events", timeline.Events)
+			r.Get("/tasks/{id}/timeline", timeline.Timeline)
+			r.Get("/tasks/{id}/history", history.Task)
 
 			// Scoring
 			r.Get("/scoring/explain/{task_id}", explain.Explain)
@@ -73,8 +89,14 @@ func NewRouter(s store.Store, h hermes.Client, w warren.Client, f forge.Client,
 			// Backlog
 			r.Post("/backlog", backlog.Create)
 			r.Get("/backlog", backlog.List)
+			r.Post("/backlog/bulk", backlog.Bulk)
+			r.Get("/backlog/export", backlog.Export)
+			r.Post("/backlog/import", backlog.Import)
 			r.Get("/backlog/next", backlog.Next)
 			r.Get("/backlog/{id}", backlog.Get)
+			r.Get("/backlog/{id}/tree", backlog.Tree)
+			r.Get("/backlog/{id}/score-history", backlog.ScoreHistory)
+			r.Get("/backlog/{id}/history", history.Backlog)
 			r.Patch("/backlog/{id}", backlog.Update)
 			r.Delete("/backlog/{id}", backlog.Delete)
 			r.Post("/backlog/{id}/start", backlog.Start)
@@ -92,6 +114,25 @@ func NewRouter(s store.Store, h hermes.Client, w warren.Client, f forge.Client,
 			r.Post("/backlog/dependencies", deps.Create)
 			r.Delete("/backlog/dependencies/{id}", deps.Delete)
 			r.Get("/backlog/{id}/dependencies", deps.ListForItem)
+			r.Get("/backlog/graph", deps.Graph)
+
+			// Schedules
+			r.Post("/schedules", schedules.Create)
+			r.Get("/schedules", schedules.List)
+			r.Get("/schedules/{id}", schedules.Get)
+			r.Delete("/schedules/{id}", schedules.Delete)
+			r.Post("/schedules/{id}/pause", schedules.Pause)
+			r.Post("/schedules/{id}/resume", schedules.Resume)
+
+			// Iterations
+			r.Post("/iterations", iterations.Create)
+			r.Get("/iterations", iterations.List)
+			r.Get("/iterations/{id}", iterations.Get)
+			r.Delete("/iterations/{id}", iterations.Delete)
+			r.Post("/iterations/{id}/plan", iterations.Plan)
+			r.Post("/iterations/{id}/commit", iterations.Commit)
+			r.Post("/iterations/{id}/uncommit", iterations.Uncommit)
+			r.Get("/iterations/{id}/burndown", iterations.Burndown)
 		})
 
 		// Admin endpoints - require admin token
@@ -101,6 +142,9 @@ func NewRouter(s store.Store, h hermes.Client, w warren.Client, f forge.Client,
 			r.Get("/stats", admin.Stats)
 			r.Get("/agents", admin.Agents)
 			r.Post("/agents/{id}/drain", admin.Drain)
+			r.Get("/agents/limits", limits.List)
+			r.Put("/agents/{id}/limit", limits.Set)
+			r.Delete("/agents/{id}/limit", limits.Delete)
 
 			// Admin-only stage operations
 			r.Post("/backlog/{id}/gate/satisfy", stages.SatisfyGate)
@@ -110,6 +154,11 @@ func NewRouter(s store.Store, h hermes.Client, w warren.Client, f forge.Client,
 			// Overrides and autonomy (admin only)
 			r.Post("/overrides", overrides.Create)
 			r.Get("/autonomy/metrics", autonomy.Metrics)
+
+			// Budgets
+			r.Get("/budgets", budgets.List)
+			r.Put("/budgets", budgets.Set)
+			r.Delete("/budgets/{id}", budgets.Delete)
 		})
 	})
 
@@ -23,8 +23,15 @@ import (
 
 // Mocks
 type mockStore struct {
-	tasks  map[uuid.UUID]*store.Task
-	events []*store.TaskEvent
+	tasks       map[uuid.UUID]*store.Task
+	events      []*store.TaskEvent
+	overrides   []*store.DispatchOverride
+	budgets     []*store.Budget
+	budgetSpend map[string]store.BudgetUsage // key: "scope|subject"
+	agentLimits map[string]int
+	schedules   map[uuid.UUID]*store.Schedule
+
+	livenessWrites int
 }
 
 func newMockStore() *mockStore {
@@ -55,6 +62,9 @@ func (m *mockStore) ListTasks(_ context.Context, f store.TaskFilter) ([]*store.T
 		if f.Agent != "" && t.AssignedAgent != f.Agent {
 			continue
 		}
+		if f.BacklogItemID != nil && (t.BacklogItemID == nil || *t.BacklogItemID != *f.BacklogItemID) {
+			continue
+		}
 		out = append(out, t)
 	}
 	return out, nil
@@ -63,32 +73,236 @@ func (m *mockStore) UpdateTask(_ context.Context, t *store.Task) error {
 	m.tasks[t.ID] = t
 	return nil
 }
-func (m *mockStore) GetPendingTasks(_ context.Context) ([]*store.Task, error)                      { return nil, nil }
+func (m *mockStore) UpdateTaskGuarded(ctx context.Context, t *store.Task, guard store.TaskGuard) error {
+	// The stored task is only distinct from t when a test has replaced it
+	// to simulate a concurrent write.
+	if cur := m.tasks[t.ID]; cur == nil || (cur != t && !guard.Matches(cur)) {
+		return store.ErrTaskChanged
+	}
+	return m.UpdateTask(ctx, t)
+}
+func (m *mockStore) UpdateTaskLiveness(_ context.Context, t *store.Task) error {
+	m.tasks[t.ID] = t
+	m.livenessWrites++
+	return nil
+}
+func (m *mockStore) GetPendingTasks(_ context.Context) ([]*store.Task, error) {
+	var out []*store.Task
+	for _, t := range m.tasks {
+		if t.Status == store.StatusPending {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
 func (m *mockStore) GetActiveTasksForAgent(_ context.Context, _ string) ([]*store.Task, error)     { return nil, nil }
-func (m *mockStore) GetActiveTasks(_ context.Context) ([]*store.Task, error)                       { return nil, nil }
+func (m *mockStore) GetActiveTasks(_ context.Context) ([]*store.Task, error) {
+	var out []*store.Task
+	for _, t := range m.tasks {
+		if t.Status == store.StatusAssigned || t.Status == store.StatusInProgress {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+func (m *mockStore) GetAffinityGroupAgent(_ context.Context, group string) (string, error) {
+	var agent string
+	var latest time.Time
+	for _, t := range m.tasks {
+		if t.AffinityGroup == group && t.AssignedAgent != "" && t.AssignedAt != nil && !t.AssignedAt.Before(latest) {
+			agent, latest = t.AssignedAgent, *t.AssignedAt
+		}
+	}
+	return agent, nil
+}
+func (m *mockStore) GetDeadlineTasks(_ context.Context) ([]*store.Task, error) {
+	var out []*store.Task
+	for _, t := range m.tasks {
+		switch t.Status {
+		case store.StatusPending, store.StatusAssigned, store.StatusInProgress:
+			if t.Deadline != nil && t.DeadlineMissedAt == nil {
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
 func (m *mockStore) CreateTaskEvent(_ context.Context, e *store.TaskEvent) error {
 	e.ID = uuid.New()
 	m.events = append(m.events, e)
 	return nil
 }
-func (m *mockStore) GetTaskEvents(_ context.Context, _ uuid.UUID) ([]*store.TaskEvent, error) {
-	return nil, nil
+func (m *mockStore) GetTaskEvents(_ context.Context, taskID uuid.UUID) ([]*store.TaskEvent, error) {
+	var out []*store.TaskEvent
+	for _, e := range m.events {
+		if e.TaskID == taskID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
 }
 func (m *mockStore) GetStats(_ context.Context) (*store.TaskStats, error) {
 	return &store.TaskStats{TotalPending: 1}, nil
 }
+func (m *mockStore) GetSLAStats(_ context.Context) ([]*store.OwnerSLA, error) {
+	byOwner := make(map[string]*store.OwnerSLA)
+	var out []*store.OwnerSLA
+	for _, t := range m.tasks {
+		if t.Deadline == nil || (t.CompletedAt == nil && t.Deadline.After(time.Now())) {
+			continue
+		}
+		o := byOwner[t.Owner]
+		if o == nil {
+			o = &store.OwnerSLA{Owner: t.Owner}
+			byOwner[t.Owner] = o
+			out = append(out, o)
+		}
+		if t.Status == store.StatusCompleted && !t.CompletedAt.After(*t.Deadline) {
+			o.Met++
+		} else {
+			o.Missed++
+		}
+		o.Attainment = float64(o.Met) / float64(o.Met+o.Missed)
+	}
+	return out, nil
+}
 func (m *mockStore) CreateAgentTaskHistory(_ context.Context, _ *store.AgentTaskHistory) error {
 	return nil
 }
 func (m *mockStore) GetAgentTaskHistory(_ context.Context, _ string, _ int) ([]*store.AgentTaskHistory, error) {
 	return nil, nil
 }
+func (m *mockStore) GetAgentFailureStats(_ context.Context, _ string) (*store.AgentFailureStats, error) {
+	return &store.AgentFailureStats{Failures: map[string]int{}}, nil
+}
 func (m *mockStore) GetAgentAvgDuration(_ context.Context, _ string) (*float64, error) {
 	return nil, nil
 }
 func (m *mockStore) GetAgentAvgCost(_ context.Context, _ string) (*float64, error) {
 	return nil, nil
 }
+func (m *mockStore) UpsertBudget(_ context.Context, b *store.Budget) error {
+	for _, existing := range m.budgets {
+		if existing.Scope == b.Scope && existing.Subject == b.Subject && existing.Period == b.Period {
+			b.ID = existing.ID
+			b.AlertedThreshold = existing.AlertedThreshold
+			b.AlertedPeriodStart = existing.AlertedPeriodStart
+			*existing = *b
+			return nil
+		}
+	}
+	b.ID = uuid.New()
+	m.budgets = append(m.budgets, b)
+	return nil
+}
+func (m *mockStore) ListBudgets(_ context.Context) ([]*store.Budget, error) {
+	return m.budgets, nil
+}
+func (m *mockStore) DeleteBudget(_ context.Context, id uuid.UUID) error {
+	for i, b := range m.budgets {
+		if b.ID == id {
+			m.budgets = append(m.budgets[:i], m.budgets[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (m *mockStore) GetBudgetUsage(_ context.Context, scope, subject string, since time.Time) (*store.BudgetUsage, error) {
+	probe := &store.Budget{Scope: scope, Subject: subject}
+	u := &store.BudgetUsage{}
+	if spent, ok := m.budgetSpend[scope+"|"+subject]; ok {
+		u.Tokens, u.CostUSD = spent.Tokens, spent.CostUSD
+	}
+	for _, t := range m.tasks {
+		if probe.Applies(t) && !t.CreatedAt.Before(since) {
+			u.Tasks++
+		}
+	}
+	for _, e := range m.events {
+		if t := m.tasks[e.TaskID]; t != nil && e.Event == "assigned" && probe.Applies(t) && !e.CreatedAt.Before(since) {
+			u.Dispatched++
+		}
+	}
+	return u, nil
+}
+func (m *mockStore) MarkBudgetAlerted(_ context.Context, id uuid.UUID, periodStart time.Time, threshold int) error {
+	for _, b := range m.budgets {
+		if b.ID == id {
+			b.AlertedThreshold = threshold
+			b.AlertedPeriodStart = &periodStart
+		}
+	}
+	return nil
+}
+func (m *mockStore) SetAgentLimit(_ context.Context, l *store.AgentLimit) error {
+	if m.agentLimits == nil {
+		m.agentLimits = make(map[string]int)
+	}
+	m.agentLimits[l.AgentSlug] = l.MaxConcurrent
+	l.UpdatedAt = time.Now()
+	return nil
+}
+func (m *mockStore) ListAgentLimits(_ context.Context) ([]*store.AgentLimit, error) {
+	var out []*store.AgentLimit
+	for slug, n := range m.agentLimits {
+		out = append(out, &store.AgentLimit{AgentSlug: slug, MaxConcurrent: n})
+	}
+	return out, nil
+}
+func (m *mockStore) DeleteAgentLimit(_ context.Context, agentSlug string) error {
+	delete(m.agentLimits, agentSlug)
+	return nil
+}
+func (m *mockStore) CreateSchedule(_ context.Context, s *store.Schedule) error {
+	if m.schedules == nil {
+		m.schedules = make(map[uuid.UUID]*store.Schedule)
+	}
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	m.schedules[s.ID] = s
+	return nil
+}
+func (m *mockStore) GetSchedule(_ context.Context, id uuid.UUID) (*store.Schedule, error) {
+	return m.schedules[id], nil
+}
+func (m *mockStore) ListSchedules(_ context.Context) ([]*store.Schedule, error) {
+	var out []*store.Schedule
+	for _, s := range m.schedules {
+		out = append(out, s)
+	}
+	return out, nil
+}
+func (m *mockStore) UpdateSchedule(_ context.Context, s *store.Schedule) error {
+	s.UpdatedAt = time.Now()
+	m.schedules[s.ID] = s
+	return nil
+}
+func (m *mockStore) UpdateScheduleRun(_ context.Context, s *store.Schedule) error {
+	cur := m.schedules[s.ID]
+	if cur == nil || !cur.UpdatedAt.Equal(s.UpdatedAt) {
+		return store.ErrScheduleChanged
+	}
+	cur.NextRunAt, cur.QueuedRunAt = s.NextRunAt, s.QueuedRunAt
+	cur.LastRunAt, cur.LastTaskID = s.LastRunAt, s.LastTaskID
+	cur.SkippedRuns, cur.MissedRuns = s.SkippedRuns, s.MissedRuns
+	cur.UpdatedAt = time.Now()
+	s.UpdatedAt = cur.UpdatedAt
+	return nil
+}
+func (m *mockStore) DeleteSchedule(_ context.Context, id uuid.UUID) error {
+	delete(m.schedules, id)
+	return nil
+}
+func (m *mockStore) GetDueSchedules(_ context.Context, now time.Time) ([]*store.Schedule, error) {
+	var out []*store.Schedule
+	for _, s := range m.schedules {
+		if !s.Paused && (!s.NextRunAt.After(now) || s.QueuedRunAt != nil) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
 func (m *mockStore) GetTrustScore(_ context.Context, _, _, _ string) (float64, error) {
 	return 0.0, nil
 }
@@ -101,30 +315,75 @@ func (m *mockStore) CreateBacklogItem(_ context.Context, item *store.BacklogItem
 func (m *mockStore) GetBacklogItem(_ context.Context, _ uuid.UUID) (*store.BacklogItem, error) {
 	return nil, nil
 }
+func (m *mockStore) GetBacklogItemByExternalID(_ context.Context, _ string) (*store.BacklogItem, error) {
+	return nil, nil
+}
+func (m *mockStore) GetBacklogItems(_ context.Context, _ []uuid.UUID) ([]*store.BacklogItem, error) {
+	return nil, nil
+}
+func (m *mockStore) ListBacklogSubtree(_ context.Context, _ uuid.UUID) ([]*store.BacklogItem, error) {
+	return nil, nil
+}
 func (m *mockStore) ListBacklogItems(_ context.Context, _ store.BacklogFilter) ([]*store.BacklogItem, error) {
 	return nil, nil
 }
 func (m *mockStore) UpdateBacklogItem(_ context.Context, _ *store.BacklogItem) error { return nil }
 func (m *mockStore) DeleteBacklogItem(_ context.Context, _ uuid.UUID) error          { return nil }
+func (m *mockStore) UpdateBacklogItemScore(_ context.Context, item *store.BacklogItem, score float64) error {
+	item.PriorityScore = &score
+	return nil
+}
 func (m *mockStore) GetNextBacklogItems(_ context.Context, _ int) ([]*store.BacklogItem, error) {
 	return nil, nil
 }
+func (m *mockStore) CountBacklogChildren(_ context.Context, _ uuid.UUID) (store.ChildCounts, error) {
+	return store.ChildCounts{}, nil
+}
+func (m *mockStore) ListOpenBacklogItems(_ context.Context) ([]*store.BacklogItem, error) {
+	return nil, nil
+}
+func (m *mockStore) CreateScoreChange(_ context.Context, _ *store.BacklogScoreChange) error {
+	return nil
+}
+func (m *mockStore) ListScoreHistory(_ context.Context, _ uuid.UUID, _ int) ([]*store.BacklogScoreChange, error) {
+	return nil, nil
+}
 func (m *mockStore) CreateDependency(_ context.Context, dep *store.BacklogDependency) error {
 	dep.ID = uuid.New()
 	return nil
 }
+func (m *mockStore) CreateDependencyChecked(_ context.Context, dep *store.BacklogDependency, _ store.DependencyCheck) error {
+	dep.ID = uuid.New()
+	return nil
+}
 func (m *mockStore) DeleteDependency(_ context.Context, _ uuid.UUID) error { return nil }
 func (m *mockStore) GetDependenciesForItem(_ context.Context, _ uuid.UUID) ([]*store.BacklogDependency, error) {
 	return nil, nil
 }
+func (m *mockStore) ListDependencies(_ context.Context) ([]*store.BacklogDependency, error) {
+	return nil, nil
+}
 func (m *mockStore) HasUnresolvedBlockers(_ context.Context, _ uuid.UUID) (bool, error) {
 	return false, nil
 }
+func (m *mockStore) ListBlockedBacklogItemIDs(_ context.Context) (map[uuid.UUID]bool, error) {
+	return nil, nil
+}
 func (m *mockStore) ResolveDependenciesForBlocker(_ context.Context, _ uuid.UUID) error { return nil }
 func (m *mockStore) CreateOverride(_ context.Context, o *store.DispatchOverride) error {
 	o.ID = uuid.New()
+	m.overrides = append(m.overrides, o)
 	return nil
 }
+func (m *mockStore) GetOverridesForTask(_ context.Context, taskID uuid.UUID) ([]*store.DispatchOverride, error) {
+	var out []*store.DispatchOverride
+	for _, o := range m.overrides {
+		if o.TaskID != nil && *o.TaskID == taskID {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
 func (m *mockStore) CreateAutonomyEvent(_ context.Context, e *store.AutonomyEvent) error {
 	e.ID = uuid.New()
 	return nil
@@ -135,6 +394,33 @@ func (m *mockStore) GetAutonomyMetrics(_ context.Context, _ int) ([]*store.Auton
 func (m *mockStore) BacklogDiscoveryComplete(_ context.Context, _ uuid.UUID, _ *store.BacklogDiscoveryCompleteRequest, _ store.ScoreFn, _ store.TierFn) (*store.BacklogDiscoveryCompleteResult, error) {
 	return &store.BacklogDiscoveryCompleteResult{}, nil
 }
+func (m *mockStore) BulkUpdateBacklogItems(_ context.Context, _ []*store.BacklogItem, _ []*store.DispatchOverride) error {
+	return nil
+}
+func (m *mockStore) ImportBacklog(_ context.Context, _ *store.BacklogImport) error {
+	return nil
+}
+func (m *mockStore) CreateIteration(_ context.Context, _ *store.Iteration) error { return nil }
+func (m *mockStore) GetIteration(_ context.Context, _ uuid.UUID) (*store.Iteration, error) {
+	return nil, nil
+}
+func (m *mockStore) ListIterations(_ context.Context) ([]*store.Iteration, error) { return nil, nil }
+func (m *mockStore) DeleteIteration(_ context.Context, _ uuid.UUID) error         { return nil }
+func (m *mockStore) CommitIterationItems(_ context.Context, _ []*store.IterationItem) error {
+	return nil
+}
+func (m *mockStore) UncommitIterationItems(_ context.Context, _ uuid.UUID, _ []uuid.UUID) error {
+	return nil
+}
+func (m *mockStore) ListIterationItems(_ context.Context, _ uuid.UUID) ([]*store.IterationItem, error) {
+	return nil, nil
+}
+func (m *mockStore) ListOpenCommitments(_ context.Context, _ uuid.UUID) (map[uuid.UUID]*store.Iteration, error) {
+	return nil, nil
+}
+func (m *mockStore) ListChangeHistory(_ context.Context, _ store.ChangeHistoryFilter) ([]*store.ChangeRecord, error) {
+	return nil, nil
+}
 func (m *mockStore) GetMedianEstimatedTokens(_ context.Context) (int64, error) { return 0, nil }
 
 // Stage engine stubs
@@ -250,6 +536,28 @@ func TestCreateTaskMissingTitle(t *testing.T) {
 	}
 }
 
+func TestCreateTaskWithDeadline(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	deadline := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/tasks", `{"title":"Report","deadline":"`+deadline.Format(time.RFC3339)+`"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var task store.Task
+	_ = json.NewDecoder(w.Body).Decode(&task)
+	if stored := ms.tasks[task.ID]; stored.Deadline == nil || !stored.Deadline.Equal(deadline) {
+		t.Errorf("expected deadline %s stored, got %v", deadline, stored.Deadline)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/tasks", `{"title":"Report","deadline":"2020-01-01T00:00:00Z"}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a past deadline, got %d", w.Code)
+	}
+}
+
 func TestListTasks(t *testing.T) {
 	router, _ := setupTestRouter()
 
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/schedule"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+type SchedulesHandler struct {
+	store store.Store
+}
+
+func NewSchedulesHandler(s store.Store) *SchedulesHandler {
+	return &SchedulesHandler{store: s}
+}
+
+type CreateScheduleRequest struct {
+	Name     string            `json:"name"`
+	Cron     string            `json:"cron"`
+	Timezone string            `json:"timezone,omitempty"`
+	Overlap  string            `json:"overlap,omitempty"`
+	CatchUp  string            `json:"catch_up,omitempty"`
+	Task     CreateTaskRequest `json:"task"`
+}
+
+// Create handles POST /api/v1/schedules. The task template takes the same
+// fields as POST /tasks except source, parent_task_id and deadline; tasks
+// created by the schedule have source "schedule:<name>".
+func (h *SchedulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name required"})
+		return
+	}
+	if req.Task.Title == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "task title required"})
+		return
+	}
+	if req.Overlap == "" {
+		req.Overlap = store.OverlapSkip
+	}
+	switch req.Overlap {
+	case store.OverlapSkip, store.OverlapQueue, store.OverlapAllow:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "overlap must be skip, queue or allow"})
+		return
+	}
+	switch req.CatchUp {
+	case "", config.CatchUpNone, config.CatchUpLatest, config.CatchUpAll:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "catch_up must be none, latest or all"})
+		return
+	}
+	next, err := schedule.NextRun(req.Cron, req.Timezone, time.Now())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	existing, err := h.store.ListSchedules(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, s := range existing {
+		if s.Name == req.Name {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "schedule name already exists"})
+			return
+		}
+	}
+
+	t := req.Task
+	s := &store.Schedule{
+		Name:     req.Name,
+		Cron:     req.Cron,
+		Timezone: req.Timezone,
+		Template: store.TaskTemplate{
+			Title:                t.Title,
+			Description:          t.Description,
+			Owner:                t.Owner,
+			RequiredCapabilities: t.RequiredCapabilities,
+			Priority:             t.Priority,
+			Metadata:             t.Metadata,
+			TimeoutSeconds:       t.TimeoutSeconds,
+			MaxRetries:           t.MaxRetries,
+			Preemptible:          t.Preemptible,
+			PreferredAgents:      t.PreferredAgents,
+			RequiredAgent:        t.RequiredAgent,
+			ExcludedAgents:       t.ExcludedAgents,
+			AffinityGroup:        t.AffinityGroup,
+		},
+		Overlap:   req.Overlap,
+		CatchUp:   req.CatchUp,
+		NextRunAt: next,
+	}
+	if err := h.store.CreateSchedule(r.Context(), s); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// List handles GET /api/v1/schedules
+func (h *SchedulesHandler) List(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.store.ListSchedules(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if schedules == nil {
+		schedules = []*store.Schedule{}
+	}
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+// Get handles GET /api/v1/schedules/{id}
+func (h *SchedulesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// Delete handles DELETE /api/v1/schedules/{id}. Tasks the schedule already
+// created are left alone.
+func (h *SchedulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteSchedule(r.Context(), s.ID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// Pause handles POST /api/v1/schedules/{id}/pause
+func (h *SchedulesHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	s.Paused = true
+	h.save(w, r, s)
+}
+
+// Resume handles POST /api/v1/schedules/{id}/resume. Runs that fell due
+// while paused are not caught up; the schedule picks up from its next run
+// after now, and any run held by the queue overlap policy is dropped.
+func (h *SchedulesHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if s.Paused {
+		next, err := schedule.NextRun(s.Cron, s.Timezone, time.Now())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		s.Paused = false
+		s.NextRunAt = next
+		s.QueuedRunAt = nil
+	}
+	h.save(w, r, s)
+}
+
+func (h *SchedulesHandler) load(w http.ResponseWriter, r *http.Request) (*store.Schedule, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid schedule id"})
+		return nil, false
+	}
+	s, err := h.store.GetSchedule(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	if s == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "schedule not found"})
+		return nil, false
+	}
+	return s, true
+}
+
+func (h *SchedulesHandler) save(w http.ResponseWriter, r *http.Request, s *store.Schedule) {
+	if err := h.store.UpdateSchedule(r.Context(), s); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func createSchedule(t *testing.T, router http.Handler, body string) *store.Schedule {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/schedules", body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var s store.Schedule
+	if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+		t.Fatalf("failed to decode schedule: %v", err)
+	}
+	return &s
+}
+
+func TestCreateSchedule(t *testing.T) {
+	router, ms := setupTestRouter()
+
+	s := createSchedule(t, router, `{"name":"nightly-audit","cron":"0 3 * * *","timezone":"UTC",
+		"task":{"title":"Dependency audit","required_capabilities":["security"],"priority":4}}`)
+	if s.Overlap != store.OverlapSkip {
+		t.Errorf("expected default overlap skip, got %q", s.Overlap)
+	}
+	if s.Template.Title != "Dependency audit" || s.Template.Priority != 4 {
+		t.Errorf("unexpected template: %+v", s.Template)
+	}
+	if s.NextRunAt.Hour() != 3 || s.NextRunAt.Minute() != 0 || !s.NextRunAt.After(time.Now()) {
+		t.Errorf("unexpected next run %s", s.NextRunAt)
+	}
+	if len(ms.schedules) != 1 {
+		t.Errorf("expected schedule stored, got %d", len(ms.schedules))
+	}
+
+	// Names are unique.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/schedules", `{"name":"nightly-audit","cron":"@daily","task":{"title":"x"}}`))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for duplicate name, got %d", w.Code)
+	}
+}
+
+func TestCreateScheduleValidation(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	for _, body := range []string{
+		`not json`,
+		`{"cron":"@daily","task":{"title":"x"}}`,
+		`{"name":"a","cron":"@daily","task":{}}`,
+		`{"name":"a","cron":"61 * * * *","task":{"title":"x"}}`,
+		`{"name":"a","cron":"@daily","timezone":"Mars/Olympus","task":{"title":"x"}}`,
+		`{"name":"a","cron":"@daily","overlap":"sometimes","task":{"title":"x"}}`,
+		`{"name":"a","cron":"@daily","catch_up":"most","task":{"title":"x"}}`,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, adminRequest("POST", "/api/v1/schedules", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestPauseAndResumeSchedule(t *testing.T) {
+	router, ms := setupTestRouter()
+	s := createSchedule(t, router, `{"name":"sync","cron":"*/5 * * * *","overlap":"queue","task":{"title":"Sync"}}`)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/schedules/"+s.ID.String()+"/pause", ""))
+	if w.Code != http.StatusOK || !ms.schedules[s.ID].Paused {
+		t.Fatalf("expected schedule paused, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stored := ms.schedules[s.ID]
+	queued := time.Now().Add(-time.Hour)
+	stored.NextRunAt = queued
+	stored.QueuedRunAt = &queued
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("POST", "/api/v1/schedules/"+s.ID.String()+"/resume", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored.Paused || stored.QueuedRunAt != nil || !stored.NextRunAt.After(time.Now()) {
+		t.Errorf("expected resume to restart from now, got %+v", stored)
+	}
+}
+
+func TestGetAndDeleteSchedule(t *testing.T) {
+	router, ms := setupTestRouter()
+	s := createSchedule(t, router, `{"name":"sync","cron":"@hourly","task":{"title":"Sync"}}`)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/schedules/"+s.ID.String(), ""))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("DELETE", "/api/v1/schedules/"+s.ID.String(), ""))
+	if w.Code != http.StatusOK || len(ms.schedules) != 0 {
+		t.Errorf("expected schedule deleted, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, adminRequest("GET", "/api/v1/schedules/"+s.ID.String(), ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
@@ -1,17 +1,16 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/orchestrator"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
 
@@ -19,10 +18,11 @@ type StagesHandler struct {
 	store  store.Store
 	hermes hermes.Client
 	cfg    *config.Config
+	orch   *orchestrator.Orchestrator
 }
 
-func NewStagesHandler(s store.Store, h hermes.Client, cfg *config.Config) *StagesHandler {
-	return &StagesHandler{store: s, hermes: h, cfg: cfg}
+func NewStagesHandler(s store.Store, h hermes.Client, cfg *config.Config, orch *orchestrator.Orchestrator) *StagesHandler {
+	return &StagesHandler{store: s, hermes: h, cfg: cfg, orch: orch}
 }
 
 // InitStages handles POST /api/v1/backlog/{id}/init-stages
@@ -118,6 +118,12 @@ func (h *StagesHandler) InitStages(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// Dispatch the first stage as a task if it is configured for dispatch
+	if _, err := h.orch.StageEntered(r.Context(), item); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"item":  item,
 		"gates": gates,
@@ -169,7 +175,7 @@ func (h *StagesHandler) SubmitEvidence(w http.ResponseWriter, r *http.Request) {
 				// Check if all criteria are now satisfied for auto-advance
 				allMet, _ := h.store.AllCriteriaMet(r.Context(), id, req.Stage)
 				if allMet {
-					h.handleAutoAdvance(r.Context(), item, req.Stage)
+					h.orch.Advance(r.Context(), item)
 				}
 			}
 		}
@@ -295,7 +301,7 @@ func (h *StagesHandler) SatisfyGate(w http.ResponseWriter, r *http.Request) {
 	// Check if all criteria are now satisfied for auto-advance
 	allMet, _ := h.store.AllCriteriaMet(r.Context(), id, stage)
 	if allMet {
-		h.handleAutoAdvance(r.Context(), item, stage)
+		h.orch.Advance(r.Context(), item)
 		// Refresh item after potential advancement
 		item, _ = h.store.GetBacklogItem(r.Context(), id)
 	}
@@ -364,6 +370,14 @@ func (h *StagesHandler) RequestChanges(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// Re-dispatch the stage so its task can address the feedback
+	if req.Stage == item.CurrentStage {
+		if _, err := h.orch.StageEntered(r.Context(), item); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
 	// Return updated gate status
 	criteria, _ := h.store.GetGateStatus(r.Context(), id, req.Stage)
 	allMet, _ := h.store.AllCriteriaMet(r.Context(), id, req.Stage)
@@ -412,45 +426,3 @@ func (h *StagesHandler) GateStatus(w http.ResponseWriter, r *http.Request) {
 		"all_met":  allMet,
 	})
 }
-
-// handleAutoAdvance advances to next stage when all criteria are met
-func (h *StagesHandler) handleAutoAdvance(ctx context.Context, item *store.BacklogItem, currentStage string) {
-	// Check if at last stage
-	if item.StageIndex >= len(item.StageTemplate)-1 {
-		// Mark as completed
-		item.Status = store.BacklogStatusDone
-		_ = h.store.UpdateBacklogItem(ctx, item)
-
-		// Publish completion event
-		if h.hermes != nil {
-			_ = h.hermes.Publish(hermes.SubjectItemCompleted(item.ID.String()), hermes.ItemCompletedEvent{
-				ItemID:          item.ID.String(),
-				Title:           item.Title,
-				StagesCompleted: len(item.StageTemplate),
-				TotalDurationMs: time.Since(item.CreatedAt).Milliseconds(),
-			})
-		}
-		return
-	}
-
-	// Advance to next stage
-	previousStage := item.CurrentStage
-	item.StageIndex++
-	item.CurrentStage = item.StageTemplate[item.StageIndex]
-
-	if err := h.store.UpdateBacklogItem(ctx, item); err != nil {
-		return
-	}
-
-	// Publish stage advancement
-	if h.hermes != nil {
-		_ = h.hermes.Publish(hermes.SubjectStageAdvanced(item.ID.String()), hermes.StageAdvancedEvent{
-			ItemID:     item.ID.String(),
-			ItemTitle:  item.Title,
-			FromStage:  previousStage,
-			ToStage:    item.CurrentStage,
-			StageIndex: item.StageIndex,
-			Tier:       item.ModelTier,
-		})
-	}
-}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -14,6 +17,8 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/orchestrator"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
 
@@ -66,6 +71,11 @@ func (m *MockStore) UpdateBacklogItem(ctx context.Context, item *store.BacklogIt
 	return args.Error(0)
 }
 
+func (m *MockStore) UpdateBacklogItemScore(ctx context.Context, item *store.BacklogItem, score float64) error {
+	args := m.Called(ctx, item, score)
+	return args.Error(0)
+}
+
 func (m *MockStore) IncrementConsecutiveApprovals(ctx context.Context, tier string) (int, error) {
 	args := m.Called(ctx, tier)
 	return args.Int(0), args.Error(1)
@@ -91,30 +101,73 @@ func (m *MockStore) CreateTask(ctx context.Context, task *store.Task) error { re
 func (m *MockStore) GetTask(ctx context.Context, id uuid.UUID) (*store.Task, error) { return nil, nil }
 func (m *MockStore) ListTasks(ctx context.Context, filter store.TaskFilter) ([]*store.Task, error) { return nil, nil }
 func (m *MockStore) UpdateTask(ctx context.Context, task *store.Task) error { return nil }
+func (m *MockStore) UpdateTaskGuarded(ctx context.Context, task *store.Task, guard store.TaskGuard) error { return nil }
+func (m *MockStore) UpdateTaskLiveness(ctx context.Context, task *store.Task) error { return nil }
 func (m *MockStore) GetPendingTasks(ctx context.Context) ([]*store.Task, error) { return nil, nil }
 func (m *MockStore) GetActiveTasksForAgent(ctx context.Context, agentID string) ([]*store.Task, error) { return nil, nil }
 func (m *MockStore) GetActiveTasks(ctx context.Context) ([]*store.Task, error) { return nil, nil }
+func (m *MockStore) GetAffinityGroupAgent(ctx context.Context, group string) (string, error) { return "", nil }
+func (m *MockStore) GetDeadlineTasks(ctx context.Context) ([]*store.Task, error) { return nil, nil }
 func (m *MockStore) CreateTaskEvent(ctx context.Context, event *store.TaskEvent) error { return nil }
 func (m *MockStore) GetTaskEvents(ctx context.Context, taskID uuid.UUID) ([]*store.TaskEvent, error) { return nil, nil }
 func (m *MockStore) GetStats(ctx context.Context) (*store.TaskStats, error) { return nil, nil }
+func (m *MockStore) GetSLAStats(ctx context.Context) ([]*store.OwnerSLA, error) { return nil, nil }
 func (m *MockStore) CreateAgentTaskHistory(ctx context.Context, h *store.AgentTaskHistory) error { return nil }
 func (m *MockStore) GetAgentTaskHistory(ctx context.Context, agentSlug string, limit int) ([]*store.AgentTaskHistory, error) { return nil, nil }
+func (m *MockStore) GetAgentFailureStats(ctx context.Context, agentSlug string) (*store.AgentFailureStats, error) { return &store.AgentFailureStats{}, nil }
 func (m *MockStore) GetAgentAvgDuration(ctx context.Context, agentSlug string) (*float64, error) { return nil, nil }
 func (m *MockStore) GetAgentAvgCost(ctx context.Context, agentSlug string) (*float64, error) { return nil, nil }
+func (m *MockStore) UpsertBudget(ctx context.Context, b *store.Budget) error { return nil }
+func (m *MockStore) ListBudgets(ctx context.Context) ([]*store.Budget, error) { return nil, nil }
+func (m *MockStore) DeleteBudget(ctx context.Context, id uuid.UUID) error { return nil }
+func (m *MockStore) GetBudgetUsage(ctx context.Context, scope, subject string, since time.Time) (*store.BudgetUsage, error) { return &store.BudgetUsage{}, nil }
+func (m *MockStore) MarkBudgetAlerted(ctx context.Context, id uuid.UUID, periodStart time.Time, threshold int) error { return nil }
+func (m *MockStore) SetAgentLimit(ctx context.Context, l *store.AgentLimit) error { return nil }
+func (m *MockStore) ListAgentLimits(ctx context.Context) ([]*store.AgentLimit, error) { return nil, nil }
+func (m *MockStore) DeleteAgentLimit(ctx context.Context, agentSlug string) error { return nil }
+func (m *MockStore) CreateSchedule(ctx context.Context, s *store.Schedule) error { return nil }
+func (m *MockStore) GetSchedule(ctx context.Context, id uuid.UUID) (*store.Schedule, error) { return nil, nil }
+func (m *MockStore) ListSchedules(ctx context.Context) ([]*store.Schedule, error) { return nil, nil }
+func (m *MockStore) UpdateSchedule(ctx context.Context, s *store.Schedule) error { return nil }
+func (m *MockStore) UpdateScheduleRun(ctx context.Context, s *store.Schedule) error { return nil }
+func (m *MockStore) DeleteSchedule(ctx context.Context, id uuid.UUID) error { return nil }
+func (m *MockStore) GetDueSchedules(ctx context.Context, now time.Time) ([]*store.Schedule, error) { return nil, nil }
 func (m *MockStore) GetTrustScore(ctx context.Context, agentSlug, category, severity string) (float64, error) { return 0, nil }
 func (m *MockStore) CreateBacklogItem(ctx context.Context, item *store.BacklogItem) error { return nil }
 func (m *MockStore) ListBacklogItems(ctx context.Context, filter store.BacklogFilter) ([]*store.BacklogItem, error) { return nil, nil }
 func (m *MockStore) DeleteBacklogItem(ctx context.Context, id uuid.UUID) error { return nil }
 func (m *MockStore) GetNextBacklogItems(ctx context.Context, limit int) ([]*store.BacklogItem, error) { return nil, nil }
+func (m *MockStore) ListOpenBacklogItems(ctx context.Context) ([]*store.BacklogItem, error) { return nil, nil }
+func (m *MockStore) CountBacklogChildren(ctx context.Context, parentID uuid.UUID) (store.ChildCounts, error) { return store.ChildCounts{}, nil }
+func (m *MockStore) CreateScoreChange(ctx context.Context, c *store.BacklogScoreChange) error { return nil }
+func (m *MockStore) ListScoreHistory(ctx context.Context, itemID uuid.UUID, limit int) ([]*store.BacklogScoreChange, error) { return nil, nil }
 func (m *MockStore) CreateDependency(ctx context.Context, dep *store.BacklogDependency) error { return nil }
+func (m *MockStore) CreateDependencyChecked(ctx context.Context, dep *store.BacklogDependency, check store.DependencyCheck) error { return nil }
 func (m *MockStore) DeleteDependency(ctx context.Context, id uuid.UUID) error { return nil }
 func (m *MockStore) GetDependenciesForItem(ctx context.Context, itemID uuid.UUID) ([]*store.BacklogDependency, error) { return nil, nil }
+func (m *MockStore) ListDependencies(ctx context.Context) ([]*store.BacklogDependency, error) { return nil, nil }
 func (m *MockStore) HasUnresolvedBlockers(ctx context.Context, itemID uuid.UUID) (bool, error) { return false, nil }
+func (m *MockStore) ListBlockedBacklogItemIDs(ctx context.Context) (map[uuid.UUID]bool, error) { return nil, nil }
 func (m *MockStore) ResolveDependenciesForBlocker(ctx context.Context, blockerID uuid.UUID) error { return nil }
 func (m *MockStore) CreateOverride(ctx context.Context, o *store.DispatchOverride) error { return nil }
+func (m *MockStore) GetOverridesForTask(ctx context.Context, taskID uuid.UUID) ([]*store.DispatchOverride, error) { return nil, nil }
 func (m *MockStore) CreateAutonomyEvent(ctx context.Context, e *store.AutonomyEvent) error { return nil }
 func (m *MockStore) GetAutonomyMetrics(ctx context.Context, days int) ([]*store.AutonomyMetrics, error) { return nil, nil }
 func (m *MockStore) BacklogDiscoveryComplete(ctx context.Context, itemID uuid.UUID, req *store.BacklogDiscoveryCompleteRequest, scoreFn store.ScoreFn, tierFn store.TierFn) (*store.BacklogDiscoveryCompleteResult, error) { return nil, nil }
+func (m *MockStore) BulkUpdateBacklogItems(ctx context.Context, items []*store.BacklogItem, overrides []*store.DispatchOverride) error { return nil }
+func (m *MockStore) ImportBacklog(ctx context.Context, imp *store.BacklogImport) error { return nil }
+func (m *MockStore) CreateIteration(ctx context.Context, it *store.Iteration) error { return nil }
+func (m *MockStore) GetIteration(ctx context.Context, id uuid.UUID) (*store.Iteration, error) { return nil, nil }
+func (m *MockStore) ListIterations(ctx context.Context) ([]*store.Iteration, error) { return nil, nil }
+func (m *MockStore) DeleteIteration(ctx context.Context, id uuid.UUID) error { return nil }
+func (m *MockStore) CommitIterationItems(ctx context.Context, items []*store.IterationItem) error { return nil }
+func (m *MockStore) UncommitIterationItems(ctx context.Context, iterationID uuid.UUID, itemIDs []uuid.UUID) error { return nil }
+func (m *MockStore) ListIterationItems(ctx context.Context, iterationID uuid.UUID) ([]*store.IterationItem, error) { return nil, nil }
+func (m *MockStore) ListOpenCommitments(ctx context.Context, exceptID uuid.UUID) (map[uuid.UUID]*store.Iteration, error) { return nil, nil }
+func (m *MockStore) GetBacklogItemByExternalID(ctx context.Context, externalID string) (*store.BacklogItem, error) { return nil, nil }
+func (m *MockStore) GetBacklogItems(ctx context.Context, ids []uuid.UUID) ([]*store.BacklogItem, error) { return nil, nil }
+func (m *MockStore) ListBacklogSubtree(ctx context.Context, rootID uuid.UUID) ([]*store.BacklogItem, error) { return nil, nil }
+func (m *MockStore) ListChangeHistory(ctx context.Context, filter store.ChangeHistoryFilter) ([]*store.ChangeRecord, error) { return nil, nil }
 func (m *MockStore) InitStages(ctx context.Context, itemID uuid.UUID, template []string) error { return nil }
 func (m *MockStore) GetCurrentStage(ctx context.Context, itemID uuid.UUID) (string, int, error) { return "", 0, nil }
 func (m *MockStore) CreateGateCriteria(ctx context.Context, itemID uuid.UUID, stage string, criteria []string) error { return nil }
@@ -143,6 +196,10 @@ func (m *MockHermes) Close() {
 	// No-op for mock
 }
 
+func newTestOrchestrator(s store.Store, h hermes.Client, cfg *config.Config) *orchestrator.Orchestrator {
+	return orchestrator.New(s, h, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
 func TestSubmitEvidence(t *testing.T) {
 	mockStore := &MockStore{}
 	mockHermes := &MockHermes{}
@@ -151,6 +208,7 @@ func TestSubmitEvidence(t *testing.T) {
 		store:  mockStore,
 		hermes: mockHermes,
 		cfg:    &config.Config{},
+		orch:   newTestOrchestrator(mockStore, mockHermes, &config.Config{}),
 	}
 
 	itemID := uuid.New()
@@ -204,6 +262,7 @@ func TestAgentCannotSatisfyGate(t *testing.T) {
 		store:  mockStore,
 		hermes: mockHermes,
 		cfg:    cfg,
+		orch:   newTestOrchestrator(mockStore, mockHermes, cfg),
 	}
 	
 	r.Route("/api/v1", func(r chi.Router) {
@@ -252,6 +311,7 @@ func TestAdminCanSatisfyGate(t *testing.T) {
 		store:  mockStore,
 		hermes: mockHermes,
 		cfg:    cfg,
+		orch:   newTestOrchestrator(mockStore, mockHermes, cfg),
 	}
 	
 	r.Route("/api/v1", func(r chi.Router) {
@@ -308,6 +368,7 @@ func TestEconomyAutoApproveGraduation(t *testing.T) {
 		store:  mockStore,
 		hermes: mockHermes,
 		cfg:    &config.Config{},
+		orch:   newTestOrchestrator(mockStore, mockHermes, &config.Config{}),
 	}
 
 	itemID := uuid.New()
@@ -352,3 +413,55 @@ func TestEconomyAutoApproveGraduation(t *testing.T) {
 	mockStore.AssertExpectations(t)
 	mockHermes.AssertExpectations(t)
 }
+
+func TestInitStagesDispatchesFirstStage(t *testing.T) {
+	mockStore := &MockStore{}
+	mockHermes := &MockHermes{}
+	cfg := &config.Config{
+		Orchestrator: config.OrchestratorConfig{
+			Enabled: true,
+			Stages:  map[string][]string{"implement": {"code"}},
+		},
+	}
+
+	handler := &StagesHandler{
+		store:  mockStore,
+		hermes: mockHermes,
+		cfg:    cfg,
+		orch:   newTestOrchestrator(mockStore, mockHermes, cfg),
+	}
+
+	itemID := uuid.New()
+	item := &store.BacklogItem{
+		ID:           itemID,
+		Title:        "Add retries",
+		ModelTier:    "economy",
+		CurrentStage: "implement",
+	}
+
+	mockStore.On("GetBacklogItem", mock.Anything, itemID).Return(item, nil)
+	mockStore.On("GetGateStatus", mock.Anything, itemID, mock.Anything).Return([]store.GateCriterion{}, nil)
+	mockHermes.On("Publish", mock.AnythingOfType("string"), mock.Anything).Return(nil)
+
+	body, _ := json.Marshal(map[string][]string{"template": {"implement", "verify"}})
+	req, _ := http.NewRequest("POST", "/api/v1/backlog/"+itemID.String()+"/init-stages", bytes.NewBuffer(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", itemID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	handler.InitStages(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var dispatched *store.Task
+	for _, call := range mockHermes.Calls {
+		if task, ok := call.Arguments.Get(1).(*store.Task); ok {
+			dispatched = task
+		}
+	}
+	if assert.NotNil(t, dispatched, "expected a task created for the implement stage") {
+		assert.Equal(t, "implement", dispatched.BacklogStage)
+		assert.Equal(t, []string{"code"}, dispatched.RequiredCapabilities)
+		assert.Equal(t, "economy", dispatched.MinModelTier)
+	}
+}
@@ -3,11 +3,14 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/MikeSquared-Agency/Dispatch/internal/broker"
+	"github.com/MikeSquared-Agency/Dispatch/internal/budget"
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
 	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
@@ -17,11 +20,12 @@ import (
 type TasksHandler struct {
 	store        store.Store
 	hermes       hermes.Client
+	broker       *broker.Broker
 	modelRouting config.ModelRoutingConfig
 }
 
-func NewTasksHandler(s store.Store, h hermes.Client, mr config.ModelRoutingConfig) *TasksHandler {
-	return &TasksHandler{store: s, hermes: h, modelRouting: mr}
+func NewTasksHandler(s store.Store, h hermes.Client, b *broker.Broker, mr config.ModelRoutingConfig) *TasksHandler {
+	return &TasksHandler{store: s, hermes: h, broker: b, modelRouting: mr}
 }
 
 type CreateTaskRequest struct {
@@ -35,6 +39,12 @@ type CreateTaskRequest struct {
 	MaxRetries           int                    `json:"max_retries,omitempty"`
 	Source               string                 `json:"source,omitempty"`
 	ParentTaskID         string                 `json:"parent_task_id,omitempty"`
+	Preemptible          bool                   `json:"preemptible,omitempty"`
+	PreferredAgents      []string               `json:"preferred_agents,omitempty"`
+	RequiredAgent        string                 `json:"required_agent,omitempty"`
+	ExcludedAgents       []string               `json:"excluded_agents,omitempty"`
+	AffinityGroup        string                 `json:"affinity_group,omitempty"`
+	Deadline             *time.Time             `json:"deadline,omitempty"`
 }
 
 func (h *TasksHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -77,6 +87,16 @@ func (h *TasksHandler) Create(w http.ResponseWriter, r *http.Request) {
 		MaxRetries:           req.MaxRetries,
 		Source:               source,
 		RetryEligible:        true,
+		Preemptible:          req.Preemptible,
+		PreferredAgents:      req.PreferredAgents,
+		RequiredAgent:        req.RequiredAgent,
+		ExcludedAgents:       req.ExcludedAgents,
+		AffinityGroup:        req.AffinityGroup,
+		Deadline:             req.Deadline,
+	}
+	if task.Deadline != nil && !task.Deadline.After(time.Now()) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "deadline must be in the future"})
+		return
 	}
 	if task.TimeoutSeconds == 0 {
 		task.TimeoutSeconds = 300
@@ -93,6 +113,24 @@ func (h *TasksHandler) Create(w http.ResponseWriter, r *http.Request) {
 		task.ParentTaskID = &pid
 	}
 
+	decision, err := budget.CheckCreate(r.Context(), h.store, h.hermes, nil, task)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if decision.Action == store.BudgetActionReject {
+		b := decision.Budget
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{
+			"error":     "budget exceeded",
+			"budget_id": b.ID.String(),
+			"scope":     b.Scope,
+			"subject":   b.Subject,
+			"period":    b.Period,
+			"limits":    strings.Join(decision.Limits, ","),
+		})
+		return
+	}
+
 	if err := h.store.CreateTask(r.Context(), task); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
@@ -115,6 +153,14 @@ func (h *TasksHandler) List(w http.ResponseWriter, r *http.Request) {
 		status := store.TaskStatus(s)
 		filter.Status = &status
 	}
+	if s := r.URL.Query().Get("backlog_item_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid backlog_item_id"})
+			return
+		}
+		filter.BacklogItemID = &id
+	}
 
 	tasks, err := h.store.ListTasks(r.Context(), filter)
 	if err != nil {
@@ -133,6 +179,11 @@ func (h *TasksHandler) Get(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid task id"})
 		return
 	}
+	at, ok := parseAt(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at must be an RFC 3339 timestamp"})
+		return
+	}
 
 	task, err := h.store.GetTask(r.Context(), id)
 	if err != nil {
@@ -143,6 +194,17 @@ func (h *TasksHandler) Get(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
 		return
 	}
+	if at != nil {
+		task, err = asOf(r, h.store, store.EntityTask, id, task, *at)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if task == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task did not exist at that time"})
+			return
+		}
+	}
 	writeJSON(w, http.StatusOK, task)
 }
 
@@ -188,6 +250,10 @@ func (h *TasksHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
 		return
 	}
+	if task.Status != store.StatusAssigned && task.Status != store.StatusInProgress {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task must be assigned or in_progress"})
+		return
+	}
 
 	var body struct {
 		Result map[string]interface{} `json:"result"`
@@ -197,22 +263,11 @@ func (h *TasksHandler) Complete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	now := time.Now()
-	task.Status = store.StatusCompleted
-	task.Result = body.Result
-	task.CompletedAt = &now
-
-	if err := h.store.UpdateTask(r.Context(), task); err != nil {
+	if err := h.broker.CompleteTask(r.Context(), task, body.Result); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
-	_ = h.store.CreateTaskEvent(r.Context(), &store.TaskEvent{
-		TaskID:  task.ID,
-		Event:   "completed",
-		AgentID: r.Header.Get("X-Agent-ID"),
-	})
-
 	if h.hermes != nil {
 		_ = h.hermes.Publish(hermes.SubjectTaskCompleted(task.ID.String()), hermes.TaskCompletedEvent{
 			TaskID: task.ID.String(),
@@ -235,44 +290,51 @@ func (h *TasksHandler) Fail(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
 		return
 	}
+	if task.Status != store.StatusAssigned && task.Status != store.StatusInProgress {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task must be assigned or in_progress"})
+		return
+	}
 
 	var body struct {
 		Error         string `json:"error"`
 		RetryEligible *bool  `json:"retry_eligible,omitempty"`
+		FailureClass  string `json:"failure_class,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
 		return
 	}
+	if body.FailureClass != "" && !store.ValidFailureClass(body.FailureClass) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failure_class must be transient, agent_fault, task_spec, dependency_unavailable or quota"})
+		return
+	}
 
-	task.Status = store.StatusFailed
-	task.Error = body.Error
+	retryEligible := task.RetryEligible
 	if body.RetryEligible != nil {
-		task.RetryEligible = *body.RetryEligible
+		retryEligible = *body.RetryEligible
 	}
 
-	if err := h.store.UpdateTask(r.Context(), task); err != nil {
+	if err := h.broker.FailTask(r.Context(), task, body.Error, body.FailureClass, retryEligible); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
-	_ = h.store.CreateTaskEvent(r.Context(), &store.TaskEvent{
-		TaskID:  task.ID,
-		Event:   "failed",
-		AgentID: r.Header.Get("X-Agent-ID"),
-	})
-
 	if h.hermes != nil {
 		_ = h.hermes.Publish(hermes.SubjectTaskFailed(task.ID.String()), hermes.TaskFailedEvent{
 			TaskID:        task.ID.String(),
 			Error:         body.Error,
-			RetryEligible: task.RetryEligible,
+			RetryEligible: retryEligible,
+			FailureClass:  body.FailureClass,
 		})
 	}
 
 	writeJSON(w, http.StatusOK, task)
 }
 
+// Progress handles POST /api/v1/tasks/{id}/progress. The body may carry the
+// typed fields percent, stage, message and eta_seconds; the latest snapshot is
+// kept on the task. Progress also counts as a heartbeat. Like heartbeats, it
+// is rejected once the task has finished.
 func (h *TasksHandler) Progress(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
@@ -285,34 +347,112 @@ func (h *TasksHandler) Progress(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
 		return
 	}
-
-	if task.Status == store.StatusAssigned {
-		now := time.Now()
-		task.Status = store.StatusInProgress
-		task.StartedAt = &now
-		if err := h.store.UpdateTask(r.Context(), task); err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-			return
-		}
+	if task.Status != store.StatusAssigned && task.Status != store.StatusInProgress {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task must be assigned or in_progress"})
+		return
 	}
 
 	var body map[string]interface{}
 	_ = json.NewDecoder(r.Body).Decode(&body)
 
+	now := time.Now()
+	agentID := r.Header.Get("X-Agent-ID")
+	started := task.Status == store.StatusAssigned
+	if started {
+		task.Status = store.StatusInProgress
+		task.StartedAt = &now
+	}
+	acked := task.Acknowledge(now)
+	if p := store.ParseProgress(body, agentID, now); p != nil {
+		task.Progress = p
+	}
+	task.LastHeartbeatAt = &now
+	if err := h.saveLiveness(r, task, started || acked); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
 	_ = h.store.CreateTaskEvent(r.Context(), &store.TaskEvent{
 		TaskID:  task.ID,
 		Event:   "progress",
-		AgentID: r.Header.Get("X-Agent-ID"),
+		AgentID: agentID,
 		Payload: body,
 	})
 
 	if h.hermes != nil {
-		_ = h.hermes.Publish(hermes.SubjectTaskProgress(task.ID.String()), body)
+		evt := map[string]interface{}{hermes.OriginField: hermes.OriginAPI}
+		for k, v := range body {
+			evt[k] = v
+		}
+		_ = h.hermes.Publish(hermes.SubjectTaskProgress(task.ID.String()), evt)
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+// Heartbeat handles POST /api/v1/tasks/{id}/heartbeat. It records liveness
+// without adding to the event log; an optional progress body updates the
+// snapshot.
+func (h *TasksHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid task id"})
+		return
+	}
+
+	task, err := h.store.GetTask(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if task == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if task.Status != store.StatusAssigned && task.Status != store.StatusInProgress {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task must be assigned or in_progress"})
+		return
+	}
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	now := time.Now()
+	agentID := r.Header.Get("X-Agent-ID")
+	task.LastHeartbeatAt = &now
+	acked := task.Acknowledge(now)
+	if p := store.ParseProgress(body, agentID, now); p != nil {
+		task.Progress = p
+	}
+	if err := h.saveLiveness(r, task, acked); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if h.hermes != nil {
+		_ = h.hermes.Publish(hermes.SubjectTaskHeartbeat(task.ID.String()), map[string]interface{}{
+			"task_id":          task.ID.String(),
+			"agent_id":         agentID,
+			hermes.OriginField: hermes.OriginAPI,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":            "ok",
+		"last_heartbeat_at": now,
+	})
+}
+
+// saveLiveness saves a progress report or heartbeat. Only the first one,
+// which acks or starts the task, changes tracked fields; the rest write just
+// the progress, heartbeat and lease.
+func (h *TasksHandler) saveLiveness(r *http.Request, task *store.Task, changed bool) error {
+	if changed {
+		return h.store.UpdateTask(r.Context(), task)
+	}
+	return h.store.UpdateTaskLiveness(r.Context(), task)
+}
+
 type DiscoveryCompleteRequest struct {
 	ComplexityScore    *float64 `json:"complexity_score,omitempty"`
 	RiskScore          *float64 `json:"risk_score,omitempty"`
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+type TimelineHandler struct {
+	store store.Store
+}
+
+func NewTimelineHandler(s store.Store) *TimelineHandler {
+	return &TimelineHandler{store: s}
+}
+
+// TimelineEntry is a single row in a task timeline. Kind is one of
+// "event", "scoring" or "override".
+type TimelineEntry struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Kind      string                 `json:"kind"`
+	Event     string                 `json:"event"`
+	AgentID   string                 `json:"agent_id,omitempty"`
+	State     string                 `json:"state,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// StateSpan records how long a task stayed in a given state.
+type StateSpan struct {
+	State           string     `json:"state"`
+	EnteredAt       time.Time  `json:"entered_at"`
+	ExitedAt        *time.Time `json:"exited_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+}
+
+type TaskTimeline struct {
+	TaskID      uuid.UUID          `json:"task_id"`
+	Status      store.TaskStatus   `json:"status"`
+	Entries     []TimelineEntry    `json:"entries"`
+	States      []StateSpan        `json:"states"`
+	TimeInState map[string]float64 `json:"time_in_state"`
+}
+
+// eventStates maps task events to the state the task enters when they occur.
+// Events not listed here do not change state.
+var eventStates = map[string]store.TaskStatus{
+	"waking":            store.StatusAssigned,
+	"assigned":          store.StatusAssigned,
+	"started":           store.StatusInProgress,
+	"progress":          store.StatusInProgress,
+	"completed":         store.StatusCompleted,
+	"failed":            store.StatusFailed,
+	"retry":             store.StatusPending,
+	"timeout_retry":     store.StatusPending,
+	"reassigned":        store.StatusPending,
+	"ack_timeout":       store.StatusPending,
+	"wake_timeout":      store.StatusPending,
+	"preempted":         store.StatusPending,
+	"timeout_exhausted": store.StatusTimedOut,
+}
+
+// Events handles GET /api/v1/tasks/{id}/events
+func (h *TimelineHandler) Events(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+	events, err := h.store.GetTaskEvents(r.Context(), task.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if events == nil {
+		events = []*store.TaskEvent{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// Timeline handles GET /api/v1/tasks/{id}/timeline
+func (h *TimelineHandler) Timeline(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+	events, err := h.store.GetTaskEvents(r.Context(), task.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	overrides, err := h.store.GetOverridesForTask(r.Context(), task.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, buildTimeline(task, events, overrides, time.Now()))
+}
+
+func (h *TimelineHandler) loadTask(w http.ResponseWriter, r *http.Request) (*store.Task, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid task id"})
+		return nil, false
+	}
+	task, err := h.store.GetTask(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	if task == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return nil, false
+	}
+	return task, true
+}
+
+// buildTimeline merges task events, scoring explanations and overrides into a
+// single time-ordered view and derives the time spent in each state.
+func buildTimeline(task *store.Task, events []*store.TaskEvent, overrides []*store.DispatchOverride, now time.Time) *TaskTimeline {
+	sorted := make([]*store.TaskEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	entries := []TimelineEntry{{
+		Timestamp: task.CreatedAt,
+		Kind:      "event",
+		Event:     "created",
+		AgentID:   task.Owner,
+		State:     string(store.StatusPending),
+	}}
+
+	lastAssigned := -1
+	for i, e := range sorted {
+		if e.Event == "assigned" {
+			lastAssigned = i
+		}
+	}
+
+	for i, e := range sorted {
+		entry := TimelineEntry{
+			Timestamp: e.CreatedAt,
+			Kind:      "event",
+			Event:     e.Event,
+			AgentID:   e.AgentID,
+			Details:   e.Payload,
+		}
+		if st, ok := eventStates[e.Event]; ok {
+			entry.State = string(st)
+		}
+		entries = append(entries, entry)
+
+		if e.Event == "assigned" {
+			details := map[string]interface{}{}
+			for k, v := range e.Payload {
+				details[k] = v
+			}
+			if i == lastAssigned {
+				addTaskScoring(details, task)
+			}
+			entries = append(entries, TimelineEntry{
+				Timestamp: e.CreatedAt,
+				Kind:      "scoring",
+				Event:     "scored",
+				AgentID:   e.AgentID,
+				Details:   details,
+			})
+		}
+	}
+
+	// Tasks assigned before assignment events carried scoring still have the
+	// persisted factors; surface them at the assignment time.
+	if lastAssigned < 0 && task.AssignedAt != nil && task.ScoringFactors != nil {
+		details := map[string]interface{}{}
+		addTaskScoring(details, task)
+		entries = append(entries, TimelineEntry{
+			Timestamp: *task.AssignedAt,
+			Kind:      "scoring",
+			Event:     "scored",
+			AgentID:   task.AssignedAgent,
+			Details:   details,
+		})
+	}
+
+	for _, o := range overrides {
+		details := map[string]interface{}{
+			"override_type": o.OverrideType,
+			"new_value":     o.NewValue,
+		}
+		if o.PreviousValue != "" {
+			details["previous_value"] = o.PreviousValue
+		}
+		if o.Reason != "" {
+			details["reason"] = o.Reason
+		}
+		entries = append(entries, TimelineEntry{
+			Timestamp: o.CreatedAt,
+			Kind:      "override",
+			Event:     "override",
+			AgentID:   o.OverriddenBy,
+			Details:   details,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	states := stateSpans(task, sorted, now)
+	timeIn := make(map[string]float64)
+	for _, s := range states {
+		timeIn[s.State] += s.DurationSeconds
+	}
+
+	return &TaskTimeline{
+		TaskID:      task.ID,
+		Status:      task.Status,
+		Entries:     entries,
+		States:      states,
+		TimeInState: timeIn,
+	}
+}
+
+func addTaskScoring(details map[string]interface{}, task *store.Task) {
+	if task.ScoringFactors != nil {
+		details["scoring_factors"] = task.ScoringFactors
+	}
+	if task.OversightLevel != "" {
+		details["oversight_level"] = task.OversightLevel
+	}
+	if task.ModelTier != "" {
+		details["model_tier"] = task.ModelTier
+	}
+}
+
+// stateSpans walks the ordered events and returns one span per state visit.
+// Terminal states have zero duration; the current non-terminal state is
+// measured up to now.
+func stateSpans(task *store.Task, events []*store.TaskEvent, now time.Time) []StateSpan {
+	current := store.StatusPending
+	entered := task.CreatedAt
+	var spans []StateSpan
+
+	for _, e := range events {
+		next, ok := eventStates[e.Event]
+		if !ok || next == current {
+			continue
+		}
+		exited := e.CreatedAt
+		spans = append(spans, StateSpan{
+			State:           string(current),
+			EnteredAt:       entered,
+			ExitedAt:        &exited,
+			DurationSeconds: exited.Sub(entered).Seconds(),
+		})
+		current = next
+		entered = e.CreatedAt
+	}
+
+	last := StateSpan{State: string(current), EnteredAt: entered}
+	if !isTerminal(current) {
+		last.DurationSeconds = now.Sub(entered).Seconds()
+	}
+	return append(spans, last)
+}
+
+func isTerminal(s store.TaskStatus) bool {
+	return s == store.StatusCompleted || s == store.StatusFailed || s == store.StatusTimedOut
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func seedTimelineTask(ms *mockStore) *store.Task {
+	base := time.Now().Add(-time.Hour)
+	assignedAt := base.Add(10 * time.Second)
+	task := &store.Task{
+		Title:          "timeline",
+		Owner:          "mike-d",
+		Status:         store.StatusCompleted,
+		AssignedAgent:  "scout",
+		AssignedAt:     &assignedAt,
+		ScoringFactors: map[string]interface{}{"capability": 1.0},
+	}
+	_ = ms.CreateTask(context.Background(), task)
+	task.CreatedAt = base
+
+	add := func(event, agent string, offset time.Duration) {
+		_ = ms.CreateTaskEvent(context.Background(), &store.TaskEvent{
+			TaskID:    task.ID,
+			Event:     event,
+			AgentID:   agent,
+			CreatedAt: base.Add(offset),
+		})
+	}
+	// Deliberately out of order — the timeline must sort.
+	add("completed", "scout", 100*time.Second)
+	add("assigned", "scout", 10*time.Second)
+	add("started", "scout", 40*time.Second)
+
+	_ = ms.CreateOverride(context.Background(), &store.DispatchOverride{
+		TaskID:       &task.ID,
+		OverrideType: "reassign",
+		NewValue:     "scout",
+		OverriddenBy: "mike-d",
+		CreatedAt:    base.Add(5 * time.Second),
+	})
+	return task
+}
+
+func TestTaskEventsEndpoint(t *testing.T) {
+	router, ms := setupTestRouter()
+	task := seedTimelineTask(ms)
+
+	req := httptest.NewRequest("GET", "/api/v1/tasks/"+task.ID.String()+"/events", nil)
+	req.Header.Set("X-Agent-ID", "scout")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var events []store.TaskEvent
+	_ = json.NewDecoder(w.Body).Decode(&events)
+	if len(events) != 3 {
+		t.Errorf("expected 3 events, got %d", len(events))
+	}
+}
+
+func TestTaskEventsNotFound(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	req := httptest.NewRequest("GET", "/api/v1/tasks/"+uuid.New().String()+"/events", nil)
+	req.Header.Set("X-Agent-ID", "scout")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestTaskTimelineEndpoint(t *testing.T) {
+	router, ms := setupTestRouter()
+	task := seedTimelineTask(ms)
+
+	req := httptest.NewRequest("GET", "/api/v1/tasks/"+task.ID.String()+"/timeline", nil)
+	req.Header.Set("X-Agent-ID", "scout")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var tl TaskTimeline
+	_ = json.NewDecoder(w.Body).Decode(&tl)
+
+	wantOrder := []string{"created", "override", "assigned", "scored", "started", "completed"}
+	if len(tl.Entries) != len(wantOrder) {
+		t.Fatalf("expected %d entries, got %d", len(wantOrder), len(tl.Entries))
+	}
+	for i, want := range wantOrder {
+		if tl.Entries[i].Event != want {
+			t.Errorf("entry %d: expected %s, got %s", i, want, tl.Entries[i].Event)
+		}
+	}
+	if tl.Entries[3].Kind != "scoring" || tl.Entries[3].Details["scoring_factors"] == nil {
+		t.Errorf("expected scoring entry with factors, got %+v", tl.Entries[3])
+	}
+
+	if got := tl.TimeInState["pending"]; got < 9.9 || got > 10.1 {
+		t.Errorf("expected ~10s pending, got %f", got)
+	}
+	if got := tl.TimeInState["assigned"]; got < 29.9 || got > 30.1 {
+		t.Errorf("expected ~30s assigned, got %f", got)
+	}
+	if got := tl.TimeInState["in_progress"]; got < 59.9 || got > 60.1 {
+		t.Errorf("expected ~60s in_progress, got %f", got)
+	}
+	last := tl.States[len(tl.States)-1]
+	if last.State != "completed" || last.ExitedAt != nil {
+		t.Errorf("expected open completed span, got %+v", last)
+	}
+}
+
+func TestBuildTimelineOpenStateMeasuredToNow(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := &store.Task{ID: uuid.New(), CreatedAt: created, Status: store.StatusInProgress}
+	events := []*store.TaskEvent{
+		{Event: "assigned", AgentID: "scout", CreatedAt: created.Add(time.Minute)},
+		{Event: "started", AgentID: "scout", CreatedAt: created.Add(2 * time.Minute)},
+		{Event: "progress", AgentID: "scout", CreatedAt: created.Add(3 * time.Minute)},
+	}
+
+	tl := buildTimeline(task, events, nil, created.Add(5*time.Minute))
+	if len(tl.States) != 3 {
+		t.Fatalf("expected 3 spans (progress does not re-enter), got %d", len(tl.States))
+	}
+	if got := tl.TimeInState["in_progress"]; got != 180 {
+		t.Errorf("expected 180s in_progress, got %f", got)
+	}
+}
@@ -0,0 +1,227 @@
+// Package backlogio converts backlog items to and from portable records, and
+// reads and writes those records as JSON, CSV or a Markdown TODO list.
+// Records refer to each other, for parents and blockers, by reference: an
+// item's external ID, or its ID when it has none.
+package backlogio
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// Formats.
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+)
+
+// ValidFormat reports whether format is a known format.
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatJSON, FormatCSV, FormatMarkdown:
+		return true
+	}
+	return false
+}
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Gate is one gate criterion of an item's current stage.
+type Gate struct {
+	Criterion string `json:"criterion"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+// Record is a backlog item in portable form. Unset fields leave an existing
+// item's value as it is when the record is applied. Gates and UpdatedAt are
+// exported for reference and never applied.
+type Record struct {
+	ID              string     `json:"id,omitempty"`
+	ExternalID      string     `json:"external_id,omitempty"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description,omitempty"`
+	ItemType        string     `json:"item_type,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	Domain          string     `json:"domain,omitempty"`
+	AssignedTo      string     `json:"assigned_to,omitempty"`
+	Parent          string     `json:"parent,omitempty"` // reference to the parent
+	Impact          *float64   `json:"impact,omitempty"`
+	Urgency         *float64   `json:"urgency,omitempty"`
+	EstimatedTokens *int64     `json:"estimated_tokens,omitempty"`
+	EffortEstimate  string     `json:"effort_estimate,omitempty"`
+	ModelTier       string     `json:"model_tier,omitempty"`
+	Labels          []string   `json:"labels,omitempty"`
+	OneWayDoor      *bool      `json:"one_way_door,omitempty"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	StageTemplate   []string   `json:"stage_template,omitempty"`
+	CurrentStage    string     `json:"current_stage,omitempty"`
+	StageIndex      *int       `json:"stage_index,omitempty"`
+	Gates           []Gate     `json:"gates,omitempty"`
+	BlockedBy       []string   `json:"blocked_by,omitempty"` // references to the item's blockers
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+}
+
+// Ref returns the reference records use for item.
+func Ref(item *store.BacklogItem) string {
+	if item.ExternalID != "" {
+		return item.ExternalID
+	}
+	return item.ID.String()
+}
+
+// NewRecord returns item as a record, given the reference to its parent,
+// the references to its blockers and the gates of its current stage.
+func NewRecord(item *store.BacklogItem, parent string, blockedBy []string, gates []store.GateCriterion) Record {
+	oneWayDoor := item.OneWayDoor
+	stageIndex := item.StageIndex
+	updatedAt := item.UpdatedAt
+	rec := Record{
+		ID:              item.ID.String(),
+		ExternalID:      item.ExternalID,
+		Title:           item.Title,
+		Description:     item.Description,
+		ItemType:        item.ItemType,
+		Status:          string(item.Status),
+		Domain:          item.Domain,
+		AssignedTo:      item.AssignedTo,
+		Parent:          parent,
+		Impact:          item.Impact,
+		Urgency:         item.Urgency,
+		EstimatedTokens: item.EstimatedTokens,
+		EffortEstimate:  item.EffortEstimate,
+		ModelTier:       item.ModelTier,
+		Labels:          item.Labels,
+		OneWayDoor:      &oneWayDoor,
+		DueDate:         item.DueDate,
+		StageTemplate:   item.StageTemplate,
+		CurrentStage:    item.CurrentStage,
+		BlockedBy:       blockedBy,
+		UpdatedAt:       &updatedAt,
+	}
+	if len(item.StageTemplate) > 0 {
+		rec.StageIndex = &stageIndex
+	}
+	for _, g := range gates {
+		rec.Gates = append(rec.Gates, Gate{Criterion: g.Criterion, Satisfied: g.Satisfied})
+	}
+	return rec
+}
+
+// Apply sets the fields rec sets on item. The parent and blockers are
+// references, so they are left to the caller to resolve. It fails if the
+// item's stage fields then disagree, and item should be discarded.
+func Apply(item *store.BacklogItem, rec Record) error {
+	if rec.ExternalID != "" {
+		item.ExternalID = rec.ExternalID
+	}
+	if rec.Title != "" {
+		item.Title = rec.Title
+	}
+	if rec.Description != "" {
+		item.Description = rec.Description
+	}
+	if rec.ItemType != "" {
+		item.ItemType = rec.ItemType
+	}
+	if rec.Status != "" {
+		item.Status = store.BacklogStatus(rec.Status)
+	}
+	if rec.Domain != "" {
+		item.Domain = rec.Domain
+	}
+	if rec.AssignedTo != "" {
+		item.AssignedTo = rec.AssignedTo
+	}
+	if rec.Impact != nil {
+		item.Impact = rec.Impact
+	}
+	if rec.Urgency != nil {
+		item.Urgency = rec.Urgency
+	}
+	if rec.EstimatedTokens != nil {
+		item.EstimatedTokens = rec.EstimatedTokens
+	}
+	if rec.EffortEstimate != "" {
+		item.EffortEstimate = rec.EffortEstimate
+	}
+	if rec.ModelTier != "" {
+		item.ModelTier = rec.ModelTier
+	}
+	if rec.Labels != nil {
+		item.Labels = rec.Labels
+	}
+	if rec.OneWayDoor != nil {
+		item.OneWayDoor = *rec.OneWayDoor
+	}
+	if rec.DueDate != nil {
+		item.DueDate = rec.DueDate
+	}
+	if rec.StageTemplate != nil {
+		item.StageTemplate = rec.StageTemplate
+	}
+	if rec.CurrentStage != "" {
+		item.CurrentStage = rec.CurrentStage
+	}
+	if rec.StageIndex != nil {
+		item.StageIndex = *rec.StageIndex
+	}
+	return checkStages(item)
+}
+
+// checkStages reports whether item's stage index is within its stage
+// template and names its current stage. An item without a template has no
+// stage.
+func checkStages(item *store.BacklogItem) error {
+	if len(item.StageTemplate) == 0 {
+		if item.CurrentStage != "" || item.StageIndex != 0 {
+			return fmt.Errorf("current_stage and stage_index need a stage_template")
+		}
+		return nil
+	}
+	if item.StageIndex < 0 || item.StageIndex >= len(item.StageTemplate) {
+		return fmt.Errorf("stage_index %d is outside a stage_template of %d stages", item.StageIndex, len(item.StageTemplate))
+	}
+	if want := item.StageTemplate[item.StageIndex]; item.CurrentStage != want {
+		return fmt.Errorf("current_stage %q does not match stage %d of stage_template, %q", item.CurrentStage, item.StageIndex, want)
+	}
+	return nil
+}
+
+// Read reads records in format from r.
+func Read(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case FormatJSON:
+		return ReadJSON(r)
+	case FormatCSV:
+		return ReadCSV(r)
+	case FormatMarkdown:
+		return ReadMarkdown(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// Write writes records to w in format.
+func Write(format string, w io.Writer, records []Record) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, records)
+	case FormatCSV:
+		return WriteCSV(w, records)
+	case FormatMarkdown:
+		return WriteMarkdown(w, records)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
@@ -0,0 +1,156 @@
+package backlogio
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func testItem() *store.BacklogItem {
+	impact, urgency := 0.8, 0.6
+	tokens := int64(40000)
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	return &store.BacklogItem{
+		ID:              uuid.New(),
+		ExternalID:      "ops-42",
+		Title:           "Rotate credentials, then redeploy",
+		Description:     "Quarterly \"rotation\"",
+		ItemType:        "task",
+		Status:          store.BacklogStatusInProgress,
+		Domain:          "operations",
+		Impact:          &impact,
+		Urgency:         &urgency,
+		EstimatedTokens: &tokens,
+		EffortEstimate:  "m",
+		ModelTier:       "standard",
+		Labels:          []string{"security", "infra"},
+		OneWayDoor:      true,
+		DueDate:         &due,
+		StageTemplate:   []string{"discovery", "build"},
+		CurrentStage:    "build",
+		StageIndex:      1,
+		UpdatedAt:       time.Date(2026, 10, 1, 12, 30, 0, 123456000, time.UTC),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	item := testItem()
+	gates := []store.GateCriterion{{Criterion: "tests pass", Satisfied: true}, {Criterion: "reviewed"}}
+	rec := NewRecord(item, "epic-1", []string{"ops-41", "ops-40"}, gates)
+
+	for _, format := range []string{FormatJSON, FormatCSV} {
+		var buf bytes.Buffer
+		if err := Write(format, &buf, []Record{rec}); err != nil {
+			t.Fatalf("%s: write: %v", format, err)
+		}
+		got, err := Read(format, &buf)
+		if err != nil {
+			t.Fatalf("%s: read: %v", format, err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("%s: read %d records, want 1", format, len(got))
+		}
+		want := rec
+		if format == FormatCSV {
+			// CSV keeps gates for reading only.
+			want.Gates = nil
+		}
+		if !reflect.DeepEqual(got[0], want) {
+			t.Errorf("%s: round trip\n got %+v\nwant %+v", format, got[0], want)
+		}
+
+		applied := &store.BacklogItem{ID: item.ID, UpdatedAt: item.UpdatedAt}
+		if err := Apply(applied, got[0]); err != nil {
+			t.Fatalf("%s: apply: %v", format, err)
+		}
+		if !reflect.DeepEqual(applied, item) {
+			t.Errorf("%s: applied\n got %+v\nwant %+v", format, applied, item)
+		}
+	}
+}
+
+func TestApplyLeavesUnsetFields(t *testing.T) {
+	item := testItem()
+	want := *item
+	urgency := 0.95
+	want.Urgency = &urgency
+	want.Status = store.BacklogStatusDone
+
+	if err := Apply(item, Record{Title: item.Title, Status: "done", Urgency: &urgency}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*item, want) {
+		t.Errorf("applied\n got %+v\nwant %+v", *item, want)
+	}
+}
+
+func TestApplyRejectsInconsistentStages(t *testing.T) {
+	index := func(i int) *int { return &i }
+	tests := []struct {
+		name string
+		rec  Record
+	}{
+		{"negative index", Record{StageIndex: index(-1)}},
+		{"index past the template", Record{StageIndex: index(2)}},
+		{"stage not at the index", Record{CurrentStage: "discovery"}},
+		{"template without the current stage", Record{StageTemplate: []string{"plan", "ship"}}},
+	}
+	for _, tt := range tests {
+		if err := Apply(testItem(), tt.rec); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	// Without a template an item has no stage.
+	if err := Apply(&store.BacklogItem{}, Record{CurrentStage: "build"}); err == nil {
+		t.Error("expected a current stage without a template to be rejected")
+	}
+	if err := Apply(testItem(), Record{StageTemplate: []string{"build"}, StageIndex: index(0)}); err != nil {
+		t.Errorf("expected a consistent new template to apply, got %v", err)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	item := testItem()
+	noRef := &store.BacklogItem{ID: uuid.New(), Title: "Untracked", Status: store.BacklogStatusDone}
+	cancelled := &store.BacklogItem{ID: uuid.New(), Title: "Dropped", Status: store.BacklogStatusCancelled}
+
+	var buf bytes.Buffer
+	records := []Record{NewRecord(item, "", nil, nil), NewRecord(noRef, "", nil, nil), NewRecord(cancelled, "", nil, nil)}
+	if err := Write(FormatMarkdown, &buf, records); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Dropped") {
+		t.Error("cancelled item written")
+	}
+
+	got, err := Read(FormatMarkdown, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("read %d records, want 2:\n%s", len(got), buf.String())
+	}
+	if got[0].ID != noRef.ID.String() || got[0].ExternalID != "" || got[0].Status != "done" {
+		t.Errorf("item without an external id read as %+v", got[0])
+	}
+	if got[1].ExternalID != "ops-42" || got[1].Domain != "operations" || got[1].Status != "" || *got[1].Urgency != 0.50 {
+		t.Errorf("item read as %+v", got[1])
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	if _, err := ReadCSV(strings.NewReader("id,name\n1,x\n")); err == nil {
+		t.Error("expected an error for a csv without a title column")
+	}
+	_, err := ReadCSV(strings.NewReader("title,urgency\nok,0.5\nbad,high\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("err = %v, want an invalid urgency on line 3", err)
+	}
+}
@@ -0,0 +1,215 @@
+package backlogio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvColumns are the columns WriteCSV writes, in order. ReadCSV finds
+// columns by name, so they may come in any order and all but title may be
+// left out.
+var csvColumns = []string{
+	"id", "external_id", "title", "description", "item_type", "status", "domain", "assigned_to", "parent",
+	"impact", "urgency", "estimated_tokens", "effort_estimate", "model_tier", "labels", "one_way_door", "due_date",
+	"stage_template", "current_stage", "stage_index", "gates_met", "gates_pending", "blocked_by", "updated_at",
+}
+
+// listSep separates the values of a list in one CSV cell.
+const listSep = "|"
+
+// WriteCSV writes records as CSV with a header row. Lists are joined with
+// "|", and the gates of the current stage are split into the criteria met
+// and those pending.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		var met, pending []string
+		for _, g := range rec.Gates {
+			if g.Satisfied {
+				met = append(met, g.Criterion)
+			} else {
+				pending = append(pending, g.Criterion)
+			}
+		}
+		row := []string{
+			rec.ID, rec.ExternalID, rec.Title, rec.Description, rec.ItemType, rec.Status, rec.Domain, rec.AssignedTo, rec.Parent,
+			formatFloat(rec.Impact), formatFloat(rec.Urgency), formatInt(rec.EstimatedTokens), rec.EffortEstimate, rec.ModelTier,
+			strings.Join(rec.Labels, listSep), formatBool(rec.OneWayDoor), formatTime(rec.DueDate),
+			strings.Join(rec.StageTemplate, listSep), rec.CurrentStage, formatStageIndex(rec.StageIndex),
+			strings.Join(met, listSep), strings.Join(pending, listSep), strings.Join(rec.BlockedBy, listSep), formatTime(rec.UpdatedAt),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads records from CSV with a header row. Unknown columns, and
+// the gate columns, are ignored.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	if _, ok := cols["title"]; !ok {
+		return nil, fmt.Errorf("csv has no title column")
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		rec, err := csvRecord(cols, row)
+		if err != nil {
+			return nil, fmt.Errorf("csv line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+func csvRecord(cols map[string]int, row []string) (Record, error) {
+	get := func(name string) string {
+		if i, ok := cols[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	rec := Record{
+		ID:             get("id"),
+		ExternalID:     get("external_id"),
+		Title:          get("title"),
+		Description:    get("description"),
+		ItemType:       get("item_type"),
+		Status:         get("status"),
+		Domain:         get("domain"),
+		AssignedTo:     get("assigned_to"),
+		Parent:         get("parent"),
+		EffortEstimate: get("effort_estimate"),
+		ModelTier:      get("model_tier"),
+		Labels:         splitList(get("labels")),
+		StageTemplate:  splitList(get("stage_template")),
+		CurrentStage:   get("current_stage"),
+		BlockedBy:      splitList(get("blocked_by")),
+	}
+
+	var err error
+	for name, dst := range map[string]**float64{"impact": &rec.Impact, "urgency": &rec.Urgency} {
+		if s := get(name); s != "" {
+			v, perr := strconv.ParseFloat(s, 64)
+			if perr != nil {
+				return rec, fmt.Errorf("invalid %s %q", name, s)
+			}
+			*dst = &v
+		}
+	}
+	if s := get("estimated_tokens"); s != "" {
+		v, perr := strconv.ParseInt(s, 10, 64)
+		if perr != nil {
+			return rec, fmt.Errorf("invalid estimated_tokens %q", s)
+		}
+		rec.EstimatedTokens = &v
+	}
+	if s := get("one_way_door"); s != "" {
+		v, perr := strconv.ParseBool(s)
+		if perr != nil {
+			return rec, fmt.Errorf("invalid one_way_door %q", s)
+		}
+		rec.OneWayDoor = &v
+	}
+	if s := get("stage_index"); s != "" {
+		v, perr := strconv.Atoi(s)
+		if perr != nil {
+			return rec, fmt.Errorf("invalid stage_index %q", s)
+		}
+		rec.StageIndex = &v
+	}
+	if rec.DueDate, err = parseTime("due_date", get("due_date")); err != nil {
+		return rec, err
+	}
+	if rec.UpdatedAt, err = parseTime("updated_at", get("updated_at")); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for _, v := range strings.Split(s, listSep) {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func parseTime(name, s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &t, nil
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func formatInt(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+func formatBool(v *bool) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v)
+}
+
+func formatStageIndex(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,26 @@
+package backlogio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ReadJSON reads a JSON array of records.
+func ReadJSON(r io.Reader) ([]Record, error) {
+	var records []Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	return records, nil
+}
+
+// WriteJSON writes records as an indented JSON array.
+func WriteJSON(w io.Writer, records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
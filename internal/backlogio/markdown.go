@@ -0,0 +1,68 @@
+package backlogio
+
+import (
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+	"github.com/MikeSquared-Agency/Dispatch/internal/todo"
+)
+
+// ReadMarkdown reads records from a TODO list. Each checkbox gives a
+// title, domain, urgency and external ID; a checked box marks the item
+// done, and an unchecked one leaves its status as it is.
+func ReadMarkdown(r io.Reader) ([]Record, error) {
+	items, err := todo.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		records = append(records, FromTodo(item))
+	}
+	return records, nil
+}
+
+// FromTodo returns a TODO list item as a record. An item whose ID is a
+// UUID refers to an item without an external ID, as WriteMarkdown writes
+// them.
+func FromTodo(item todo.Item) Record {
+	rec := Record{
+		Title:   item.Title,
+		Domain:  item.Domain,
+		Urgency: item.Urgency,
+	}
+	if _, err := uuid.Parse(item.ExternalID); err == nil {
+		rec.ID = item.ExternalID
+	} else {
+		rec.ExternalID = item.ExternalID
+	}
+	if item.Done {
+		rec.Status = string(store.BacklogStatusDone)
+	}
+	return rec
+}
+
+// WriteMarkdown writes records as a TODO list with a section per domain.
+// Cancelled items are left out, since the list can only mark items done.
+func WriteMarkdown(w io.Writer, records []Record) error {
+	var items []todo.Item
+	for _, rec := range records {
+		if rec.Status == string(store.BacklogStatusCancelled) {
+			continue
+		}
+		ref := rec.ExternalID
+		if ref == "" {
+			ref = rec.ID
+		}
+		items = append(items, todo.Item{
+			Title:      rec.Title,
+			Domain:     rec.Domain,
+			Done:       rec.Status == string(store.BacklogStatusDone),
+			Urgency:    rec.Urgency,
+			ExternalID: ref,
+		})
+	}
+	return todo.Write(w, items)
+}
@@ -0,0 +1,116 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// placement is a task's resolved agent constraints. A required agent and
+// exclusions are hard filters; warm agents are favoured by the contextuality
+// factor. Agents are matched by slug or name.
+type placement struct {
+	required string
+	excluded map[string]string // agent -> reason
+	warm     map[string]string // agent -> reason
+}
+
+// placementFor resolves task's placement once per tick. Review tasks never
+// go to the agent that implemented their parent task.
+func (b *Broker) placementFor(ctx context.Context, snap *tickSnapshot, task *store.Task) *placement {
+	if p := snap.placement(task); p != nil {
+		return p
+	}
+
+	p := &placement{
+		required: task.RequiredAgent,
+		excluded: make(map[string]string),
+		warm:     make(map[string]string),
+	}
+	for _, a := range task.ExcludedAgents {
+		p.excluded[a] = "excluded by task"
+	}
+	for _, a := range task.PreferredAgents {
+		p.warm[a] = "preferred agent"
+	}
+	if agent, _ := task.Metadata[metaRetryAgent].(string); agent != "" {
+		if _, ok := p.warm[agent]; !ok {
+			p.warm[agent] = "retrying on the agent that last ran it"
+		}
+	}
+	if task.AffinityGroup != "" {
+		agent, err := b.store.GetAffinityGroupAgent(ctx, task.AffinityGroup)
+		if err != nil {
+			b.logger.Warn("failed to look up affinity group agent", "task_id", task.ID, "group", task.AffinityGroup, "error", err)
+		} else if _, ok := p.warm[agent]; agent != "" && !ok {
+			p.warm[agent] = fmt.Sprintf("warm context from affinity group %q", task.AffinityGroup)
+		}
+	}
+	if isReviewTask(task) && task.ParentTaskID != nil {
+		parent, err := b.store.GetTask(ctx, *task.ParentTaskID)
+		if err != nil {
+			b.logger.Warn("failed to look up parent task", "task_id", task.ID, "parent_task_id", task.ParentTaskID, "error", err)
+		} else if parent != nil && parent.AssignedAgent != "" {
+			p.excluded[parent.AssignedAgent] = "implemented parent task " + parent.ID.String()
+		}
+	}
+	for a := range p.excluded {
+		delete(p.warm, a)
+	}
+
+	return snap.setPlacement(task, p)
+}
+
+// isReviewTask reports whether task reviews its parent's work, by label or
+// required capability.
+func isReviewTask(task *store.Task) bool {
+	for _, l := range append(append([]string(nil), task.Labels...), task.RequiredCapabilities...) {
+		if strings.EqualFold(l, "review") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *placement) allows(c forge.Persona) bool {
+	if p.required != "" && p.required != c.Slug && p.required != c.Name {
+		return false
+	}
+	_, bySlug := p.excluded[c.Slug]
+	_, byName := p.excluded[c.Name]
+	return !bySlug && !byName
+}
+
+// warmContext returns why c has warm context for the task, or "".
+func (p *placement) warmContext(c forge.Persona) string {
+	if r, ok := p.warm[c.Slug]; ok {
+		return r
+	}
+	return p.warm[c.Name]
+}
+
+func (p *placement) constrained() bool {
+	return p.required != "" || len(p.excluded) > 0
+}
+
+// explain returns the resolved placement for the scoring breakdown, or nil
+// when the task has none.
+func (p *placement) explain() map[string]interface{} {
+	if !p.constrained() && len(p.warm) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{})
+	if p.required != "" {
+		out["required_agent"] = p.required
+	}
+	if len(p.excluded) > 0 {
+		out["excluded"] = p.excluded
+	}
+	if len(p.warm) > 0 {
+		out["warm"] = p.warm
+	}
+	return out
+}
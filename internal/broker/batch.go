@@ -0,0 +1,142 @@
+package broker
+
+import (
+	"context"
+	"math"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// assignBatch assigns a tick's pending tasks together instead of one at a
+// time. It builds a task × agent-slot utility matrix, where utility is the
+// candidate score scaled by task priority, and picks the assignment with the
+// highest total. Each agent contributes one slot per unit of remaining
+// capacity under its concurrency limit.
+func (b *Broker) assignBatch(ctx context.Context, snap *tickSnapshot, tasks []*store.Task) {
+	// Tasks held by an exhausted budget or capability limit, or beyond their
+	// fair-share group's in-flight cap, take no part in the matching. Tasks
+	// arrive in fair order, so each group keeps its most urgent ones.
+	admitted := tasks[:0:0]
+	queued := make(map[string]int)
+	for _, task := range tasks {
+		if !snap.capabilityAllows(task) {
+			continue
+		}
+		if allowed, _ := b.checkBudget(ctx, snap, task); !allowed {
+			continue
+		}
+		if snap.fair.admit(task, queued) {
+			admitted = append(admitted, task)
+		}
+	}
+	tasks = admitted
+	if len(tasks) == 0 {
+		return
+	}
+
+	scored := make([][]scoredCandidate, len(tasks))
+	for i, task := range tasks {
+		scored[i] = b.scoreTask(ctx, snap, task)
+	}
+
+	// One column per free slot of every agent that is eligible for at least
+	// one task.
+	var slots []string
+	seen := make(map[string]bool)
+	for _, candidates := range scored {
+		for _, c := range candidates {
+			slug := c.persona.Slug
+			if seen[slug] {
+				continue
+			}
+			seen[slug] = true
+			for n := b.freeSlots(snap, slug, len(tasks)); n > 0; n-- {
+				slots = append(slots, slug)
+			}
+		}
+	}
+	// With no free slots every task is unmatched, but urgent tasks may still
+	// preempt.
+
+	weights := make([][]float64, len(tasks))
+	for i, task := range tasks {
+		byAgent := make(map[string]float64, len(scored[i]))
+		for _, c := range scored[i] {
+			byAgent[c.persona.Slug] = c.result.TotalScore * scoring.PriorityWeight(task.Priority)
+		}
+		weights[i] = make([]float64, len(slots))
+		for j, slug := range slots {
+			if w, ok := byAgent[slug]; ok {
+				weights[i][j] = w
+			} else {
+				weights[i][j] = math.Inf(-1)
+			}
+		}
+	}
+
+	total := 0.0
+	assigned := 0
+	for i, col := range scoring.MaxWeightAssignment(weights) {
+		// Earlier commits in this batch may have used up a fair-share
+		// allowance, a capability limit or a budget.
+		if !snap.fair.allow(tasks[i]) || !snap.capabilityAllows(tasks[i]) {
+			continue
+		}
+		allowed, downgrade := b.checkBudget(ctx, snap, tasks[i])
+		if !allowed {
+			continue
+		}
+		winner, candidates := scoredCandidate{}, len(scored[i])
+		switch {
+		case col >= 0:
+			if !snap.fits(slots[col], tasks[i]) {
+				continue
+			}
+			winner = pickCandidate(scored[i], slots[col])
+		case candidates == 0:
+			var ok bool
+			if winner, candidates, ok = b.preempt(ctx, snap, tasks[i]); !ok {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := b.commitAssignment(ctx, snap, tasks[i], winner, candidates, downgrade); err != nil {
+			b.logger.Warn("failed to assign task", "task_id", tasks[i].ID, "error", err)
+			continue
+		}
+		if col >= 0 {
+			total += weights[i][col]
+		}
+		assigned++
+	}
+	b.logger.Info("batch assignment complete", "tasks", len(tasks), "assigned", assigned, "utility", total)
+}
+
+// freeSlots is how many more concurrency slots slug has this tick, capped at
+// limit. Heavy tasks take several slots, so commits re-check that the
+// winner still has room.
+func (b *Broker) freeSlots(snap *tickSnapshot, slug string, limit int) int {
+	max := snap.limit(slug)
+	if max <= 0 {
+		return limit
+	}
+	free := max - snap.slotsInUse(slug)
+	if free < 0 {
+		return 0
+	}
+	if free > limit {
+		return limit
+	}
+	return free
+}
+
+func pickCandidate(candidates []scoredCandidate, slug string) scoredCandidate {
+	for _, c := range candidates {
+		if c.persona.Slug == slug {
+			return c
+		}
+	}
+	return scoredCandidate{}
+}
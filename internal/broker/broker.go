@@ -11,9 +11,11 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/MikeSquared-Agency/Dispatch/internal/alexandria"
+	"github.com/MikeSquared-Agency/Dispatch/internal/budget"
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
 	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/orchestrator"
 	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 	"github.com/MikeSquared-Agency/Dispatch/internal/warren"
@@ -26,6 +28,7 @@ type Broker struct {
 	forge      forge.Client
 	alexandria alexandria.Client
 	scorer     *scoring.Scorer
+	orch       *orchestrator.Orchestrator
 	cfg        *config.Config
 	logger     *slog.Logger
 
@@ -60,6 +63,7 @@ func New(s store.Store, h hermes.Client, w warren.Client, f forge.Client, a alex
 		forge:      f,
 		alexandria: a,
 		scorer:     sc,
+		orch:       orchestrator.New(s, h, cfg, logger),
 		cfg:        cfg,
 		logger:     logger,
 		drained:    make(map[string]bool),
@@ -71,6 +75,14 @@ func (b *Broker) Start(ctx context.Context) {
 	b.wg.Add(2)
 	go b.assignmentLoop(ctx)
 	go b.timeoutLoop(ctx)
+	if b.cfg.ScheduleInterval() > 0 {
+		b.wg.Add(1)
+		go b.scheduleLoop(ctx)
+	}
+	if b.cfg.RescoreInterval() > 0 {
+		b.wg.Add(1)
+		go b.rescoreLoop(ctx)
+	}
 }
 
 func (b *Broker) Stop() {
@@ -119,35 +131,107 @@ func (b *Broker) processPendingTasks(ctx context.Context) {
 		b.logger.Error("failed to get pending tasks", "error", err)
 		return
 	}
+	if len(tasks) == 0 && !b.hasWakingTasks(ctx) {
+		b.logger.Info("processing pending tasks", "count", 0)
+		return
+	}
+	snap, err := b.loadSnapshot(ctx)
+	if err != nil {
+		b.logger.Error("failed to load assignment snapshot", "error", err)
+		return
+	}
+	// Tasks whose agent failed to wake rejoin the queue for this tick.
+	tasks = append(tasks, b.checkWaking(ctx, snap)...)
 
 	b.logger.Info("processing pending tasks", "count", len(tasks))
+	if len(tasks) == 0 {
+		return
+	}
+	now := time.Now()
+	tasks = snap.order(tasks, now)
+	b.flagPendingAtRisk(ctx, snap, tasks, now)
+	if b.cfg.Assignment.Mode == config.AssignmentModeBatch {
+		b.assignBatch(ctx, snap, tasks)
+		return
+	}
 	for _, task := range tasks {
-		if err := b.assignTask(ctx, task); err != nil {
+		if !snap.fair.allow(task) {
+			continue
+		}
+		if err := b.assignWithSnapshot(ctx, snap, task); err != nil {
 			b.logger.Warn("failed to assign task", "task_id", task.ID, "error", err)
 		}
 	}
 }
 
+// hasWakingTasks reports whether any task is waiting for its agent to wake,
+// so an otherwise idle tick can skip loading a snapshot.
+func (b *Broker) hasWakingTasks(ctx context.Context) bool {
+	tasks, err := b.store.GetActiveTasks(ctx)
+	if err != nil {
+		b.logger.Error("failed to get active tasks for wake check", "error", err)
+		return false
+	}
+	for _, task := range tasks {
+		if task.SubState == store.SubStateWaking {
+			return true
+		}
+	}
+	return false
+}
+
+// assignTask assigns a single task outside the tick loop, loading a fresh
+// snapshot for it.
 func (b *Broker) assignTask(ctx context.Context, task *store.Task) error {
+	snap, err := b.loadSnapshot(ctx)
+	if err != nil {
+		b.logger.Error("failed to load assignment snapshot", "error", err)
+		return err
+	}
+	return b.assignWithSnapshot(ctx, snap, task)
+}
+
+// scoredCandidate is an eligible agent and its score for one task.
+type scoredCandidate struct {
+	persona forge.Persona
+	state   *warren.AgentState
+	result  scoring.ScoringResult
+}
+
+// assignWithSnapshot assigns task to its highest-scoring candidate.
+func (b *Broker) assignWithSnapshot(ctx context.Context, snap *tickSnapshot, task *store.Task) error {
+	if !snap.capabilityAllows(task) {
+		b.logger.Info("capability concurrency limit reached, holding task", "task_id", task.ID, "capabilities", task.RequiredCapabilities)
+		return nil
+	}
+	allowed, downgrade := b.checkBudget(ctx, snap, task)
+	if !allowed {
+		return nil
+	}
+	scored := b.scoreTask(ctx, snap, task)
+	if len(scored) == 0 {
+		winner, candidates, ok := b.preempt(ctx, snap, task)
+		if !ok {
+			return nil
+		}
+		return b.commitAssignment(ctx, snap, task, winner, candidates, downgrade)
+	}
+	return b.commitAssignment(ctx, snap, task, scored[0], len(scored), downgrade)
+}
+
+// scoreTask returns the eligible candidates for task, best first. Tasks with
+// no candidates at all are reported as unmatched.
+func (b *Broker) scoreTask(ctx context.Context, snap *tickSnapshot, task *store.Task) []scoredCandidate {
 	b.logger.Info("attempting assignment", "task_id", task.ID, "capabilities", task.RequiredCapabilities, "owner", task.Owner)
 
-	// Query forge for candidates — all agents if no capabilities required, else by primary capability
+	// Candidates — all agents if no capabilities required, else by primary capability
 	var candidates []forge.Persona
-	var err error
 	if len(task.RequiredCapabilities) == 0 {
-		candidates, err = b.forge.ListPersonas(ctx)
-		if err != nil {
-			b.logger.Error("forge list personas failed", "error", err)
-			return err
-		}
+		candidates = snap.candidates("")
 		b.logger.Info("no capabilities required, all agents eligible", "count", len(candidates))
 	} else {
 		primaryCap := task.RequiredCapabilities[0]
-		candidates, err = b.forge.GetAgentsByCapability(ctx, primaryCap)
-		if err != nil {
-			b.logger.Error("forge capability query failed", "error", err)
-			return err
-		}
+		candidates = snap.candidates(primaryCap)
 		b.logger.Info("capability candidates", "count", len(candidates), "primary_cap", primaryCap)
 	}
 
@@ -173,6 +257,38 @@ func (b *Broker) assignTask(ctx context.Context, task *store.Task) error {
 		}
 	}
 
+	// Placement: a required agent and exclusions are hard filters.
+	if place := b.placementFor(ctx, snap, task); place.constrained() {
+		var allowed []forge.Persona
+		for _, c := range candidates {
+			if place.allows(c) {
+				allowed = append(allowed, c)
+			}
+		}
+		candidates = allowed
+		b.logger.Info("after placement filter", "count", len(candidates), "required_agent", task.RequiredAgent)
+	}
+
+	// Prefer agents that have not already missed this task's ack deadline,
+	// failed to wake for it, or failed it under a retry_elsewhere policy.
+	missed := append(ackMissedAgents(task), metadataList(task, metaWakeFailedAgents)...)
+	missed = append(missed, metadataList(task, metaFailedAgents)...)
+	if len(missed) > 0 {
+		skip := make(map[string]bool, len(missed))
+		for _, m := range missed {
+			skip[m] = true
+		}
+		var fresh []forge.Persona
+		for _, c := range candidates {
+			if !skip[c.Slug] && !skip[c.Name] {
+				fresh = append(fresh, c)
+			}
+		}
+		if len(fresh) > 0 {
+			candidates = fresh
+		}
+	}
+
 	if len(candidates) == 0 {
 		b.logger.Warn("no candidates after filtering", "task_id", task.ID)
 		_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
@@ -189,63 +305,84 @@ func (b *Broker) assignTask(ctx context.Context, task *store.Task) error {
 		return nil
 	}
 
-	type scoredV2 struct {
-		persona forge.Persona
-		result  scoring.ScoringResult
-	}
-	var scoredCandidates []scoredV2
+	// Metadata hints are written onto the task, so apply them once before
+	// candidates are scored concurrently.
+	b.enrichFromMetadata(&scoring.TaskContext{Task: task})
 
-	for _, c := range candidates {
-		if b.IsDrained(c.Name) {
-			continue
+	evaluated := make([]*scoredCandidate, len(candidates))
+	b.parallel(len(candidates), func(i int) {
+		c := candidates[i]
+		if b.IsDrained(c.Name) || !snap.fits(c.Slug, task) {
+			return
 		}
-		state, err := b.warren.GetAgentState(ctx, c.Slug)
-		if err != nil {
-			b.logger.Warn("failed to get agent state", "agent", c.Name, "error", err)
-			continue
+		state := snap.state(c.Slug)
+		if state == nil {
+			return
 		}
-
-		tc := b.buildTaskContext(ctx, c, state, task)
-		result := b.scorer.ScoreCandidate(tc)
-		if result.Eligible {
-			scoredCandidates = append(scoredCandidates, scoredV2{persona: c, result: result})
+		tc := b.buildTaskContext(ctx, snap, c, state, task)
+		if result := b.scorer.ScoreCandidate(tc); result.Eligible {
+			evaluated[i] = &scoredCandidate{persona: c, state: state, result: result}
 		}
-	}
+	})
 
-	if len(scoredCandidates) == 0 {
-		return nil
+	var scoredCandidates []scoredCandidate
+	for _, e := range evaluated {
+		if e != nil {
+			scoredCandidates = append(scoredCandidates, *e)
+		}
 	}
 
-	sort.Slice(scoredCandidates, func(i, j int) bool {
+	sort.SliceStable(scoredCandidates, func(i, j int) bool {
 		return scoredCandidates[i].result.TotalScore > scoredCandidates[j].result.TotalScore
 	})
+	return scoredCandidates
+}
 
-	winner := scoredCandidates[0]
-
-	// Wake if sleeping
-	state, _ := b.warren.GetAgentState(ctx, winner.persona.Slug)
-	if state != nil && state.Status == "sleeping" {
+// commitAssignment assigns task to winner, persists it and announces it (or
+// holds it while a sleeping winner wakes). candidates is the number of
+// eligible agents considered, recorded on the assigned event. downgrade
+// routes the task to the cheapest model tier because a budget is exhausted.
+func (b *Broker) commitAssignment(ctx context.Context, snap *tickSnapshot, task *store.Task, winner scoredCandidate, candidates int, downgrade bool) error {
+	// Wake if sleeping. Waking is asynchronous: the task is held in the
+	// assigned/waking sub-state and announced once the agent reports ready
+	// (see checkWaking and HandleAgentStarted).
+	waking := winner.state.Status == "sleeping"
+	if waking && !snap.wakeRequested(winner.persona.Slug) {
 		if err := b.warren.WakeAgent(ctx, winner.persona.Name); err != nil {
 			b.logger.Warn("failed to wake agent", "agent", winner.persona.Name, "error", err)
 			return nil
 		}
-		time.Sleep(2 * time.Second)
+		snap.recordWake(winner.persona.Slug)
 	}
 
 	now := time.Now()
 	task.Status = store.StatusAssigned
 	task.AssignedAgent = winner.persona.Slug
 	task.AssignedAt = &now
+	task.SubState = ""
+	if waking {
+		task.SubState = store.SubStateWaking
+	}
 
 	// Apply v2 scoring fields to task for persistence
 	b.applyScoring(task, winner.result)
+	if place := snap.placement(task); place != nil {
+		if e := place.explain(); e != nil {
+			task.ScoringFactors["placement"] = e
+		}
+	}
 
 	// Derive model tier after scoring
 	if b.cfg.ModelRouting.Enabled {
-		tier := scoring.DeriveModelTier(task, b.cfg.ModelRouting, false)
+		var tier scoring.ModelTier
+		if downgrade {
+			tier = scoring.CheapestTier(b.cfg.ModelRouting)
+		} else {
+			tier = scoring.DeriveModelTier(task, b.cfg.ModelRouting, false)
 
-		// Apply effectiveness safety net: auto-promote tiers with high correction rates
-		b.applyEffectivenessSafetyNet(ctx, &tier, task)
+			// Apply effectiveness safety net: auto-promote tiers with high correction rates
+			b.applyEffectivenessSafetyNet(ctx, &tier, task)
+		}
 
 		runtime := scoring.RuntimeForTier(tier.Name, len(task.FilePatterns))
 		model := ""
@@ -265,38 +402,70 @@ func (b *Broker) assignTask(ctx context.Context, task *store.Task) error {
 	if err := b.store.UpdateTask(ctx, task); err != nil {
 		return err
 	}
+	snap.recordAssignment(task)
+
+	if waking {
+		_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+			TaskID:  task.ID,
+			Event:   "waking",
+			AgentID: winner.persona.Slug,
+			Payload: map[string]interface{}{"total_score": winner.result.TotalScore},
+		})
+		if b.hermes != nil {
+			_ = b.hermes.Publish(hermes.SubjectTaskWaking(task.ID.String()), map[string]interface{}{
+				"task_id": task.ID.String(),
+				"agent":   winner.persona.Slug,
+			})
+		}
+		b.logger.Info("task held while agent wakes", "task_id", task.ID, "agent", winner.persona.Name)
+		return nil
+	}
+
+	b.announceAssignment(ctx, task, winner.persona.Name, winner.result, candidates)
+	return nil
+}
 
+// announceAssignment records the assigned event and publishes the assignment
+// to Hermes once the agent is ready to receive it. The event records the
+// agent's slug, as every other task event does; agentName is only logged.
+func (b *Broker) announceAssignment(ctx context.Context, task *store.Task, agentName string, result scoring.ScoringResult, candidates int) {
 	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
 		TaskID:  task.ID,
 		Event:   "assigned",
-		AgentID: winner.persona.Name,
+		AgentID: task.AssignedAgent,
+		Payload: map[string]interface{}{
+			"total_score":     result.TotalScore,
+			"oversight_level": result.OversightLevel,
+			"fast_path":       result.FastPath,
+			"model_tier":      result.ModelTier,
+			"candidates":      candidates,
+		},
 	})
 
 	if b.hermes != nil {
 		_ = b.hermes.Publish(hermes.SubjectTaskAssigned(task.ID.String()), task)
 		_ = b.hermes.Publish(hermes.SubjectDispatchAssigned(task.ID.String()), hermes.DispatchAssignedEvent{
 			TaskID:           task.ID.String(),
-			AssignedAgent:    winner.persona.Slug,
-			TotalScore:       winner.result.TotalScore,
-			Factors:          winner.result.Factors,
-			OversightLevel:   winner.result.OversightLevel,
-			FastPath:         winner.result.FastPath,
-			RecommendedModel: winner.result.RecommendedModel,
-			ModelTier:        winner.result.ModelTier,
-			RoutingMethod:    winner.result.RoutingMethod,
-			Runtime:          winner.result.Runtime,
+			AssignedAgent:    task.AssignedAgent,
+			TotalScore:       result.TotalScore,
+			Factors:          result.Factors,
+			OversightLevel:   result.OversightLevel,
+			FastPath:         result.FastPath,
+			RecommendedModel: result.RecommendedModel,
+			ModelTier:        result.ModelTier,
+			RoutingMethod:    result.RoutingMethod,
+			Runtime:          result.Runtime,
 		})
-		if winner.result.OversightLevel != "" {
+		if result.OversightLevel != "" {
 			_ = b.hermes.Publish(hermes.SubjectDispatchOversight(task.ID.String()), hermes.OversightSetEvent{
 				TaskID:         task.ID.String(),
-				OversightLevel: winner.result.OversightLevel,
+				OversightLevel: result.OversightLevel,
 			})
 		}
 	}
 
-	b.logger.Info("task assigned", "task_id", task.ID, "assigned_agent", winner.persona.Name,
-		"score", winner.result.TotalScore, "oversight", winner.result.OversightLevel, "fast_path", winner.result.FastPath)
-	return nil
+	b.logger.Info("task assigned", "task_id", task.ID, "assigned_agent", agentName,
+		"score", result.TotalScore, "oversight", result.OversightLevel, "fast_path", result.FastPath)
 }
 
 func (b *Broker) HandleAgentStopped(ctx context.Context, agentID string) {
@@ -306,14 +475,17 @@ func (b *Broker) HandleAgentStopped(ctx context.Context, agentID string) {
 		return
 	}
 	for _, task := range tasks {
-		task.Status = store.StatusPending
-		task.AssignedAgent = ""
-		task.AssignedAt = nil
-		task.StartedAt = nil
+		task.ClearAssignment()
 		if err := b.store.UpdateTask(ctx, task); err != nil {
 			b.logger.Error("failed to reset task", "task_id", task.ID, "error", err)
 			continue
 		}
+		_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+			TaskID:  task.ID,
+			Event:   "reassigned",
+			AgentID: agentID,
+			Payload: map[string]interface{}{"reason": "agent_stopped"},
+		})
 		if b.hermes != nil {
 			_ = b.hermes.Publish(hermes.SubjectTaskReassigned(task.ID.String()), map[string]interface{}{
 				"task_id": task.ID.String(),
@@ -324,6 +496,21 @@ func (b *Broker) HandleAgentStopped(ctx context.Context, agentID string) {
 	}
 }
 
+// admitTask checks a new task against budgets, reporting false if a budget
+// rejects it. It fails open when the check itself fails.
+func (b *Broker) admitTask(ctx context.Context, task *store.Task) bool {
+	d, err := budget.CheckCreate(ctx, b.store, b.hermes, b.logger, task)
+	if err != nil {
+		b.logger.Warn("budget check failed", "error", err)
+		return true
+	}
+	if d.Action == store.BudgetActionReject {
+		b.logger.Warn("task rejected by budget", "owner", task.Owner, "source", task.Source, "budget_id", d.Budget.ID, "limits", d.Limits)
+		return false
+	}
+	return true
+}
+
 // SetupSubscriptions registers NATS subscriptions for bookkeeping events.
 func (b *Broker) SetupSubscriptions() {
 	if b.hermes == nil {
@@ -349,6 +536,19 @@ func (b *Broker) SetupSubscriptions() {
 			MaxRetries:           req.MaxRetries,
 			Source:               req.Source,
 			RetryEligible:        true,
+			Preemptible:          req.Preemptible,
+			PreferredAgents:      req.PreferredAgents,
+			RequiredAgent:        req.RequiredAgent,
+			ExcludedAgents:       req.ExcludedAgents,
+			AffinityGroup:        req.AffinityGroup,
+			Deadline:             req.Deadline,
+		}
+		if req.ParentTaskID != "" {
+			if pid, err := uuid.Parse(req.ParentTaskID); err == nil {
+				task.ParentTaskID = &pid
+			} else {
+				b.logger.Warn("ignoring invalid parent_task_id in task request", "parent_task_id", req.ParentTaskID)
+			}
 		}
 		if task.Priority < 0 {
 			task.Priority = 0
@@ -365,6 +565,9 @@ func (b *Broker) SetupSubscriptions() {
 		if task.Owner == "" {
 			task.Owner = "system"
 		}
+		if !b.admitTask(context.Background(), task) {
+			return
+		}
 		if err := b.store.CreateTask(context.Background(), task); err != nil {
 			b.logger.Error("failed to create task from NATS request", "error", err)
 		} else {
@@ -408,6 +611,23 @@ func (b *Broker) SetupSubscriptions() {
 		b.handleProgress(evt)
 	})
 
+	// Heartbeat events (liveness only)
+	_ = b.hermes.Subscribe("swarm.task.*.heartbeat", func(_ string, data []byte) {
+		var evt map[string]interface{}
+		if err := json.Unmarshal(data, &evt); err != nil {
+			return
+		}
+		b.handleHeartbeat(evt)
+	})
+
+	// Agent started (completes any pending wakes)
+	_ = b.hermes.Subscribe(hermes.SubjectAgentStarted, func(subject string, _ []byte) {
+		parts := splitSubject(subject)
+		if len(parts) >= 3 {
+			b.HandleAgentStarted(context.Background(), parts[2])
+		}
+	})
+
 	// Agent stopped
 	_ = b.hermes.Subscribe(hermes.SubjectAgentStopped, func(subject string, _ []byte) {
 		parts := splitSubject(subject)
@@ -421,22 +641,44 @@ func (b *Broker) SetupSubscriptions() {
 
 func (b *Broker) handleCompleted(evt hermes.TaskCompletedEvent) {
 	ctx := context.Background()
-	id, err := uuid.Parse(evt.TaskID)
+	if task := b.runningTask(ctx, evt.TaskID); task != nil {
+		_ = b.CompleteTask(ctx, task, evt.Result)
+	}
+}
+
+// runningTask returns the assigned or in-progress task with id, or nil. A
+// task finished through the API is published to Hermes afterwards and comes
+// back here no longer running, so it is not finished twice.
+func (b *Broker) runningTask(ctx context.Context, id string) *store.Task {
+	taskID, err := uuid.Parse(id)
 	if err != nil {
-		return
+		return nil
 	}
-	task, err := b.store.GetTask(ctx, id)
+	task, err := b.store.GetTask(ctx, taskID)
 	if err != nil || task == nil {
-		return
+		return nil
+	}
+	if task.Status != store.StatusAssigned && task.Status != store.StatusInProgress {
+		return nil
 	}
+	return task
+}
+
+// CompleteTask records task as completed with result, records the agent's
+// history and hands stage tasks on to the orchestrator. Completions from
+// Hermes and from the API both end here.
+func (b *Broker) CompleteTask(ctx context.Context, task *store.Task, result map[string]interface{}) error {
 	now := time.Now()
 	task.Status = store.StatusCompleted
-	task.Result = evt.Result
+	task.Result = result
 	task.CompletedAt = &now
-	_ = b.store.UpdateTask(ctx, task)
+	if err := b.store.UpdateTask(ctx, task); err != nil {
+		return err
+	}
 	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
-		TaskID: task.ID,
-		Event:  "completed",
+		TaskID:  task.ID,
+		Event:   "completed",
+		AgentID: task.AssignedAgent,
 	})
 
 	// Record agent task history for v2 scoring enrichment
@@ -453,12 +695,12 @@ func (b *Broker) handleCompleted(evt hermes.TaskCompletedEvent) {
 			h.DurationSeconds = &dur
 		}
 		// Extract tokens/cost from result if available
-		if evt.Result != nil {
-			if tokens, ok := evt.Result["tokens_used"].(float64); ok {
+		if result != nil {
+			if tokens, ok := result["tokens_used"].(float64); ok {
 				t := int64(tokens)
 				h.TokensUsed = &t
 			}
-			if cost, ok := evt.Result["cost_usd"].(float64); ok {
+			if cost, ok := result["cost_usd"].(float64); ok {
 				h.CostUSD = &cost
 			}
 		}
@@ -477,81 +719,141 @@ func (b *Broker) handleCompleted(evt hermes.TaskCompletedEvent) {
 			DurationSeconds: dur,
 		})
 	}
+
+	b.orch.TaskCompleted(ctx, task)
+	return nil
 }
 
 func (b *Broker) handleFailed(evt hermes.TaskFailedEvent) {
 	ctx := context.Background()
-	id, err := uuid.Parse(evt.TaskID)
-	if err != nil {
-		return
-	}
-	task, err := b.store.GetTask(ctx, id)
-	if err != nil || task == nil {
-		return
+	if task := b.runningTask(ctx, evt.TaskID); task != nil {
+		_ = b.FailTask(ctx, task, evt.Error, evt.FailureClass, evt.RetryEligible)
 	}
+}
+
+// FailTask records a failed attempt at task. The task is retried when
+// retryEligible and its retries and the failure class's policy allow;
+// otherwise it is dead-lettered and its backlog item, if any, blocked.
+// Failures from Hermes and from the API both end here.
+func (b *Broker) FailTask(ctx context.Context, task *store.Task, reason, failureClass string, retryEligible bool) error {
 	task.Status = store.StatusFailed
-	task.Error = evt.Error
-	task.RetryEligible = evt.RetryEligible
-	_ = b.store.UpdateTask(ctx, task)
+	task.Error = reason
+	task.FailureClass = failureClass
+	task.RetryEligible = retryEligible
+	if err := b.store.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+	prevAgent := task.AssignedAgent
+	payload := map[string]interface{}{"error": reason, "retry_eligible": retryEligible}
+	if failureClass != "" {
+		payload["failure_class"] = failureClass
+	}
 	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
-		TaskID: task.ID,
-		Event:  "failed",
+		TaskID:  task.ID,
+		Event:   "failed",
+		AgentID: prevAgent,
+		Payload: payload,
 	})
+	b.recordFailure(ctx, task, failureClass)
+
+	// Escalation steps that change how the task runs earn it another
+	// attempt even once its retries are used up.
+	rerun := b.escalate(ctx, task, task.RetryCount+1, reason)
+
+	// If retry eligible and retries remain, transition back to pending,
+	// unless the failure class's policy says otherwise.
+	policy := b.cfg.Retry.Policy(failureClass)
+	retry := task.RetryEligible && (task.RetryCount < task.MaxRetries || rerun)
+	switch policy {
+	case config.RetryStop:
+		retry = false
+	case config.RetryEscalate:
+		retry = task.RetryEligible && rerun
+	}
 
-	// If retry eligible and retries remain, transition back to pending
-	if task.RetryEligible && task.RetryCount < task.MaxRetries {
+	if retry {
 		task.RetryCount++
-		task.Status = store.StatusPending
-		task.AssignedAgent = ""
-		task.AssignedAt = nil
-		task.StartedAt = nil
+		steerRetry(task, policy, prevAgent)
+		task.ClearAssignment()
 		task.Error = ""
+		task.FailureClass = ""
 		_ = b.store.UpdateTask(ctx, task)
+		payload := map[string]interface{}{"retry_count": task.RetryCount, "previous_state": "failed"}
+		if policy != "" {
+			payload["policy"] = policy
+		}
+		_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+			TaskID:  task.ID,
+			Event:   "retry",
+			AgentID: prevAgent,
+			Payload: payload,
+		})
 		if b.hermes != nil {
 			_ = b.hermes.Publish(hermes.SubjectTaskRetry(task.ID.String()), map[string]interface{}{
 				"task_id":        task.ID.String(),
 				"retry_count":    task.RetryCount,
 				"max_retries":    task.MaxRetries,
 				"previous_state": "failed",
+				"previous_agent": prevAgent,
+				"policy":         policy,
 			})
 		}
-	} else if !task.RetryEligible || task.RetryCount >= task.MaxRetries {
+	} else {
 		// DLQ
 		now := time.Now()
 		task.CompletedAt = &now
 		_ = b.store.UpdateTask(ctx, task)
+		_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+			TaskID:  task.ID,
+			Event:   "dlq",
+			AgentID: prevAgent,
+			Payload: map[string]interface{}{"reason": "execution_failed", "failure_class": task.FailureClass},
+		})
 		if b.hermes != nil {
 			_ = b.hermes.Publish(hermes.SubjectTaskDLQ(task.ID.String()), map[string]interface{}{
-				"task_id":     task.ID.String(),
-				"reason":      "execution_failed",
-				"retry_count": task.RetryCount,
-				"max_retries": task.MaxRetries,
+				"task_id":       task.ID.String(),
+				"reason":        "execution_failed",
+				"failure_class": task.FailureClass,
+				"retry_count":   task.RetryCount,
+				"max_retries":   task.MaxRetries,
 			})
 		}
+		b.orch.TaskFailed(ctx, task)
 	}
+	return nil
 }
 
+// handleStarted moves an assigned task to in_progress when its agent reports
+// that it started. The agent defaults to the assignee; a started event from
+// any other agent, or for a task that is not running, is ignored.
 func (b *Broker) handleStarted(evt map[string]interface{}) {
 	ctx := context.Background()
 	taskID, ok := evt["task_id"].(string)
 	if !ok {
 		return
 	}
-	id, err := uuid.Parse(taskID)
-	if err != nil {
+	task := b.runningTask(ctx, taskID)
+	if task == nil {
 		return
 	}
-	task, err := b.store.GetTask(ctx, id)
-	if err != nil || task == nil {
+	agentID, _ := evt["agent_id"].(string)
+	if agentID == "" {
+		agentID = task.AssignedAgent
+	}
+	if agentID != task.AssignedAgent {
+		b.logger.Warn("ignoring started event from unassigned agent", "task_id", task.ID, "agent", agentID, "assigned_agent", task.AssignedAgent)
 		return
 	}
 	if task.Status == store.StatusAssigned {
 		now := time.Now()
 		task.Status = store.StatusInProgress
 		task.StartedAt = &now
-		_ = b.store.UpdateTask(ctx, task)
+		// Publishing started counts as an acknowledgement.
+		task.Acknowledge(now)
+		if err := b.store.UpdateTaskGuarded(ctx, task, store.Running(agentID)); err != nil {
+			return
+		}
 	}
-	agentID, _ := evt["agent"].(string)
 	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
 		TaskID:  task.ID,
 		Event:   "started",
@@ -559,27 +861,31 @@ func (b *Broker) handleStarted(evt map[string]interface{}) {
 	})
 }
 
+// handleProgress records a progress report published by an agent. Reports
+// made through the API were saved there and are skipped.
 func (b *Broker) handleProgress(evt map[string]interface{}) {
 	ctx := context.Background()
 	taskID, ok := evt["task_id"].(string)
-	if !ok {
+	if !ok || evt[hermes.OriginField] == hermes.OriginAPI {
 		return
 	}
-	id, err := uuid.Parse(taskID)
-	if err != nil {
+	task := b.runningTask(ctx, taskID)
+	if task == nil {
 		return
 	}
-	task, err := b.store.GetTask(ctx, id)
-	if err != nil || task == nil {
-		return
-	}
-	if task.Status == store.StatusAssigned {
-		now := time.Now()
+	now := time.Now()
+	started := task.Status == store.StatusAssigned
+	if started {
 		task.Status = store.StatusInProgress
 		task.StartedAt = &now
-		_ = b.store.UpdateTask(ctx, task)
 	}
+	acked := task.Acknowledge(now)
 	agentID, _ := evt["agent_id"].(string)
+	if p := store.ParseProgress(evt, agentID, now); p != nil {
+		task.Progress = p
+	}
+	task.LastHeartbeatAt = &now
+	_ = b.saveLiveness(ctx, task, started || acked)
 	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
 		TaskID:  task.ID,
 		Event:   "progress",
@@ -588,23 +894,61 @@ func (b *Broker) handleProgress(evt map[string]interface{}) {
 	})
 }
 
-// buildTaskContext creates a TaskContext for v2 scoring, with optional enrichment.
-func (b *Broker) buildTaskContext(ctx context.Context, persona forge.Persona, state *warren.AgentState, task *store.Task) *scoring.TaskContext {
-	active, _ := b.store.GetActiveTasksForAgent(ctx, persona.Slug)
+// handleHeartbeat records agent liveness for an active task. Heartbeats do not
+// create task events; they only move last_heartbeat_at forward. Heartbeats
+// made through the API were saved there and are skipped.
+func (b *Broker) handleHeartbeat(evt map[string]interface{}) {
+	ctx := context.Background()
+	taskID, ok := evt["task_id"].(string)
+	if !ok || evt[hermes.OriginField] == hermes.OriginAPI {
+		return
+	}
+	task := b.runningTask(ctx, taskID)
+	if task == nil {
+		return
+	}
+	now := time.Now()
+	task.LastHeartbeatAt = &now
+	acked := task.Acknowledge(now)
+	agentID, _ := evt["agent_id"].(string)
+	if p := store.ParseProgress(evt, agentID, now); p != nil {
+		task.Progress = p
+	}
+	_ = b.saveLiveness(ctx, task, acked)
+}
+
+// saveLiveness saves a progress report or heartbeat. Only one that starts or
+// acks the task changes tracked fields; the rest write just the progress,
+// heartbeat and lease. Either write is dropped if the task stopped running
+// after it was read.
+func (b *Broker) saveLiveness(ctx context.Context, task *store.Task, changed bool) error {
+	if changed {
+		return b.store.UpdateTaskGuarded(ctx, task, store.Running(task.AssignedAgent))
+	}
+	return b.store.UpdateTaskLiveness(ctx, task)
+}
 
+// buildTaskContext creates a TaskContext for v2 scoring from the tick
+// snapshot. It does not modify task, so it is safe to call concurrently.
+func (b *Broker) buildTaskContext(ctx context.Context, snap *tickSnapshot, persona forge.Persona, state *warren.AgentState, task *store.Task) *scoring.TaskContext {
 	tc := &scoring.TaskContext{
 		Task:            task,
 		Persona:         persona,
 		AgentState:      state,
-		ActiveTaskCount: len(active),
-		MaxConcurrent:   b.cfg.Assignment.MaxConcurrentPerAgent,
+		ActiveTaskCount: snap.slotsInUse(persona.Slug),
+		MaxConcurrent:   snap.limit(persona.Slug),
+		TaskSlots:       snap.taskSlots(task),
+	}
+	if place := snap.placement(task); place != nil {
+		tc.WarmContext = place.warmContext(persona)
+		tc.PrefersOtherAgents = tc.WarmContext == "" && len(place.warm) > 0
+	}
+	if v, ok := task.Metadata["trust_level"].(float64); ok {
+		tc.AgentTrustLevel = &v
 	}
-
-	// Enrich from task metadata
-	b.enrichFromMetadata(tc)
 
 	// Enrich from agent history (best-effort)
-	b.enrichFromHistory(ctx, tc)
+	b.enrichFromHistory(ctx, snap, tc)
 
 	return tc
 }
@@ -644,23 +988,23 @@ func (b *Broker) enrichFromMetadata(tc *scoring.TaskContext) {
 	}
 }
 
-// enrichFromHistory queries agent history for average duration, cost, and trust.
-func (b *Broker) enrichFromHistory(ctx context.Context, tc *scoring.TaskContext) {
-	avgDur, err := b.store.GetAgentAvgDuration(ctx, tc.Persona.Slug)
-	if err == nil && avgDur != nil {
-		tc.AgentAvgDuration = avgDur
+// enrichFromHistory fills in average duration, cost, and trust from the
+// snapshot.
+func (b *Broker) enrichFromHistory(ctx context.Context, snap *tickSnapshot, tc *scoring.TaskContext) {
+	h := snap.agentHistory(tc.Persona.Slug)
+	if h.avgDuration != nil {
+		tc.AgentAvgDuration = h.avgDuration
 	}
-	avgCost, err := b.store.GetAgentAvgCost(ctx, tc.Persona.Slug)
-	if err == nil && avgCost != nil {
-		tc.AgentAvgCost = avgCost
+	if h.avgCost != nil {
+		tc.AgentAvgCost = h.avgCost
 	}
+	tc.AgentFaultRate = h.faultRate
 
 	// Trust score from agent_trust table (only if not already set from metadata)
 	if tc.AgentTrustLevel == nil {
 		category, _ := tc.Task.Metadata["category"].(string)
 		severity, _ := tc.Task.Metadata["severity"].(string)
-		trust, err := b.store.GetTrustScore(ctx, tc.Persona.Slug, category, severity)
-		if err == nil && trust > 0 {
+		if trust := b.trustScore(ctx, snap, tc.Persona.Slug, category, severity); trust > 0 {
 			tc.AgentTrustLevel = &trust
 		}
 	}
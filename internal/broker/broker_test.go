@@ -2,9 +2,14 @@ package broker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,6 +19,7 @@ import (
 	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
 	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 	"github.com/MikeSquared-Agency/Dispatch/internal/warren"
 )
@@ -24,6 +30,19 @@ type mockStore struct {
 	tasks       map[uuid.UUID]*store.Task
 	events      []*store.TaskEvent
 	trustScores map[string]float64 // key: "slug|category|severity"
+	budgets     []*store.Budget
+	budgetSpend map[string]store.BudgetUsage // key: "scope|subject"
+	agentLimits map[string]int
+	schedules   map[uuid.UUID]*store.Schedule
+	avgDuration map[string]float64 // seconds, by agent slug
+	backlog     []*store.BacklogItem
+	history     []*store.AgentTaskHistory
+	gates       map[string][]store.GateCriterion // by stage
+	autonomy    map[string]*store.AutonomyConfig // by tier
+	scores      []*store.BacklogScoreChange
+	blocked     map[uuid.UUID]bool // backlog items with an unresolved blocker
+
+	livenessWrites int
 }
 
 func newMockStore() *mockStore {
@@ -40,9 +59,12 @@ func (m *mockStore) CreateTask(_ context.Context, t *store.Task) error {
 func (m *mockStore) GetTask(_ context.Context, id uuid.UUID) (*store.Task, error) {
 	return m.tasks[id], nil
 }
-func (m *mockStore) ListTasks(_ context.Context, _ store.TaskFilter) ([]*store.Task, error) {
+func (m *mockStore) ListTasks(_ context.Context, f store.TaskFilter) ([]*store.Task, error) {
 	var out []*store.Task
 	for _, t := range m.tasks {
+		if f.BacklogItemID != nil && (t.BacklogItemID == nil || *t.BacklogItemID != *f.BacklogItemID) {
+			continue
+		}
 		out = append(out, t)
 	}
 	return out, nil
@@ -51,6 +73,19 @@ func (m *mockStore) UpdateTask(_ context.Context, t *store.Task) error {
 	m.tasks[t.ID] = t
 	return nil
 }
+func (m *mockStore) UpdateTaskGuarded(ctx context.Context, t *store.Task, guard store.TaskGuard) error {
+	// The stored task is only distinct from t when a test has replaced it
+	// to simulate a concurrent write.
+	if cur := m.tasks[t.ID]; cur == nil || (cur != t && !guard.Matches(cur)) {
+		return store.ErrTaskChanged
+	}
+	return m.UpdateTask(ctx, t)
+}
+func (m *mockStore) UpdateTaskLiveness(_ context.Context, t *store.Task) error {
+	m.tasks[t.ID] = t
+	m.livenessWrites++
+	return nil
+}
 func (m *mockStore) GetPendingTasks(_ context.Context) ([]*store.Task, error) {
 	var out []*store.Task
 	for _, t := range m.tasks {
@@ -78,6 +113,28 @@ func (m *mockStore) GetActiveTasks(_ context.Context) ([]*store.Task, error) {
 	}
 	return out, nil
 }
+func (m *mockStore) GetAffinityGroupAgent(_ context.Context, group string) (string, error) {
+	var agent string
+	var latest time.Time
+	for _, t := range m.tasks {
+		if t.AffinityGroup == group && t.AssignedAgent != "" && t.AssignedAt != nil && !t.AssignedAt.Before(latest) {
+			agent, latest = t.AssignedAgent, *t.AssignedAt
+		}
+	}
+	return agent, nil
+}
+func (m *mockStore) GetDeadlineTasks(_ context.Context) ([]*store.Task, error) {
+	var out []*store.Task
+	for _, t := range m.tasks {
+		switch t.Status {
+		case store.StatusPending, store.StatusAssigned, store.StatusInProgress:
+			if t.Deadline != nil && t.DeadlineMissedAt == nil {
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
 func (m *mockStore) CreateTaskEvent(_ context.Context, e *store.TaskEvent) error {
 	e.ID = uuid.New()
 	e.CreatedAt = time.Now()
@@ -96,18 +153,160 @@ func (m *mockStore) GetTaskEvents(_ context.Context, taskID uuid.UUID) ([]*store
 func (m *mockStore) GetStats(_ context.Context) (*store.TaskStats, error) {
 	return &store.TaskStats{}, nil
 }
-func (m *mockStore) CreateAgentTaskHistory(_ context.Context, _ *store.AgentTaskHistory) error {
+func (m *mockStore) GetSLAStats(_ context.Context) ([]*store.OwnerSLA, error) {
+	return nil, nil
+}
+func (m *mockStore) CreateAgentTaskHistory(_ context.Context, h *store.AgentTaskHistory) error {
+	m.history = append(m.history, h)
 	return nil
 }
 func (m *mockStore) GetAgentTaskHistory(_ context.Context, _ string, _ int) ([]*store.AgentTaskHistory, error) {
 	return nil, nil
 }
-func (m *mockStore) GetAgentAvgDuration(_ context.Context, _ string) (*float64, error) {
+func (m *mockStore) GetAgentFailureStats(_ context.Context, slug string) (*store.AgentFailureStats, error) {
+	stats := &store.AgentFailureStats{Failures: make(map[string]int)}
+	for _, h := range m.history {
+		if h.AgentSlug != slug {
+			continue
+		}
+		stats.Attempts++
+		if h.FailureClass != "" {
+			stats.Failures[h.FailureClass]++
+		}
+	}
+	return stats, nil
+}
+func (m *mockStore) GetAgentAvgDuration(_ context.Context, slug string) (*float64, error) {
+	if v, ok := m.avgDuration[slug]; ok {
+		return &v, nil
+	}
 	return nil, nil
 }
 func (m *mockStore) GetAgentAvgCost(_ context.Context, _ string) (*float64, error) {
 	return nil, nil
 }
+func (m *mockStore) UpsertBudget(_ context.Context, b *store.Budget) error {
+	for _, existing := range m.budgets {
+		if existing.Scope == b.Scope && existing.Subject == b.Subject && existing.Period == b.Period {
+			b.ID = existing.ID
+			b.AlertedThreshold = existing.AlertedThreshold
+			b.AlertedPeriodStart = existing.AlertedPeriodStart
+			*existing = *b
+			return nil
+		}
+	}
+	b.ID = uuid.New()
+	m.budgets = append(m.budgets, b)
+	return nil
+}
+func (m *mockStore) ListBudgets(_ context.Context) ([]*store.Budget, error) {
+	return m.budgets, nil
+}
+func (m *mockStore) DeleteBudget(_ context.Context, id uuid.UUID) error {
+	for i, b := range m.budgets {
+		if b.ID == id {
+			m.budgets = append(m.budgets[:i], m.budgets[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (m *mockStore) GetBudgetUsage(_ context.Context, scope, subject string, since time.Time) (*store.BudgetUsage, error) {
+	probe := &store.Budget{Scope: scope, Subject: subject}
+	u := &store.BudgetUsage{}
+	if spent, ok := m.budgetSpend[scope+"|"+subject]; ok {
+		u.Tokens, u.CostUSD = spent.Tokens, spent.CostUSD
+	}
+	for _, t := range m.tasks {
+		if probe.Applies(t) && !t.CreatedAt.Before(since) {
+			u.Tasks++
+		}
+	}
+	for _, e := range m.events {
+		if t := m.tasks[e.TaskID]; t != nil && e.Event == "assigned" && probe.Applies(t) && !e.CreatedAt.Before(since) {
+			u.Dispatched++
+		}
+	}
+	return u, nil
+}
+func (m *mockStore) MarkBudgetAlerted(_ context.Context, id uuid.UUID, periodStart time.Time, threshold int) error {
+	for _, b := range m.budgets {
+		if b.ID == id {
+			b.AlertedThreshold = threshold
+			b.AlertedPeriodStart = &periodStart
+		}
+	}
+	return nil
+}
+func (m *mockStore) SetAgentLimit(_ context.Context, l *store.AgentLimit) error {
+	if m.agentLimits == nil {
+		m.agentLimits = make(map[string]int)
+	}
+	m.agentLimits[l.AgentSlug] = l.MaxConcurrent
+	l.UpdatedAt = time.Now()
+	return nil
+}
+func (m *mockStore) ListAgentLimits(_ context.Context) ([]*store.AgentLimit, error) {
+	var out []*store.AgentLimit
+	for slug, n := range m.agentLimits {
+		out = append(out, &store.AgentLimit{AgentSlug: slug, MaxConcurrent: n})
+	}
+	return out, nil
+}
+func (m *mockStore) DeleteAgentLimit(_ context.Context, agentSlug string) error {
+	delete(m.agentLimits, agentSlug)
+	return nil
+}
+func (m *mockStore) CreateSchedule(_ context.Context, s *store.Schedule) error {
+	if m.schedules == nil {
+		m.schedules = make(map[uuid.UUID]*store.Schedule)
+	}
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	m.schedules[s.ID] = s
+	return nil
+}
+func (m *mockStore) GetSchedule(_ context.Context, id uuid.UUID) (*store.Schedule, error) {
+	return m.schedules[id], nil
+}
+func (m *mockStore) ListSchedules(_ context.Context) ([]*store.Schedule, error) {
+	var out []*store.Schedule
+	for _, s := range m.schedules {
+		out = append(out, s)
+	}
+	return out, nil
+}
+func (m *mockStore) UpdateSchedule(_ context.Context, s *store.Schedule) error {
+	s.UpdatedAt = time.Now()
+	m.schedules[s.ID] = s
+	return nil
+}
+func (m *mockStore) UpdateScheduleRun(_ context.Context, s *store.Schedule) error {
+	cur := m.schedules[s.ID]
+	if cur == nil || !cur.UpdatedAt.Equal(s.UpdatedAt) {
+		return store.ErrScheduleChanged
+	}
+	cur.NextRunAt, cur.QueuedRunAt = s.NextRunAt, s.QueuedRunAt
+	cur.LastRunAt, cur.LastTaskID = s.LastRunAt, s.LastTaskID
+	cur.SkippedRuns, cur.MissedRuns = s.SkippedRuns, s.MissedRuns
+	cur.UpdatedAt = time.Now()
+	s.UpdatedAt = cur.UpdatedAt
+	return nil
+}
+func (m *mockStore) DeleteSchedule(_ context.Context, id uuid.UUID) error {
+	delete(m.schedules, id)
+	return nil
+}
+func (m *mockStore) GetDueSchedules(_ context.Context, now time.Time) ([]*store.Schedule, error) {
+	var out []*store.Schedule
+	for _, s := range m.schedules {
+		if !s.Paused && (!s.NextRunAt.After(now) || s.QueuedRunAt != nil) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
 func (m *mockStore) GetTrustScore(_ context.Context, slug, category, severity string) (float64, error) {
 	if m.trustScores != nil {
 		if v, ok := m.trustScores[slug+"|"+category+"|"+severity]; ok {
@@ -119,9 +318,24 @@ func (m *mockStore) GetTrustScore(_ context.Context, slug, category, severity st
 // Backlog interface stubs
 func (m *mockStore) CreateBacklogItem(_ context.Context, item *store.BacklogItem) error {
 	item.ID = uuid.New()
+	m.backlog = append(m.backlog, item)
 	return nil
 }
-func (m *mockStore) GetBacklogItem(_ context.Context, _ uuid.UUID) (*store.BacklogItem, error) {
+func (m *mockStore) GetBacklogItem(_ context.Context, id uuid.UUID) (*store.BacklogItem, error) {
+	for _, item := range m.backlog {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+func (m *mockStore) GetBacklogItemByExternalID(_ context.Context, _ string) (*store.BacklogItem, error) {
+	return nil, nil
+}
+func (m *mockStore) GetBacklogItems(_ context.Context, _ []uuid.UUID) ([]*store.BacklogItem, error) {
+	return nil, nil
+}
+func (m *mockStore) ListBacklogSubtree(_ context.Context, _ uuid.UUID) ([]*store.BacklogItem, error) {
 	return nil, nil
 }
 func (m *mockStore) ListBacklogItems(_ context.Context, _ store.BacklogFilter) ([]*store.BacklogItem, error) {
@@ -129,25 +343,63 @@ func (m *mockStore) ListBacklogItems(_ context.Context, _ store.BacklogFilter) (
 }
 func (m *mockStore) UpdateBacklogItem(_ context.Context, _ *store.BacklogItem) error { return nil }
 func (m *mockStore) DeleteBacklogItem(_ context.Context, _ uuid.UUID) error          { return nil }
+func (m *mockStore) UpdateBacklogItemScore(_ context.Context, item *store.BacklogItem, score float64) error {
+	item.PriorityScore = &score
+	return nil
+}
 func (m *mockStore) GetNextBacklogItems(_ context.Context, _ int) ([]*store.BacklogItem, error) {
 	return nil, nil
 }
+func (m *mockStore) CountBacklogChildren(_ context.Context, _ uuid.UUID) (store.ChildCounts, error) {
+	return store.ChildCounts{}, nil
+}
+func (m *mockStore) ListOpenBacklogItems(_ context.Context) ([]*store.BacklogItem, error) {
+	var out []*store.BacklogItem
+	for _, item := range m.backlog {
+		if item.Status != store.BacklogStatusDone && item.Status != store.BacklogStatusCancelled {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+func (m *mockStore) CreateScoreChange(_ context.Context, c *store.BacklogScoreChange) error {
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	m.scores = append(m.scores, c)
+	return nil
+}
+func (m *mockStore) ListScoreHistory(_ context.Context, _ uuid.UUID, _ int) ([]*store.BacklogScoreChange, error) {
+	return nil, nil
+}
 func (m *mockStore) CreateDependency(_ context.Context, dep *store.BacklogDependency) error {
 	dep.ID = uuid.New()
 	return nil
 }
+func (m *mockStore) CreateDependencyChecked(_ context.Context, dep *store.BacklogDependency, _ store.DependencyCheck) error {
+	dep.ID = uuid.New()
+	return nil
+}
 func (m *mockStore) DeleteDependency(_ context.Context, _ uuid.UUID) error { return nil }
 func (m *mockStore) GetDependenciesForItem(_ context.Context, _ uuid.UUID) ([]*store.BacklogDependency, error) {
 	return nil, nil
 }
+func (m *mockStore) ListDependencies(_ context.Context) ([]*store.BacklogDependency, error) {
+	return nil, nil
+}
 func (m *mockStore) HasUnresolvedBlockers(_ context.Context, _ uuid.UUID) (bool, error) {
 	return false, nil
 }
+func (m *mockStore) ListBlockedBacklogItemIDs(_ context.Context) (map[uuid.UUID]bool, error) {
+	return m.blocked, nil
+}
 func (m *mockStore) ResolveDependenciesForBlocker(_ context.Context, _ uuid.UUID) error { return nil }
 func (m *mockStore) CreateOverride(_ context.Context, o *store.DispatchOverride) error {
 	o.ID = uuid.New()
 	return nil
 }
+func (m *mockStore) GetOverridesForTask(_ context.Context, _ uuid.UUID) ([]*store.DispatchOverride, error) {
+	return nil, nil
+}
 func (m *mockStore) CreateAutonomyEvent(_ context.Context, e *store.AutonomyEvent) error {
 	e.ID = uuid.New()
 	return nil
@@ -158,6 +410,33 @@ func (m *mockStore) GetAutonomyMetrics(_ context.Context, _ int) ([]*store.Auton
 func (m *mockStore) BacklogDiscoveryComplete(_ context.Context, _ uuid.UUID, _ *store.BacklogDiscoveryCompleteRequest, _ store.ScoreFn, _ store.TierFn) (*store.BacklogDiscoveryCompleteResult, error) {
 	return &store.BacklogDiscoveryCompleteResult{}, nil
 }
+func (m *mockStore) BulkUpdateBacklogItems(_ context.Context, _ []*store.BacklogItem, _ []*store.DispatchOverride) error {
+	return nil
+}
+func (m *mockStore) ImportBacklog(_ context.Context, _ *store.BacklogImport) error {
+	return nil
+}
+func (m *mockStore) CreateIteration(_ context.Context, _ *store.Iteration) error { return nil }
+func (m *mockStore) GetIteration(_ context.Context, _ uuid.UUID) (*store.Iteration, error) {
+	return nil, nil
+}
+func (m *mockStore) ListIterations(_ context.Context) ([]*store.Iteration, error) { return nil, nil }
+func (m *mockStore) DeleteIteration(_ context.Context, _ uuid.UUID) error         { return nil }
+func (m *mockStore) CommitIterationItems(_ context.Context, _ []*store.IterationItem) error {
+	return nil
+}
+func (m *mockStore) UncommitIterationItems(_ context.Context, _ uuid.UUID, _ []uuid.UUID) error {
+	return nil
+}
+func (m *mockStore) ListIterationItems(_ context.Context, _ uuid.UUID) ([]*store.IterationItem, error) {
+	return nil, nil
+}
+func (m *mockStore) ListOpenCommitments(_ context.Context, _ uuid.UUID) (map[uuid.UUID]*store.Iteration, error) {
+	return nil, nil
+}
+func (m *mockStore) ListChangeHistory(_ context.Context, _ store.ChangeHistoryFilter) ([]*store.ChangeRecord, error) {
+	return nil, nil
+}
 func (m *mockStore) GetMedianEstimatedTokens(_ context.Context) (int64, error) { return 0, nil }
 
 func (m *mockStore) Ping(_ context.Context) error { return nil }
@@ -199,6 +478,52 @@ func (m *mockWarren) ListAgents(_ context.Context) ([]warren.AgentState, error)
 	return out, nil
 }
 
+// fakeWakeWarren simulates agents that wake after a number of state polls.
+// A negative count means the agent never wakes.
+type fakeWakeWarren struct {
+	mu        sync.Mutex
+	states    map[string]*warren.AgentState
+	wakeAfter map[string]int
+	wakeCalls []string
+	polls     int
+}
+
+func (f *fakeWakeWarren) GetAgentState(_ context.Context, id string) (*warren.AgentState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls++
+	s, ok := f.states[id]
+	if !ok {
+		return &warren.AgentState{Name: id, Status: "stopped"}, nil
+	}
+	if s.Status == "sleeping" && f.woken(id) {
+		if n := f.wakeAfter[id]; n > 0 {
+			f.wakeAfter[id] = n - 1
+		} else if n == 0 {
+			s.Status = "ready"
+		}
+	}
+	cp := *s
+	return &cp, nil
+}
+func (f *fakeWakeWarren) WakeAgent(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.wakeCalls = append(f.wakeCalls, id)
+	return nil
+}
+func (f *fakeWakeWarren) ListAgents(_ context.Context) ([]warren.AgentState, error) {
+	return nil, nil
+}
+func (f *fakeWakeWarren) woken(id string) bool {
+	for _, c := range f.wakeCalls {
+		if c == id {
+			return true
+		}
+	}
+	return false
+}
+
 type mockForge struct {
 	personas []forge.Persona
 }
@@ -244,6 +569,7 @@ func testConfig() *config.Config {
 		Assignment: config.AssignmentConfig{
 			TickIntervalMs:        100,
 			WakeTimeoutMs:         1000,
+			AckTimeoutMs:          1000,
 			DefaultTimeoutMs:      5000,
 			MaxConcurrentPerAgent: 3,
 			OwnerFilterEnabled:    true,
@@ -392,6 +718,38 @@ func TestBrokerAssignment(t *testing.T) {
 	}
 }
 
+func TestAssignedEventRecordsSlug(t *testing.T) {
+	ms := newMockStore()
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "Lily", Slug: "lily", Capabilities: []string{"research"}},
+	}}
+	b := New(ms, &mockHermes{}, mw, mf, nil, testConfig(), discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "test task",
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusPending,
+		TimeoutSeconds:       5,
+		Source:               "manual",
+	}
+	_ = ms.CreateTask(ctx, task)
+	b.processPendingTasks(ctx)
+
+	events, _ := ms.GetTaskEvents(ctx, task.ID)
+	if len(events) == 0 || events[len(events)-1].Event != "assigned" {
+		t.Fatalf("expected an assigned event, got %+v", events)
+	}
+	// Completion events record the slug, so one agent is one timeline span.
+	if got := events[len(events)-1].AgentID; got != "lily" {
+		t.Errorf("expected assigned event for slug lily, got %q", got)
+	}
+}
+
 func TestOwnerScopedFiltering(t *testing.T) {
 	ms := newMockStore()
 	mh := &mockHermes{}
@@ -621,8 +979,8 @@ func TestHandleStartedTransition(t *testing.T) {
 	_ = ms.CreateTask(ctx, task)
 
 	b.handleStarted(map[string]interface{}{
-		"task_id": task.ID.String(),
-		"agent":   "scout",
+		"task_id":  task.ID.String(),
+		"agent_id": "scout",
 	})
 
 	updated := ms.tasks[task.ID]
@@ -662,8 +1020,8 @@ func TestHandleStartedIgnoresNonAssigned(t *testing.T) {
 	_ = ms.CreateTask(ctx, task)
 
 	b.handleStarted(map[string]interface{}{
-		"task_id": task.ID.String(),
-		"agent":   "scout",
+		"task_id":  task.ID.String(),
+		"agent_id": "scout",
 	})
 
 	updated := ms.tasks[task.ID]
@@ -672,6 +1030,37 @@ func TestHandleStartedIgnoresNonAssigned(t *testing.T) {
 	}
 }
 
+func TestHandleStartedChecksAgent(t *testing.T) {
+	ms := newMockStore()
+	b := New(ms, &mockHermes{}, nil, nil, nil, testConfig(), discardLogger())
+
+	ctx := context.Background()
+	now := time.Now()
+	task := &store.Task{
+		Owner:         "system",
+		Title:         "assigned to scout",
+		Status:        store.StatusAssigned,
+		AssignedAgent: "scout",
+		AssignedAt:    &now,
+		Source:        "manual",
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.handleStarted(map[string]interface{}{"task_id": task.ID.String(), "agent_id": "nova"})
+	if task.Status != store.StatusAssigned || len(ms.events) != 0 {
+		t.Fatalf("expected a started event from another agent ignored, got %s and %d events", task.Status, len(ms.events))
+	}
+
+	// Without an agent the event is taken as the assignee's.
+	b.handleStarted(map[string]interface{}{"task_id": task.ID.String()})
+	if task.Status != store.StatusInProgress {
+		t.Errorf("expected in_progress, got %s", task.Status)
+	}
+	if len(ms.events) != 1 || ms.events[0].AgentID != "scout" {
+		t.Errorf("expected one started event for scout, got %+v", ms.events)
+	}
+}
+
 func TestHandleFailedWithRetry(t *testing.T) {
 	ms := newMockStore()
 	mh := &mockHermes{}
@@ -726,6 +1115,61 @@ func TestHandleFailedWithRetry(t *testing.T) {
 	}
 }
 
+func TestTransitionEventsRecordAgentID(t *testing.T) {
+	ms := newMockStore()
+	b := New(ms, &mockHermes{}, nil, nil, nil, testConfig(), discardLogger())
+
+	ctx := context.Background()
+	now := time.Now()
+	past := now.Add(-10 * time.Second)
+	newTask := func(title string, started *time.Time) *store.Task {
+		task := &store.Task{
+			Owner:          "system",
+			Title:          title,
+			Status:         store.StatusInProgress,
+			AssignedAgent:  "scout",
+			AssignedAt:     started,
+			StartedAt:      started,
+			TimeoutSeconds: 1,
+			MaxRetries:     3,
+			RetryEligible:  true,
+			Source:         "manual",
+		}
+		_ = ms.CreateTask(ctx, task)
+		return task
+	}
+
+	completed := newTask("complete", &now)
+	b.handleCompleted(hermes.TaskCompletedEvent{TaskID: completed.ID.String()})
+	failed := newTask("fail", &now)
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: failed.ID.String(), Error: "boom", RetryEligible: true})
+	timedOut := newTask("timeout", &past)
+	b.checkTimeouts(ctx)
+	stopped := newTask("stopped", &now)
+	b.HandleAgentStopped(ctx, "scout")
+
+	want := map[uuid.UUID][]string{
+		completed.ID: {"completed"},
+		failed.ID:    {"failed", "retry"},
+		timedOut.ID:  {"timeout_retry"},
+		stopped.ID:   {"reassigned"},
+	}
+	for id, events := range want {
+		got, _ := ms.GetTaskEvents(ctx, id)
+		if len(got) != len(events) {
+			t.Fatalf("task %s: expected events %v, got %d", id, events, len(got))
+		}
+		for i, e := range got {
+			if e.Event != events[i] {
+				t.Errorf("task %s: expected event %s, got %s", id, events[i], e.Event)
+			}
+			if e.AgentID != "scout" {
+				t.Errorf("task %s: event %s missing agent_id", id, e.Event)
+			}
+		}
+	}
+}
+
 func TestHandleFailedNotRetryEligible(t *testing.T) {
 	ms := newMockStore()
 	mh := &mockHermes{}
@@ -818,74 +1262,297 @@ func TestHandleFailedRetriesExhausted(t *testing.T) {
 	}
 }
 
-func TestTimeoutRetryPublishesRetryAndTimeoutEvents(t *testing.T) {
+func newEscalationTestBroker(steps ...config.EscalationStep) (*Broker, *mockStore, *mockHermes) {
 	ms := newMockStore()
 	mh := &mockHermes{}
-	b := New(ms, mh, nil, nil, nil, testConfig(), discardLogger())
+	cfg := testConfig()
+	cfg.Escalation.Steps = steps
+	return New(ms, mh, nil, nil, nil, cfg, discardLogger()), ms, mh
+}
 
-	ctx := context.Background()
+func newFailingTask(ms *mockStore, retryCount, maxRetries int) *store.Task {
 	past := time.Now().Add(-10 * time.Second)
 	task := &store.Task{
 		Owner:                "system",
-		Title:                "timeout events test",
-		RequiredCapabilities: []string{"research"},
-		Status:               store.StatusAssigned,
+		Title:                "flaky",
+		RequiredCapabilities: []string{"research", "golang"},
+		Status:               store.StatusInProgress,
 		AssignedAgent:        "scout",
-		TimeoutSeconds:       1,
-		MaxRetries:           3,
-		RetryCount:           0,
 		AssignedAt:           &past,
-		Source:               "manual",
+		StartedAt:            &past,
+		TimeoutSeconds:       1,
+		MaxRetries:           maxRetries,
+		RetryCount:           retryCount,
 		RetryEligible:        true,
+		Source:               "manual",
 	}
-	_ = ms.CreateTask(ctx, task)
+	_ = ms.CreateTask(context.Background(), task)
+	return task
+}
 
-	b.checkTimeouts(ctx)
+func escalatedEvents(ms *mockStore, taskID uuid.UUID) []*store.TaskEvent {
+	var out []*store.TaskEvent
+	for _, e := range ms.events {
+		if e.TaskID == taskID && e.Event == "escalated" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestEscalationPromotesModelPastMaxRetries(t *testing.T) {
+	b, ms, mh := newEscalationTestBroker(config.EscalationStep{AfterFailures: 2, Action: config.EscalatePromoteModel})
+	task := newFailingTask(ms, 1, 1)
+
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "boom", RetryEligible: true})
 
 	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusPending {
-		t.Errorf("expected pending (retry), got %s", updated.Status)
+	if updated.Status != store.StatusPending || updated.RetryCount != 2 {
+		t.Fatalf("expected another attempt past max retries, got %s with retry_count %d", updated.Status, updated.RetryCount)
 	}
-
-	timeoutFound, retryFound := false, false
-	for _, p := range mh.published {
-		if p.subject == "swarm.task."+task.ID.String()+".timeout" {
-			timeoutFound = true
-		}
-		if p.subject == "swarm.task."+task.ID.String()+".retry" {
-			retryFound = true
-		}
+	if updated.MinModelTier != "premium" {
+		t.Errorf("expected min model tier premium, got %q", updated.MinModelTier)
 	}
-	if !timeoutFound {
-		t.Error("expected timeout event")
+	events := escalatedEvents(ms, task.ID)
+	if len(events) != 1 || events[0].Payload["action"] != config.EscalatePromoteModel {
+		t.Fatalf("expected one promote_model escalation event, got %+v", events)
 	}
-	if !retryFound {
-		t.Error("expected retry event")
+	if countPublished(mh, "swarm.task."+task.ID.String()+".escalated") != 1 {
+		t.Error("expected escalated event published")
+	}
+
+	// The step runs once; the next failure goes to the DLQ.
+	updated.Status = store.StatusInProgress
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "boom", RetryEligible: true})
+	if updated.Status != store.StatusFailed || updated.CompletedAt == nil {
+		t.Errorf("expected DLQ on third failure, got %s", updated.Status)
+	}
+	if len(escalatedEvents(ms, task.ID)) != 1 {
+		t.Error("expected no further escalation")
 	}
 }
 
-func TestTimeoutExhaustedPublishesDLQ(t *testing.T) {
-	ms := newMockStore()
-	mh := &mockHermes{}
-	b := New(ms, mh, nil, nil, nil, testConfig(), discardLogger())
+func TestEscalationWidenCapabilitiesAndReassign(t *testing.T) {
+	b, ms, _ := newEscalationTestBroker(
+		config.EscalationStep{AfterFailures: 1, Action: config.EscalateWidenCapabilities},
+		config.EscalationStep{AfterFailures: 1, Action: config.EscalateReassign, Agent: "senior"},
+	)
+	task := newFailingTask(ms, 0, 3)
 
-	ctx := context.Background()
-	past := time.Now().Add(-10 * time.Second)
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "dlq timeout test",
-		RequiredCapabilities: []string{"research"},
-		Status:               store.StatusInProgress,
-		AssignedAgent:        "scout",
-		TimeoutSeconds:       1,
-		MaxRetries:           1,
-		RetryCount:           1,
-		AssignedAt:           &past,
-		StartedAt:            &past,
-		Source:               "manual",
-		RetryEligible:        true,
-	}
-	_ = ms.CreateTask(ctx, task)
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "boom", RetryEligible: true})
+
+	updated := ms.tasks[task.ID]
+	if len(updated.RequiredCapabilities) != 1 || updated.RequiredCapabilities[0] != "research" {
+		t.Errorf("expected capabilities widened to primary, got %v", updated.RequiredCapabilities)
+	}
+	if updated.RequiredAgent != "senior" {
+		t.Errorf("expected task pinned to senior, got %q", updated.RequiredAgent)
+	}
+	if n := len(escalatedEvents(ms, task.ID)); n != 2 {
+		t.Errorf("expected 2 escalation events, got %d", n)
+	}
+}
+
+func TestEscalationCreatesBacklogBug(t *testing.T) {
+	b, ms, _ := newEscalationTestBroker(config.EscalationStep{AfterFailures: 1, Action: config.EscalateBacklogItem})
+	task := newFailingTask(ms, 0, 0)
+
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "segfault", RetryEligible: true})
+
+	if len(ms.backlog) != 1 {
+		t.Fatalf("expected one backlog item, got %d", len(ms.backlog))
+	}
+	item := ms.backlog[0]
+	if item.ItemType != "bug" || item.Metadata["failed_task_id"] != task.ID.String() {
+		t.Errorf("unexpected backlog item: %+v", item)
+	}
+	if !strings.Contains(item.Description, "segfault") {
+		t.Errorf("expected error in description, got %q", item.Description)
+	}
+	// Opening a bug is not a reason to run again.
+	if ms.tasks[task.ID].Status != store.StatusFailed {
+		t.Errorf("expected DLQ, got %s", ms.tasks[task.ID].Status)
+	}
+	events := escalatedEvents(ms, task.ID)
+	if len(events) != 1 || events[0].Payload["backlog_item_id"] != item.ID.String() {
+		t.Errorf("expected escalation event linking the backlog item, got %+v", events)
+	}
+}
+
+func TestEscalationOnTimeout(t *testing.T) {
+	b, ms, _ := newEscalationTestBroker(config.EscalationStep{AfterFailures: 1, Action: config.EscalatePromoteModel, Tier: "standard"})
+	task := newFailingTask(ms, 0, 0)
+
+	b.checkTimeouts(context.Background())
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusPending || updated.MinModelTier != "standard" {
+		t.Errorf("expected retry at standard tier, got %s at %q", updated.Status, updated.MinModelTier)
+	}
+}
+
+func TestFailureClassRetryPolicies(t *testing.T) {
+	tests := []struct {
+		class      string
+		wantStatus store.TaskStatus
+		retryAgent string
+		avoided    []string
+	}{
+		{store.FailureTransient, store.StatusPending, "scout", nil},
+		{store.FailureAgentFault, store.StatusPending, "", []string{"scout"}},
+		{store.FailureTaskSpec, store.StatusFailed, "", nil},
+		{"", store.StatusPending, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			ms := newMockStore()
+			cfg := testConfig()
+			cfg.Retry = config.RetryConfig{Policies: map[string]string{
+				store.FailureTransient:  config.RetrySameAgent,
+				store.FailureAgentFault: config.RetryElsewhere,
+				store.FailureTaskSpec:   config.RetryStop,
+			}}
+			b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
+			task := newFailingTask(ms, 0, 3)
+
+			b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "boom", RetryEligible: true, FailureClass: tt.class})
+
+			updated := ms.tasks[task.ID]
+			if updated.Status != tt.wantStatus {
+				t.Fatalf("expected %s, got %s", tt.wantStatus, updated.Status)
+			}
+			if got, _ := updated.Metadata[metaRetryAgent].(string); got != tt.retryAgent {
+				t.Errorf("expected retry agent %q, got %q", tt.retryAgent, got)
+			}
+			if got := metadataList(updated, metaFailedAgents); len(got) != len(tt.avoided) {
+				t.Errorf("expected avoided agents %v, got %v", tt.avoided, got)
+			}
+			if len(ms.history) != 1 || ms.history[0].FailureClass != tt.class || *ms.history[0].Success {
+				t.Errorf("expected failed attempt recorded with class %q, got %+v", tt.class, ms.history)
+			}
+		})
+	}
+}
+
+func TestEscalateRetryPolicyRetriesOnlyWhenEscalated(t *testing.T) {
+	b, ms, _ := newEscalationTestBroker(config.EscalationStep{AfterFailures: 2, Action: config.EscalatePromoteModel})
+	b.cfg.Retry.Policies = map[string]string{store.FailureQuota: config.RetryEscalate}
+	task := newFailingTask(ms, 0, 3)
+
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "rate limited", RetryEligible: true, FailureClass: store.FailureQuota})
+	if task.Status != store.StatusFailed {
+		t.Fatalf("expected DLQ with no escalation due, got %s", task.Status)
+	}
+
+	task = newFailingTask(ms, 1, 3)
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "rate limited", RetryEligible: true, FailureClass: store.FailureQuota})
+	if task.Status != store.StatusPending || task.MinModelTier != "premium" {
+		t.Errorf("expected escalated retry, got %s at %q", task.Status, task.MinModelTier)
+	}
+}
+
+func TestRetryElsewhereAvoidsFailedAgent(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(2, 0)
+	b.cfg.Retry.Policies = map[string]string{store.FailureAgentFault: config.RetryElsewhere}
+	task := newFailingTask(ms, 0, 3)
+	task.AssignedAgent = "agent-00"
+
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "crashed", RetryEligible: true, FailureClass: store.FailureAgentFault})
+	task.RequiredCapabilities = []string{"research"}
+	b.processPendingTasks(context.Background())
+
+	if task.AssignedAgent != "agent-01" {
+		t.Errorf("expected retry on agent-01, got %q", task.AssignedAgent)
+	}
+}
+
+func TestSnapshotLoadsAgentFaultRate(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(2, 0)
+	for i := 0; i < faultRateMinAttempts; i++ {
+		h := &store.AgentTaskHistory{AgentSlug: "agent-00", Success: boolPtr(false), FailureClass: store.FailureAgentFault}
+		_ = ms.CreateAgentTaskHistory(context.Background(), h)
+	}
+	snap, err := b.loadSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("loadSnapshot: %v", err)
+	}
+	if r := snap.agentHistory("agent-00").faultRate; r == nil || *r != 1 {
+		t.Fatalf("expected agent-00 fault rate 1, got %v", r)
+	}
+	if r := snap.agentHistory("agent-01").faultRate; r != nil {
+		t.Errorf("expected no fault rate without history, got %v", *r)
+	}
+}
+
+func TestTimeoutRetryPublishesRetryAndTimeoutEvents(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	b := New(ms, mh, nil, nil, nil, testConfig(), discardLogger())
+
+	ctx := context.Background()
+	past := time.Now().Add(-10 * time.Second)
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "timeout events test",
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusAssigned,
+		AssignedAgent:        "scout",
+		TimeoutSeconds:       1,
+		MaxRetries:           3,
+		RetryCount:           0,
+		AssignedAt:           &past,
+		AckedAt:              &past,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.checkTimeouts(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusPending {
+		t.Errorf("expected pending (retry), got %s", updated.Status)
+	}
+
+	timeoutFound, retryFound := false, false
+	for _, p := range mh.published {
+		if p.subject == "swarm.task."+task.ID.String()+".timeout" {
+			timeoutFound = true
+		}
+		if p.subject == "swarm.task."+task.ID.String()+".retry" {
+			retryFound = true
+		}
+	}
+	if !timeoutFound {
+		t.Error("expected timeout event")
+	}
+	if !retryFound {
+		t.Error("expected retry event")
+	}
+}
+
+func TestTimeoutExhaustedPublishesDLQ(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	b := New(ms, mh, nil, nil, nil, testConfig(), discardLogger())
+
+	ctx := context.Background()
+	past := time.Now().Add(-10 * time.Second)
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "dlq timeout test",
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusInProgress,
+		AssignedAgent:        "scout",
+		TimeoutSeconds:       1,
+		MaxRetries:           1,
+		RetryCount:           1,
+		AssignedAt:           &past,
+		StartedAt:            &past,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
 
 	b.checkTimeouts(ctx)
 
@@ -931,6 +1598,7 @@ func TestTimeoutAssignedState(t *testing.T) {
 		MaxRetries:           2,
 		RetryCount:           0,
 		AssignedAt:           &past,
+		AckedAt:              &past,
 		Source:               "manual",
 		RetryEligible:        true,
 	}
@@ -1013,913 +1681,2909 @@ func TestHandleProgressTransitionsAssignedToInProgress(t *testing.T) {
 	}
 }
 
-func TestHandleAgentStoppedClearsFields(t *testing.T) {
+func TestHeartbeatKeepsTaskAlivePastTimeout(t *testing.T) {
 	ms := newMockStore()
-	mh := &mockHermes{}
-	b := New(ms, mh, nil, nil, nil, testConfig(), discardLogger())
+	cfg := testConfig()
+	cfg.Assignment.HeartbeatIntervalMs = 1000
+	cfg.Assignment.HeartbeatMissedLimit = 3
+	cfg.Assignment.HardDeadlineMs = 60000
+	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
 
 	ctx := context.Background()
 	now := time.Now()
-	// One assigned, one in_progress
-	assigned := &store.Task{
-		Owner:         "system",
-		Title:         "assigned task",
-		Status:        store.StatusAssigned,
-		AssignedAgent: "lily",
-		AssignedAt:    &now,
-		Source:        "manual",
+	started := now.Add(-10 * time.Second)
+	beat := now.Add(-1 * time.Second)
+	task := &store.Task{
+		Owner:           "system",
+		Title:           "long runner",
+		Status:          store.StatusInProgress,
+		AssignedAgent:   "scout",
+		AssignedAt:      &started,
+		StartedAt:       &started,
+		LastHeartbeatAt: &beat,
+		TimeoutSeconds:  1,
+		MaxRetries:      3,
+		RetryEligible:   true,
+		Source:          "manual",
 	}
-	running := &store.Task{
+	_ = ms.CreateTask(ctx, task)
+
+	b.checkTimeouts(ctx)
+
+	if ms.tasks[task.ID].Status != store.StatusInProgress {
+		t.Errorf("expected heartbeating task to stay in_progress, got %s", ms.tasks[task.ID].Status)
+	}
+}
+
+func TestTimeoutReasons(t *testing.T) {
+	cfg := testConfig()
+	cfg.Assignment.HeartbeatIntervalMs = 1000
+	cfg.Assignment.HeartbeatMissedLimit = 3
+	cfg.Assignment.HardDeadlineMs = 60000
+	b := New(newMockStore(), nil, nil, nil, nil, cfg, discardLogger())
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+
+	tests := []struct {
+		name    string
+		started *time.Time
+		beat    *time.Time
+		timeout int
+		want    string
+	}{
+		{"no heartbeat within timeout", at(5 * time.Second), nil, 10, ""},
+		{"no heartbeat past timeout", at(15 * time.Second), nil, 10, "deadline"},
+		{"fresh heartbeat past timeout", at(15 * time.Second), at(time.Second), 10, ""},
+		{"stale heartbeat", at(15 * time.Second), at(5 * time.Second), 10, "heartbeat_missed"},
+		{"hard deadline", at(2 * time.Minute), at(time.Second), 10, "hard_deadline"},
+		{"own timeout beyond hard deadline", at(2 * time.Minute), at(time.Second), 600, ""},
+	}
+	for _, tt := range tests {
+		task := &store.Task{StartedAt: tt.started, LastHeartbeatAt: tt.beat, TimeoutSeconds: tt.timeout}
+		if got := b.timeoutReason(task, now); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestHandleHeartbeatUpdatesLiveness(t *testing.T) {
+	ms := newMockStore()
+	b := New(ms, &mockHermes{}, nil, nil, nil, testConfig(), discardLogger())
+
+	ctx := context.Background()
+	now := time.Now()
+	task := &store.Task{
 		Owner:         "system",
-		Title:         "running task",
+		Title:         "heartbeat",
 		Status:        store.StatusInProgress,
-		AssignedAgent: "lily",
-		AssignedAt:    &now,
+		AssignedAgent: "scout",
 		StartedAt:     &now,
 		Source:        "manual",
 	}
-	_ = ms.CreateTask(ctx, assigned)
-	_ = ms.CreateTask(ctx, running)
+	_ = ms.CreateTask(ctx, task)
 
-	b.HandleAgentStopped(ctx, "lily")
+	b.handleHeartbeat(map[string]interface{}{
+		"task_id":  task.ID.String(),
+		"agent_id": "scout",
+		"percent":  20.0,
+	})
 
-	for _, id := range []uuid.UUID{assigned.ID, running.ID} {
-		task := ms.tasks[id]
-		if task.Status != store.StatusPending {
-			t.Errorf("task %s: expected pending, got %s", id, task.Status)
-		}
-		if task.AssignedAgent != "" {
-			t.Errorf("task %s: expected assigned_agent cleared", id)
-		}
-		if task.AssignedAt != nil {
-			t.Errorf("task %s: expected assigned_at cleared", id)
-		}
-		if task.StartedAt != nil {
-			t.Errorf("task %s: expected started_at cleared", id)
-		}
+	updated := ms.tasks[task.ID]
+	if updated.LastHeartbeatAt == nil {
+		t.Error("expected last_heartbeat_at set")
 	}
-
-	// Should have published reassigned events for both
-	reassignCount := 0
-	for _, p := range mh.published {
-		if p.subject == "swarm.task."+assigned.ID.String()+".reassigned" ||
-			p.subject == "swarm.task."+running.ID.String()+".reassigned" {
-			reassignCount++
-		}
+	if updated.Progress == nil || *updated.Progress.Percent != 20 {
+		t.Errorf("expected progress snapshot from heartbeat, got %+v", updated.Progress)
 	}
-	if reassignCount != 2 {
-		t.Errorf("expected 2 reassigned events, got %d", reassignCount)
+	if len(ms.events) != 0 {
+		t.Errorf("expected no events for heartbeat, got %d", len(ms.events))
 	}
 }
 
-func TestStopIdempotent(t *testing.T) {
-	// Reproduces the double-close panic fixed in broker.Stop().
-	// Before the fix, calling Stop() twice would panic on close(b.stopCh).
+func TestLivenessIgnoredForFinishedTask(t *testing.T) {
 	ms := newMockStore()
-	cfg := testConfig()
-	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
-
-	ctx, cancel := context.WithCancel(context.Background())
-	b.Start(ctx)
+	b := New(ms, &mockHermes{}, nil, nil, nil, testConfig(), discardLogger())
 
-	// First stop — should close cleanly
-	b.Stop()
+	ctx := context.Background()
+	now := time.Now()
+	task := &store.Task{
+		Owner:         "system",
+		Title:         "finished",
+		Status:        store.StatusCompleted,
+		AssignedAgent: "scout",
+		CompletedAt:   &now,
+		Source:        "manual",
+	}
+	_ = ms.CreateTask(ctx, task)
 
-	// Second stop — must not panic (sync.Once guards the channel close)
-	b.Stop()
+	evt := map[string]interface{}{"task_id": task.ID.String(), "agent_id": "scout", "percent": 50.0}
+	b.handleProgress(evt)
+	b.handleHeartbeat(evt)
 
-	cancel() // cleanup
+	if task.Status != store.StatusCompleted || task.LastHeartbeatAt != nil || task.Progress != nil {
+		t.Errorf("expected the finished task untouched, got %s", task.Status)
+	}
+	if len(ms.events) != 0 {
+		t.Errorf("expected no progress event, got %d events", len(ms.events))
+	}
 }
 
-func TestStopWithoutStart(t *testing.T) {
-	// Stop on a broker that was never started must not hang or panic.
-	cfg := testConfig()
-	b := New(nil, nil, nil, nil, nil, cfg, discardLogger())
+func TestHeartbeatOnAckedTaskWritesLivenessOnly(t *testing.T) {
+	ms := newMockStore()
+	b := New(ms, &mockHermes{}, nil, nil, nil, testConfig(), discardLogger())
 
-	done := make(chan struct{})
-	go func() {
-		b.Stop()
-		close(done)
-	}()
+	ctx := context.Background()
+	now := time.Now()
+	task := &store.Task{
+		Owner:         "system",
+		Title:         "heartbeat",
+		Status:        store.StatusInProgress,
+		AssignedAgent: "scout",
+		AckedAt:       &now,
+		StartedAt:     &now,
+		Source:        "manual",
+	}
+	_ = ms.CreateTask(ctx, task)
 
-	select {
-	case <-done:
-		// OK — returned without hanging
-	case <-time.After(2 * time.Second):
-		t.Fatal("Stop() hung on broker that was never started")
+	b.handleHeartbeat(map[string]interface{}{"task_id": task.ID.String(), "agent_id": "scout"})
+	b.handleProgress(map[string]interface{}{"task_id": task.ID.String(), "agent_id": "scout", "percent": 40.0})
+
+	if ms.livenessWrites != 2 {
+		t.Errorf("expected both reports written as liveness, got %d", ms.livenessWrites)
 	}
 }
 
-func TestStartStopLifecycle(t *testing.T) {
-	// Verify Start launches goroutines and Stop terminates them cleanly.
+func TestLivenessFromAPINotSavedAgain(t *testing.T) {
 	ms := newMockStore()
-	cfg := testConfig()
-	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	b.Start(ctx)
+	b := New(ms, &mockHermes{}, nil, nil, nil, testConfig(), discardLogger())
 
-	// Let the loops run for a couple of ticks
-	time.Sleep(300 * time.Millisecond)
+	ctx := context.Background()
+	now := time.Now()
+	task := &store.Task{
+		Owner:         "system",
+		Title:         "reported through the API",
+		Status:        store.StatusAssigned,
+		AssignedAgent: "scout",
+		AssignedAt:    &now,
+		Source:        "manual",
+	}
+	_ = ms.CreateTask(ctx, task)
 
-	done := make(chan struct{})
-	go func() {
-		b.Stop()
-		close(done)
-	}()
+	evt := map[string]interface{}{"task_id": task.ID.String(), "agent_id": "scout", hermes.OriginField: hermes.OriginAPI}
+	b.handleProgress(evt)
+	b.handleHeartbeat(evt)
 
-	select {
-	case <-done:
-		// Clean shutdown
-	case <-time.After(5 * time.Second):
-		t.Fatal("Stop() did not return within 5 seconds")
+	if task.Status != store.StatusAssigned || task.LastHeartbeatAt != nil {
+		t.Errorf("expected the task untouched, got %s with heartbeat %v", task.Status, task.LastHeartbeatAt)
+	}
+	if ms.livenessWrites != 0 || len(ms.events) != 0 {
+		t.Errorf("expected no writes or events, got %d writes and %d events", ms.livenessWrites, len(ms.events))
 	}
 }
 
-func TestContextCancelStopsBroker(t *testing.T) {
-	// When the parent context is cancelled, the broker loops should exit
-	// and Stop() should return promptly.
+func TestUnackedTaskReclaimedWithoutRetry(t *testing.T) {
 	ms := newMockStore()
-	cfg := testConfig()
-	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
-
-	ctx, cancel := context.WithCancel(context.Background())
-	b.Start(ctx)
-
-	// Cancel context — goroutines should exit
-	cancel()
-
-	done := make(chan struct{})
-	go func() {
-		b.Stop()
-		close(done)
-	}()
+	mh := &mockHermes{}
+	b := New(ms, mh, nil, nil, nil, testConfig(), discardLogger())
 
-	select {
-	case <-done:
-		// OK
-	case <-time.After(5 * time.Second):
-		t.Fatal("Stop() did not return after context cancel")
+	ctx := context.Background()
+	past := time.Now().Add(-5 * time.Second)
+	task := &store.Task{
+		Owner:          "system",
+		Title:          "never acked",
+		Status:         store.StatusAssigned,
+		AssignedAgent:  "scout",
+		AssignedAt:     &past,
+		TimeoutSeconds: 300,
+		MaxRetries:     3,
+		RetryEligible:  true,
+		Source:         "manual",
 	}
-}
-
-// --- E2E: cross-owner assignment ---
-
-// TestCrossOwnerAssignmentE2E exercises the full broker pipeline for cross-owner dispatch.
-// When OwnerFilterEnabled=false, a task submitted by owner A should be assignable to agents
-// owned by owner B. When OwnerFilterEnabled=true, the same scenario must produce no match.
-func TestCrossOwnerAssignmentE2E(t *testing.T) {
-	ownerA := uuid.New().String() // task submitter
-	ownerB := "different-owner"   // owns all agents
+	_ = ms.CreateTask(ctx, task)
 
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily":  {Name: "lily", Status: "ready", Policy: "always-on"},
-		"scout": {Name: "scout", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-		{Name: "scout", Slug: "scout", Capabilities: []string{"research", "code"}},
-	}}
-	// All agents owned by ownerB — ownerA owns nothing
-	ma := &mockAlexandria{devices: []alexandria.Device{
-		{ID: "d1", Name: "lily", OwnerID: ownerB},
-		{ID: "d2", Name: "scout", OwnerID: ownerB},
-	}}
+	b.checkTimeouts(ctx)
 
-	makeTask := func() *store.Task {
-		return &store.Task{
-			Owner:                ownerA,
-			Title:                "cross-owner research",
-			RequiredCapabilities: []string{"research"},
-			Priority:             5,
-			Status:               store.StatusPending,
-			TimeoutSeconds:       60,
-			Source:               "agent",
-			RetryEligible:        true,
-		}
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusPending {
+		t.Errorf("expected pending after ack timeout, got %s", updated.Status)
 	}
-
-	t.Run("filter enabled blocks cross-owner", func(t *testing.T) {
-		ms := newMockStore()
-		mh := &mockHermes{}
-		cfg := testConfig()
-		cfg.Assignment.OwnerFilterEnabled = true
-		b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
-
-		ctx := context.Background()
-		task := makeTask()
-		_ = ms.CreateTask(ctx, task)
-
-		b.processPendingTasks(ctx)
-
-		updated := ms.tasks[task.ID]
-		if updated.Status != store.StatusPending {
-			t.Errorf("expected pending (blocked by owner filter), got %s", updated.Status)
-		}
-		// Verify unmatched event was published
-		unmatchedFound := false
-		for _, p := range mh.published {
-			if p.subject == "swarm.task."+task.ID.String()+".unmatched" {
-				unmatchedFound = true
-			}
-		}
-		if !unmatchedFound {
-			t.Error("expected unmatched event when owner filter blocks all candidates")
+	if updated.RetryCount != 0 {
+		t.Errorf("expected ack timeout not to consume a retry, got %d", updated.RetryCount)
+	}
+	if got := ackMissedAgents(updated); len(got) != 1 || got[0] != "scout" {
+		t.Errorf("expected scout recorded as ack-missed, got %v", got)
+	}
+	found := false
+	for _, p := range mh.published {
+		if p.subject == "swarm.task."+task.ID.String()+".reclaimed" {
+			found = true
 		}
-	})
+	}
+	if !found {
+		t.Error("expected reclaimed event")
+	}
+}
 
-	t.Run("filter disabled allows cross-owner", func(t *testing.T) {
-		ms := newMockStore()
-		mh := &mockHermes{}
-		cfg := testConfig()
-		cfg.Assignment.OwnerFilterEnabled = false
-		b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
+func TestAckedTaskNotReclaimed(t *testing.T) {
+	ms := newMockStore()
+	b := New(ms, &mockHermes{}, nil, nil, nil, testConfig(), discardLogger())
 
-		ctx := context.Background()
-		task := makeTask()
-		_ = ms.CreateTask(ctx, task)
+	ctx := context.Background()
+	past := time.Now().Add(-5 * time.Second)
+	task := &store.Task{
+		Owner:          "system",
+		Title:          "acked",
+		Status:         store.StatusAssigned,
+		AssignedAgent:  "scout",
+		AssignedAt:     &past,
+		TimeoutSeconds: 300,
+		MaxRetries:     3,
+		Source:         "manual",
+	}
+	task.Acknowledge(past)
+	_ = ms.CreateTask(ctx, task)
 
-		b.processPendingTasks(ctx)
+	b.checkTimeouts(ctx)
 
-		updated := ms.tasks[task.ID]
-		if updated.Status != store.StatusAssigned {
-			t.Errorf("expected assigned (owner filter disabled), got %s", updated.Status)
-		}
-		if updated.AssignedAgent == "" {
-			t.Error("expected an agent to be assigned")
-		}
-		// Verify assigned event was published
-		assignedFound := false
-		for _, p := range mh.published {
-			if p.subject == "swarm.task."+task.ID.String()+".assigned" {
-				assignedFound = true
-			}
-		}
-		if !assignedFound {
-			t.Error("expected assigned event when cross-owner dispatch succeeds")
-		}
-	})
+	if ms.tasks[task.ID].Status != store.StatusAssigned {
+		t.Errorf("expected acked task to stay assigned, got %s", ms.tasks[task.ID].Status)
+	}
 }
 
-func TestOwnerFilterDisabled(t *testing.T) {
+func TestReassignmentSkipsAckMissedAgent(t *testing.T) {
 	ms := newMockStore()
-	mh := &mockHermes{}
-	ownerID := uuid.New().String()
 	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-		"nova": {Name: "nova", Status: "ready", Policy: "always-on"},
+		"lily":  {Name: "lily", Status: "ready", Policy: "always-on"},
+		"scout": {Name: "scout", Status: "ready", Policy: "always-on"},
 	}}
 	mf := &mockForge{personas: []forge.Persona{
 		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-		{Name: "nova", Slug: "nova", Capabilities: []string{"research"}},
-	}}
-	// Alexandria returns no devices for this owner — with filtering enabled, no candidates would match
-	ma := &mockAlexandria{devices: []alexandria.Device{
-		{ID: "d1", Name: "lily", OwnerID: "different-owner"},
-		{ID: "d2", Name: "nova", OwnerID: "different-owner"},
+		{Name: "scout", Slug: "scout", Capabilities: []string{"research"}},
 	}}
-
-	cfg := testConfig()
-	cfg.Assignment.OwnerFilterEnabled = false
-	b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
+	b := New(ms, &mockHermes{}, mw, mf, nil, testConfig(), discardLogger())
 
 	ctx := context.Background()
 	task := &store.Task{
-		Owner:                ownerID,
-		Title:                "cross-owner task",
+		Owner:                "system",
+		Title:                "reassign elsewhere",
 		RequiredCapabilities: []string{"research"},
-		Priority:             5,
 		Status:               store.StatusPending,
-		TimeoutSeconds:       5,
+		Metadata:             map[string]interface{}{"ack_missed_agents": []interface{}{"lily"}},
 		Source:               "manual",
-		RetryEligible:        true,
 	}
 	_ = ms.CreateTask(ctx, task)
 
 	b.processPendingTasks(ctx)
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Errorf("expected assigned (owner filter disabled), got %s", updated.Status)
+	if got := ms.tasks[task.ID].AssignedAgent; got != "scout" {
+		t.Errorf("expected scout (lily missed ack), got %q", got)
 	}
-	if updated.AssignedAgent == "" {
-		t.Error("expected an agent to be assigned when owner filter is disabled")
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	b := New(newMockStore(), nil, nil, nil, nil, testConfig(), discardLogger())
+
+	now := time.Now()
+	started := now.Add(-20 * time.Second)
+	expired := now.Add(-time.Second)
+	extended := now.Add(time.Minute)
+
+	task := &store.Task{StartedAt: &started, TimeoutSeconds: 10, LeaseExpiresAt: &extended}
+	if got := b.timeoutReason(task, now); got != "" {
+		t.Errorf("expected extended lease to keep task alive past timeout, got %q", got)
+	}
+	task.LeaseExpiresAt = &expired
+	if got := b.timeoutReason(task, now); got != "lease_expired" {
+		t.Errorf("expected lease_expired, got %q", got)
 	}
 }
 
-// --- Nil/empty capabilities assignment tests ---
+func TestHeartbeatsSupersedeLease(t *testing.T) {
+	cfg := testConfig()
+	cfg.Assignment.HeartbeatIntervalMs = 1000
+	cfg.Assignment.HeartbeatMissedLimit = 3
+	b := New(newMockStore(), nil, nil, nil, nil, cfg, discardLogger())
 
-func TestNilCapabilitiesAssignsToAnyAgent(t *testing.T) {
-	// Tasks with nil RequiredCapabilities should be assignable to any agent
-	// via ListPersonas(), not silently skipped.
+	now := time.Now()
+	started := now.Add(-20 * time.Second)
+	expired := now.Add(-time.Second)
+	fresh := now.Add(-time.Second)
+	stale := now.Add(-5 * time.Second)
+
+	task := &store.Task{StartedAt: &started, TimeoutSeconds: 10, LeaseExpiresAt: &expired, LastHeartbeatAt: &fresh}
+	if got := b.timeoutReason(task, now); got != "" {
+		t.Errorf("expected heartbeats to keep the task alive past its lease, got %q", got)
+	}
+	task.LastHeartbeatAt = &stale
+	if got := b.timeoutReason(task, now); got != "heartbeat_missed" {
+		t.Errorf("expected heartbeat_missed, got %q", got)
+	}
+}
+
+func TestAckTimeoutIndependentOfWakeTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.Assignment.WakeTimeoutMs = 60000
+	cfg.Assignment.AckTimeoutMs = 1000
+	b := New(newMockStore(), nil, nil, nil, nil, cfg, discardLogger())
+
+	now := time.Now()
+	assigned := now.Add(-5 * time.Second)
+	task := &store.Task{Status: store.StatusAssigned, AssignedAgent: "scout", AssignedAt: &assigned}
+	if !b.ackExpired(task, now) {
+		t.Error("expected the ack timeout to apply, not the wake timeout")
+	}
+	b.cfg.Assignment.AckTimeoutMs = 0
+	if b.ackExpired(task, now) {
+		t.Error("expected a zero ack timeout to disable reclaiming")
+	}
+}
+
+func newWakeTestBroker(wakeAfter int) (*Broker, *mockStore, *mockHermes, *fakeWakeWarren, *store.Task) {
 	ms := newMockStore()
 	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-		"nova": {Name: "nova", Status: "ready", Policy: "on-demand"},
-	}}
+	fw := &fakeWakeWarren{
+		states: map[string]*warren.AgentState{
+			"nova": {Name: "nova", Status: "sleeping", Policy: "always-on"},
+			"lily": {Name: "lily", Status: "busy", Policy: "on-demand"},
+		},
+		wakeAfter: map[string]int{"nova": wakeAfter},
+	}
 	mf := &mockForge{personas: []forge.Persona{
+		{Name: "nova", Slug: "nova", Capabilities: []string{"research"}},
 		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-		{Name: "nova", Slug: "nova", Capabilities: []string{"code"}},
 	}}
+	b := New(ms, mh, fw, mf, nil, testConfig(), discardLogger())
 
-	cfg := testConfig()
-	cfg.Assignment.OwnerFilterEnabled = false
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+	// Load lily so a sleeping nova outscores her but she remains eligible.
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		_ = ms.CreateTask(context.Background(), &store.Task{
+			Title:          "busy work",
+			Status:         store.StatusInProgress,
+			AssignedAgent:  "lily",
+			AssignedAt:     &now,
+			AckedAt:        &now,
+			StartedAt:      &now,
+			TimeoutSeconds: 300,
+		})
+	}
 
-	ctx := context.Background()
 	task := &store.Task{
 		Owner:                "system",
-		Title:                "no-cap task",
-		RequiredCapabilities: nil, // NULL from Postgres
-		Priority:             5,
+		Title:                "wake test",
+		RequiredCapabilities: []string{"research"},
 		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
+		TimeoutSeconds:       300,
+		MaxRetries:           3,
 		Source:               "manual",
-		RetryEligible:        true,
 	}
-	_ = ms.CreateTask(ctx, task)
+	_ = ms.CreateTask(context.Background(), task)
+	return b, ms, mh, fw, task
+}
 
-	b.processPendingTasks(ctx)
+func assignedPublished(mh *mockHermes, taskID uuid.UUID) bool {
+	for _, p := range mh.published {
+		if p.subject == hermes.SubjectTaskAssigned(taskID.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWakeDoesNotBlockAssignment(t *testing.T) {
+	b, ms, mh, fw, task := newWakeTestBroker(-1)
+
+	start := time.Now()
+	_ = b.assignTask(context.Background(), task)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected assignTask not to block on wake, took %v", elapsed)
+	}
 
 	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Errorf("expected assigned for nil capabilities, got %s", updated.Status)
+	if updated.Status != store.StatusAssigned || updated.SubState != store.SubStateWaking {
+		t.Fatalf("expected assigned/waking, got %s/%s", updated.Status, updated.SubState)
 	}
-	if updated.AssignedAgent == "" {
-		t.Error("expected an agent to be assigned for nil capabilities task")
+	if updated.AssignedAgent != "nova" {
+		t.Errorf("expected nova (sleeping beats loaded lily), got %s", updated.AssignedAgent)
 	}
-	// lily should win (always-on > on-demand)
-	if updated.AssignedAgent != "lily" {
-		t.Errorf("expected lily (always-on ready), got %s", updated.AssignedAgent)
+	if len(fw.wakeCalls) != 1 {
+		t.Errorf("expected one wake call, got %d", len(fw.wakeCalls))
+	}
+	if assignedPublished(mh, task.ID) {
+		t.Error("expected assignment not announced while agent wakes")
 	}
 }
 
-func TestEmptyCapabilitiesAssignsToAnyAgent(t *testing.T) {
-	// Same as nil but with explicit empty slice.
-	ms := newMockStore()
-	mh := &mockHermes{}
+func TestSlowWakeCompletesOnPoll(t *testing.T) {
+	b, ms, mh, _, task := newWakeTestBroker(2)
+	ctx := context.Background()
+	_ = b.assignTask(ctx, task)
+
+	// Still asleep for the first two polls.
+	b.processPendingTasks(ctx)
+	b.processPendingTasks(ctx)
+	if ms.tasks[task.ID].SubState != store.SubStateWaking {
+		t.Fatalf("expected still waking, got %q", ms.tasks[task.ID].SubState)
+	}
+
+	b.processPendingTasks(ctx)
+	updated := ms.tasks[task.ID]
+	if updated.SubState != "" || updated.AssignedAgent != "nova" {
+		t.Errorf("expected nova awake and assigned, got %q/%s", updated.SubState, updated.AssignedAgent)
+	}
+	if !assignedPublished(mh, task.ID) {
+		t.Error("expected assignment announced once agent is ready")
+	}
+}
+
+func TestNeverWakesFallsBackToNextCandidate(t *testing.T) {
+	b, ms, mh, _, task := newWakeTestBroker(-1)
+	ctx := context.Background()
+	_ = b.assignTask(ctx, task)
+
+	// Age the wake beyond WakeTimeout (1s in testConfig).
+	past := time.Now().Add(-2 * time.Second)
+	ms.tasks[task.ID].AssignedAt = &past
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.AssignedAgent != "lily" {
+		t.Fatalf("expected fallback to lily, got %q", updated.AssignedAgent)
+	}
+	if updated.SubState != "" || updated.Status != store.StatusAssigned {
+		t.Errorf("expected lily assigned directly, got %s/%q", updated.Status, updated.SubState)
+	}
+	if updated.RetryCount != 0 {
+		t.Errorf("expected wake fallback not to consume a retry, got %d", updated.RetryCount)
+	}
+	if got := metadataList(updated, metaWakeFailedAgents); len(got) != 1 || got[0] != "nova" {
+		t.Errorf("expected nova recorded as wake-failed, got %v", got)
+	}
+	if !assignedPublished(mh, task.ID) {
+		t.Error("expected fallback assignment announced")
+	}
+}
+
+func TestWakeCheckSharesTickStates(t *testing.T) {
+	b, ms, _, fw, task := newWakeTestBroker(-1)
+	ctx := context.Background()
+	_ = b.assignTask(ctx, task)
+	second := *ms.tasks[task.ID]
+	second.ID = uuid.New()
+	ms.tasks[second.ID] = &second
+
+	fw.polls = 0
+	b.processPendingTasks(ctx)
+	if fw.polls != 2 {
+		t.Errorf("expected one Warren lookup per persona for two waking tasks, got %d", fw.polls)
+	}
+}
+
+func TestAgentStartedEventCompletesWake(t *testing.T) {
+	b, ms, mh, _, task := newWakeTestBroker(-1)
+	ctx := context.Background()
+	_ = b.assignTask(ctx, task)
+
+	b.HandleAgentStarted(ctx, "nova")
+
+	if ms.tasks[task.ID].SubState != "" {
+		t.Errorf("expected waking cleared on agent started, got %q", ms.tasks[task.ID].SubState)
+	}
+	if !assignedPublished(mh, task.ID) {
+		t.Error("expected assignment announced on agent started")
+	}
+}
+
+func TestWakeResolvedElsewhereNotAbandoned(t *testing.T) {
+	b, ms, mh, _, task := newWakeTestBroker(-1)
+	ctx := context.Background()
+	_ = b.assignTask(ctx, task)
+
+	snap, err := b.loadSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("loadSnapshot: %v", err)
+	}
+	// The agent-started handler completes the wake after the tick's
+	// snapshot, which still holds the task as waking and overdue.
+	woken := *ms.tasks[task.ID]
+	woken.SubState = ""
+	ms.tasks[task.ID] = &woken
+	past := time.Now().Add(-2 * time.Second)
+	for _, active := range snap.activeTasks {
+		if active.ID == task.ID {
+			active.AssignedAt = &past
+		}
+	}
+
+	if released := b.checkWaking(ctx, snap); len(released) != 0 {
+		t.Fatalf("expected nothing released, got %d", len(released))
+	}
+	if ms.tasks[task.ID].AssignedAgent != "nova" || ms.tasks[task.ID].Status != store.StatusAssigned {
+		t.Errorf("expected the woken assignment kept, got %s/%q", ms.tasks[task.ID].Status, ms.tasks[task.ID].AssignedAgent)
+	}
+	if countPublished(mh, "swarm.task."+task.ID.String()+".wake_timeout") != 0 {
+		t.Error("expected no wake timeout published")
+	}
+}
+
+func TestAgentStartedWithoutForge(t *testing.T) {
+	b, ms, mh, _, task := newWakeTestBroker(-1)
+	ctx := context.Background()
+	_ = b.assignTask(ctx, task)
+	b.forge = nil
+
+	b.HandleAgentStarted(ctx, "nova")
+
+	if ms.tasks[task.ID].SubState != "" || !assignedPublished(mh, task.ID) {
+		t.Error("expected the wake completed without Forge")
+	}
+}
+
+func TestWakingTaskNotReclaimedForAck(t *testing.T) {
+	b, ms, _, _, task := newWakeTestBroker(-1)
+	ctx := context.Background()
+	_ = b.assignTask(ctx, task)
+
+	past := time.Now().Add(-5 * time.Second)
+	ms.tasks[task.ID].AssignedAt = &past
+	b.checkTimeouts(ctx)
+
+	if ms.tasks[task.ID].SubState != store.SubStateWaking {
+		t.Errorf("expected waking task left to the wake check, got %s/%q", ms.tasks[task.ID].Status, ms.tasks[task.ID].SubState)
+	}
+}
+
+func TestHandleAgentStoppedClearsFields(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	b := New(ms, mh, nil, nil, nil, testConfig(), discardLogger())
+
+	ctx := context.Background()
+	now := time.Now()
+	// One assigned, one in_progress
+	assigned := &store.Task{
+		Owner:         "system",
+		Title:         "assigned task",
+		Status:        store.StatusAssigned,
+		AssignedAgent: "lily",
+		AssignedAt:    &now,
+		Source:        "manual",
+	}
+	running := &store.Task{
+		Owner:         "system",
+		Title:         "running task",
+		Status:        store.StatusInProgress,
+		AssignedAgent: "lily",
+		AssignedAt:    &now,
+		StartedAt:     &now,
+		Source:        "manual",
+	}
+	_ = ms.CreateTask(ctx, assigned)
+	_ = ms.CreateTask(ctx, running)
+
+	b.HandleAgentStopped(ctx, "lily")
+
+	for _, id := range []uuid.UUID{assigned.ID, running.ID} {
+		task := ms.tasks[id]
+		if task.Status != store.StatusPending {
+			t.Errorf("task %s: expected pending, got %s", id, task.Status)
+		}
+		if task.AssignedAgent != "" {
+			t.Errorf("task %s: expected assigned_agent cleared", id)
+		}
+		if task.AssignedAt != nil {
+			t.Errorf("task %s: expected assigned_at cleared", id)
+		}
+		if task.StartedAt != nil {
+			t.Errorf("task %s: expected started_at cleared", id)
+		}
+	}
+
+	// Should have published reassigned events for both
+	reassignCount := 0
+	for _, p := range mh.published {
+		if p.subject == "swarm.task."+assigned.ID.String()+".reassigned" ||
+			p.subject == "swarm.task."+running.ID.String()+".reassigned" {
+			reassignCount++
+		}
+	}
+	if reassignCount != 2 {
+		t.Errorf("expected 2 reassigned events, got %d", reassignCount)
+	}
+}
+
+func TestStopIdempotent(t *testing.T) {
+	// Reproduces the double-close panic fixed in broker.Stop().
+	// Before the fix, calling Stop() twice would panic on close(b.stopCh).
+	ms := newMockStore()
+	cfg := testConfig()
+	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Start(ctx)
+
+	// First stop — should close cleanly
+	b.Stop()
+
+	// Second stop — must not panic (sync.Once guards the channel close)
+	b.Stop()
+
+	cancel() // cleanup
+}
+
+func TestStopWithoutStart(t *testing.T) {
+	// Stop on a broker that was never started must not hang or panic.
+	cfg := testConfig()
+	b := New(nil, nil, nil, nil, nil, cfg, discardLogger())
+
+	done := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// OK — returned without hanging
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop() hung on broker that was never started")
+	}
+}
+
+func TestStartStopLifecycle(t *testing.T) {
+	// Verify Start launches goroutines and Stop terminates them cleanly.
+	ms := newMockStore()
+	cfg := testConfig()
+	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b.Start(ctx)
+
+	// Let the loops run for a couple of ticks
+	time.Sleep(300 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// Clean shutdown
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not return within 5 seconds")
+	}
+}
+
+func TestContextCancelStopsBroker(t *testing.T) {
+	// When the parent context is cancelled, the broker loops should exit
+	// and Stop() should return promptly.
+	ms := newMockStore()
+	cfg := testConfig()
+	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Start(ctx)
+
+	// Cancel context — goroutines should exit
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// OK
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not return after context cancel")
+	}
+}
+
+// --- E2E: cross-owner assignment ---
+
+// TestCrossOwnerAssignmentE2E exercises the full broker pipeline for cross-owner dispatch.
+// When OwnerFilterEnabled=false, a task submitted by owner A should be assignable to agents
+// owned by owner B. When OwnerFilterEnabled=true, the same scenario must produce no match.
+func TestCrossOwnerAssignmentE2E(t *testing.T) {
+	ownerA := uuid.New().String() // task submitter
+	ownerB := "different-owner"   // owns all agents
+
 	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily":  {Name: "lily", Status: "ready", Policy: "always-on"},
 		"scout": {Name: "scout", Status: "ready", Policy: "always-on"},
 	}}
 	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
 		{Name: "scout", Slug: "scout", Capabilities: []string{"research", "code"}},
 	}}
+	// All agents owned by ownerB — ownerA owns nothing
+	ma := &mockAlexandria{devices: []alexandria.Device{
+		{ID: "d1", Name: "lily", OwnerID: ownerB},
+		{ID: "d2", Name: "scout", OwnerID: ownerB},
+	}}
+
+	makeTask := func() *store.Task {
+		return &store.Task{
+			Owner:                ownerA,
+			Title:                "cross-owner research",
+			RequiredCapabilities: []string{"research"},
+			Priority:             5,
+			Status:               store.StatusPending,
+			TimeoutSeconds:       60,
+			Source:               "agent",
+			RetryEligible:        true,
+		}
+	}
+
+	t.Run("filter enabled blocks cross-owner", func(t *testing.T) {
+		ms := newMockStore()
+		mh := &mockHermes{}
+		cfg := testConfig()
+		cfg.Assignment.OwnerFilterEnabled = true
+		b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
+
+		ctx := context.Background()
+		task := makeTask()
+		_ = ms.CreateTask(ctx, task)
+
+		b.processPendingTasks(ctx)
+
+		updated := ms.tasks[task.ID]
+		if updated.Status != store.StatusPending {
+			t.Errorf("expected pending (blocked by owner filter), got %s", updated.Status)
+		}
+		// Verify unmatched event was published
+		unmatchedFound := false
+		for _, p := range mh.published {
+			if p.subject == "swarm.task."+task.ID.String()+".unmatched" {
+				unmatchedFound = true
+			}
+		}
+		if !unmatchedFound {
+			t.Error("expected unmatched event when owner filter blocks all candidates")
+		}
+	})
+
+	t.Run("filter disabled allows cross-owner", func(t *testing.T) {
+		ms := newMockStore()
+		mh := &mockHermes{}
+		cfg := testConfig()
+		cfg.Assignment.OwnerFilterEnabled = false
+		b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
+
+		ctx := context.Background()
+		task := makeTask()
+		_ = ms.CreateTask(ctx, task)
+
+		b.processPendingTasks(ctx)
+
+		updated := ms.tasks[task.ID]
+		if updated.Status != store.StatusAssigned {
+			t.Errorf("expected assigned (owner filter disabled), got %s", updated.Status)
+		}
+		if updated.AssignedAgent == "" {
+			t.Error("expected an agent to be assigned")
+		}
+		// Verify assigned event was published
+		assignedFound := false
+		for _, p := range mh.published {
+			if p.subject == "swarm.task."+task.ID.String()+".assigned" {
+				assignedFound = true
+			}
+		}
+		if !assignedFound {
+			t.Error("expected assigned event when cross-owner dispatch succeeds")
+		}
+	})
+}
+
+func TestOwnerFilterDisabled(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	ownerID := uuid.New().String()
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+		"nova": {Name: "nova", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+		{Name: "nova", Slug: "nova", Capabilities: []string{"research"}},
+	}}
+	// Alexandria returns no devices for this owner — with filtering enabled, no candidates would match
+	ma := &mockAlexandria{devices: []alexandria.Device{
+		{ID: "d1", Name: "lily", OwnerID: "different-owner"},
+		{ID: "d2", Name: "nova", OwnerID: "different-owner"},
+	}}
+
+	cfg := testConfig()
+	cfg.Assignment.OwnerFilterEnabled = false
+	b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                ownerID,
+		Title:                "cross-owner task",
+		RequiredCapabilities: []string{"research"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       5,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Errorf("expected assigned (owner filter disabled), got %s", updated.Status)
+	}
+	if updated.AssignedAgent == "" {
+		t.Error("expected an agent to be assigned when owner filter is disabled")
+	}
+}
+
+// --- Nil/empty capabilities assignment tests ---
+
+func TestNilCapabilitiesAssignsToAnyAgent(t *testing.T) {
+	// Tasks with nil RequiredCapabilities should be assignable to any agent
+	// via ListPersonas(), not silently skipped.
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+		"nova": {Name: "nova", Status: "ready", Policy: "on-demand"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+		{Name: "nova", Slug: "nova", Capabilities: []string{"code"}},
+	}}
+
+	cfg := testConfig()
+	cfg.Assignment.OwnerFilterEnabled = false
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "no-cap task",
+		RequiredCapabilities: nil, // NULL from Postgres
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Errorf("expected assigned for nil capabilities, got %s", updated.Status)
+	}
+	if updated.AssignedAgent == "" {
+		t.Error("expected an agent to be assigned for nil capabilities task")
+	}
+	// lily should win (always-on > on-demand)
+	if updated.AssignedAgent != "lily" {
+		t.Errorf("expected lily (always-on ready), got %s", updated.AssignedAgent)
+	}
+}
+
+func TestEmptyCapabilitiesAssignsToAnyAgent(t *testing.T) {
+	// Same as nil but with explicit empty slice.
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"scout": {Name: "scout", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "scout", Slug: "scout", Capabilities: []string{"research", "code"}},
+	}}
+
+	cfg := testConfig()
+	cfg.Assignment.OwnerFilterEnabled = false
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "empty-cap task",
+		RequiredCapabilities: []string{}, // explicit empty
+		Priority:             3,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Errorf("expected assigned for empty capabilities, got %s", updated.Status)
+	}
+	if updated.AssignedAgent != "scout" {
+		t.Errorf("expected scout, got %s", updated.AssignedAgent)
+	}
+}
+
+func TestNilCapabilitiesWithOwnerFilter(t *testing.T) {
+	// Nil capabilities + owner filter should still scope to owned agents.
+	ms := newMockStore()
+	mh := &mockHermes{}
+	ownerID := uuid.New().String()
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+		"nova": {Name: "nova", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+		{Name: "nova", Slug: "nova", Capabilities: []string{"code"}},
+	}}
+	ma := &mockAlexandria{devices: []alexandria.Device{
+		{ID: "d1", Name: "nova", OwnerID: ownerID},
+	}}
+
+	cfg := testConfig()
+	cfg.Assignment.OwnerFilterEnabled = true
+	b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                ownerID,
+		Title:                "nil-cap owner-scoped",
+		RequiredCapabilities: nil,
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Errorf("expected assigned, got %s", updated.Status)
+	}
+	if updated.AssignedAgent != "nova" {
+		t.Errorf("expected nova (owner-scoped), got %s", updated.AssignedAgent)
+	}
+}
+
+func TestBrokerAssignmentV2Scoring(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+		"nova": {Name: "nova", Status: "sleeping", Policy: "on-demand"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research", "analysis"}},
+		{Name: "nova", Slug: "nova", Capabilities: []string{"research", "code"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "v2 scoring test",
+		RequiredCapabilities: []string{"research"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Errorf("expected assigned, got %s", updated.Status)
+	}
+	// Scoring v2 fields should be populated
+	if updated.ScoringVersion != 2 {
+		t.Errorf("expected scoring_version=2, got %d", updated.ScoringVersion)
+	}
+	if updated.ScoringFactors == nil {
+		t.Error("expected scoring_factors to be set")
+	}
+	if updated.OversightLevel == "" {
+		t.Error("expected oversight_level to be set")
+	}
+	// Verify total_score is in the factors
+	if _, ok := updated.ScoringFactors["total_score"]; !ok {
+		t.Error("expected total_score key in scoring_factors")
+	}
+}
+
+func TestFastPathAssignment(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	// Task with low complexity, low risk, high reversibility → fast path eligible
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "fast path test",
+		RequiredCapabilities: []string{"research"},
+		Priority:             1,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+		Metadata: map[string]interface{}{
+			"complexity":    0.1,
+			"risk":          0.1,
+			"reversibility": 0.9,
+		},
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Errorf("expected assigned, got %s", updated.Status)
+	}
+	if !updated.FastPath {
+		t.Error("expected fast_path=true for low-risk simple task")
+	}
+}
+
+func TestTrustScoreLookup(t *testing.T) {
+	ms := newMockStore()
+	ms.trustScores = map[string]float64{
+		"lily||": 0.85, // default category/severity
+	}
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	// Set low risk + high verifiability/reversibility so trust is the deciding factor
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "trust lookup test",
+		RequiredCapabilities: []string{"research"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+		Metadata: map[string]interface{}{
+			"risk":          0.1,
+			"verifiability": 0.9,
+			"reversibility": 0.9,
+		},
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Fatalf("expected assigned, got %s", updated.Status)
+	}
+	if updated.OversightLevel == "" {
+		t.Error("expected oversight_level to be set")
+	}
+	// oversight = 0.1*0.35 + 0.1*0.25 + 0.1*0.25 + 0.15*0.15 = 0.035+0.025+0.025+0.0225 = 0.1075 → autonomous
+	if updated.OversightLevel != "autonomous" {
+		t.Errorf("expected autonomous with high trust + low risk, got %s", updated.OversightLevel)
+	}
+}
+
+func TestTrustScoreLookupByCategorySeverity(t *testing.T) {
+	ms := newMockStore()
+	ms.trustScores = map[string]float64{
+		"lily|deploy|critical": 0.3, // low trust for critical deploys
+	}
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"deploy"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "critical deploy",
+		RequiredCapabilities: []string{"deploy"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+		Metadata: map[string]interface{}{
+			"category": "deploy",
+			"severity": "critical",
+		},
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Fatalf("expected assigned, got %s", updated.Status)
+	}
+	// Low trust (0.3) should produce higher oversight
+	if updated.OversightLevel == "autonomous" {
+		t.Error("expected non-autonomous oversight with low trust score")
+	}
+}
+
+func TestTrustScoreMetadataOverridesDB(t *testing.T) {
+	ms := newMockStore()
+	ms.trustScores = map[string]float64{
+		"lily||": 0.2, // low trust in DB — should be ignored
+	}
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "metadata trust override",
+		RequiredCapabilities: []string{"research"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+		Metadata: map[string]interface{}{
+			"trust_level":   0.95, // high trust via metadata — takes precedence
+			"risk":          0.1,
+			"verifiability": 0.9,
+			"reversibility": 0.9,
+		},
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Fatalf("expected assigned, got %s", updated.Status)
+	}
+	// oversight = 0.1*0.35 + 0.1*0.25 + 0.1*0.25 + 0.05*0.15 = 0.035+0.025+0.025+0.0075 = 0.0925 → autonomous
+	// Metadata trust (0.95) should take precedence over DB trust (0.2)
+	if updated.OversightLevel != "autonomous" {
+		t.Errorf("expected autonomous with high metadata trust, got %s", updated.OversightLevel)
+	}
+}
+
+func TestTrustScoreZeroUsesDefault(t *testing.T) {
+	// No trust score in DB → AgentTrustLevel stays nil → default 0.5 used by scorer
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "no trust score",
+		RequiredCapabilities: []string{"research"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Fatalf("expected assigned, got %s", updated.Status)
+	}
+	// With default trust (0.5) and default risk/verifiability/reversibility (all 0.5),
+	// oversight should land in the middle range
+	if updated.OversightLevel == "" {
+		t.Error("expected oversight_level to be set even without trust score")
+	}
+}
+
+func TestBrokerAssignmentPopulatesModelRouting(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "model routing test",
+		RequiredCapabilities: []string{"research"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Fatalf("expected assigned, got %s", updated.Status)
+	}
+	if updated.ModelTier == "" {
+		t.Error("expected model_tier to be set after assignment")
+	}
+	if updated.RecommendedModel == "" {
+		t.Error("expected recommended_model to be set after assignment")
+	}
+	if updated.RoutingMethod != "cold_start" {
+		t.Errorf("expected routing_method 'cold_start', got '%s'", updated.RoutingMethod)
+	}
+	if updated.Runtime == "" {
+		t.Error("expected runtime to be set after assignment")
+	}
+}
+
+func TestBrokerAssignmentModelRoutingWithLabels(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"config"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "config update",
+		RequiredCapabilities: []string{"config"},
+		Priority:             1,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+		Labels:               []string{"config"},
+		FilePatterns:         []string{"app.yaml"},
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Fatalf("expected assigned, got %s", updated.Status)
+	}
+	if updated.ModelTier != "economy" {
+		t.Errorf("expected economy tier for config task, got '%s'", updated.ModelTier)
+	}
+	if updated.Runtime != "openclaw" {
+		t.Errorf("expected openclaw runtime for economy tier (picoclaw disabled), got '%s'", updated.Runtime)
+	}
+}
+
+func TestBrokerAssignmentOneWayDoorForcesPremium(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"config"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "irreversible deploy",
+		RequiredCapabilities: []string{"config"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+		Labels:               []string{"config"},
+		FilePatterns:         []string{"app.yaml"},
+		OneWayDoor:           true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	updated := ms.tasks[task.ID]
+	if updated.Status != store.StatusAssigned {
+		t.Fatalf("expected assigned, got %s", updated.Status)
+	}
+	if updated.ModelTier != "premium" {
+		t.Errorf("expected premium tier for one_way_door task, got '%s'", updated.ModelTier)
+	}
+	if updated.Runtime != "openclaw" {
+		t.Errorf("expected openclaw runtime for premium tier, got '%s'", updated.Runtime)
+	}
+}
+
+func TestBrokerAssignmentModelRoutingEvent(t *testing.T) {
+	ms := newMockStore()
+	mh := &mockHermes{}
+	mw := &mockWarren{states: map[string]*warren.AgentState{
+		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
+	}}
+	mf := &mockForge{personas: []forge.Persona{
+		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
+	}}
+
+	cfg := testConfig()
+	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+
+	ctx := context.Background()
+	task := &store.Task{
+		Owner:                "system",
+		Title:                "event check",
+		RequiredCapabilities: []string{"research"},
+		Priority:             5,
+		Status:               store.StatusPending,
+		TimeoutSeconds:       60,
+		Source:               "manual",
+		RetryEligible:        true,
+	}
+	_ = ms.CreateTask(ctx, task)
+
+	b.processPendingTasks(ctx)
+
+	// Find the dispatch assigned event
+	var dispatchEvt *hermes.DispatchAssignedEvent
+	for _, p := range mh.published {
+		if evt, ok := p.data.(hermes.DispatchAssignedEvent); ok {
+			dispatchEvt = &evt
+			break
+		}
+	}
+	if dispatchEvt == nil {
+		t.Fatal("expected DispatchAssignedEvent to be published")
+	}
+	if dispatchEvt.ModelTier == "" {
+		t.Error("expected model_tier in DispatchAssignedEvent")
+	}
+	if dispatchEvt.RecommendedModel == "" {
+		t.Error("expected recommended_model in DispatchAssignedEvent")
+	}
+	if dispatchEvt.RoutingMethod != "cold_start" {
+		t.Errorf("expected routing_method 'cold_start' in event, got '%s'", dispatchEvt.RoutingMethod)
+	}
+	if dispatchEvt.Runtime == "" {
+		t.Error("expected runtime in DispatchAssignedEvent")
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// Stage engine stubs (satisfy store.Store interface)
+func (m *mockStore) InitStages(_ context.Context, _ uuid.UUID, _ []string) error { return nil }
+func (m *mockStore) GetCurrentStage(_ context.Context, _ uuid.UUID) (string, int, error) { return "", 0, nil }
+func (m *mockStore) CreateGateCriteria(_ context.Context, _ uuid.UUID, _ string, _ []string) error { return nil }
+func (m *mockStore) SatisfyCriterion(_ context.Context, _ uuid.UUID, stage, criterion, _ string) error {
+	for i := range m.gates[stage] {
+		if m.gates[stage][i].Criterion == criterion {
+			m.gates[stage][i].Satisfied = true
+		}
+	}
+	return nil
+}
+func (m *mockStore) SatisfyAllCriteria(_ context.Context, _ uuid.UUID, _, _ string) error { return nil }
+func (m *mockStore) GetGateStatus(_ context.Context, _ uuid.UUID, stage string) ([]store.GateCriterion, error) {
+	return append([]store.GateCriterion(nil), m.gates[stage]...), nil
+}
+func (m *mockStore) AllCriteriaMet(_ context.Context, _ uuid.UUID, stage string) (bool, error) {
+	for _, c := range m.gates[stage] {
+		if !c.Satisfied {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Add missing autonomy methods to broker mockStore
+func (m *mockStore) GetAutonomyConfig(ctx context.Context, tier string) (*store.AutonomyConfig, error) { return m.autonomy[tier], nil }
+func (m *mockStore) UpdateAutonomyConfig(ctx context.Context, tier string, autoApprove bool, consecutiveApprovals, consecutiveCorrections int) error { return nil }
+func (m *mockStore) IncrementConsecutiveApprovals(ctx context.Context, tier string) (int, error) { return 0, nil }
+func (m *mockStore) IncrementConsecutiveCorrections(ctx context.Context, tier string) (int, error) { return 0, nil }
+func (m *mockStore) ResetAutonomyCounters(ctx context.Context, tier string) error { return nil }
+func (m *mockStore) SubmitEvidence(ctx context.Context, itemID uuid.UUID, stage, criterion, evidence, submittedBy string) error {
+	for i := range m.gates[stage] {
+		if m.gates[stage][i].Criterion == criterion {
+			m.gates[stage][i].Evidence = evidence
+		}
+	}
+	return nil
+}
+func (m *mockStore) ResetStageToActive(ctx context.Context, itemID uuid.UUID, stage string) error { return nil }
+
+
+// countingWarren wraps mockWarren and counts state lookups.
+type countingWarren struct {
+	mockWarren
+	calls atomic.Int64
+}
+
+func (c *countingWarren) GetAgentState(ctx context.Context, id string) (*warren.AgentState, error) {
+	c.calls.Add(1)
+	return c.mockWarren.GetAgentState(ctx, id)
+}
+
+func newSnapshotTestBroker(agents, tasks int) (*Broker, *mockStore, *countingWarren) {
+	ms := newMockStore()
+	cw := &countingWarren{mockWarren: mockWarren{states: map[string]*warren.AgentState{}}}
+	mf := &mockForge{}
+	for i := 0; i < agents; i++ {
+		name := fmt.Sprintf("agent-%02d", i)
+		cw.states[name] = &warren.AgentState{Name: name, Status: "ready", Policy: "always-on"}
+		mf.personas = append(mf.personas, forge.Persona{Name: name, Slug: name, Capabilities: []string{"research"}})
+	}
+	cfg := testConfig()
+	cfg.Assignment.MaxConcurrentPerAgent = tasks + 1
+	cfg.Assignment.MaxParallelEvaluations = 8
+	b := New(ms, &mockHermes{}, cw, mf, nil, cfg, discardLogger())
+	for i := 0; i < tasks; i++ {
+		_ = ms.CreateTask(context.Background(), &store.Task{
+			Owner:                "system",
+			Title:                fmt.Sprintf("task %d", i),
+			RequiredCapabilities: []string{"research"},
+			Status:               store.StatusPending,
+			TimeoutSeconds:       300,
+			MaxRetries:           3,
+		})
+	}
+	return b, ms, cw
+}
+
+func TestTickSnapshotQueriesWarrenOncePerAgent(t *testing.T) {
+	b, ms, cw := newSnapshotTestBroker(5, 20)
+
+	b.processPendingTasks(context.Background())
+
+	if got := cw.calls.Load(); got != 5 {
+		t.Errorf("expected one state lookup per agent, got %d", got)
+	}
+	for _, task := range ms.tasks {
+		if task.Status != store.StatusAssigned {
+			t.Errorf("expected all tasks assigned, %s is %s", task.Title, task.Status)
+		}
+	}
+}
+
+func TestTickSnapshotSpreadsLoadWithinTick(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(3, 6)
+
+	b.processPendingTasks(context.Background())
+
+	perAgent := map[string]int{}
+	for _, task := range ms.tasks {
+		perAgent[task.AssignedAgent]++
+	}
+	for agent, n := range perAgent {
+		if n != 2 {
+			t.Errorf("expected load spread evenly, %s got %d tasks (%v)", agent, n, perAgent)
+		}
+	}
+}
+
+func BenchmarkProcessPendingTasks(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		br, _, _ := newSnapshotTestBroker(30, 200)
+		b.StartTimer()
+		br.processPendingTasks(context.Background())
+	}
+}
+
+type batchScenarioTask struct {
+	title    string
+	cap      string
+	priority int
+}
 
+// newBatchScenario builds a broker with ready agents holding capacity 1 and
+// the given pending tasks, returned in the order greedy would process them.
+func newBatchScenario(personas []forge.Persona, specs []batchScenarioTask) (*Broker, []*store.Task) {
+	ms := newMockStore()
+	mw := &mockWarren{states: map[string]*warren.AgentState{}}
+	for _, p := range personas {
+		mw.states[p.Slug] = &warren.AgentState{Name: p.Name, Status: "ready", Policy: "always-on"}
+	}
 	cfg := testConfig()
-	cfg.Assignment.OwnerFilterEnabled = false
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+	cfg.Assignment.MaxConcurrentPerAgent = 1
+	b := New(ms, &mockHermes{}, mw, &mockForge{personas: personas}, nil, cfg, discardLogger())
+
+	var tasks []*store.Task
+	for _, spec := range specs {
+		task := &store.Task{
+			Owner:                "system",
+			Title:                spec.title,
+			RequiredCapabilities: []string{spec.cap},
+			Priority:             spec.priority,
+			Status:               store.StatusPending,
+			TimeoutSeconds:       300,
+			MaxRetries:           3,
+		}
+		_ = ms.CreateTask(context.Background(), task)
+		tasks = append(tasks, task)
+	}
+	return b, tasks
+}
+
+func runGreedy(b *Broker, tasks []*store.Task) {
+	ctx := context.Background()
+	snap, _ := b.loadSnapshot(ctx)
+	for _, task := range tasks {
+		_ = b.assignWithSnapshot(ctx, snap, task)
+	}
+}
+
+func runBatch(b *Broker, tasks []*store.Task) {
+	ctx := context.Background()
+	snap, _ := b.loadSnapshot(ctx)
+	b.assignBatch(ctx, snap, tasks)
+}
+
+// totalUtility sums priority-weighted scores of the assigned tasks.
+func totalUtility(tasks []*store.Task) float64 {
+	total := 0.0
+	for _, task := range tasks {
+		if task.Status != store.StatusAssigned {
+			continue
+		}
+		score, _ := task.ScoringFactors["total_score"].(float64)
+		total += score * scoring.PriorityWeight(task.Priority)
+	}
+	return total
+}
+
+func TestBatchAssignmentKeepsSpecialistForTaskThatNeedsIt(t *testing.T) {
+	personas := []forge.Persona{
+		{Name: "specialist", Slug: "specialist", Capabilities: []string{"research", "code"}},
+		{Name: "generalist", Slug: "generalist", Capabilities: []string{"research"}},
+	}
+	specs := []batchScenarioTask{
+		{title: "research", cap: "research", priority: 5},
+		{title: "code", cap: "code", priority: 1},
+	}
+
+	gb, greedyTasks := newBatchScenario(personas, specs)
+	runGreedy(gb, greedyTasks)
+	bb, batchTasks := newBatchScenario(personas, specs)
+	runBatch(bb, batchTasks)
+
+	if greedyTasks[1].Status != store.StatusPending {
+		t.Fatalf("expected greedy to strand the code task, got %s", greedyTasks[1].Status)
+	}
+	if batchTasks[0].AssignedAgent != "generalist" || batchTasks[1].AssignedAgent != "specialist" {
+		t.Errorf("expected research→generalist and code→specialist, got %s and %s",
+			batchTasks[0].AssignedAgent, batchTasks[1].AssignedAgent)
+	}
+	greedy, batch := totalUtility(greedyTasks), totalUtility(batchTasks)
+	if batch <= greedy {
+		t.Errorf("expected batch utility %f to beat greedy %f", batch, greedy)
+	}
+}
+
+func TestBatchAssignmentFavoursHigherPriority(t *testing.T) {
+	personas := []forge.Persona{
+		{Name: "solo", Slug: "solo", Capabilities: []string{"research"}},
+	}
+	// Greedy takes tasks in the order given, so the low-priority task
+	// claims the only agent.
+	specs := []batchScenarioTask{
+		{title: "low", cap: "research", priority: 1},
+		{title: "high", cap: "research", priority: 9},
+	}
+
+	gb, greedyTasks := newBatchScenario(personas, specs)
+	runGreedy(gb, greedyTasks)
+	bb, batchTasks := newBatchScenario(personas, specs)
+	runBatch(bb, batchTasks)
+
+	if batchTasks[1].Status != store.StatusAssigned || batchTasks[0].Status != store.StatusPending {
+		t.Errorf("expected only the high-priority task assigned, got low=%s high=%s",
+			batchTasks[0].Status, batchTasks[1].Status)
+	}
+	greedy, batch := totalUtility(greedyTasks), totalUtility(batchTasks)
+	if batch <= greedy {
+		t.Errorf("expected batch utility %f to beat greedy %f", batch, greedy)
+	}
+}
+
+func TestBatchAssignmentRespectsCapacity(t *testing.T) {
+	personas := []forge.Persona{
+		{Name: "a", Slug: "a", Capabilities: []string{"research"}},
+		{Name: "b", Slug: "b", Capabilities: []string{"research"}},
+	}
+	var specs []batchScenarioTask
+	for i := 0; i < 5; i++ {
+		specs = append(specs, batchScenarioTask{title: fmt.Sprintf("t%d", i), cap: "research"})
+	}
+	bb, tasks := newBatchScenario(personas, specs)
+	bb.cfg.Assignment.MaxConcurrentPerAgent = 2
+	runBatch(bb, tasks)
+
+	perAgent := map[string]int{}
+	for _, task := range tasks {
+		if task.Status == store.StatusAssigned {
+			perAgent[task.AssignedAgent]++
+		}
+	}
+	if perAgent["a"] != 2 || perAgent["b"] != 2 {
+		t.Errorf("expected two tasks per agent, got %v", perAgent)
+	}
+}
+
+func TestProcessPendingTasksUsesBatchMode(t *testing.T) {
+	personas := []forge.Persona{
+		{Name: "specialist", Slug: "specialist", Capabilities: []string{"research", "code"}},
+		{Name: "generalist", Slug: "generalist", Capabilities: []string{"research"}},
+	}
+	bb, tasks := newBatchScenario(personas, []batchScenarioTask{
+		{title: "research", cap: "research", priority: 5},
+		{title: "code", cap: "code", priority: 1},
+	})
+	bb.cfg.Assignment.Mode = config.AssignmentModeBatch
+
+	bb.processPendingTasks(context.Background())
+
+	for _, task := range tasks {
+		if task.Status != store.StatusAssigned {
+			t.Errorf("expected %s assigned in batch mode, got %s", task.Title, task.Status)
+		}
+	}
+}
+
+func fairShareConfig() config.FairShareConfig {
+	return config.FairShareConfig{Enabled: true, Key: config.FairShareByOwner}
+}
+
+func TestFairShareInterleavesOwners(t *testing.T) {
+	base := time.Now().Add(-time.Minute)
+	var tasks []*store.Task
+	// Owner "flood" queued ten high-priority tasks before "quiet" queued two.
+	for i := 0; i < 10; i++ {
+		tasks = append(tasks, &store.Task{Title: fmt.Sprintf("flood-%d", i), Owner: "flood", Priority: 9, CreatedAt: base.Add(time.Duration(i) * time.Second)})
+	}
+	for i := 0; i < 2; i++ {
+		tasks = append(tasks, &store.Task{Title: fmt.Sprintf("quiet-%d", i), Owner: "quiet", Priority: 1, CreatedAt: base.Add(time.Duration(20+i) * time.Second)})
+	}
+
+	ordered := newFairShare(fairShareConfig(), nil).order(tasks, time.Now(), nil)
+
+	var owners []string
+	for _, task := range ordered[:4] {
+		owners = append(owners, task.Owner)
+	}
+	want := []string{"flood", "quiet", "flood", "quiet"}
+	for i := range want {
+		if owners[i] != want[i] {
+			t.Fatalf("expected owners to alternate %v, got %v", want, owners)
+		}
+	}
+}
+
+func TestFairShareWeightsAndInFlight(t *testing.T) {
+	cfg := fairShareConfig()
+	cfg.Weights = map[string]float64{"heavy": 2}
+	// "heavy" already has two tasks running, which uses up its head start.
+	active := []*store.Task{{Owner: "heavy"}, {Owner: "heavy"}}
+	base := time.Now()
+	var tasks []*store.Task
+	for i := 0; i < 4; i++ {
+		tasks = append(tasks,
+			&store.Task{Title: fmt.Sprintf("heavy-%d", i), Owner: "heavy", CreatedAt: base.Add(time.Duration(i) * time.Millisecond)},
+			&store.Task{Title: fmt.Sprintf("light-%d", i), Owner: "light", CreatedAt: base.Add(time.Duration(i) * time.Millisecond)},
+		)
+	}
+
+	ordered := newFairShare(cfg, active).order(tasks, base, nil)
+
+	// Finish tags: heavy = 1.5, 2, 2.5, 3; light = 1, 2, 3, 4. Ties go to
+	// the older task.
+	var titles []string
+	for _, task := range ordered {
+		titles = append(titles, task.Title)
+	}
+	want := []string{"light-0", "heavy-0", "heavy-1", "light-1", "heavy-2", "light-2", "heavy-3", "light-3"}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, titles)
+		}
+	}
+}
+
+func TestFairShareAgingLiftsOldTasks(t *testing.T) {
+	cfg := fairShareConfig()
+	cfg.AgingIntervalMs = 60000
+	now := time.Now()
+	old := &store.Task{Title: "old", Owner: "a", Priority: 1, CreatedAt: now.Add(-10 * time.Minute)}
+	fresh := &store.Task{Title: "fresh", Owner: "a", Priority: 8, CreatedAt: now.Add(-time.Minute)}
+
+	if got := effectivePriority(old, cfg.AgingInterval(), now); got != 10 {
+		t.Errorf("expected old task aged to 10, got %d", got)
+	}
+	ordered := newFairShare(cfg, nil).order([]*store.Task{fresh, old}, now, nil)
+	if ordered[0] != old {
+		t.Errorf("expected aged task first, got %s", ordered[0].Title)
+	}
+
+	cfg.AgingIntervalMs = 0
+	ordered = newFairShare(cfg, nil).order([]*store.Task{old, fresh}, now, nil)
+	if ordered[0] != fresh {
+		t.Errorf("expected priority order without aging, got %s first", ordered[0].Title)
+	}
+}
+
+func TestFairShareCapsInFlightPerOwner(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(5, 0)
+	b.cfg.Assignment.FairShare = fairShareConfig()
+	b.cfg.Assignment.FairShare.MaxInFlight = map[string]int{"flood": 2}
+
+	for i := 0; i < 6; i++ {
+		_ = ms.CreateTask(context.Background(), &store.Task{
+			Owner:                "flood",
+			Title:                fmt.Sprintf("flood-%d", i),
+			RequiredCapabilities: []string{"research"},
+			Status:               store.StatusPending,
+			TimeoutSeconds:       300,
+		})
+	}
+	_ = ms.CreateTask(context.Background(), &store.Task{
+		Owner:                "quiet",
+		Title:                "quiet",
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusPending,
+		TimeoutSeconds:       300,
+	})
+
+	b.processPendingTasks(context.Background())
+
+	assigned := map[string]int{}
+	for _, task := range ms.tasks {
+		if task.Status == store.StatusAssigned {
+			assigned[task.Owner]++
+		}
+	}
+	if assigned["flood"] != 2 || assigned["quiet"] != 1 {
+		t.Errorf("expected flood capped at 2 and quiet assigned, got %v", assigned)
+	}
+}
+
+func TestFairShareCapsBatchBeforeMatching(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	b.cfg.Assignment.Mode = config.AssignmentModeBatch
+	b.cfg.Assignment.MaxConcurrentPerAgent = 2
+	b.cfg.Assignment.FairShare = fairShareConfig()
+	b.cfg.Assignment.FairShare.MaxInFlight = map[string]int{"flood": 1}
+
+	// The flood tasks outweigh quiet for both slots, but only one may run.
+	for i := 0; i < 3; i++ {
+		_ = ms.CreateTask(context.Background(), &store.Task{
+			Owner:                "flood",
+			Title:                fmt.Sprintf("flood-%d", i),
+			RequiredCapabilities: []string{"research"},
+			Status:               store.StatusPending,
+			Priority:             9,
+			TimeoutSeconds:       300,
+		})
+	}
+	_ = ms.CreateTask(context.Background(), &store.Task{
+		Owner:                "quiet",
+		Title:                "quiet",
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusPending,
+		Priority:             1,
+		TimeoutSeconds:       300,
+	})
+
+	b.processPendingTasks(context.Background())
+
+	assigned := map[string]int{}
+	for _, task := range ms.tasks {
+		if task.Status == store.StatusAssigned {
+			assigned[task.Owner]++
+		}
+	}
+	if assigned["flood"] != 1 || assigned["quiet"] != 1 {
+		t.Errorf("expected one flood task and quiet to share the slots, got %v", assigned)
+	}
+}
+
+func intPtr(n int) *int           { return &n }
+func int64Ptr(n int64) *int64     { return &n }
+func floatPtr(f float64) *float64 { return &f }
+
+func countPublished(mh *mockHermes, prefix string) int {
+	n := 0
+	for _, p := range mh.published {
+		if strings.HasPrefix(p.subject, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestBudgetQueueHoldsTasksOverLimit(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(5, 0)
+	ctx := context.Background()
+	_ = ms.UpsertBudget(ctx, &store.Budget{
+		Scope:    store.BudgetScopeOwner,
+		Subject:  "flood",
+		Period:   store.BudgetPeriodDay,
+		MaxTasks: intPtr(2),
+		Action:   store.BudgetActionQueue,
+	})
+	for i := 0; i < 4; i++ {
+		_ = ms.CreateTask(ctx, &store.Task{
+			Owner:                "flood",
+			Title:                fmt.Sprintf("flood-%d", i),
+			RequiredCapabilities: []string{"research"},
+			Status:               store.StatusPending,
+			TimeoutSeconds:       300,
+		})
+	}
+	_ = ms.CreateTask(ctx, &store.Task{
+		Owner:                "quiet",
+		Title:                "quiet",
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusPending,
+		TimeoutSeconds:       300,
+	})
+
+	b.processPendingTasks(ctx)
+	b.processPendingTasks(ctx)
+
+	assigned := map[string]int{}
+	for _, task := range ms.tasks {
+		if task.Status == store.StatusAssigned {
+			assigned[task.Owner]++
+		}
+	}
+	if assigned["flood"] != 2 || assigned["quiet"] != 1 {
+		t.Errorf("expected flood held at 2 and quiet assigned, got %v", assigned)
+	}
+
+	mh := b.hermes.(*mockHermes)
+	if n := countPublished(mh, "swarm.budget."); n != 1 {
+		t.Errorf("expected one threshold event across ticks, got %d", n)
+	}
+	if ms.budgets[0].AlertedThreshold != 100 {
+		t.Errorf("expected alerted threshold 100, got %d", ms.budgets[0].AlertedThreshold)
+	}
+}
 
+func TestBudgetDowngradeRoutesToCheapestTier(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
 	ctx := context.Background()
+	_ = ms.UpsertBudget(ctx, &store.Budget{
+		Scope:      store.BudgetScopeSource,
+		Subject:    "kai",
+		Period:     store.BudgetPeriodMonth,
+		MaxCostUSD: floatPtr(10),
+		Action:     store.BudgetActionDowngrade,
+	})
+	ms.budgetSpend = map[string]store.BudgetUsage{"source|kai": {CostUSD: 10}}
 	task := &store.Task{
 		Owner:                "system",
-		Title:                "empty-cap task",
-		RequiredCapabilities: []string{}, // explicit empty
-		Priority:             3,
+		Source:               "kai",
+		Title:                "over budget",
+		RequiredCapabilities: []string{"research"},
 		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
+		TimeoutSeconds:       300,
 	}
 	_ = ms.CreateTask(ctx, task)
 
 	b.processPendingTasks(ctx)
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Errorf("expected assigned for empty capabilities, got %s", updated.Status)
+	if task.Status != store.StatusAssigned {
+		t.Fatalf("expected downgraded task to be assigned, got %s", task.Status)
 	}
-	if updated.AssignedAgent != "scout" {
-		t.Errorf("expected scout, got %s", updated.AssignedAgent)
+	if task.ModelTier != "economy" || task.RoutingMethod != "budget" {
+		t.Errorf("expected economy tier via budget routing, got %s/%s", task.ModelTier, task.RoutingMethod)
 	}
 }
 
-func TestNilCapabilitiesWithOwnerFilter(t *testing.T) {
-	// Nil capabilities + owner filter should still scope to owned agents.
-	ms := newMockStore()
-	mh := &mockHermes{}
-	ownerID := uuid.New().String()
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-		"nova": {Name: "nova", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-		{Name: "nova", Slug: "nova", Capabilities: []string{"code"}},
-	}}
-	ma := &mockAlexandria{devices: []alexandria.Device{
-		{ID: "d1", Name: "nova", OwnerID: ownerID},
-	}}
+func TestBudgetWarnThresholdPublishedOnce(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	ctx := context.Background()
+	_ = ms.UpsertBudget(ctx, &store.Budget{
+		Scope:     store.BudgetScopeOwner,
+		Subject:   "system",
+		Period:    store.BudgetPeriodDay,
+		MaxTokens: int64Ptr(1000),
+		Action:    store.BudgetActionReject,
+	})
+	ms.budgetSpend = map[string]store.BudgetUsage{"owner|system": {Tokens: 850}}
 
-	cfg := testConfig()
-	cfg.Assignment.OwnerFilterEnabled = true
-	b := New(ms, mh, mw, mf, ma, cfg, discardLogger())
+	snap, err := b.loadSnapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := &store.Task{Owner: "system"}
+	for i := 0; i < 3; i++ {
+		if allowed, _ := b.checkBudget(ctx, snap, task); !allowed {
+			t.Fatal("expected task within budget")
+		}
+	}
+
+	mh := b.hermes.(*mockHermes)
+	if n := countPublished(mh, "swarm.budget."); n != 1 {
+		t.Fatalf("expected one threshold event, got %d", n)
+	}
+	evt := mh.published[0].data.(hermes.BudgetThresholdEvent)
+	if evt.Threshold != 80 || evt.Tokens != 850 {
+		t.Errorf("expected 80%% threshold at 850 tokens, got %d at %d", evt.Threshold, evt.Tokens)
+	}
+}
+
+func newPreemptionScenario(victimPreemptible bool) (*Broker, *mockStore, *store.Task, *store.Task) {
+	b, ms, cw := newSnapshotTestBroker(1, 0)
+	b.cfg.Assignment.MaxConcurrentPerAgent = 1
+	b.cfg.Assignment.Preemption = config.PreemptionConfig{Enabled: true, MinPriority: 10}
+	cw.states["agent-00"].Status = "busy"
 
 	ctx := context.Background()
-	task := &store.Task{
-		Owner:                ownerID,
-		Title:                "nil-cap owner-scoped",
-		RequiredCapabilities: nil,
-		Priority:             5,
+	assignedAt := time.Now().Add(-time.Minute)
+	victim := &store.Task{
+		Owner:                "system",
+		Title:                "background",
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusInProgress,
+		AssignedAgent:        "agent-00",
+		AssignedAt:           &assignedAt,
+		Priority:             2,
+		RetryCount:           1,
+		Preemptible:          victimPreemptible,
+		TimeoutSeconds:       300,
+	}
+	_ = ms.CreateTask(ctx, victim)
+	urgent := &store.Task{
+		Owner:                "system",
+		Title:                "urgent",
+		RequiredCapabilities: []string{"research"},
 		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
+		Priority:             10,
+		TimeoutSeconds:       300,
+	}
+	_ = ms.CreateTask(ctx, urgent)
+	return b, ms, victim, urgent
+}
+
+func TestPreemptionYieldsLowestPriorityTask(t *testing.T) {
+	b, ms, victim, urgent := newPreemptionScenario(true)
+
+	b.processPendingTasks(context.Background())
+
+	if urgent.Status != store.StatusAssigned || urgent.AssignedAgent != "agent-00" {
+		t.Fatalf("expected urgent task assigned to agent-00, got %s/%s", urgent.Status, urgent.AssignedAgent)
+	}
+	if victim.Status != store.StatusPending || victim.AssignedAgent != "" {
+		t.Errorf("expected victim requeued, got %s/%s", victim.Status, victim.AssignedAgent)
+	}
+	if victim.RetryCount != 1 {
+		t.Errorf("expected preemption not to consume a retry, got retry count %d", victim.RetryCount)
+	}
+	if n := countPublished(b.hermes.(*mockHermes), "swarm.task."+victim.ID.String()+".preempt"); n != 1 {
+		t.Errorf("expected one preempt event, got %d", n)
+	}
+
+	var preempted, preemption *store.TaskEvent
+	for _, e := range ms.events {
+		switch {
+		case e.Event == "preempted" && e.TaskID == victim.ID:
+			preempted = e
+		case e.Event == "preemption" && e.TaskID == urgent.ID:
+			preemption = e
+		}
+	}
+	if preempted == nil || preempted.Payload["preempted_by"] != urgent.ID.String() {
+		t.Errorf("expected preempted event on victim, got %+v", preempted)
+	}
+	if preemption == nil || preemption.Payload["preempted_task_id"] != victim.ID.String() {
+		t.Errorf("expected preemption event on urgent task, got %+v", preemption)
+	}
+}
+
+func TestPreemptionSkipsVictimFinishedDuringTick(t *testing.T) {
+	b, ms, victim, urgent := newPreemptionScenario(true)
+	agent := victim.AssignedAgent
+
+	// The victim completed after the tick's snapshot was taken.
+	finished := *victim
+	finished.Status = store.StatusCompleted
+	ms.tasks[victim.ID] = &finished
+
+	if err := b.yieldTask(context.Background(), victim, urgent); !errors.Is(err, store.ErrTaskChanged) {
+		t.Fatalf("expected the preemption refused, got %v", err)
+	}
+	if ms.tasks[victim.ID].Status != store.StatusCompleted {
+		t.Errorf("expected the finished task left completed, got %s", ms.tasks[victim.ID].Status)
+	}
+	if victim.AssignedAgent != agent {
+		t.Errorf("expected the snapshot copy restored, got agent %q", victim.AssignedAgent)
+	}
+	if n := countPublished(b.hermes.(*mockHermes), "swarm.task."+victim.ID.String()+".preempt"); n != 0 {
+		t.Errorf("expected no preempt event, got %d", n)
+	}
+}
+
+func TestPreemptionSkipsNonPreemptibleTasks(t *testing.T) {
+	b, _, victim, urgent := newPreemptionScenario(false)
+
+	b.processPendingTasks(context.Background())
+
+	if urgent.Status != store.StatusPending {
+		t.Errorf("expected urgent task to wait, got %s", urgent.Status)
+	}
+	if victim.Status != store.StatusInProgress {
+		t.Errorf("expected victim untouched, got %s", victim.Status)
+	}
+}
+
+func TestPreemptionRequiresMinPriority(t *testing.T) {
+	b, _, victim, urgent := newPreemptionScenario(true)
+	urgent.Priority = 9
+
+	b.processPendingTasks(context.Background())
+
+	if urgent.Status != store.StatusPending || victim.Status != store.StatusInProgress {
+		t.Errorf("expected no preemption below min priority, got urgent %s victim %s", urgent.Status, victim.Status)
+	}
+}
+
+func TestPreemptionDisabledByDefault(t *testing.T) {
+	b, _, victim, urgent := newPreemptionScenario(true)
+	b.cfg.Assignment.Preemption.Enabled = false
+
+	b.processPendingTasks(context.Background())
+
+	if urgent.Status != store.StatusPending || victim.Status != store.StatusInProgress {
+		t.Errorf("expected no preemption when disabled, got urgent %s victim %s", urgent.Status, victim.Status)
+	}
+}
+
+func TestPreemptionInBatchMode(t *testing.T) {
+	b, _, victim, urgent := newPreemptionScenario(true)
+	b.cfg.Assignment.Mode = config.AssignmentModeBatch
+
+	b.processPendingTasks(context.Background())
+
+	if urgent.AssignedAgent != "agent-00" || victim.Status != store.StatusPending {
+		t.Errorf("expected batch mode to preempt, got urgent %s/%s victim %s", urgent.Status, urgent.AssignedAgent, victim.Status)
+	}
+}
+
+func countAssigned(ms *mockStore) int {
+	n := 0
+	for _, task := range ms.tasks {
+		if task.Status == store.StatusAssigned {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPersonaMaxConcurrentCapsAgent(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 4)
+	b.forge.(*mockForge).personas[0].MaxConcurrent = 2
+
+	b.processPendingTasks(context.Background())
+	b.processPendingTasks(context.Background())
+
+	if n := countAssigned(ms); n != 2 {
+		t.Errorf("expected persona limit of 2 assignments, got %d", n)
+	}
+}
+
+func TestAgentLimitOverrideBeatsPersona(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 4)
+	b.forge.(*mockForge).personas[0].MaxConcurrent = 2
+	_ = ms.SetAgentLimit(context.Background(), &store.AgentLimit{AgentSlug: "agent-00", MaxConcurrent: 3})
+
+	b.processPendingTasks(context.Background())
+
+	if n := countAssigned(ms); n != 3 {
+		t.Errorf("expected override limit of 3 assignments, got %d", n)
+	}
+	limits, err := b.AgentLimits(context.Background())
+	if err != nil || limits["agent-00"] != 3 {
+		t.Errorf("expected resolved limit 3, got %v (%v)", limits, err)
+	}
+}
+
+func TestCapabilityLimitCapsSwarmWide(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(4, 0)
+	b.cfg.Assignment.CapabilityLimits = map[string]int{"Research": 2}
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_ = ms.CreateTask(ctx, &store.Task{
+			Owner:                "system",
+			Title:                fmt.Sprintf("deploy %d", i),
+			RequiredCapabilities: []string{"research"},
+			Status:               store.StatusPending,
+			TimeoutSeconds:       300,
+		})
 	}
-	_ = ms.CreateTask(ctx, task)
 
+	b.processPendingTasks(ctx)
 	b.processPendingTasks(ctx)
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Errorf("expected assigned, got %s", updated.Status)
+	if n := countAssigned(ms); n != 2 {
+		t.Errorf("expected capability limit of 2 assignments, got %d", n)
 	}
-	if updated.AssignedAgent != "nova" {
-		t.Errorf("expected nova (owner-scoped), got %s", updated.AssignedAgent)
+
+	b.cfg.Assignment.Mode = config.AssignmentModeBatch
+	b.processPendingTasks(ctx)
+	if n := countAssigned(ms); n != 2 {
+		t.Errorf("expected batch mode to respect capability limit, got %d", n)
 	}
 }
 
-func TestBrokerAssignmentV2Scoring(t *testing.T) {
-	ms := newMockStore()
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-		"nova": {Name: "nova", Status: "sleeping", Policy: "on-demand"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research", "analysis"}},
-		{Name: "nova", Slug: "nova", Capabilities: []string{"research", "code"}},
-	}}
-
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
-
+func TestHeavyTaskConsumesSeveralSlots(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	b.cfg.Assignment.MaxConcurrentPerAgent = 3
+	b.cfg.Assignment.Slots = config.SlotConfig{ByDurationClass: map[string]int{"long": 2}}
 	ctx := context.Background()
-	task := &store.Task{
+	heavy := &store.Task{
 		Owner:                "system",
-		Title:                "v2 scoring test",
+		Title:                "heavy",
 		RequiredCapabilities: []string{"research"},
+		DurationClass:        "long",
 		Priority:             5,
 		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
+		TimeoutSeconds:       300,
+	}
+	_ = ms.CreateTask(ctx, heavy)
+	for i := 0; i < 2; i++ {
+		_ = ms.CreateTask(ctx, &store.Task{
+			Owner:                "system",
+			Title:                fmt.Sprintf("light %d", i),
+			RequiredCapabilities: []string{"research"},
+			Status:               store.StatusPending,
+			TimeoutSeconds:       300,
+		})
 	}
-	_ = ms.CreateTask(ctx, task)
 
+	b.processPendingTasks(ctx)
 	b.processPendingTasks(ctx)
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Errorf("expected assigned, got %s", updated.Status)
+	if heavy.Status != store.StatusAssigned {
+		t.Fatalf("expected heavy task assigned, got %s", heavy.Status)
 	}
-	// Scoring v2 fields should be populated
-	if updated.ScoringVersion != 2 {
-		t.Errorf("expected scoring_version=2, got %d", updated.ScoringVersion)
+	if n := countAssigned(ms); n != 2 {
+		t.Errorf("expected heavy task plus one light task in 3 slots, got %d", n)
 	}
-	if updated.ScoringFactors == nil {
-		t.Error("expected scoring_factors to be set")
+}
+
+func newPlacementTask(ms *mockStore, title string, mutate func(*store.Task)) *store.Task {
+	task := &store.Task{
+		Owner:                "system",
+		Title:                title,
+		RequiredCapabilities: []string{"research"},
+		Status:               store.StatusPending,
+		TimeoutSeconds:       300,
 	}
-	if updated.OversightLevel == "" {
-		t.Error("expected oversight_level to be set")
+	mutate(task)
+	_ = ms.CreateTask(context.Background(), task)
+	return task
+}
+
+func TestRequiredAgentPinsTask(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(3, 0)
+	task := newPlacementTask(ms, "pinned", func(t *store.Task) { t.RequiredAgent = "agent-02" })
+
+	b.processPendingTasks(context.Background())
+
+	if task.AssignedAgent != "agent-02" {
+		t.Errorf("expected pinned agent-02, got %q", task.AssignedAgent)
 	}
-	// Verify total_score is in the factors
-	if _, ok := updated.ScoringFactors["total_score"]; !ok {
-		t.Error("expected total_score key in scoring_factors")
+}
+
+func TestRequiredAgentUnavailableHoldsTask(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(2, 0)
+	task := newPlacementTask(ms, "pinned", func(t *store.Task) { t.RequiredAgent = "agent-09" })
+
+	b.processPendingTasks(context.Background())
+
+	if task.Status != store.StatusPending {
+		t.Errorf("expected task held for missing agent, got %s on %q", task.Status, task.AssignedAgent)
 	}
 }
 
-func TestFastPathAssignment(t *testing.T) {
-	ms := newMockStore()
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-	}}
+func TestExcludedAgentsNeverAssigned(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(2, 0)
+	task := newPlacementTask(ms, "excluded", func(t *store.Task) { t.ExcludedAgents = []string{"agent-00"} })
 
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+	b.processPendingTasks(context.Background())
 
-	ctx := context.Background()
-	// Task with low complexity, low risk, high reversibility → fast path eligible
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "fast path test",
-		RequiredCapabilities: []string{"research"},
-		Priority:             1,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
-		Metadata: map[string]interface{}{
-			"complexity":    0.1,
-			"risk":          0.1,
-			"reversibility": 0.9,
-		},
+	if task.AssignedAgent != "agent-01" {
+		t.Errorf("expected agent-01, got %q", task.AssignedAgent)
 	}
-	_ = ms.CreateTask(ctx, task)
+	placement, _ := task.ScoringFactors["placement"].(map[string]interface{})
+	if placement == nil || placement["excluded"] == nil {
+		t.Errorf("expected placement recorded in scoring factors, got %v", task.ScoringFactors["placement"])
+	}
+}
 
-	b.processPendingTasks(ctx)
+func TestPreferredAgentBoostsScore(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(3, 0)
+	task := newPlacementTask(ms, "preferred", func(t *store.Task) { t.PreferredAgents = []string{"agent-02"} })
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Errorf("expected assigned, got %s", updated.Status)
+	b.processPendingTasks(context.Background())
+
+	if task.AssignedAgent != "agent-02" {
+		t.Errorf("expected preferred agent-02, got %q", task.AssignedAgent)
 	}
-	if !updated.FastPath {
-		t.Error("expected fast_path=true for low-risk simple task")
+	factor, _ := task.ScoringFactors["contextuality"].(map[string]interface{})
+	if factor["reason"] != "preferred agent" {
+		t.Errorf("expected contextuality reason 'preferred agent', got %v", factor["reason"])
 	}
 }
 
-func TestTrustScoreLookup(t *testing.T) {
-	ms := newMockStore()
-	ms.trustScores = map[string]float64{
-		"lily||": 0.85, // default category/severity
+func TestAffinityGroupFollowsPreviousAgent(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(3, 0)
+	earlier := time.Now().Add(-time.Hour)
+	newPlacementTask(ms, "step 1", func(t *store.Task) {
+		t.AffinityGroup = "pr-42"
+		t.Status = store.StatusCompleted
+		t.AssignedAgent = "agent-01"
+		t.AssignedAt = &earlier
+	})
+	task := newPlacementTask(ms, "step 2", func(t *store.Task) { t.AffinityGroup = "pr-42" })
+
+	b.processPendingTasks(context.Background())
+
+	if task.AssignedAgent != "agent-01" {
+		t.Errorf("expected affinity with agent-01, got %q", task.AssignedAgent)
 	}
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-	}}
+}
 
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+func TestReviewTaskAvoidsParentImplementer(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(2, 0)
+	parent := newPlacementTask(ms, "implement", func(t *store.Task) {
+		t.Status = store.StatusCompleted
+		t.AssignedAgent = "agent-00"
+	})
+	review := newPlacementTask(ms, "review", func(t *store.Task) {
+		t.Labels = []string{"review"}
+		t.ParentTaskID = &parent.ID
+		t.AffinityGroup = "pr-42"
+		t.PreferredAgents = []string{"agent-00"}
+	})
 
-	ctx := context.Background()
-	// Set low risk + high verifiability/reversibility so trust is the deciding factor
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "trust lookup test",
-		RequiredCapabilities: []string{"research"},
-		Priority:             5,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
-		Metadata: map[string]interface{}{
-			"risk":          0.1,
-			"verifiability": 0.9,
-			"reversibility": 0.9,
-		},
+	b.processPendingTasks(context.Background())
+
+	if review.AssignedAgent != "agent-01" {
+		t.Errorf("expected review to avoid implementer agent-00, got %q", review.AssignedAgent)
 	}
-	_ = ms.CreateTask(ctx, task)
+}
 
-	b.processPendingTasks(ctx)
+func newScheduleTestBroker(t *testing.T, overlap string) (*Broker, *mockStore, *store.Schedule) {
+	t.Helper()
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	b.cfg.Schedules = config.SchedulesConfig{MissedAfterMs: 300000, CatchUp: config.CatchUpLatest, MaxCatchUp: 10}
+	s := &store.Schedule{
+		Name:      "hourly-sync",
+		Cron:      "0 * * * *",
+		Template:  store.TaskTemplate{Title: "Sync", RequiredCapabilities: []string{"research"}},
+		Overlap:   overlap,
+		NextRunAt: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC),
+	}
+	_ = ms.CreateSchedule(context.Background(), s)
+	return b, ms, s
+}
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Fatalf("expected assigned, got %s", updated.Status)
+func scheduledTasks(ms *mockStore, s *store.Schedule) []*store.Task {
+	var out []*store.Task
+	for _, t := range ms.tasks {
+		if t.Source == s.Source() {
+			out = append(out, t)
+		}
 	}
-	if updated.OversightLevel == "" {
-		t.Error("expected oversight_level to be set")
+	return out
+}
+
+func TestScheduleCreatesTaskWhenDue(t *testing.T) {
+	b, ms, s := newScheduleTestBroker(t, store.OverlapSkip)
+	ctx := context.Background()
+
+	b.runSchedules(ctx, time.Date(2026, 3, 2, 9, 59, 0, 0, time.UTC))
+	if n := len(scheduledTasks(ms, s)); n != 0 {
+		t.Fatalf("expected no task before the run is due, got %d", n)
 	}
-	// oversight = 0.1*0.35 + 0.1*0.25 + 0.1*0.25 + 0.15*0.15 = 0.035+0.025+0.025+0.0225 = 0.1075 → autonomous
-	if updated.OversightLevel != "autonomous" {
-		t.Errorf("expected autonomous with high trust + low risk, got %s", updated.OversightLevel)
+
+	b.runSchedules(ctx, time.Date(2026, 3, 2, 10, 0, 30, 0, time.UTC))
+	tasks := scheduledTasks(ms, s)
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 scheduled task, got %d", len(tasks))
+	}
+	if tasks[0].Metadata["schedule_id"] != s.ID.String() || tasks[0].Metadata["scheduled_for"] != "2026-03-02T10:00:00Z" {
+		t.Errorf("unexpected metadata: %v", tasks[0].Metadata)
+	}
+	if s.LastTaskID == nil || *s.LastTaskID != tasks[0].ID {
+		t.Error("expected schedule to record its last task")
+	}
+	if want := time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC); !s.NextRunAt.Equal(want) {
+		t.Errorf("expected next run %s, got %s", want, s.NextRunAt)
+	}
+	if n := countPublished(b.hermes.(*mockHermes), "swarm.task."+tasks[0].ID.String()+".created"); n != 1 {
+		t.Errorf("expected a created event, got %d", n)
 	}
 }
 
-func TestTrustScoreLookupByCategorySeverity(t *testing.T) {
-	ms := newMockStore()
-	ms.trustScores = map[string]float64{
-		"lily|deploy|critical": 0.3, // low trust for critical deploys
+func TestScheduleOverlapPolicies(t *testing.T) {
+	cases := []struct {
+		overlap string
+		tasks   int // after the second run falls due with the first still running
+		skipped int
+		queued  bool
+	}{
+		{store.OverlapSkip, 1, 1, false},
+		{store.OverlapQueue, 1, 0, true},
+		{store.OverlapAllow, 2, 0, false},
 	}
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"deploy"}},
-	}}
+	for _, tc := range cases {
+		b, ms, s := newScheduleTestBroker(t, tc.overlap)
+		ctx := context.Background()
 
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+		b.runSchedules(ctx, time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+		b.runSchedules(ctx, time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC))
 
-	ctx := context.Background()
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "critical deploy",
-		RequiredCapabilities: []string{"deploy"},
-		Priority:             5,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
-		Metadata: map[string]interface{}{
-			"category": "deploy",
-			"severity": "critical",
-		},
+		if n := len(scheduledTasks(ms, s)); n != tc.tasks {
+			t.Errorf("%s: expected %d tasks, got %d", tc.overlap, tc.tasks, n)
+		}
+		if s.SkippedRuns != tc.skipped {
+			t.Errorf("%s: expected %d skipped runs, got %d", tc.overlap, tc.skipped, s.SkippedRuns)
+		}
+		if (s.QueuedRunAt != nil) != tc.queued {
+			t.Errorf("%s: expected queued=%v, got %v", tc.overlap, tc.queued, s.QueuedRunAt)
+		}
 	}
-	_ = ms.CreateTask(ctx, task)
+}
 
-	b.processPendingTasks(ctx)
+func TestScheduleQueuedRunStartsWhenPreviousFinishes(t *testing.T) {
+	b, ms, s := newScheduleTestBroker(t, store.OverlapQueue)
+	ctx := context.Background()
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Fatalf("expected assigned, got %s", updated.Status)
+	b.runSchedules(ctx, time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	b.runSchedules(ctx, time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC))
+	ms.tasks[*s.LastTaskID].Status = store.StatusCompleted
+
+	b.runSchedules(ctx, time.Date(2026, 3, 2, 11, 0, 15, 0, time.UTC))
+	tasks := scheduledTasks(ms, s)
+	if len(tasks) != 2 {
+		t.Fatalf("expected the queued run to start, got %d tasks", len(tasks))
 	}
-	// Low trust (0.3) should produce higher oversight
-	if updated.OversightLevel == "autonomous" {
-		t.Error("expected non-autonomous oversight with low trust score")
+	if s.QueuedRunAt != nil {
+		t.Error("expected the queue to be empty")
+	}
+	if last := ms.tasks[*s.LastTaskID]; last.Metadata["scheduled_for"] != "2026-03-02T11:00:00Z" {
+		t.Errorf("expected the queued 11:00 run, got %v", last.Metadata["scheduled_for"])
 	}
 }
 
-func TestTrustScoreMetadataOverridesDB(t *testing.T) {
-	ms := newMockStore()
-	ms.trustScores = map[string]float64{
-		"lily||": 0.2, // low trust in DB — should be ignored
+func TestScheduleCatchUpAfterDowntime(t *testing.T) {
+	b, ms, s := newScheduleTestBroker(t, store.OverlapAllow)
+	ctx := context.Background()
+
+	// Down from 10:00 until 14:30: of five late runs only the latest runs.
+	b.runSchedules(ctx, time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC))
+	tasks := scheduledTasks(ms, s)
+	if len(tasks) != 1 || tasks[0].Metadata["scheduled_for"] != "2026-03-02T14:00:00Z" {
+		t.Fatalf("expected only the latest missed run, got %d tasks", len(tasks))
 	}
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-	}}
+	if s.MissedRuns != 4 {
+		t.Errorf("expected 4 missed runs, got %d", s.MissedRuns)
+	}
+}
 
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+func TestPausedScheduleDoesNotRun(t *testing.T) {
+	b, ms, s := newScheduleTestBroker(t, store.OverlapSkip)
+	s.Paused = true
 
-	ctx := context.Background()
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "metadata trust override",
-		RequiredCapabilities: []string{"research"},
-		Priority:             5,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
-		Metadata: map[string]interface{}{
-			"trust_level":   0.95, // high trust via metadata — takes precedence
-			"risk":          0.1,
-			"verifiability": 0.9,
-			"reversibility": 0.9,
-		},
+	b.runSchedules(context.Background(), time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	if n := len(scheduledTasks(ms, s)); n != 0 {
+		t.Errorf("expected paused schedule not to run, got %d tasks", n)
 	}
-	_ = ms.CreateTask(ctx, task)
+}
 
-	b.processPendingTasks(ctx)
+func TestScheduleRunKeepsConcurrentPause(t *testing.T) {
+	b, ms, s := newScheduleTestBroker(t, store.OverlapSkip)
+	ctx := context.Background()
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Fatalf("expected assigned, got %s", updated.Status)
+	// The schedule is paused through the API after the scheduler read it.
+	read := *s
+	paused := *s
+	paused.Paused = true
+	paused.UpdatedAt = s.UpdatedAt.Add(time.Second)
+	ms.schedules[s.ID] = &paused
+
+	b.runSchedule(ctx, &read, time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+
+	cur := ms.schedules[s.ID]
+	if !cur.Paused {
+		t.Error("expected the pause kept")
 	}
-	// oversight = 0.1*0.35 + 0.1*0.25 + 0.1*0.25 + 0.05*0.15 = 0.035+0.025+0.025+0.0075 = 0.0925 → autonomous
-	// Metadata trust (0.95) should take precedence over DB trust (0.2)
-	if updated.OversightLevel != "autonomous" {
-		t.Errorf("expected autonomous with high metadata trust, got %s", updated.OversightLevel)
+	tasks := scheduledTasks(ms, s)
+	if len(tasks) != 1 || cur.LastTaskID == nil || *cur.LastTaskID != tasks[0].ID {
+		t.Errorf("expected the run recorded on the paused schedule, got %d tasks", len(tasks))
+	}
+	if want := time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC); !cur.NextRunAt.Equal(want) {
+		t.Errorf("expected next run %s, got %s", want, cur.NextRunAt)
+	}
+}
+
+func TestDeadlineBoostGrowsAsSlackShrinks(t *testing.T) {
+	now := time.Now()
+	deadline := now.Add(2 * time.Hour)
+	task := &store.Task{Deadline: &deadline}
+	window := time.Hour
+
+	cases := []struct {
+		estimate time.Duration
+		want     int
+	}{
+		{30 * time.Minute, 0},  // 90m slack
+		{90 * time.Minute, 5},  // 30m slack
+		{114 * time.Minute, 9}, // 6m slack
+		{3 * time.Hour, 10},    // already late
+	}
+	for _, tc := range cases {
+		if got := deadlineBoost(task, tc.estimate, window, now); got != tc.want {
+			t.Errorf("estimate %s: expected boost %d, got %d", tc.estimate, tc.want, got)
+		}
+	}
+	if got := deadlineBoost(&store.Task{}, time.Hour, window, now); got != 0 {
+		t.Errorf("expected no boost without a deadline, got %d", got)
 	}
 }
 
-func TestTrustScoreZeroUsesDefault(t *testing.T) {
-	// No trust score in DB → AgentTrustLevel stays nil → default 0.5 used by scorer
-	ms := newMockStore()
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-	}}
-
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
-
-	ctx := context.Background()
+func newDeadlineTask(ms *mockStore, title string, priority int, deadline *time.Time) *store.Task {
 	task := &store.Task{
 		Owner:                "system",
-		Title:                "no trust score",
+		Title:                title,
 		RequiredCapabilities: []string{"research"},
-		Priority:             5,
+		Priority:             priority,
 		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
+		TimeoutSeconds:       300,
+		MaxRetries:           3,
+		Deadline:             deadline,
 	}
-	_ = ms.CreateTask(ctx, task)
+	_ = ms.CreateTask(context.Background(), task)
+	return task
+}
 
-	b.processPendingTasks(ctx)
+func TestDeadlineTaskOvertakesHigherPriority(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	b.cfg.Assignment.SLA.BoostWindowMs = 3600000
+	ms.avgDuration = map[string]float64{"agent-00": 1200}
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Fatalf("expected assigned, got %s", updated.Status)
-	}
-	// With default trust (0.5) and default risk/verifiability/reversibility (all 0.5),
-	// oversight should land in the middle range
-	if updated.OversightLevel == "" {
-		t.Error("expected oversight_level to be set even without trust score")
+	deadline := time.Now().Add(30 * time.Minute)
+	urgent := newDeadlineTask(ms, "report", 3, &deadline)
+	routine := newDeadlineTask(ms, "cleanup", 6, nil)
+	routine.CreatedAt = urgent.CreatedAt.Add(-time.Hour)
+
+	b.processPendingTasks(context.Background())
+
+	if urgent.Status != store.StatusAssigned || routine.Status != store.StatusPending {
+		t.Errorf("expected the deadline task to take the only slot, got urgent=%s routine=%s", urgent.Status, routine.Status)
 	}
 }
 
-func TestBrokerAssignmentPopulatesModelRouting(t *testing.T) {
-	ms := newMockStore()
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-	}}
+func TestPendingTaskFlaggedAtRisk(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	ms.avgDuration = map[string]float64{"agent-00": 3600}
+	mh := b.hermes.(*mockHermes)
 
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+	deadline := time.Now().Add(20 * time.Minute)
+	task := newDeadlineTask(ms, "report", 5, &deadline)
+	relaxed := time.Now().Add(3 * time.Hour)
+	newDeadlineTask(ms, "digest", 5, &relaxed)
+
+	b.processPendingTasks(context.Background())
+	b.processPendingTasks(context.Background())
+
+	if task.SLAAtRiskAt == nil {
+		t.Fatal("expected task flagged at risk")
+	}
+	if n := countPublished(mh, "swarm.task."+task.ID.String()+".sla.at_risk"); n != 1 {
+		t.Errorf("expected one sla.at_risk event for the tight task, got %d", n)
+	}
+	var atRisk int
+	for _, p := range mh.published {
+		if strings.HasSuffix(p.subject, ".sla.at_risk") {
+			atRisk++
+		}
+	}
+	if atRisk != 1 {
+		t.Errorf("expected only the tight task at risk, got %d events", atRisk)
+	}
+}
 
+func TestCheckDeadlinesFlagsRunningAndRecordsMisses(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	ms.avgDuration = map[string]float64{"agent-00": 1800}
+	mh := b.hermes.(*mockHermes)
 	ctx := context.Background()
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "model routing test",
-		RequiredCapabilities: []string{"research"},
-		Priority:             5,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
+	now := time.Now()
+
+	started := now.Add(-10 * time.Minute)
+	soon, later, past := now.Add(5*time.Minute), now.Add(time.Hour), now.Add(-time.Minute)
+
+	slow := newDeadlineTask(ms, "slow", 5, &soon) // 20 more minutes expected
+	fine := newDeadlineTask(ms, "fine", 5, &later)
+	overdue := newDeadlineTask(ms, "overdue", 5, &past)
+	for _, task := range []*store.Task{slow, fine, overdue} {
+		task.Status = store.StatusInProgress
+		task.AssignedAgent = "agent-00"
+		task.StartedAt = &started
 	}
-	_ = ms.CreateTask(ctx, task)
 
-	b.processPendingTasks(ctx)
+	b.checkDeadlines(ctx, now)
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Fatalf("expected assigned, got %s", updated.Status)
+	if slow.SLAAtRiskAt == nil || fine.SLAAtRiskAt != nil {
+		t.Errorf("expected only the slow task at risk, got slow=%v fine=%v", slow.SLAAtRiskAt, fine.SLAAtRiskAt)
 	}
-	if updated.ModelTier == "" {
-		t.Error("expected model_tier to be set after assignment")
+	if overdue.DeadlineMissedAt == nil {
+		t.Fatal("expected the overdue task's miss recorded")
 	}
-	if updated.RecommendedModel == "" {
-		t.Error("expected recommended_model to be set after assignment")
+	if countPublished(mh, "swarm.task."+overdue.ID.String()+".sla.missed") != 1 {
+		t.Error("expected an sla.missed event")
 	}
-	if updated.RoutingMethod != "cold_start" {
-		t.Errorf("expected routing_method 'cold_start', got '%s'", updated.RoutingMethod)
+	var recorded bool
+	for _, e := range ms.events {
+		if e.TaskID == overdue.ID && e.Event == "deadline_missed" {
+			recorded = true
+		}
 	}
-	if updated.Runtime == "" {
-		t.Error("expected runtime to be set after assignment")
+	if !recorded {
+		t.Error("expected a deadline_missed event")
+	}
+
+	// A recorded miss is not reported again.
+	b.checkDeadlines(ctx, now.Add(time.Minute))
+	if countPublished(mh, "swarm.task."+overdue.ID.String()+".sla.missed") != 1 {
+		t.Error("expected the miss reported once")
 	}
 }
 
-func TestBrokerAssignmentModelRoutingWithLabels(t *testing.T) {
+func TestRunningETAPrefersReportedETA(t *testing.T) {
+	b, ms, _ := newSnapshotTestBroker(1, 0)
+	ms.avgDuration = map[string]float64{"agent-00": 7200}
+	now := time.Now()
+	eta := 60
+	task := &store.Task{
+		AssignedAgent: "agent-00",
+		StartedAt:     &now,
+		Progress:      &store.TaskProgress{ETASeconds: &eta, ReportedAt: now},
+	}
+	if got := b.runningETA(context.Background(), task, now); !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the reported ETA, got %s", got.Sub(now))
+	}
+}
+
+func newStageTestBroker(tier string, criteria ...string) (*Broker, *mockStore, *mockHermes, *store.BacklogItem) {
 	ms := newMockStore()
 	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"config"}},
-	}}
-
 	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+	cfg.Orchestrator = config.OrchestratorConfig{
+		Enabled: true,
+		Stages:  map[string][]string{"implement": {"code"}, "verify": {"testing"}},
+	}
+	item := &store.BacklogItem{
+		Title:         "Add retries",
+		Status:        store.BacklogStatusInProgress,
+		ModelTier:     tier,
+		Labels:        []string{"backend"},
+		StageTemplate: []string{"implement", "verify"},
+		CurrentStage:  "implement",
+	}
+	_ = ms.CreateBacklogItem(context.Background(), item)
+	ms.gates = map[string][]store.GateCriterion{}
+	for _, c := range criteria {
+		ms.gates["implement"] = append(ms.gates["implement"], store.GateCriterion{Criterion: c})
+	}
+	return New(ms, mh, nil, nil, nil, cfg, discardLogger()), ms, mh, item
+}
 
-	ctx := context.Background()
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "config update",
-		RequiredCapabilities: []string{"config"},
-		Priority:             1,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
-		Labels:               []string{"config"},
-		FilePatterns:         []string{"app.yaml"},
+// startStageTask dispatches item's current stage and marks the task running
+// on scout.
+func startStageTask(t *testing.T, b *Broker, item *store.BacklogItem) *store.Task {
+	t.Helper()
+	task, err := b.orch.StageEntered(context.Background(), item)
+	if err != nil || task == nil {
+		t.Fatalf("expected a stage task, got %v, %v", task, err)
 	}
-	_ = ms.CreateTask(ctx, task)
+	now := time.Now()
+	task.Status = store.StatusInProgress
+	task.AssignedAgent = "scout"
+	task.AssignedAt = &now
+	task.StartedAt = &now
+	return task
+}
 
-	b.processPendingTasks(ctx)
+func TestBacklogStageDispatchesTask(t *testing.T) {
+	b, ms, _, item := newStageTestBroker("standard")
+	task := startStageTask(t, b, item)
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Fatalf("expected assigned, got %s", updated.Status)
+	if task.BacklogItemID == nil || *task.BacklogItemID != item.ID || task.BacklogStage != "implement" {
+		t.Errorf("expected the task linked to the item's implement stage, got %v %q", task.BacklogItemID, task.BacklogStage)
 	}
-	if updated.ModelTier != "economy" {
-		t.Errorf("expected economy tier for config task, got '%s'", updated.ModelTier)
+	if len(task.RequiredCapabilities) != 1 || task.RequiredCapabilities[0] != "code" {
+		t.Errorf("expected the implement capabilities, got %v", task.RequiredCapabilities)
 	}
-	if updated.Runtime != "openclaw" {
-		t.Errorf("expected openclaw runtime for economy tier (picoclaw disabled), got '%s'", updated.Runtime)
+	if task.MinModelTier != "standard" || task.Source != "backlog" || len(task.Labels) != 1 {
+		t.Errorf("expected tier, source and labels from the item, got %q %q %v", task.MinModelTier, task.Source, task.Labels)
+	}
+
+	// The stage already has an active task.
+	if dup, _ := b.orch.StageEntered(context.Background(), item); dup != nil {
+		t.Error("expected no second task for an active stage")
+	}
+
+	// Stages without configured capabilities are left to humans.
+	item.CurrentStage = "review"
+	if other, _ := b.orch.StageEntered(context.Background(), item); other != nil {
+		t.Error("expected no task for an undispatched stage")
+	}
+	if len(ms.tasks) != 1 {
+		t.Errorf("expected one task, got %d", len(ms.tasks))
 	}
 }
 
-func TestBrokerAssignmentOneWayDoorForcesPremium(t *testing.T) {
-	ms := newMockStore()
-	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"config"}},
-	}}
+func TestBacklogStageCompletionSubmitsEvidence(t *testing.T) {
+	b, ms, mh, item := newStageTestBroker("standard", "code complete", "tests passing")
+	task := startStageTask(t, b, item)
 
-	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
+	b.handleCompleted(hermes.TaskCompletedEvent{
+		TaskID: task.ID.String(),
+		Result: map[string]interface{}{
+			"summary":  "implemented retries",
+			"evidence": map[string]interface{}{"code complete": "PR #42"},
+		},
+	})
 
-	ctx := context.Background()
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "irreversible deploy",
-		RequiredCapabilities: []string{"config"},
-		Priority:             5,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
-		Labels:               []string{"config"},
-		FilePatterns:         []string{"app.yaml"},
-		OneWayDoor:           true,
+	gate := ms.gates["implement"]
+	if gate[0].Evidence != "PR #42" || gate[1].Evidence != "implemented retries" {
+		t.Errorf("expected evidence from the task result, got %q and %q", gate[0].Evidence, gate[1].Evidence)
 	}
-	_ = ms.CreateTask(ctx, task)
+	if countPublished(mh, hermes.SubjectGateEvidence(item.ID.String())) != 2 {
+		t.Error("expected a gate evidence event per criterion")
+	}
+	// Without auto-approve the gate still waits for a reviewer.
+	if gate[0].Satisfied || item.CurrentStage != "implement" {
+		t.Errorf("expected the item held at implement, got %q", item.CurrentStage)
+	}
+}
 
-	b.processPendingTasks(ctx)
+func TestBacklogStageAutoApproveAdvances(t *testing.T) {
+	b, ms, mh, item := newStageTestBroker("economy", "code complete")
+	ms.autonomy = map[string]*store.AutonomyConfig{"economy": {Tier: "economy", AutoApprove: true}}
+	task := startStageTask(t, b, item)
 
-	updated := ms.tasks[task.ID]
-	if updated.Status != store.StatusAssigned {
-		t.Fatalf("expected assigned, got %s", updated.Status)
+	b.handleCompleted(hermes.TaskCompletedEvent{TaskID: task.ID.String()})
+
+	if !ms.gates["implement"][0].Satisfied {
+		t.Error("expected the criterion auto-approved")
 	}
-	if updated.ModelTier != "premium" {
-		t.Errorf("expected premium tier for one_way_door task, got '%s'", updated.ModelTier)
+	if item.CurrentStage != "verify" {
+		t.Fatalf("expected the item advanced to verify, got %q", item.CurrentStage)
 	}
-	if updated.Runtime != "openclaw" {
-		t.Errorf("expected openclaw runtime for premium tier, got '%s'", updated.Runtime)
+	if countPublished(mh, hermes.SubjectStageAdvanced(item.ID.String())) != 1 {
+		t.Error("expected a stage advanced event")
+	}
+	var verify *store.Task
+	for _, tk := range ms.tasks {
+		if tk.BacklogStage == "verify" {
+			verify = tk
+		}
+	}
+	if verify == nil || verify.RequiredCapabilities[0] != "testing" {
+		t.Fatalf("expected a verify task dispatched, got %v", verify)
 	}
 }
 
-func TestBrokerAssignmentModelRoutingEvent(t *testing.T) {
+func TestBacklogStageFailureBlocksItem(t *testing.T) {
+	b, _, mh, item := newStageTestBroker("standard", "code complete")
+	task := startStageTask(t, b, item)
+	task.MaxRetries = 0
+
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "build broken", RetryEligible: true})
+
+	if item.Status != store.BacklogStatusBlocked {
+		t.Fatalf("expected the item blocked, got %s", item.Status)
+	}
+	if item.Metadata["blocked_reason"] != "build broken" {
+		t.Errorf("expected the task error as the blocked reason, got %v", item.Metadata["blocked_reason"])
+	}
+	if countPublished(mh, hermes.SubjectItemBlocked(item.ID.String())) != 1 {
+		t.Error("expected an item blocked event")
+	}
+}
+
+func TestBacklogStageRetryDoesNotBlockItem(t *testing.T) {
+	b, _, _, item := newStageTestBroker("standard", "code complete")
+	task := startStageTask(t, b, item)
+
+	b.handleFailed(hermes.TaskFailedEvent{TaskID: task.ID.String(), Error: "flaky", RetryEligible: true})
+
+	if task.Status != store.StatusPending || item.Status != store.BacklogStatusInProgress {
+		t.Errorf("expected the task retried and the item untouched, got %s and %s", task.Status, item.Status)
+	}
+}
+
+func TestBacklogRescore(t *testing.T) {
 	ms := newMockStore()
 	mh := &mockHermes{}
-	mw := &mockWarren{states: map[string]*warren.AgentState{
-		"lily": {Name: "lily", Status: "ready", Policy: "always-on"},
-	}}
-	mf := &mockForge{personas: []forge.Persona{
-		{Name: "lily", Slug: "lily", Capabilities: []string{"research"}},
-	}}
-
 	cfg := testConfig()
-	b := New(ms, mh, mw, mf, nil, cfg, discardLogger())
-
+	cfg.Scoring.BacklogWeights = config.BacklogScoringWeights{Urgency: 1}
+	cfg.Scoring.BacklogRescore = config.BacklogRescoreConfig{
+		UrgencyGrowthPerDay:   0.01,
+		DueWindowHours:        24,
+		SignificantRankChange: 2,
+	}
+	b := New(ms, mh, nil, nil, nil, cfg, discardLogger())
 	ctx := context.Background()
-	task := &store.Task{
-		Owner:                "system",
-		Title:                "event check",
-		RequiredCapabilities: []string{"research"},
-		Priority:             5,
-		Status:               store.StatusPending,
-		TimeoutSeconds:       60,
-		Source:               "manual",
-		RetryEligible:        true,
+	now := time.Now()
+
+	score := func(v float64) *float64 { return &v }
+	urgency := 0.5
+	due := now.Add(time.Hour)
+	// Ranked by their stale scores: fresh, old, due, then the done item.
+	fresh := &store.BacklogItem{Title: "fresh", Status: store.BacklogStatusReady, Urgency: &urgency, PriorityScore: score(0.5)}
+	old := &store.BacklogItem{Title: "old", Status: store.BacklogStatusReady, Urgency: &urgency, PriorityScore: score(0.45)}
+	dueSoon := &store.BacklogItem{Title: "due soon", Status: store.BacklogStatusBacklog, Urgency: &urgency, DueDate: &due, PriorityScore: score(0.4)}
+	done := &store.BacklogItem{Title: "done", Status: store.BacklogStatusDone, PriorityScore: score(0.1)}
+	for _, item := range []*store.BacklogItem{fresh, old, dueSoon, done} {
+		_ = ms.CreateBacklogItem(ctx, item)
+		item.CreatedAt = now
 	}
-	_ = ms.CreateTask(ctx, task)
+	old.CreatedAt = now.Add(-20 * 24 * time.Hour)
 
-	b.processPendingTasks(ctx)
+	if err := b.orch.Rescore(ctx, now); err != nil {
+		t.Fatal(err)
+	}
 
-	// Find the dispatch assigned event
-	var dispatchEvt *hermes.DispatchAssignedEvent
-	for _, p := range mh.published {
-		if evt, ok := p.data.(hermes.DispatchAssignedEvent); ok {
-			dispatchEvt = &evt
-			break
+	if *fresh.PriorityScore != 0.5 {
+		t.Errorf("expected the fresh item to keep its score, got %v", *fresh.PriorityScore)
+	}
+	if math.Abs(*old.PriorityScore-0.7) > 1e-9 {
+		t.Errorf("expected the old item's urgency to grow to 0.7, got %v", *old.PriorityScore)
+	}
+	if *dueSoon.PriorityScore < 0.95 {
+		t.Errorf("expected the item due within the hour near full urgency, got %v", *dueSoon.PriorityScore)
+	}
+	if *done.PriorityScore != 0.1 {
+		t.Errorf("expected closed items left alone, got %v", *done.PriorityScore)
+	}
+
+	if len(ms.scores) != 2 {
+		t.Fatalf("expected two score changes recorded, got %d", len(ms.scores))
+	}
+	for _, c := range ms.scores {
+		if c.PreviousScore == nil || c.Reason != "rescore" {
+			t.Errorf("expected the previous score and reason recorded, got %+v", c)
 		}
 	}
-	if dispatchEvt == nil {
-		t.Fatal("expected DispatchAssignedEvent to be published")
+
+	// Due soon jumped from third to first and fresh fell from first to
+	// third; old moved a single place, below the threshold.
+	var event hermes.BacklogRescoredEvent
+	for _, p := range mh.published {
+		if p.subject == hermes.SubjectBacklogRescored() {
+			event = p.data.(hermes.BacklogRescoredEvent)
+		}
 	}
-	if dispatchEvt.ModelTier == "" {
-		t.Error("expected model_tier in DispatchAssignedEvent")
+	if len(event.Moves) != 2 {
+		t.Fatalf("expected two moves published, got %+v", event)
 	}
-	if dispatchEvt.RecommendedModel == "" {
-		t.Error("expected recommended_model in DispatchAssignedEvent")
+	if m := event.Moves[0]; m.Title != "due soon" || m.PreviousRank != 3 || m.NewRank != 1 {
+		t.Errorf("expected due soon to move from 3 to 1, got %+v", m)
 	}
-	if dispatchEvt.RoutingMethod != "cold_start" {
-		t.Errorf("expected routing_method 'cold_start' in event, got '%s'", dispatchEvt.RoutingMethod)
+	if m := event.Moves[1]; m.Title != "fresh" || m.PreviousRank != 1 || m.NewRank != 3 {
+		t.Errorf("expected fresh to move from 1 to 3, got %+v", m)
 	}
-	if dispatchEvt.Runtime == "" {
-		t.Error("expected runtime in DispatchAssignedEvent")
+	if event.ItemsScored != 3 || event.ItemsChanged != 2 {
+		t.Errorf("expected 3 items scored and 2 changed, got %d and %d", event.ItemsScored, event.ItemsChanged)
 	}
-}
 
-func discardLogger() *slog.Logger {
-	return slog.New(slog.NewTextHandler(io.Discard, nil))
+	// A pass with no rank changes publishes nothing.
+	mh.published = nil
+	_ = b.orch.Rescore(ctx, now)
+	if countPublished(mh, hermes.SubjectBacklogRescored()) != 0 {
+		t.Error("expected no summary without significant moves")
+	}
 }
 
-// Stage engine stubs (satisfy store.Store interface)
-func (m *mockStore) InitStages(_ context.Context, _ uuid.UUID, _ []string) error { return nil }
-func (m *mockStore) GetCurrentStage(_ context.Context, _ uuid.UUID) (string, int, error) { return "", 0, nil }
-func (m *mockStore) CreateGateCriteria(_ context.Context, _ uuid.UUID, _ string, _ []string) error { return nil }
-func (m *mockStore) SatisfyCriterion(_ context.Context, _ uuid.UUID, _, _, _ string) error { return nil }
-func (m *mockStore) SatisfyAllCriteria(_ context.Context, _ uuid.UUID, _, _ string) error { return nil }
-func (m *mockStore) GetGateStatus(_ context.Context, _ uuid.UUID, _ string) ([]store.GateCriterion, error) { return nil, nil }
-func (m *mockStore) AllCriteriaMet(_ context.Context, _ uuid.UUID, _ string) (bool, error) { return true, nil }
+func TestBacklogRescoreUsesBlockedSet(t *testing.T) {
+	ms := newMockStore()
+	cfg := testConfig()
+	cfg.Scoring.BacklogWeights = config.BacklogScoringWeights{DependencyReadiness: 1}
+	b := New(ms, &mockHermes{}, nil, nil, nil, cfg, discardLogger())
+	ctx := context.Background()
 
-// Add missing autonomy methods to broker mockStore
-func (m *mockStore) GetAutonomyConfig(ctx context.Context, tier string) (*store.AutonomyConfig, error) { return nil, nil }
-func (m *mockStore) UpdateAutonomyConfig(ctx context.Context, tier string, autoApprove bool, consecutiveApprovals, consecutiveCorrections int) error { return nil }
-func (m *mockStore) IncrementConsecutiveApprovals(ctx context.Context, tier string) (int, error) { return 0, nil }
-func (m *mockStore) IncrementConsecutiveCorrections(ctx context.Context, tier string) (int, error) { return 0, nil }
-func (m *mockStore) ResetAutonomyCounters(ctx context.Context, tier string) error { return nil }
-func (m *mockStore) SubmitEvidence(ctx context.Context, itemID uuid.UUID, stage, criterion, evidence, submittedBy string) error { return nil }
-func (m *mockStore) ResetStageToActive(ctx context.Context, itemID uuid.UUID, stage string) error { return nil }
+	free := &store.BacklogItem{Title: "free", Status: store.BacklogStatusReady}
+	blocked := &store.BacklogItem{Title: "blocked", Status: store.BacklogStatusBlocked}
+	_ = ms.CreateBacklogItem(ctx, free)
+	_ = ms.CreateBacklogItem(ctx, blocked)
+	ms.blocked = map[uuid.UUID]bool{blocked.ID: true}
 
+	if err := b.orch.Rescore(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if free.PriorityScore == nil || blocked.PriorityScore == nil || *blocked.PriorityScore >= *free.PriorityScore {
+		t.Errorf("expected the blocked item scored below the free one, got %v and %v", blocked.PriorityScore, free.PriorityScore)
+	}
+}
@@ -0,0 +1,31 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/budget"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// checkBudget applies the assignment-time budget check to task. It reports
+// whether the task may be assigned now and whether it must be routed to the
+// cheapest model tier. Tasks over a reject or queue budget stay pending
+// until the period rolls over or the budget is raised. A failed check lets
+// the task through so a store error cannot stall the queue.
+func (b *Broker) checkBudget(ctx context.Context, snap *tickSnapshot, task *store.Task) (allowed, downgrade bool) {
+	d, err := snap.budgets.Check(ctx, task, budget.StageAssign)
+	if err != nil {
+		b.logger.Warn("budget check failed", "task_id", task.ID, "error", err)
+		return true, false
+	}
+	switch d.Action {
+	case "":
+		return true, false
+	case store.BudgetActionDowngrade:
+		b.logger.Info("budget exhausted, downgrading model tier", "task_id", task.ID, "budget_id", d.Budget.ID, "limits", d.Limits)
+		return true, true
+	default:
+		b.logger.Info("budget exhausted, holding task", "task_id", task.ID, "budget_id", d.Budget.ID, "action", d.Action, "limits", d.Limits)
+		return false, false
+	}
+}
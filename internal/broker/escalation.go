@@ -0,0 +1,98 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// escalate applies the escalation steps due on task's failures-th failure
+// and reports whether any of them changed how the task runs, earning it
+// another attempt past MaxRetries. Changes to task are left for the caller
+// to save along with the retry or DLQ transition.
+func (b *Broker) escalate(ctx context.Context, task *store.Task, failures int, reason string) bool {
+	rerun := false
+	for _, step := range b.cfg.Escalation.Due(failures) {
+		evt := hermes.TaskEscalatedEvent{
+			TaskID:   task.ID.String(),
+			Action:   step.Action,
+			Failures: failures,
+		}
+		switch step.Action {
+		case config.EscalatePromoteModel:
+			tier := step.Tier
+			if tier == "" {
+				tier = "premium"
+			}
+			task.MinModelTier = tier
+			evt.ModelTier = tier
+			rerun = true
+		case config.EscalateWidenCapabilities:
+			caps := step.Capabilities
+			if len(caps) == 0 && len(task.RequiredCapabilities) > 0 {
+				caps = task.RequiredCapabilities[:1]
+			}
+			task.RequiredCapabilities = caps
+			evt.Capabilities = caps
+			rerun = true
+		case config.EscalateReassign:
+			if step.Agent == "" {
+				b.logger.Warn("escalation reassign step has no agent", "task_id", task.ID)
+				continue
+			}
+			task.RequiredAgent = step.Agent
+			evt.Agent = step.Agent
+			rerun = true
+		case config.EscalateBacklogItem:
+			item := &store.BacklogItem{
+				Title:       "Investigate failing task: " + task.Title,
+				Description: fmt.Sprintf("Task %s failed %d times. Last error: %s", task.ID, failures, reason),
+				ItemType:    "bug",
+				Status:      store.BacklogStatusBacklog,
+				Source:      "escalation",
+				Metadata:    map[string]interface{}{"failed_task_id": task.ID.String()},
+			}
+			if err := b.store.CreateBacklogItem(ctx, item); err != nil {
+				b.logger.Error("failed to create escalation backlog item", "task_id", task.ID, "error", err)
+				continue
+			}
+			evt.BacklogItemID = item.ID.String()
+			if b.hermes != nil {
+				_ = b.hermes.Publish(hermes.SubjectBacklogCreated(item.ID.String()), hermes.BacklogItemEvent{
+					ItemID: item.ID.String(),
+					Status: string(item.Status),
+					Title:  item.Title,
+				})
+			}
+		default:
+			b.logger.Warn("unknown escalation action", "task_id", task.ID, "action", step.Action)
+			continue
+		}
+
+		b.logger.Info("task escalated", "task_id", task.ID, "action", step.Action, "failures", failures)
+		payload := map[string]interface{}{"action": step.Action, "failures": failures}
+		switch {
+		case evt.ModelTier != "":
+			payload["model_tier"] = evt.ModelTier
+		case evt.Capabilities != nil:
+			payload["capabilities"] = evt.Capabilities
+		case evt.Agent != "":
+			payload["agent"] = evt.Agent
+		case evt.BacklogItemID != "":
+			payload["backlog_item_id"] = evt.BacklogItemID
+		}
+		_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+			TaskID:  task.ID,
+			Event:   "escalated",
+			AgentID: task.AssignedAgent,
+			Payload: payload,
+		})
+		if b.hermes != nil {
+			_ = b.hermes.Publish(hermes.SubjectTaskEscalated(task.ID.String()), evt)
+		}
+	}
+	return rerun
+}
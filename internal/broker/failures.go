@@ -0,0 +1,64 @@
+package broker
+
+import (
+	"context"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// Task metadata keys steering a retry after a classified failure.
+const (
+	// metaRetryAgent names the agent a retry_same retry favours.
+	metaRetryAgent = "retry_agent"
+	// metaFailedAgents lists agents a retry_elsewhere retry avoids.
+	metaFailedAgents = "failed_agents"
+)
+
+// faultRateMinAttempts is how many recorded attempts an agent needs before
+// its agent-fault rate counts against it in scoring.
+const faultRateMinAttempts = 5
+
+// recordFailure adds the failed attempt to the agent's task history, so its
+// failure classes feed into scoring.
+func (b *Broker) recordFailure(ctx context.Context, task *store.Task, class string) {
+	if task.AssignedAgent == "" {
+		return
+	}
+	now := time.Now()
+	h := &store.AgentTaskHistory{
+		AgentSlug:    task.AssignedAgent,
+		TaskID:       task.ID,
+		StartedAt:    task.StartedAt,
+		CompletedAt:  &now,
+		Success:      boolPtr(false),
+		FailureClass: class,
+	}
+	if task.AssignedAt != nil {
+		dur := now.Sub(*task.AssignedAt).Seconds()
+		h.DurationSeconds = &dur
+	}
+	_ = b.store.CreateAgentTaskHistory(ctx, h)
+}
+
+// steerRetry records in task metadata where its retry should run under
+// policy: favouring agent for retry_same, avoiding it for retry_elsewhere.
+func steerRetry(task *store.Task, policy, agent string) {
+	delete(task.Metadata, metaRetryAgent)
+	if agent == "" {
+		return
+	}
+	switch policy {
+	case config.RetrySameAgent:
+		if task.Metadata == nil {
+			task.Metadata = map[string]interface{}{}
+		}
+		task.Metadata[metaRetryAgent] = agent
+	case config.RetryElsewhere:
+		if task.Metadata == nil {
+			task.Metadata = map[string]interface{}{}
+		}
+		task.Metadata[metaFailedAgents] = appendUnique(metadataList(task, metaFailedAgents), agent)
+	}
+}
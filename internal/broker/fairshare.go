@@ -0,0 +1,212 @@
+package broker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// maxPriority is the top of the task priority scale; aging never lifts a
+// task above it.
+const maxPriority = 10
+
+// fairShare orders pending tasks with weighted fair queueing across groups
+// (owners or sources) and enforces per-group in-flight caps. A nil
+// *fairShare leaves the queue order alone and allows every task.
+type fairShare struct {
+	cfg config.FairShareConfig
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+func newFairShare(cfg config.FairShareConfig, active []*store.Task) *fairShare {
+	f := &fairShare{cfg: cfg, inFlight: make(map[string]int)}
+	for _, t := range active {
+		f.inFlight[f.group(t)]++
+	}
+	return f
+}
+
+// group returns the fair-share group a task belongs to.
+func (f *fairShare) group(task *store.Task) string {
+	return fairShareGroup(f.cfg, task)
+}
+
+func fairShareGroup(cfg config.FairShareConfig, task *store.Task) string {
+	if cfg.Key == config.FairShareBySource {
+		return task.Source
+	}
+	return task.Owner
+}
+
+// effectivePriority is the task priority plus one point per aging interval
+// the task has been waiting, capped at maxPriority.
+func effectivePriority(task *store.Task, aging time.Duration, now time.Time) int {
+	p := task.Priority
+	if aging > 0 && !task.CreatedAt.IsZero() {
+		p += int(now.Sub(task.CreatedAt) / aging)
+	}
+	if p > maxPriority {
+		p = maxPriority
+	}
+	return p
+}
+
+// order returns tasks in weighted fair queueing order. Within a group tasks
+// are ordered by urgency: effective priority including aging and any boost,
+// then deadline, then age. Across groups the n-th task of group g gets the
+// virtual finish time (inFlight(g) + n) / weight(g), and the queue is served
+// in finish-time order, so each group receives service in proportion to its
+// weight regardless of how many tasks it has queued.
+func (f *fairShare) order(tasks []*store.Task, now time.Time, boost func(*store.Task) int) []*store.Task {
+	if f == nil {
+		return tasks
+	}
+	prio := urgency(tasks, f.cfg.AgingInterval(), now, boost)
+
+	byGroup := make(map[string][]*store.Task)
+	for _, t := range tasks {
+		g := f.group(t)
+		byGroup[g] = append(byGroup[g], t)
+	}
+
+	finish := make(map[*store.Task]float64, len(tasks))
+	for g, queue := range byGroup {
+		sort.SliceStable(queue, func(i, j int) bool { return moreUrgent(queue[i], queue[j], prio) })
+		weight := f.cfg.ShareWeight(g)
+		for n, t := range queue {
+			finish[t] = float64(f.inFlight[g]+n+1) / weight
+		}
+	}
+
+	out := append([]*store.Task(nil), tasks...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if finish[out[i]] != finish[out[j]] {
+			return finish[out[i]] < finish[out[j]]
+		}
+		return moreUrgent(out[i], out[j], prio)
+	})
+	return out
+}
+
+// allow reports whether the task's group is below its in-flight cap.
+func (f *fairShare) allow(task *store.Task) bool {
+	if f == nil {
+		return true
+	}
+	g := f.group(task)
+	limit := f.cfg.InFlightCap(g)
+	if limit <= 0 {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.inFlight[g] < limit
+}
+
+// admit reports whether task still fits under its group's in-flight cap once
+// the tasks already admitted to a batch, counted per group in queued, are
+// assigned too. Admitted tasks are added to queued.
+func (f *fairShare) admit(task *store.Task, queued map[string]int) bool {
+	if f == nil {
+		return true
+	}
+	g := f.group(task)
+	if limit := f.cfg.InFlightCap(g); limit > 0 {
+		f.mu.Lock()
+		n := f.inFlight[g]
+		f.mu.Unlock()
+		if n+queued[g] >= limit {
+			return false
+		}
+	}
+	queued[g]++
+	return true
+}
+
+// record counts a new assignment against the task's group.
+func (f *fairShare) record(task *store.Task) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.inFlight[f.group(task)]++
+	f.mu.Unlock()
+}
+
+// release removes a preempted task from its group's in-flight count.
+func (f *fairShare) release(task *store.Task) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.inFlight[f.group(task)]--
+	f.mu.Unlock()
+}
+
+// ShareUsage reports one fair-share group's queue depth and how its share of
+// in-flight work compares with its entitlement.
+type ShareUsage struct {
+	Group       string  `json:"group"`
+	Pending     int     `json:"pending"`
+	InFlight    int     `json:"in_flight"`
+	Weight      float64 `json:"weight"`
+	Share       float64 `json:"share"`
+	Usage       float64 `json:"usage"`
+	MaxInFlight int     `json:"max_in_flight,omitempty"`
+}
+
+// FairShareUsage returns per-group queue depth and share usage, grouped by
+// the configured fair-share key. Share is the group's weight as a fraction of
+// the weights of all groups with pending or in-flight work; Usage is its
+// fraction of all in-flight tasks.
+func (b *Broker) FairShareUsage(ctx context.Context) ([]ShareUsage, error) {
+	cfg := b.cfg.Assignment.FairShare
+	pending, err := b.store.GetPendingTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active, err := b.store.GetActiveTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*ShareUsage)
+	get := func(t *store.Task) *ShareUsage {
+		g := fairShareGroup(cfg, t)
+		u, ok := groups[g]
+		if !ok {
+			u = &ShareUsage{Group: g, Weight: cfg.ShareWeight(g), MaxInFlight: cfg.InFlightCap(g)}
+			groups[g] = u
+		}
+		return u
+	}
+	for _, t := range pending {
+		get(t).Pending++
+	}
+	for _, t := range active {
+		get(t).InFlight++
+	}
+
+	totalWeight := 0.0
+	for _, u := range groups {
+		totalWeight += u.Weight
+	}
+	out := make([]ShareUsage, 0, len(groups))
+	for _, u := range groups {
+		if totalWeight > 0 {
+			u.Share = u.Weight / totalWeight
+		}
+		if len(active) > 0 {
+			u.Usage = float64(u.InFlight) / float64(len(active))
+		}
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Group < out[j].Group })
+	return out, nil
+}
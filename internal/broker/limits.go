@@ -0,0 +1,54 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
+)
+
+// agentLimit resolves an agent's concurrency limit: an admin override wins,
+// then the persona's own max_concurrent, then the configured default.
+func agentLimit(def int, p forge.Persona, overrides map[string]int) int {
+	if n, ok := overrides[p.Slug]; ok {
+		return n
+	}
+	if p.MaxConcurrent > 0 {
+		return p.MaxConcurrent
+	}
+	return def
+}
+
+func (b *Broker) agentLimitOverrides(ctx context.Context) (map[string]int, error) {
+	limits, err := b.store.ListAgentLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int, len(limits))
+	for _, l := range limits {
+		out[l.AgentSlug] = l.MaxConcurrent
+	}
+	return out, nil
+}
+
+// AgentLimits returns every persona's resolved concurrency limit, keyed by
+// both slug and name, plus any admin overrides for agents Forge doesn't list.
+func (b *Broker) AgentLimits(ctx context.Context) (map[string]int, error) {
+	personas, err := b.forge.ListPersonas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := b.agentLimitOverrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int, 2*len(personas)+len(overrides))
+	for slug, n := range overrides {
+		out[slug] = n
+	}
+	for _, p := range personas {
+		n := agentLimit(b.cfg.Assignment.MaxConcurrentPerAgent, p, overrides)
+		out[p.Slug] = n
+		out[p.Name] = n
+	}
+	return out, nil
+}
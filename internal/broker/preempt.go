@@ -0,0 +1,107 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// preempt makes room for an urgent task that has no eligible candidate. It
+// looks for a capable agent running a preemptible task of lower priority,
+// asks that agent to checkpoint and yield the task, and requeues it without
+// consuming a retry. It returns the freed candidate and the number of
+// eligible candidates once the slot is free, or ok=false when nothing can be
+// preempted.
+func (b *Broker) preempt(ctx context.Context, snap *tickSnapshot, task *store.Task) (winner scoredCandidate, candidates int, ok bool) {
+	p := b.cfg.Assignment.Preemption
+	if !p.Enabled || task.Priority < p.MinPriority {
+		return scoredCandidate{}, 0, false
+	}
+
+	capability := ""
+	if len(task.RequiredCapabilities) > 0 {
+		capability = task.RequiredCapabilities[0]
+	}
+	capable := make(map[string]bool)
+	for _, c := range snap.candidates(capability) {
+		capable[c.Slug] = true
+	}
+
+	tried := make(map[string]bool)
+	for _, victim := range snap.preemptible(task.Priority) {
+		agent := victim.AssignedAgent
+		if !capable[agent] || tried[agent] {
+			continue
+		}
+		tried[agent] = true
+
+		// Score the urgent task as if the victim's slot were free; only
+		// preempt if that makes the agent eligible.
+		snap.releaseSlots(agent, victim)
+		scored := b.scoreTask(ctx, snap, task)
+		winner := pickCandidate(scored, agent)
+		if winner.persona.Slug == "" {
+			snap.reserveSlots(agent, victim)
+			continue
+		}
+		if err := b.yieldTask(ctx, victim, task); err != nil {
+			b.logger.Warn("failed to preempt task", "task_id", victim.ID, "error", err)
+			snap.reserveSlots(agent, victim)
+			continue
+		}
+		snap.recordPreemption(victim)
+		return winner, len(scored), true
+	}
+	return scoredCandidate{}, 0, false
+}
+
+// yieldTask requeues victim so urgent can take its agent's slot. The agent is
+// told to checkpoint and stop through swarm.task.<id>.preempt. Both tasks get
+// an event recording the decision.
+func (b *Broker) yieldTask(ctx context.Context, victim, urgent *store.Task) error {
+	agent := victim.AssignedAgent
+	reason := fmt.Sprintf("priority %d task had no eligible candidate; yielding lowest-priority preemptible task (priority %d)", urgent.Priority, victim.Priority)
+
+	// victim is from the tick's snapshot; if it finished or moved since,
+	// the write is refused and nothing is preempted.
+	prev := *victim
+	victim.ClearAssignment()
+	if err := b.store.UpdateTaskGuarded(ctx, victim, store.Running(agent)); err != nil {
+		*victim = prev
+		return err
+	}
+	b.logger.Info("preempting task", "task_id", victim.ID, "agent", agent, "preempted_by", urgent.ID)
+
+	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+		TaskID:  victim.ID,
+		Event:   "preempted",
+		AgentID: agent,
+		Payload: map[string]interface{}{
+			"preempted_by":          urgent.ID.String(),
+			"preempted_by_priority": urgent.Priority,
+			"priority":              victim.Priority,
+			"reason":                reason,
+		},
+	})
+	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+		TaskID:  urgent.ID,
+		Event:   "preemption",
+		AgentID: agent,
+		Payload: map[string]interface{}{
+			"preempted_task_id":  victim.ID.String(),
+			"preempted_priority": victim.Priority,
+			"reason":             reason,
+		},
+	})
+	if b.hermes != nil {
+		_ = b.hermes.Publish(hermes.SubjectTaskPreempt(victim.ID.String()), map[string]interface{}{
+			"task_id":      victim.ID.String(),
+			"agent":        agent,
+			"preempted_by": urgent.ID.String(),
+			"reason":       reason,
+		})
+	}
+	return nil
+}
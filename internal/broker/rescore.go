@@ -0,0 +1,26 @@
+package broker
+
+import (
+	"context"
+	"time"
+)
+
+// rescoreLoop periodically recomputes open backlog items' priority scores.
+func (b *Broker) rescoreLoop(ctx context.Context) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.RescoreInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.orch.Rescore(ctx, time.Now()); err != nil {
+				b.logger.Error("failed to rescore backlog", "error", err)
+			}
+		}
+	}
+}
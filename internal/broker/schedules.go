@@ -0,0 +1,133 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/schedule"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func (b *Broker) scheduleLoop(ctx context.Context) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.ScheduleInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.runSchedules(ctx, time.Now())
+		}
+	}
+}
+
+// runSchedules creates the tasks for every schedule run due at now.
+func (b *Broker) runSchedules(ctx context.Context, now time.Time) {
+	due, err := b.store.GetDueSchedules(ctx, now)
+	if err != nil {
+		b.logger.Error("failed to get due schedules", "error", err)
+		return
+	}
+	for _, s := range due {
+		b.runSchedule(ctx, s, now)
+	}
+}
+
+// runSchedule creates tasks for s's due runs, applying its catch-up and
+// overlap policies, and advances it to its next run. A run held by the queue
+// overlap policy goes first once the previous task has finished.
+func (b *Broker) runSchedule(ctx context.Context, s *store.Schedule, now time.Time) {
+	read := *s
+	plan, err := schedule.PlanRuns(s, now, b.cfg.Schedules)
+	if err != nil {
+		b.logger.Warn("invalid schedule", "schedule", s.Name, "error", err)
+		return
+	}
+	if plan.Missed > 0 {
+		b.logger.Warn("schedule runs missed", "schedule", s.Name, "missed", plan.Missed)
+		s.MissedRuns += plan.Missed
+	}
+
+	runs := plan.Runs
+	if s.QueuedRunAt != nil {
+		runs = append([]time.Time{*s.QueuedRunAt}, runs...)
+		s.QueuedRunAt = nil
+	}
+	for _, at := range runs {
+		if s.Overlap != store.OverlapAllow && b.scheduleRunActive(ctx, s) {
+			if s.Overlap == store.OverlapQueue && s.QueuedRunAt == nil {
+				s.QueuedRunAt = &at
+				continue
+			}
+			b.logger.Info("schedule run skipped, previous run still active", "schedule", s.Name, "scheduled_for", at)
+			s.SkippedRuns++
+			continue
+		}
+
+		task := schedule.NewTask(s, at)
+		if !b.admitTask(ctx, task) {
+			s.SkippedRuns++
+			continue
+		}
+		if err := b.store.CreateTask(ctx, task); err != nil {
+			b.logger.Error("failed to create scheduled task", "schedule", s.Name, "error", err)
+			s.SkippedRuns++
+			continue
+		}
+		if b.hermes != nil {
+			_ = b.hermes.Publish(hermes.SubjectTaskCreated(task.ID.String()), task)
+		}
+		b.logger.Info("scheduled task created", "schedule", s.Name, "task_id", task.ID, "scheduled_for", at)
+		s.LastRunAt = &at
+		s.LastTaskID = &task.ID
+	}
+
+	s.NextRunAt = plan.Next
+	b.saveScheduleRun(ctx, s, &read)
+}
+
+// saveScheduleRun writes s's run state. If the schedule was edited through
+// the API while its runs were created, the edit is kept and the outcome of
+// the runs is recorded on top of it; the next run only advances if the edit
+// left it alone.
+func (b *Broker) saveScheduleRun(ctx context.Context, s, read *store.Schedule) {
+	err := b.store.UpdateScheduleRun(ctx, s)
+	if errors.Is(err, store.ErrScheduleChanged) {
+		var cur *store.Schedule
+		if cur, err = b.store.GetSchedule(ctx, s.ID); err == nil && cur != nil {
+			cur.LastRunAt, cur.LastTaskID = s.LastRunAt, s.LastTaskID
+			cur.SkippedRuns += s.SkippedRuns - read.SkippedRuns
+			cur.MissedRuns += s.MissedRuns - read.MissedRuns
+			if cur.NextRunAt.Equal(read.NextRunAt) {
+				cur.NextRunAt, cur.QueuedRunAt = s.NextRunAt, s.QueuedRunAt
+			}
+			err = b.store.UpdateScheduleRun(ctx, cur)
+		}
+	}
+	if err != nil {
+		b.logger.Error("failed to update schedule", "schedule", s.Name, "error", err)
+	}
+}
+
+// scheduleRunActive reports whether the task from s's previous run has yet
+// to finish.
+func (b *Broker) scheduleRunActive(ctx context.Context, s *store.Schedule) bool {
+	if s.LastTaskID == nil {
+		return false
+	}
+	task, err := b.store.GetTask(ctx, *s.LastTaskID)
+	if err != nil || task == nil {
+		return false
+	}
+	switch task.Status {
+	case store.StatusPending, store.StatusAssigned, store.StatusInProgress:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package broker
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// deadlineBoost returns the priority points a task gains as its slack, the
+// time left before its deadline less its estimated run time, shrinks below
+// window: none at window or more, rising to maxPriority once slack runs out.
+func deadlineBoost(task *store.Task, estimate, window time.Duration, now time.Time) int {
+	if task.Deadline == nil || window <= 0 {
+		return 0
+	}
+	slack := task.Deadline.Sub(now) - estimate
+	if slack >= window {
+		return 0
+	}
+	if slack <= 0 {
+		return maxPriority
+	}
+	return int(math.Ceil(maxPriority * float64(window-slack) / float64(window)))
+}
+
+// urgency returns each task's effective priority, the task priority plus
+// aging and boost, capped at maxPriority. boost may be nil.
+func urgency(tasks []*store.Task, aging time.Duration, now time.Time, boost func(*store.Task) int) map[*store.Task]int {
+	prio := make(map[*store.Task]int, len(tasks))
+	for _, t := range tasks {
+		p := effectivePriority(t, aging, now)
+		if boost != nil {
+			p = min(p+boost(t), maxPriority)
+		}
+		prio[t] = p
+	}
+	return prio
+}
+
+// moreUrgent orders tasks by effective priority, then earliest deadline,
+// with deadlines ahead of none, then age.
+func moreUrgent(a, b *store.Task, prio map[*store.Task]int) bool {
+	if prio[a] != prio[b] {
+		return prio[a] > prio[b]
+	}
+	switch {
+	case a.Deadline != nil && b.Deadline != nil:
+		if !a.Deadline.Equal(*b.Deadline) {
+			return a.Deadline.Before(*b.Deadline)
+		}
+	case a.Deadline != nil:
+		return true
+	case b.Deadline != nil:
+		return false
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// order returns the tick's pending tasks in assignment order: weighted fair
+// queueing when fair share is enabled, otherwise by urgency. Either way
+// tasks with a deadline are boosted as their slack shrinks.
+func (s *tickSnapshot) order(tasks []*store.Task, now time.Time) []*store.Task {
+	window := s.cfg.SLA.BoostWindow()
+	boost := func(t *store.Task) int {
+		if t.Deadline == nil {
+			return 0
+		}
+		return deadlineBoost(t, s.estimate(t), window, now)
+	}
+	if s.fair != nil {
+		return s.fair.order(tasks, now, boost)
+	}
+	prio := urgency(tasks, 0, now, boost)
+	out := append([]*store.Task(nil), tasks...)
+	sort.SliceStable(out, func(i, j int) bool { return moreUrgent(out[i], out[j], prio) })
+	return out
+}
+
+// estimate is how long task is expected to run: the shortest historical
+// average duration among agents with its primary capability, or its timeout
+// when none of them has history.
+func (s *tickSnapshot) estimate(task *store.Task) time.Duration {
+	var capability string
+	if len(task.RequiredCapabilities) > 0 {
+		capability = task.RequiredCapabilities[0]
+	}
+	var best *float64
+	s.mu.RLock()
+	for _, p := range s.candidates(capability) {
+		if avg := s.history[p.Slug].avgDuration; avg != nil && (best == nil || *avg < *best) {
+			best = avg
+		}
+	}
+	s.mu.RUnlock()
+	if best != nil {
+		return time.Duration(*best * float64(time.Second))
+	}
+	return time.Duration(task.TimeoutSeconds) * time.Second
+}
+
+// slaOpen reports whether task has a deadline still ahead that has not yet
+// been flagged at risk.
+func slaOpen(task *store.Task, now time.Time) bool {
+	return task.Deadline != nil && task.SLAAtRiskAt == nil && task.DeadlineMissedAt == nil && now.Before(*task.Deadline)
+}
+
+// flagPendingAtRisk flags pending tasks whose estimated run time, starting
+// now, no longer fits before their deadline.
+func (b *Broker) flagPendingAtRisk(ctx context.Context, snap *tickSnapshot, tasks []*store.Task, now time.Time) {
+	for _, t := range tasks {
+		if !slaOpen(t, now) {
+			continue
+		}
+		if eta := now.Add(snap.estimate(t)); eta.After(*t.Deadline) {
+			b.flagAtRisk(ctx, t, eta, now)
+		}
+	}
+}
+
+// checkDeadlines records missed deadlines and flags running tasks that are
+// expected to finish late. Pending tasks are flagged by the assignment tick,
+// which has the agent history to estimate them.
+func (b *Broker) checkDeadlines(ctx context.Context, now time.Time) {
+	tasks, err := b.store.GetDeadlineTasks(ctx)
+	if err != nil {
+		b.logger.Error("failed to get tasks with deadlines", "error", err)
+		return
+	}
+	for _, t := range tasks {
+		if !now.Before(*t.Deadline) {
+			b.recordDeadlineMissed(ctx, t, now)
+			continue
+		}
+		if t.Status == store.StatusPending || !slaOpen(t, now) {
+			continue
+		}
+		if eta := b.runningETA(ctx, t, now); eta.After(*t.Deadline) {
+			b.flagAtRisk(ctx, t, eta, now)
+		}
+	}
+}
+
+// runningETA estimates when an assigned or in-progress task will finish: the
+// agent's latest reported ETA if any, otherwise its start time plus the
+// agent's historical average duration (or the task timeout without history).
+func (b *Broker) runningETA(ctx context.Context, task *store.Task, now time.Time) time.Time {
+	if p := task.Progress; p != nil && p.ETASeconds != nil {
+		return p.ReportedAt.Add(time.Duration(*p.ETASeconds) * time.Second)
+	}
+	start := now
+	if task.StartedAt != nil {
+		start = *task.StartedAt
+	} else if task.AssignedAt != nil {
+		start = *task.AssignedAt
+	}
+	d := time.Duration(task.TimeoutSeconds) * time.Second
+	if avg, err := b.store.GetAgentAvgDuration(ctx, task.AssignedAgent); err == nil && avg != nil {
+		d = time.Duration(*avg * float64(time.Second))
+	}
+	if eta := start.Add(d); eta.After(now) {
+		return eta
+	}
+	return now
+}
+
+func (b *Broker) flagAtRisk(ctx context.Context, task *store.Task, eta, now time.Time) {
+	task.SLAAtRiskAt = &now
+	if err := b.store.UpdateTask(ctx, task); err != nil {
+		b.logger.Error("failed to flag task at risk", "task_id", task.ID, "error", err)
+		return
+	}
+	b.logger.Warn("task at risk of missing its deadline", "task_id", task.ID, "deadline", *task.Deadline, "estimated_completion", eta)
+	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+		TaskID:  task.ID,
+		Event:   "sla_at_risk",
+		AgentID: task.AssignedAgent,
+		Payload: map[string]interface{}{"deadline": *task.Deadline, "estimated_completion": eta},
+	})
+	if b.hermes != nil {
+		_ = b.hermes.Publish(hermes.SubjectTaskSLAAtRisk(task.ID.String()), slaEvent(task, &eta))
+	}
+}
+
+func (b *Broker) recordDeadlineMissed(ctx context.Context, task *store.Task, now time.Time) {
+	task.DeadlineMissedAt = &now
+	if err := b.store.UpdateTask(ctx, task); err != nil {
+		b.logger.Error("failed to record missed deadline", "task_id", task.ID, "error", err)
+		return
+	}
+	b.logger.Warn("task missed its deadline", "task_id", task.ID, "deadline", *task.Deadline, "status", task.Status)
+	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+		TaskID:  task.ID,
+		Event:   "deadline_missed",
+		AgentID: task.AssignedAgent,
+		Payload: map[string]interface{}{"deadline": *task.Deadline, "status": string(task.Status)},
+	})
+	if b.hermes != nil {
+		_ = b.hermes.Publish(hermes.SubjectTaskSLAMissed(task.ID.String()), slaEvent(task, nil))
+	}
+}
+
+func slaEvent(task *store.Task, eta *time.Time) hermes.TaskSLAEvent {
+	return hermes.TaskSLAEvent{
+		TaskID:              task.ID.String(),
+		Owner:               task.Owner,
+		Status:              string(task.Status),
+		AssignedAgent:       task.AssignedAgent,
+		Deadline:            *task.Deadline,
+		EstimatedCompletion: eta,
+	}
+}
@@ -0,0 +1,355 @@
+package broker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/budget"
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
+	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+	"github.com/MikeSquared-Agency/Dispatch/internal/warren"
+)
+
+// agentHistory holds the per-agent aggregates used by scoring.
+type agentHistory struct {
+	avgDuration *float64
+	avgCost     *float64
+	faultRate   *float64 // nil until the agent has faultRateMinAttempts attempts
+}
+
+// tickSnapshot caches everything candidate scoring reads from Forge, Warren
+// and the store, so one tick queries each source once instead of once per
+// pending task and candidate. Assignments made during the tick are applied to
+// the snapshot so later tasks see the updated load. It is safe for concurrent
+// use.
+type tickSnapshot struct {
+	personas []forge.Persona
+	cfg      config.AssignmentConfig
+
+	mu      sync.RWMutex
+	states  map[string]*warren.AgentState
+	active  map[string]int // concurrency slots in use per agent
+	limits  map[string]int // resolved concurrency limit per agent
+	capUse  map[string]int // active tasks per required capability
+	history map[string]agentHistory
+	trust   map[string]float64
+	woken   map[string]bool
+
+	// placements caches each task's resolved agent constraints.
+	placements map[uuid.UUID]*placement
+
+	// activeTasks are the assigned and in-progress tasks, less any preempted
+	// this tick.
+	activeTasks []*store.Task
+
+	// fair is nil unless fair-share scheduling is enabled.
+	fair *fairShare
+
+	budgets *budget.Tracker
+}
+
+// loadSnapshot fetches personas, agent states, active task load, concurrency
+// limits and history aggregates. Per-agent lookups run with bounded
+// parallelism.
+func (b *Broker) loadSnapshot(ctx context.Context) (*tickSnapshot, error) {
+	personas, err := b.forge.ListPersonas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active, err := b.store.GetActiveTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := b.agentLimitOverrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &tickSnapshot{
+		personas:    personas,
+		cfg:         b.cfg.Assignment,
+		activeTasks: active,
+		states:      make(map[string]*warren.AgentState, len(personas)),
+		active:      make(map[string]int, len(personas)),
+		limits:      make(map[string]int, len(personas)),
+		capUse:      make(map[string]int),
+		history:     make(map[string]agentHistory, len(personas)),
+		trust:       make(map[string]float64),
+		woken:       make(map[string]bool),
+		placements:  make(map[uuid.UUID]*placement),
+	}
+	for _, t := range active {
+		snap.active[t.AssignedAgent] += snap.taskSlots(t)
+		for _, c := range t.RequiredCapabilities {
+			snap.capUse[strings.ToLower(c)]++
+		}
+	}
+	for _, p := range personas {
+		snap.limits[p.Slug] = agentLimit(b.cfg.Assignment.MaxConcurrentPerAgent, p, overrides)
+	}
+	if fs := b.cfg.Assignment.FairShare; fs.Enabled {
+		snap.fair = newFairShare(fs, active)
+	}
+	if snap.budgets, err = budget.NewTracker(ctx, b.store, b.hermes, b.logger, time.Now()); err != nil {
+		return nil, err
+	}
+
+	b.parallel(len(personas), func(i int) {
+		p := personas[i]
+		state, err := b.warren.GetAgentState(ctx, p.Slug)
+		if err != nil {
+			b.logger.Warn("failed to get agent state", "agent", p.Name, "error", err)
+		}
+		var h agentHistory
+		if avg, err := b.store.GetAgentAvgDuration(ctx, p.Slug); err == nil {
+			h.avgDuration = avg
+		}
+		if avg, err := b.store.GetAgentAvgCost(ctx, p.Slug); err == nil {
+			h.avgCost = avg
+		}
+		if stats, err := b.store.GetAgentFailureStats(ctx, p.Slug); err == nil && stats != nil && stats.Attempts >= faultRateMinAttempts {
+			rate := stats.FaultRate()
+			h.faultRate = &rate
+		}
+
+		snap.mu.Lock()
+		if state != nil {
+			snap.states[p.Slug] = state
+		}
+		snap.history[p.Slug] = h
+		snap.mu.Unlock()
+	})
+
+	return snap, nil
+}
+
+// parallel runs fn for 0..n-1 with at most EvalParallelism calls in flight.
+func (b *Broker) parallel(n int, fn func(i int)) {
+	sem := make(chan struct{}, b.cfg.EvalParallelism())
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// candidates returns the personas holding capability, or all personas when
+// capability is empty. Matching is case-insensitive, as in Forge.
+func (s *tickSnapshot) candidates(capability string) []forge.Persona {
+	if capability == "" {
+		return append([]forge.Persona(nil), s.personas...)
+	}
+	var out []forge.Persona
+	for _, p := range s.personas {
+		for _, c := range p.Capabilities {
+			if strings.EqualFold(c, capability) {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (s *tickSnapshot) state(slug string) *warren.AgentState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.states[slug]
+}
+
+// slotsInUse returns the concurrency slots slug's active tasks take.
+func (s *tickSnapshot) slotsInUse(slug string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active[slug]
+}
+
+func (s *tickSnapshot) agentHistory(slug string) agentHistory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.history[slug]
+}
+
+// trustScore returns the cached trust score for the agent and task category,
+// loading it from the store on first use.
+func (b *Broker) trustScore(ctx context.Context, s *tickSnapshot, slug, category, severity string) float64 {
+	key := slug + "|" + category + "|" + severity
+	s.mu.RLock()
+	trust, ok := s.trust[key]
+	s.mu.RUnlock()
+	if ok {
+		return trust
+	}
+
+	trust, err := b.store.GetTrustScore(ctx, slug, category, severity)
+	if err != nil {
+		trust = 0
+	}
+	s.mu.Lock()
+	s.trust[key] = trust
+	s.mu.Unlock()
+	return trust
+}
+
+// wakeRequested reports whether a wake has already been sent to slug this
+// tick, so several tasks held for the same agent trigger a single wake call.
+func (s *tickSnapshot) wakeRequested(slug string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.woken[slug]
+}
+
+func (s *tickSnapshot) recordWake(slug string) {
+	s.mu.Lock()
+	s.woken[slug] = true
+	s.mu.Unlock()
+}
+
+func (s *tickSnapshot) placement(task *store.Task) *placement {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.placements[task.ID]
+}
+
+// setPlacement caches p for task unless another goroutine got there first,
+// and returns the cached placement.
+func (s *tickSnapshot) setPlacement(task *store.Task, p *placement) *placement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached := s.placements[task.ID]; cached != nil {
+		return cached
+	}
+	s.placements[task.ID] = p
+	return p
+}
+
+// recordAssignment applies an assignment to the snapshot: the agent's load
+// goes up, a ready agent is treated as busy for the rest of the tick, and the
+// task counts against its fair-share group, budgets and capability limits.
+func (s *tickSnapshot) recordAssignment(task *store.Task) {
+	s.fair.record(task)
+	s.budgets.Record(task, budget.StageAssign)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[task.AssignedAgent] += s.taskSlots(task)
+	for _, c := range task.RequiredCapabilities {
+		s.capUse[strings.ToLower(c)]++
+	}
+	if st := s.states[task.AssignedAgent]; st != nil && st.Status == "ready" {
+		busy := *st
+		busy.Status = "busy"
+		s.states[task.AssignedAgent] = &busy
+	}
+}
+
+// preemptible returns the active preemptible tasks with priority below
+// priority, lowest priority first and, within a priority, most recently
+// assigned first so the least work is lost.
+func (s *tickSnapshot) preemptible(priority int) []*store.Task {
+	s.mu.RLock()
+	var out []*store.Task
+	for _, t := range s.activeTasks {
+		if t.Preemptible && t.Priority < priority && t.AssignedAgent != "" {
+			out = append(out, t)
+		}
+	}
+	s.mu.RUnlock()
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority < out[j].Priority
+		}
+		return assignedAfter(out[i], out[j])
+	})
+	return out
+}
+
+func assignedAfter(a, b *store.Task) bool {
+	if a.AssignedAt == nil || b.AssignedAt == nil {
+		return a.AssignedAt != nil
+	}
+	return a.AssignedAt.After(*b.AssignedAt)
+}
+
+// releaseSlots frees the slots task takes on slug for the rest of the tick;
+// reserveSlots takes them back.
+func (s *tickSnapshot) releaseSlots(slug string, task *store.Task) {
+	s.mu.Lock()
+	s.active[slug] -= s.taskSlots(task)
+	s.mu.Unlock()
+}
+
+func (s *tickSnapshot) reserveSlots(slug string, task *store.Task) {
+	s.mu.Lock()
+	s.active[slug] += s.taskSlots(task)
+	s.mu.Unlock()
+}
+
+// recordPreemption removes a yielded or released task from the snapshot's
+// active set.
+// Its agent slots are released separately by releaseSlots.
+func (s *tickSnapshot) recordPreemption(task *store.Task) {
+	s.fair.release(task)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range task.RequiredCapabilities {
+		s.capUse[strings.ToLower(c)]--
+	}
+	for i, t := range s.activeTasks {
+		if t == task {
+			s.activeTasks = append(s.activeTasks[:i:i], s.activeTasks[i+1:]...)
+			return
+		}
+	}
+}
+
+// taskSlots is the number of agent concurrency slots task takes.
+func (s *tickSnapshot) taskSlots(task *store.Task) int {
+	return scoring.TaskSlots(task, s.cfg.Slots)
+}
+
+// limit returns slug's resolved concurrency limit; zero means unlimited.
+func (s *tickSnapshot) limit(slug string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if n, ok := s.limits[slug]; ok {
+		return n
+	}
+	return s.cfg.MaxConcurrentPerAgent
+}
+
+// fits reports whether slug has room for task. A task heavier than the
+// agent's whole limit fits an idle agent.
+func (s *tickSnapshot) fits(slug string, task *store.Task) bool {
+	limit := s.limit(slug)
+	if limit <= 0 {
+		return true
+	}
+	slots := min(s.taskSlots(task), limit)
+	return s.slotsInUse(slug)+slots <= limit
+}
+
+// capabilityAllows reports whether every capability task requires is below
+// its swarm-wide concurrency limit.
+func (s *tickSnapshot) capabilityAllows(task *store.Task) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range task.RequiredCapabilities {
+		if limit := s.cfg.CapabilityLimit(c); limit > 0 && s.capUse[strings.ToLower(c)] >= limit {
+			return false
+		}
+	}
+	return true
+}
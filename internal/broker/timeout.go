@@ -21,6 +21,7 @@ func (b *Broker) timeoutLoop(ctx context.Context) {
 			return
 		case <-ticker.C:
 			b.checkTimeouts(ctx)
+			b.checkDeadlines(ctx, time.Now())
 		}
 	}
 }
@@ -34,37 +35,38 @@ func (b *Broker) checkTimeouts(ctx context.Context) {
 
 	now := time.Now()
 	for _, task := range tasks {
-		var start time.Time
-		if task.StartedAt != nil {
-			start = *task.StartedAt
-		} else if task.AssignedAt != nil {
-			start = *task.AssignedAt
-		} else {
+		// Waking tasks are owned by checkWaking until the agent is up.
+		if task.SubState == store.SubStateWaking {
+			continue
+		}
+		if b.ackExpired(task, now) {
+			b.reclaimUnacked(ctx, task)
 			continue
 		}
 
-		timeout := time.Duration(task.TimeoutSeconds) * time.Second
-		if now.Sub(start) <= timeout {
+		reason := b.timeoutReason(task, now)
+		if reason == "" {
 			continue
 		}
 
 		timedOutIn := string(task.Status)
-		b.logger.Warn("task timed out", "task_id", task.ID, "assigned_agent", task.AssignedAgent, "timed_out_in", timedOutIn)
+		prevAgent := task.AssignedAgent
+		b.logger.Warn("task timed out", "task_id", task.ID, "assigned_agent", task.AssignedAgent, "timed_out_in", timedOutIn, "reason", reason)
 
-		if task.RetryCount < task.MaxRetries {
+		rerun := b.escalate(ctx, task, task.RetryCount+1, "timed out: "+reason)
+		if task.RetryCount < task.MaxRetries || rerun {
 			// Retry — reset to pending for re-assignment
 			task.RetryCount++
-			task.Status = store.StatusPending
-			task.AssignedAgent = ""
-			task.AssignedAt = nil
-			task.StartedAt = nil
+			task.ClearAssignment()
 			if err := b.store.UpdateTask(ctx, task); err != nil {
 				b.logger.Error("failed to reset timed out task", "task_id", task.ID, "error", err)
 				continue
 			}
 			_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
-				TaskID: task.ID,
-				Event:  "timeout_retry",
+				TaskID:  task.ID,
+				Event:   "timeout_retry",
+				AgentID: prevAgent,
+				Payload: map[string]interface{}{"timed_out_in": timedOutIn, "reason": reason, "retry_count": task.RetryCount},
 			})
 			if b.hermes != nil {
 				_ = b.hermes.Publish(hermes.SubjectTaskTimeout(task.ID.String()), hermes.TaskTimeoutEvent{
@@ -72,13 +74,14 @@ func (b *Broker) checkTimeouts(ctx context.Context) {
 					RetryCount: task.RetryCount,
 					MaxRetries: task.MaxRetries,
 					TimedOutIn: timedOutIn,
+					Reason:     reason,
 				})
 				_ = b.hermes.Publish(hermes.SubjectTaskRetry(task.ID.String()), map[string]interface{}{
 					"task_id":        task.ID.String(),
 					"retry_count":    task.RetryCount,
 					"max_retries":    task.MaxRetries,
 					"previous_state": timedOutIn,
-					"previous_agent": task.AssignedAgent,
+					"previous_agent": prevAgent,
 				})
 			}
 		} else {
@@ -92,8 +95,10 @@ func (b *Broker) checkTimeouts(ctx context.Context) {
 				continue
 			}
 			_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
-				TaskID: task.ID,
-				Event:  "timeout_exhausted",
+				TaskID:  task.ID,
+				Event:   "timeout_exhausted",
+				AgentID: prevAgent,
+				Payload: map[string]interface{}{"timed_out_in": timedOutIn, "reason": reason},
 			})
 			if b.hermes != nil {
 				_ = b.hermes.Publish(hermes.SubjectTaskTimeout(task.ID.String()), hermes.TaskTimeoutEvent{
@@ -101,6 +106,7 @@ func (b *Broker) checkTimeouts(ctx context.Context) {
 					RetryCount: task.RetryCount,
 					MaxRetries: task.MaxRetries,
 					TimedOutIn: timedOutIn,
+					Reason:     reason,
 				})
 				_ = b.hermes.Publish(hermes.SubjectTaskDLQ(task.ID.String()), map[string]interface{}{
 					"task_id":     task.ID.String(),
@@ -109,6 +115,134 @@ func (b *Broker) checkTimeouts(ctx context.Context) {
 					"max_retries": task.MaxRetries,
 				})
 			}
+			b.orch.TaskFailed(ctx, task)
+		}
+	}
+}
+
+// timeoutReason reports why a task should be timed out, or "" if it is still
+// live. Tasks that have never sent a heartbeat keep their lease, or the fixed
+// timeout_seconds deadline before an ack. Once an agent heartbeats, the task
+// stays alive as long as heartbeats keep arriving, up to the hard deadline:
+// heartbeats supersede the lease, which is not checked again.
+func (b *Broker) timeoutReason(task *store.Task, now time.Time) string {
+	var start time.Time
+	if task.StartedAt != nil {
+		start = *task.StartedAt
+	} else if task.AssignedAt != nil {
+		start = *task.AssignedAt
+	} else {
+		return ""
+	}
+
+	timeout := time.Duration(task.TimeoutSeconds) * time.Second
+	liveness := b.cfg.HeartbeatTimeout()
+	if task.LastHeartbeatAt == nil || liveness <= 0 {
+		if task.LeaseExpiresAt != nil {
+			if now.After(*task.LeaseExpiresAt) {
+				return "lease_expired"
+			}
+			return ""
+		}
+		if now.Sub(start) > timeout {
+			return "deadline"
+		}
+		return ""
+	}
+
+	// The hard deadline never cuts a task shorter than its own timeout.
+	hard := b.cfg.HardDeadline()
+	if hard > 0 {
+		if timeout > hard {
+			hard = timeout
+		}
+		if now.Sub(start) > hard {
+			return "hard_deadline"
+		}
+	}
+	if now.Sub(*task.LastHeartbeatAt) > liveness {
+		return "heartbeat_missed"
+	}
+	return ""
+}
+
+// ackExpired reports whether an assigned task has gone unacknowledged for
+// longer than the ack timeout.
+func (b *Broker) ackExpired(task *store.Task, now time.Time) bool {
+	ackTimeout := b.cfg.AckTimeout()
+	if ackTimeout <= 0 || task.Status != store.StatusAssigned || task.SubState == store.SubStateWaking ||
+		task.AckedAt != nil || task.AssignedAt == nil {
+		return false
+	}
+	return now.Sub(*task.AssignedAt) > ackTimeout
+}
+
+// reclaimUnacked returns a task the agent never acknowledged to the pending
+// queue without consuming a retry. The agent is remembered in metadata so the
+// next assignment prefers someone else.
+func (b *Broker) reclaimUnacked(ctx context.Context, task *store.Task) {
+	prevAgent := task.AssignedAgent
+	b.logger.Warn("task not acknowledged, reclaiming", "task_id", task.ID, "assigned_agent", prevAgent)
+
+	if task.Metadata == nil {
+		task.Metadata = map[string]interface{}{}
+	}
+	task.Metadata[metaAckMissedAgents] = appendUnique(ackMissedAgents(task), prevAgent)
+	task.ClearAssignment()
+	if err := b.store.UpdateTask(ctx, task); err != nil {
+		b.logger.Error("failed to reclaim unacknowledged task", "task_id", task.ID, "error", err)
+		return
+	}
+	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+		TaskID:  task.ID,
+		Event:   "ack_timeout",
+		AgentID: prevAgent,
+		Payload: map[string]interface{}{"ack_timeout_ms": b.cfg.Assignment.AckTimeoutMs},
+	})
+	if b.hermes != nil {
+		_ = b.hermes.Publish(hermes.SubjectTaskReclaimed(task.ID.String()), map[string]interface{}{
+			"task_id":        task.ID.String(),
+			"reason":         "ack_timeout",
+			"previous_agent": prevAgent,
+		})
+	}
+}
+
+// metaAckMissedAgents is the task metadata key listing agents that failed to
+// acknowledge the task.
+const metaAckMissedAgents = "ack_missed_agents"
+
+func ackMissedAgents(task *store.Task) []string {
+	return metadataList(task, metaAckMissedAgents)
+}
+
+// metadataList reads a string list from task metadata, accepting both the
+// in-memory []string form and the []interface{} form produced by JSON.
+func metadataList(task *store.Task, key string) []string {
+	raw, ok := task.Metadata[key]
+	if !ok {
+		return nil
+	}
+	switch v := raw.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, existing := range list {
+		if existing == v {
+			return list
 		}
 	}
+	return append(list, v)
 }
@@ -0,0 +1,175 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// metaWakeFailedAgents is the task metadata key listing agents that did not
+// wake in time for the task.
+const metaWakeFailedAgents = "wake_failed_agents"
+
+// checkWaking resolves every task held in the waking sub-state against the
+// tick's agent states. Tasks whose agent is now up are announced; tasks whose
+// agent has not woken within WakeTimeout are released back to pending and
+// returned, so the rest of the tick assigns them to the next-best candidate.
+func (b *Broker) checkWaking(ctx context.Context, snap *tickSnapshot) []*store.Task {
+	var waking []*store.Task
+	for _, task := range snap.activeTasks {
+		if task.SubState == store.SubStateWaking {
+			waking = append(waking, task)
+		}
+	}
+
+	var released []*store.Task
+	now := time.Now()
+	for _, task := range waking {
+		if state := snap.state(task.AssignedAgent); state != nil && agentAwake(state.Status) {
+			b.completeWake(ctx, task, personaName(snap.personas, task.AssignedAgent))
+			continue
+		}
+		if task.AssignedAt != nil && now.Sub(*task.AssignedAt) > b.cfg.WakeTimeout() {
+			if b.abandonWake(ctx, snap, task) {
+				released = append(released, task)
+			}
+		}
+	}
+	return released
+}
+
+// HandleAgentStarted announces any tasks that were waiting for agentID to wake.
+func (b *Broker) HandleAgentStarted(ctx context.Context, agentID string) {
+	tasks, err := b.store.GetActiveTasksForAgent(ctx, agentID)
+	if err != nil {
+		b.logger.Error("failed to get tasks for started agent", "agent", agentID, "error", err)
+		return
+	}
+	name := agentID
+	for _, task := range tasks {
+		if task.SubState != store.SubStateWaking {
+			continue
+		}
+		if name == agentID && b.forge != nil {
+			if personas, err := b.forge.ListPersonas(ctx); err == nil {
+				name = personaName(personas, agentID)
+			}
+		}
+		b.completeWake(ctx, task, name)
+	}
+}
+
+// personaName returns the name of the persona with slug, or slug itself when
+// Forge does not list it.
+func personaName(personas []forge.Persona, slug string) string {
+	for _, p := range personas {
+		if p.Slug == slug {
+			return p.Name
+		}
+	}
+	return slug
+}
+
+// completeWake clears the waking sub-state and announces the assignment to
+// agentName. The ack deadline starts from this point rather than from when
+// the wake began. The tick and the agent-started handler both resolve wakes,
+// so the write only lands if the task is still waking on the same agent.
+func (b *Broker) completeWake(ctx context.Context, task *store.Task, agentName string) {
+	prev := *task
+	now := time.Now()
+	task.SubState = ""
+	task.AssignedAt = &now
+	if err := b.store.UpdateTaskGuarded(ctx, task, store.Waking(task.AssignedAgent)); err != nil {
+		*task = prev
+		if !errors.Is(err, store.ErrTaskChanged) {
+			b.logger.Error("failed to complete wake", "task_id", task.ID, "error", err)
+		}
+		return
+	}
+	b.logger.Info("agent awake, announcing assignment", "task_id", task.ID, "agent", agentName)
+	b.announceAssignment(ctx, task, agentName, resultFromTask(task), 0)
+}
+
+// abandonWake gives up on the current agent and returns the task to pending,
+// recording the agent so it is excluded from the next assignment. The
+// agent's slots are released in snap. It reports whether the task was
+// released; a task that woke or moved since the snapshot is left alone.
+func (b *Broker) abandonWake(ctx context.Context, snap *tickSnapshot, task *store.Task) bool {
+	agent := task.AssignedAgent
+	prev := *task
+
+	metadata := make(map[string]interface{}, len(task.Metadata)+1)
+	for k, v := range task.Metadata {
+		metadata[k] = v
+	}
+	metadata[metaWakeFailedAgents] = appendUnique(metadataList(task, metaWakeFailedAgents), agent)
+	task.Metadata = metadata
+	task.ClearAssignment()
+	if err := b.store.UpdateTaskGuarded(ctx, task, store.Waking(agent)); err != nil {
+		*task = prev
+		if !errors.Is(err, store.ErrTaskChanged) {
+			b.logger.Error("failed to release waking task", "task_id", task.ID, "error", err)
+		}
+		return false
+	}
+	b.logger.Warn("agent did not wake in time, falling back", "task_id", task.ID, "agent", agent)
+	snap.releaseSlots(agent, task)
+	snap.recordPreemption(task)
+	_ = b.store.CreateTaskEvent(ctx, &store.TaskEvent{
+		TaskID:  task.ID,
+		Event:   "wake_timeout",
+		AgentID: agent,
+		Payload: map[string]interface{}{"wake_timeout_ms": b.cfg.Assignment.WakeTimeoutMs},
+	})
+	if b.hermes != nil {
+		_ = b.hermes.Publish(hermes.SubjectTaskWakeTimeout(task.ID.String()), map[string]interface{}{
+			"task_id": task.ID.String(),
+			"agent":   agent,
+		})
+	}
+	return true
+}
+
+func agentAwake(status string) bool {
+	return status == "ready" || status == "busy"
+}
+
+// resultFromTask rebuilds the scoring result persisted by applyScoring so a
+// deferred assignment can be announced with the same breakdown.
+func resultFromTask(task *store.Task) scoring.ScoringResult {
+	r := scoring.ScoringResult{
+		AgentSlug:        task.AssignedAgent,
+		OversightLevel:   task.OversightLevel,
+		FastPath:         task.FastPath,
+		Eligible:         true,
+		RecommendedModel: task.RecommendedModel,
+		ModelTier:        task.ModelTier,
+		RoutingMethod:    task.RoutingMethod,
+		Runtime:          task.Runtime,
+	}
+	for name, raw := range task.ScoringFactors {
+		if name == "total_score" {
+			r.TotalScore, _ = raw.(float64)
+			continue
+		}
+		f, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fr := scoring.FactorResult{Name: name}
+		fr.Score, _ = f["score"].(float64)
+		fr.Weight, _ = f["weight"].(float64)
+		fr.Weighted, _ = f["weighted"].(float64)
+		fr.Available, _ = f["available"].(bool)
+		fr.Reason, _ = f["reason"].(string)
+		r.Factors = append(r.Factors, fr)
+	}
+	sort.Slice(r.Factors, func(i, j int) bool { return r.Factors[i].Name < r.Factors[j].Name })
+	return r
+}
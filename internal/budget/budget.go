@@ -0,0 +1,229 @@
+// Package budget enforces per-owner and per-source task, token and cost
+// budgets when tasks are created and when they are assigned.
+package budget
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// Usage thresholds, in percent, that trigger a budget.threshold event.
+const (
+	ThresholdWarn      = 80
+	ThresholdExhausted = 100
+)
+
+// Stage is the point in a task's life at which budgets are checked.
+type Stage int
+
+const (
+	// StageCreate counts created tasks against MaxTasks.
+	StageCreate Stage = iota
+	// StageAssign counts dispatched tasks against MaxTasks.
+	StageAssign
+)
+
+// Decision is the outcome of a budget check. An empty Action means the task
+// is within every budget that applies to it.
+type Decision struct {
+	Action string        `json:"action,omitempty"`
+	Budget *store.Budget `json:"budget,omitempty"`
+	Limits []string      `json:"limits,omitempty"`
+}
+
+// actionRank orders actions from most to least permissive, so the strictest
+// exhausted budget wins.
+var actionRank = map[string]int{
+	store.BudgetActionDowngrade: 1,
+	store.BudgetActionQueue:     2,
+	store.BudgetActionReject:    3,
+}
+
+// Tracker checks tasks against budgets. It loads budgets once and caches
+// each budget's usage, so one Tracker can serve every task in an assignment
+// tick. It is safe for concurrent use.
+type Tracker struct {
+	store  store.Store
+	hermes hermes.Client
+	logger *slog.Logger
+	now    time.Time
+
+	mu      sync.Mutex
+	budgets []*store.Budget
+	usage   map[uuid.UUID]*store.BudgetUsage
+}
+
+// NewTracker loads the configured budgets. now fixes the budget periods for
+// the lifetime of the tracker. A nil logger uses slog.Default().
+func NewTracker(ctx context.Context, s store.Store, h hermes.Client, logger *slog.Logger, now time.Time) (*Tracker, error) {
+	budgets, err := s.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Tracker{
+		store:   s,
+		hermes:  h,
+		logger:  logger,
+		now:     now,
+		budgets: budgets,
+		usage:   make(map[uuid.UUID]*store.BudgetUsage),
+	}, nil
+}
+
+// CheckCreate loads the current budgets and checks a task that is about to
+// be created. Callers refuse the task when the decision is reject.
+func CheckCreate(ctx context.Context, s store.Store, h hermes.Client, logger *slog.Logger, task *store.Task) (Decision, error) {
+	t, err := NewTracker(ctx, s, h, logger, time.Now())
+	if err != nil {
+		return Decision{}, err
+	}
+	return t.Check(ctx, task, StageCreate)
+}
+
+// Check evaluates task against every budget that applies to it and returns
+// the action of the strictest exhausted budget. It also publishes threshold
+// events for budgets whose usage has crossed 80% or 100% this period.
+func (t *Tracker) Check(ctx context.Context, task *store.Task, stage Stage) (Decision, error) {
+	var d Decision
+	if t == nil {
+		return d, nil
+	}
+	for _, b := range t.budgets {
+		if !b.Applies(task) {
+			continue
+		}
+		u, err := t.usageFor(ctx, b)
+		if err != nil {
+			return Decision{}, err
+		}
+		t.alert(ctx, b, u)
+
+		count := u.Tasks
+		if stage == StageAssign {
+			count = u.Dispatched
+		}
+		limits := b.Exceeded(count, u, task)
+		if len(limits) == 0 {
+			continue
+		}
+		if actionRank[b.Action] > actionRank[d.Action] {
+			d = Decision{Action: b.Action, Budget: b, Limits: limits}
+		}
+	}
+	return d, nil
+}
+
+// Record applies a created or assigned task to the cached usage so later
+// checks from the same tracker see it. Assigned tasks add their estimates to
+// token and cost usage until the real figures are recorded.
+func (t *Tracker) Record(task *store.Task, stage Stage) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range t.budgets {
+		u, ok := t.usage[b.ID]
+		if !ok || !b.Applies(task) {
+			continue
+		}
+		if stage == StageCreate {
+			u.Tasks++
+			continue
+		}
+		u.Dispatched++
+		if task.CostEstimateTokens != nil {
+			u.Tokens += *task.CostEstimateTokens
+		}
+		if task.CostEstimateUSD != nil {
+			u.CostUSD += *task.CostEstimateUSD
+		}
+	}
+}
+
+func (t *Tracker) usageFor(ctx context.Context, b *store.Budget) (store.BudgetUsage, error) {
+	t.mu.Lock()
+	u, ok := t.usage[b.ID]
+	t.mu.Unlock()
+	if ok {
+		return t.snapshot(u), nil
+	}
+
+	u, err := t.store.GetBudgetUsage(ctx, b.Scope, b.Subject, b.PeriodStart(t.now))
+	if err != nil {
+		return store.BudgetUsage{}, err
+	}
+	t.mu.Lock()
+	if cached, ok := t.usage[b.ID]; ok {
+		u = cached
+	} else {
+		t.usage[b.ID] = u
+	}
+	t.mu.Unlock()
+	return t.snapshot(u), nil
+}
+
+func (t *Tracker) snapshot(u *store.BudgetUsage) store.BudgetUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *u
+}
+
+// alert publishes a threshold event the first time usage crosses 80% and
+// 100% in a period.
+func (t *Tracker) alert(ctx context.Context, b *store.Budget, u store.BudgetUsage) {
+	fraction := b.Fraction(u)
+	threshold := 0
+	switch {
+	case fraction >= 1:
+		threshold = ThresholdExhausted
+	case fraction >= float64(ThresholdWarn)/100:
+		threshold = ThresholdWarn
+	default:
+		return
+	}
+
+	periodStart := b.PeriodStart(t.now)
+	t.mu.Lock()
+	alerted := b.AlertedThreshold
+	if b.AlertedPeriodStart == nil || !b.AlertedPeriodStart.Equal(periodStart) {
+		alerted = 0
+	}
+	if threshold <= alerted {
+		t.mu.Unlock()
+		return
+	}
+	b.AlertedThreshold = threshold
+	b.AlertedPeriodStart = &periodStart
+	t.mu.Unlock()
+
+	if err := t.store.MarkBudgetAlerted(ctx, b.ID, periodStart, threshold); err != nil {
+		t.logger.Warn("failed to record budget alert", "budget_id", b.ID, "error", err)
+	}
+	t.logger.Warn("budget threshold crossed", "scope", b.Scope, "subject", b.Subject, "period", b.Period, "threshold", threshold)
+	if t.hermes != nil {
+		_ = t.hermes.Publish(hermes.SubjectBudgetThreshold(b.ID.String()), hermes.BudgetThresholdEvent{
+			BudgetID:    b.ID.String(),
+			Scope:       b.Scope,
+			Subject:     b.Subject,
+			Period:      b.Period,
+			PeriodStart: periodStart,
+			Threshold:   threshold,
+			Fraction:    fraction,
+			Tasks:       u.Tasks,
+			Tokens:      u.Tokens,
+			CostUSD:     u.CostUSD,
+			Action:      b.Action,
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -20,6 +21,13 @@ type Config struct {
 	Scoring      ScoringConfig      `yaml:"scoring"`
 	ModelRouting ModelRoutingConfig `yaml:"model_routing"`
 	StageGates   StageGatesConfig   `yaml:"stage_gates"`
+	Schedules    SchedulesConfig    `yaml:"schedules"`
+	Escalation   EscalationConfig   `yaml:"escalation"`
+	Retry        RetryConfig        `yaml:"retry"`
+	Orchestrator OrchestratorConfig `yaml:"orchestrator"`
+	Dependencies DependenciesConfig `yaml:"dependencies"`
+	Hierarchy    HierarchyConfig    `yaml:"hierarchy"`
+	Iterations   IterationsConfig   `yaml:"iterations"`
 	Logging      LoggingConfig      `yaml:"logging"`
 }
 
@@ -27,6 +35,127 @@ type StageGatesConfig struct {
 	Gates map[string][]string `yaml:"gates"`
 }
 
+// Escalation actions.
+const (
+	EscalatePromoteModel      = "promote_model"
+	EscalateWidenCapabilities = "widen_capabilities"
+	EscalateReassign          = "reassign"
+	EscalateBacklogItem       = "create_backlog_item"
+)
+
+// EscalationConfig is the chain of steps applied as a task keeps failing.
+// Agent-reported failures and timeouts both count.
+type EscalationConfig struct {
+	Steps []EscalationStep `yaml:"steps"`
+}
+
+// EscalationStep runs once, on a task's AfterFailures-th failure. Steps that
+// change how the task runs (promote_model, widen_capabilities, reassign)
+// also give it another attempt if its retries are used up.
+type EscalationStep struct {
+	AfterFailures int    `yaml:"after_failures"`
+	Action        string `yaml:"action"`
+
+	// Tier is the minimum model tier for promote_model (default premium).
+	Tier string `yaml:"tier"`
+	// Capabilities replace the task's required capabilities for
+	// widen_capabilities; when empty only the primary capability is kept.
+	Capabilities []string `yaml:"capabilities"`
+	// Agent is the senior agent reassign pins the task to.
+	Agent string `yaml:"agent"`
+}
+
+// Due returns the steps that run on a task's failures-th failure.
+func (e EscalationConfig) Due(failures int) []EscalationStep {
+	var out []EscalationStep
+	for _, s := range e.Steps {
+		if s.AfterFailures == failures {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// OrchestratorConfig controls dispatching backlog item stages as swarm
+// tasks. Stages maps each dispatched stage to the capabilities its task
+// requires; other stages are left to humans.
+type OrchestratorConfig struct {
+	Enabled bool                `yaml:"enabled"`
+	Stages  map[string][]string `yaml:"stages"`
+}
+
+// Policies for the items a cancelled backlog item was blocking.
+const (
+	OnCancelResolve = "resolve" // resolve the dependency, as if the blocker were done
+	OnCancelCascade = "cascade" // cancel the blocked items too
+)
+
+// DependenciesConfig controls what closing a backlog item does to the items
+// it blocks.
+type DependenciesConfig struct {
+	OnCancel string `yaml:"on_cancel"`
+}
+
+// HierarchyConfig controls how backlog items roll up to their parents.
+// With AutoComplete, a parent is marked done once all of its children are
+// done or cancelled.
+type HierarchyConfig struct {
+	AutoComplete bool `yaml:"auto_complete"`
+}
+
+// IterationsConfig controls how iterations are planned. MaxDomainShare is
+// the largest share of an iteration's capacity the auto-planner gives to
+// one domain; 1 turns the balance off.
+type IterationsConfig struct {
+	MaxDomainShare float64 `yaml:"max_domain_share"`
+}
+
+// Retry policies for a classified task failure.
+const (
+	RetrySameAgent = "retry_same"      // retry, favouring the agent that failed
+	RetryElsewhere = "retry_elsewhere" // retry, avoiding the agent that failed
+	RetryEscalate  = "escalate"        // retry only if an escalation step changes how the task runs
+	RetryStop      = "stop"            // send to the DLQ
+)
+
+// RetryConfig maps failure classes to retry policies. Failures reported
+// without a class are retried on any agent.
+type RetryConfig struct {
+	Policies map[string]string `yaml:"policies"`
+}
+
+// Policy returns the retry policy for a failure class, or "" for an
+// unclassified failure. Classes without a policy retry elsewhere.
+func (r RetryConfig) Policy(class string) string {
+	if class == "" {
+		return ""
+	}
+	if p, ok := r.Policies[class]; ok {
+		return p
+	}
+	return RetryElsewhere
+}
+
+// Catch-up policies for schedule runs missed while Dispatch was down.
+const (
+	CatchUpNone   = "none"
+	CatchUpLatest = "latest"
+	CatchUpAll    = "all"
+)
+
+// SchedulesConfig controls the recurring task scheduler.
+type SchedulesConfig struct {
+	TickIntervalMs int `yaml:"tick_interval_ms"` // zero disables the scheduler
+
+	// A run more than MissedAfterMs late counts as missed. CatchUp decides
+	// what happens to missed runs: none drops them, latest runs the most
+	// recent one, all runs each of them up to MaxCatchUp. Schedules may
+	// override CatchUp.
+	MissedAfterMs int    `yaml:"missed_after_ms"`
+	CatchUp       string `yaml:"catch_up"`
+	MaxCatchUp    int    `yaml:"max_catch_up"`
+}
+
 type ServerConfig struct {
 	Port        int    `yaml:"port"`
 	MetricsPort int    `yaml:"metrics_port"`
@@ -54,17 +183,136 @@ type AlexandriaConfig struct {
 	URL string `yaml:"url"`
 }
 
+// Assignment modes. Greedy assigns pending tasks one at a time in priority
+// order; batch solves all of a tick's pending tasks together.
+const (
+	AssignmentModeGreedy = "greedy"
+	AssignmentModeBatch  = "batch"
+)
+
 type AssignmentConfig struct {
-	TickIntervalMs        int  `yaml:"tick_interval_ms"`
-	WakeTimeoutMs         int  `yaml:"wake_timeout_ms"`
-	DefaultTimeoutMs      int  `yaml:"default_timeout_ms"`
-	MaxConcurrentPerAgent int  `yaml:"max_concurrent_per_agent"`
-	OwnerFilterEnabled    bool `yaml:"owner_filter_enabled"`
+	Mode                  string `yaml:"mode"` // greedy (default) or batch
+	TickIntervalMs        int    `yaml:"tick_interval_ms"`
+	WakeTimeoutMs         int    `yaml:"wake_timeout_ms"`
+	AckTimeoutMs          int    `yaml:"ack_timeout_ms"` // 0 disables ack reclaiming
+	DefaultTimeoutMs      int    `yaml:"default_timeout_ms"`
+	MaxConcurrentPerAgent int    `yaml:"max_concurrent_per_agent"`
+	OwnerFilterEnabled    bool   `yaml:"owner_filter_enabled"`
+
+	// Liveness: once an agent has sent a heartbeat, the task times out when
+	// HeartbeatMissedLimit intervals pass without one, or when HardDeadlineMs
+	// elapses since start, whichever comes first. Zero interval disables it.
+	HeartbeatIntervalMs  int `yaml:"heartbeat_interval_ms"`
+	HeartbeatMissedLimit int `yaml:"heartbeat_missed_limit"`
+	HardDeadlineMs       int `yaml:"hard_deadline_ms"`
+
+	// MaxParallelEvaluations bounds concurrent agent lookups and candidate
+	// scoring within a tick.
+	MaxParallelEvaluations int `yaml:"max_parallel_evaluations"`
+
+	// CapabilityLimits caps swarm-wide concurrent tasks that require a
+	// capability, e.g. deploy: 2. Unlisted capabilities are unlimited.
+	CapabilityLimits map[string]int `yaml:"capability_limits"`
+	Slots            SlotConfig     `yaml:"slots"`
+
+	FairShare  FairShareConfig  `yaml:"fair_share"`
+	Preemption PreemptionConfig `yaml:"preemption"`
+	SLA        SLAConfig        `yaml:"sla"`
+}
+
+// CapabilityLimit returns the swarm-wide limit for capability, matched
+// case-insensitively; zero means unlimited.
+func (a AssignmentConfig) CapabilityLimit(capability string) int {
+	for c, n := range a.CapabilityLimits {
+		if strings.EqualFold(c, capability) {
+			return n
+		}
+	}
+	return 0
+}
+
+// SlotConfig sets how many of an agent's concurrency slots a task takes.
+// Tasks take one slot unless their duration class or complexity marks them
+// as heavy; the larger applicable weight wins.
+type SlotConfig struct {
+	ByDurationClass map[string]int `yaml:"by_duration_class"`
+
+	// Tasks with complexity_score at or above HeavyComplexity take
+	// HeavySlots slots. Zero disables.
+	HeavyComplexity float64 `yaml:"heavy_complexity"`
+	HeavySlots      int     `yaml:"heavy_slots"`
+}
+
+// PreemptionConfig lets urgent tasks displace preemptible lower-priority
+// work when every capable agent is at MaxConcurrentPerAgent.
+type PreemptionConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// MinPriority is the lowest task priority allowed to preempt.
+	MinPriority int `yaml:"min_priority"`
+}
+
+// SLAConfig controls deadline-aware ordering. A task's slack is the time to
+// its deadline less its estimated run time; once slack drops below
+// BoostWindowMs the task's effective priority rises, reaching the top of the
+// scale when slack runs out. Zero disables the boost.
+type SLAConfig struct {
+	BoostWindowMs int `yaml:"boost_window_ms"`
+}
+
+func (s SLAConfig) BoostWindow() time.Duration {
+	return time.Duration(s.BoostWindowMs) * time.Millisecond
+}
+
+// Fair-share grouping keys.
+const (
+	FairShareByOwner  = "owner"
+	FairShareBySource = "source"
+)
+
+// FairShareConfig controls weighted fair queueing of pending tasks across
+// owners (or sources) so one busy group cannot starve the rest.
+type FairShareConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Key     string `yaml:"key"` // owner (default) or source
+
+	// Weights sets each group's relative share; unlisted groups weigh 1.
+	Weights map[string]float64 `yaml:"weights"`
+
+	// MaxInFlight caps a group's assigned plus in-progress tasks.
+	// DefaultMaxInFlight applies to unlisted groups; zero means no cap.
+	MaxInFlight        map[string]int `yaml:"max_in_flight"`
+	DefaultMaxInFlight int            `yaml:"default_max_in_flight"`
+
+	// AgingIntervalMs raises a waiting task's effective priority by one for
+	// every interval it has been pending, up to 10. Zero disables aging.
+	AgingIntervalMs int `yaml:"aging_interval_ms"`
+}
+
+// ShareWeight returns the configured weight for group, defaulting to 1.
+func (f FairShareConfig) ShareWeight(group string) float64 {
+	if w, ok := f.Weights[group]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// InFlightCap returns the in-flight cap for group; zero means unlimited.
+func (f FairShareConfig) InFlightCap(group string) int {
+	if n, ok := f.MaxInFlight[group]; ok {
+		return n
+	}
+	return f.DefaultMaxInFlight
+}
+
+func (f FairShareConfig) AgingInterval() time.Duration {
+	return time.Duration(f.AgingIntervalMs) * time.Millisecond
 }
 
 type ScoringConfig struct {
 	Weights              ScoringWeights        `yaml:"weights"`
 	BacklogWeights       BacklogScoringWeights `yaml:"backlog_weights"`
+	BacklogRescore       BacklogRescoreConfig  `yaml:"backlog_rescore"`
 	FastPathEnabled      bool                  `yaml:"fast_path_enabled"`
 	ParetoEnabled        bool                  `yaml:"pareto_enabled"`
 }
@@ -76,6 +324,19 @@ type BacklogScoringWeights struct {
 	CostEfficiency      float64 `yaml:"cost_efficiency"`
 }
 
+// BacklogRescoreConfig controls how backlog urgency ages, both when an item
+// is scored on write and when open items are rescored periodically, which is
+// off by default. An item's urgency grows by UrgencyGrowthPerDay for each day since
+// it was created, and rises to 1 over the DueWindowHours before its due
+// date. A rescore that moves any item SignificantRankChange or more places
+// in the ranking is published.
+type BacklogRescoreConfig struct {
+	IntervalMs            int     `yaml:"interval_ms"` // zero disables rescoring
+	UrgencyGrowthPerDay   float64 `yaml:"urgency_growth_per_day"`
+	DueWindowHours        int     `yaml:"due_window_hours"`
+	SignificantRankChange int     `yaml:"significant_rank_change"`
+}
+
 type ScoringWeights struct {
 	Capability     float64 `yaml:"capability"`
 	Availability   float64 `yaml:"availability"`
@@ -131,14 +392,53 @@ func (c *Config) TickInterval() time.Duration {
 	return time.Duration(c.Assignment.TickIntervalMs) * time.Millisecond
 }
 
+func (c *Config) RescoreInterval() time.Duration {
+	return time.Duration(c.Scoring.BacklogRescore.IntervalMs) * time.Millisecond
+}
+
+func (c *Config) ScheduleInterval() time.Duration {
+	return time.Duration(c.Schedules.TickIntervalMs) * time.Millisecond
+}
+
 func (c *Config) WakeTimeout() time.Duration {
 	return time.Duration(c.Assignment.WakeTimeoutMs) * time.Millisecond
 }
 
+func (c *Config) AckTimeout() time.Duration {
+	return time.Duration(c.Assignment.AckTimeoutMs) * time.Millisecond
+}
+
 func (c *Config) DefaultTimeout() time.Duration {
 	return time.Duration(c.Assignment.DefaultTimeoutMs) * time.Millisecond
 }
 
+func (c *Config) HeartbeatInterval() time.Duration {
+	return time.Duration(c.Assignment.HeartbeatIntervalMs) * time.Millisecond
+}
+
+// HeartbeatTimeout is how long a heartbeating task may stay silent before it
+// is considered dead.
+func (c *Config) HeartbeatTimeout() time.Duration {
+	missed := c.Assignment.HeartbeatMissedLimit
+	if missed <= 0 {
+		missed = 1
+	}
+	return c.HeartbeatInterval() * time.Duration(missed)
+}
+
+func (c *Config) HardDeadline() time.Duration {
+	return time.Duration(c.Assignment.HardDeadlineMs) * time.Millisecond
+}
+
+// EvalParallelism is the number of candidate evaluations that may run at
+// once, never less than one.
+func (c *Config) EvalParallelism() int {
+	if c.Assignment.MaxParallelEvaluations < 1 {
+		return 1
+	}
+	return c.Assignment.MaxParallelEvaluations
+}
+
 func Load(path string) (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
@@ -158,11 +458,56 @@ func Load(path string) (*Config, error) {
 			URL: "http://localhost:8500",
 		},
 		Assignment: AssignmentConfig{
+			Mode:                  AssignmentModeGreedy,
 			TickIntervalMs:        5000,
 			WakeTimeoutMs:         30000,
+			AckTimeoutMs:          30000,
 			DefaultTimeoutMs:      300000,
 			MaxConcurrentPerAgent: 3,
 			OwnerFilterEnabled:    true,
+			HeartbeatIntervalMs:   60000,
+			HeartbeatMissedLimit:  3,
+			HardDeadlineMs:        14400000,
+
+			MaxParallelEvaluations: 8,
+
+			FairShare: FairShareConfig{
+				Key:             FairShareByOwner,
+				AgingIntervalMs: 600000,
+			},
+			Preemption: PreemptionConfig{
+				MinPriority: 10,
+			},
+			SLA: SLAConfig{
+				BoostWindowMs: 3600000,
+			},
+		},
+		Schedules: SchedulesConfig{
+			TickIntervalMs: 15000,
+			MissedAfterMs:  300000,
+			CatchUp:        CatchUpLatest,
+			MaxCatchUp:     10,
+		},
+		Dependencies: DependenciesConfig{
+			OnCancel: OnCancelResolve,
+		},
+		Iterations: IterationsConfig{
+			MaxDomainShare: 0.5,
+		},
+		Orchestrator: OrchestratorConfig{
+			Stages: map[string][]string{
+				"implement": {"code"},
+				"verify":    {"testing"},
+			},
+		},
+		Retry: RetryConfig{
+			Policies: map[string]string{
+				"transient":              RetrySameAgent,
+				"agent_fault":            RetryElsewhere,
+				"task_spec":              RetryStop,
+				"dependency_unavailable": RetrySameAgent,
+				"quota":                  RetryElsewhere,
+			},
 		},
 		Scoring: ScoringConfig{
 			BacklogWeights: BacklogScoringWeights{
@@ -171,6 +516,11 @@ func Load(path string) (*Config, error) {
 				Urgency:             0.25,
 				CostEfficiency:      0.20,
 			},
+			BacklogRescore: BacklogRescoreConfig{
+				UrgencyGrowthPerDay:   0.01,
+				DueWindowHours:        168,
+				SignificantRankChange: 3,
+			},
 			Weights: ScoringWeights{
 				Capability:     0.20,
 				Availability:   0.10,
@@ -278,6 +628,55 @@ func applyEnv(cfg *Config) {
 			cfg.Assignment.TickIntervalMs = n
 		}
 	}
+	if v := os.Getenv("DISPATCH_BACKLOG_RESCORE_INTERVAL_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Scoring.BacklogRescore.IntervalMs = n
+		}
+	}
+	if v := os.Getenv("DISPATCH_ASSIGNMENT_MODE"); v != "" {
+		cfg.Assignment.Mode = v
+	}
+	if v := os.Getenv("DISPATCH_FAIR_SHARE_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Assignment.FairShare.Enabled = b
+		}
+	}
+	if v := os.Getenv("DISPATCH_PREEMPTION_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Assignment.Preemption.Enabled = b
+		}
+	}
+	if v := os.Getenv("DISPATCH_ORCHESTRATOR_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Orchestrator.Enabled = b
+		}
+	}
+	if v := os.Getenv("DISPATCH_DEPENDENCIES_ON_CANCEL"); v != "" {
+		cfg.Dependencies.OnCancel = v
+	}
+	if v := os.Getenv("DISPATCH_HIERARCHY_AUTO_COMPLETE"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Hierarchy.AutoComplete = b
+		}
+	}
+	if v := os.Getenv("DISPATCH_SCHEDULE_CATCH_UP"); v != "" {
+		cfg.Schedules.CatchUp = v
+	}
+	if v := os.Getenv("DISPATCH_ACK_TIMEOUT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Assignment.AckTimeoutMs = n
+		}
+	}
+	if v := os.Getenv("DISPATCH_HEARTBEAT_INTERVAL_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Assignment.HeartbeatIntervalMs = n
+		}
+	}
+	if v := os.Getenv("DISPATCH_HARD_DEADLINE_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Assignment.HardDeadlineMs = n
+		}
+	}
 	if v := os.Getenv("DISPATCH_OWNER_FILTER_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.Assignment.OwnerFilterEnabled = b
@@ -14,6 +14,9 @@ func TestLoadDefaults(t *testing.T) {
 		"DISPATCH_DATABASE_URL", "DISPATCH_HERMES_URL", "DISPATCH_WARREN_URL",
 		"DISPATCH_WARREN_TOKEN", "DISPATCH_FORGE_URL", "DISPATCH_ALEXANDRIA_URL",
 		"DISPATCH_TICK_INTERVAL_MS", "DISPATCH_OWNER_FILTER_ENABLED", "DISPATCH_LOG_LEVEL",
+		"DISPATCH_HEARTBEAT_INTERVAL_MS", "DISPATCH_HARD_DEADLINE_MS", "DISPATCH_ASSIGNMENT_MODE",
+		"DISPATCH_FAIR_SHARE_ENABLED", "DISPATCH_PREEMPTION_ENABLED", "DISPATCH_SCHEDULE_CATCH_UP",
+		"DISPATCH_BACKLOG_RESCORE_INTERVAL_MS",
 	}
 	for _, k := range envVars {
 		t.Setenv(k, "")
@@ -49,6 +52,48 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.Assignment.MaxConcurrentPerAgent != 3 {
 		t.Errorf("expected max concurrent 3, got %d", cfg.Assignment.MaxConcurrentPerAgent)
 	}
+	if cfg.Assignment.Mode != AssignmentModeGreedy {
+		t.Errorf("expected greedy assignment mode, got %q", cfg.Assignment.Mode)
+	}
+	if fs := cfg.Assignment.FairShare; fs.Enabled || fs.Key != FairShareByOwner || fs.AgingInterval() != 10*time.Minute {
+		t.Errorf("unexpected fair share defaults: %+v", fs)
+	}
+	if p := cfg.Assignment.Preemption; p.Enabled || p.MinPriority != 10 {
+		t.Errorf("unexpected preemption defaults: %+v", p)
+	}
+	if w := cfg.Assignment.SLA.BoostWindow(); w != time.Hour {
+		t.Errorf("expected SLA boost window 1h, got %s", w)
+	}
+	if sc := cfg.Schedules; cfg.ScheduleInterval() != 15*time.Second || sc.MissedAfterMs != 300000 || sc.CatchUp != CatchUpLatest || sc.MaxCatchUp != 10 {
+		t.Errorf("unexpected schedule defaults: %+v", sc)
+	}
+	if cfg.Retry.Policy("transient") != RetrySameAgent || cfg.Retry.Policy("task_spec") != RetryStop || cfg.Retry.Policy("") != "" {
+		t.Errorf("unexpected retry policy defaults: %+v", cfg.Retry.Policies)
+	}
+	if cfg.Retry.Policy("unheard_of") != RetryElsewhere {
+		t.Errorf("expected unlisted class to retry elsewhere, got %q", cfg.Retry.Policy("unheard_of"))
+	}
+	if len(cfg.Escalation.Steps) != 0 {
+		t.Errorf("expected no escalation steps by default, got %+v", cfg.Escalation.Steps)
+	}
+	if cfg.Orchestrator.Enabled || len(cfg.Orchestrator.Stages["implement"]) != 1 || len(cfg.Orchestrator.Stages["verify"]) != 1 {
+		t.Errorf("expected orchestrator disabled with implement and verify stages, got %+v", cfg.Orchestrator)
+	}
+	if cfg.Dependencies.OnCancel != OnCancelResolve {
+		t.Errorf("expected cancelled blockers to resolve by default, got %q", cfg.Dependencies.OnCancel)
+	}
+	if cfg.Hierarchy.AutoComplete {
+		t.Error("expected parent auto-complete to be off by default")
+	}
+	if cfg.Iterations.MaxDomainShare != 0.5 {
+		t.Errorf("expected half an iteration per domain by default, got %v", cfg.Iterations.MaxDomainShare)
+	}
+	if cfg.RescoreInterval() != 0 || cfg.Scoring.BacklogRescore.DueWindowHours != 168 {
+		t.Errorf("expected rescoring off with a week's due window, got %v and %dh", cfg.RescoreInterval(), cfg.Scoring.BacklogRescore.DueWindowHours)
+	}
+	if cfg.EvalParallelism() != 8 {
+		t.Errorf("expected eval parallelism 8, got %d", cfg.EvalParallelism())
+	}
 	if !cfg.Assignment.OwnerFilterEnabled {
 		t.Error("expected owner filter enabled by default")
 	}
@@ -121,9 +166,21 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.WakeTimeout() != 30*time.Second {
 		t.Errorf("expected WakeTimeout 30s, got %v", cfg.WakeTimeout())
 	}
+	if cfg.AckTimeout() != 30*time.Second {
+		t.Errorf("expected AckTimeout 30s, got %v", cfg.AckTimeout())
+	}
 	if cfg.DefaultTimeout() != 5*time.Minute {
 		t.Errorf("expected DefaultTimeout 5m, got %v", cfg.DefaultTimeout())
 	}
+	if cfg.HeartbeatInterval() != time.Minute {
+		t.Errorf("expected HeartbeatInterval 1m, got %v", cfg.HeartbeatInterval())
+	}
+	if cfg.HeartbeatTimeout() != 3*time.Minute {
+		t.Errorf("expected HeartbeatTimeout 3m, got %v", cfg.HeartbeatTimeout())
+	}
+	if cfg.HardDeadline() != 4*time.Hour {
+		t.Errorf("expected HardDeadline 4h, got %v", cfg.HardDeadline())
+	}
 }
 
 func TestLoadFromEnv(t *testing.T) {
@@ -137,7 +194,12 @@ func TestLoadFromEnv(t *testing.T) {
 	t.Setenv("DISPATCH_FORGE_URL", "http://forge:8083")
 	t.Setenv("DISPATCH_ALEXANDRIA_URL", "http://alex:8500")
 	t.Setenv("DISPATCH_TICK_INTERVAL_MS", "2000")
+	t.Setenv("DISPATCH_ACK_TIMEOUT_MS", "45000")
 	t.Setenv("DISPATCH_OWNER_FILTER_ENABLED", "false")
+	t.Setenv("DISPATCH_ASSIGNMENT_MODE", "batch")
+	t.Setenv("DISPATCH_FAIR_SHARE_ENABLED", "true")
+	t.Setenv("DISPATCH_PREEMPTION_ENABLED", "true")
+	t.Setenv("DISPATCH_SCHEDULE_CATCH_UP", "none")
 	t.Setenv("DISPATCH_LOG_LEVEL", "debug")
 
 	cfg, err := Load("")
@@ -175,10 +237,72 @@ func TestLoadFromEnv(t *testing.T) {
 	if cfg.Assignment.TickIntervalMs != 2000 {
 		t.Errorf("expected tick 2000, got %d", cfg.Assignment.TickIntervalMs)
 	}
+	if cfg.Assignment.AckTimeoutMs != 45000 {
+		t.Errorf("expected ack timeout 45000, got %d", cfg.Assignment.AckTimeoutMs)
+	}
 	if cfg.Assignment.OwnerFilterEnabled {
 		t.Error("expected owner filter disabled")
 	}
+	if cfg.Assignment.Mode != AssignmentModeBatch {
+		t.Errorf("expected batch assignment mode, got %q", cfg.Assignment.Mode)
+	}
+	if !cfg.Assignment.FairShare.Enabled {
+		t.Error("expected fair share enabled")
+	}
+	if !cfg.Assignment.Preemption.Enabled {
+		t.Error("expected preemption enabled")
+	}
+	if cfg.Schedules.CatchUp != CatchUpNone {
+		t.Errorf("expected schedule catch-up 'none', got %q", cfg.Schedules.CatchUp)
+	}
 	if cfg.Logging.Level != "debug" {
 		t.Errorf("expected log level 'debug', got '%s'", cfg.Logging.Level)
 	}
 }
+
+func TestFairShareWeightAndCapDefaults(t *testing.T) {
+	fs := FairShareConfig{
+		Weights:            map[string]float64{"ops": 3, "broken": -1},
+		MaxInFlight:        map[string]int{"ops": 10},
+		DefaultMaxInFlight: 4,
+	}
+	if got := fs.ShareWeight("ops"); got != 3 {
+		t.Errorf("expected ops weight 3, got %f", got)
+	}
+	if got := fs.ShareWeight("broken"); got != 1 {
+		t.Errorf("expected invalid weight to fall back to 1, got %f", got)
+	}
+	if got := fs.ShareWeight("other"); got != 1 {
+		t.Errorf("expected unlisted weight 1, got %f", got)
+	}
+	if got := fs.InFlightCap("ops"); got != 10 {
+		t.Errorf("expected ops cap 10, got %d", got)
+	}
+	if got := fs.InFlightCap("other"); got != 4 {
+		t.Errorf("expected default cap 4, got %d", got)
+	}
+}
+
+func TestCapabilityLimitIsCaseInsensitive(t *testing.T) {
+	a := AssignmentConfig{CapabilityLimits: map[string]int{"Deploy": 2}}
+	if a.CapabilityLimit("deploy") != 2 {
+		t.Errorf("expected deploy limit 2, got %d", a.CapabilityLimit("deploy"))
+	}
+	if a.CapabilityLimit("lint") != 0 {
+		t.Errorf("expected lint unlimited, got %d", a.CapabilityLimit("lint"))
+	}
+}
+
+func TestEscalationDue(t *testing.T) {
+	e := EscalationConfig{Steps: []EscalationStep{
+		{AfterFailures: 2, Action: EscalatePromoteModel},
+		{AfterFailures: 3, Action: EscalateReassign, Agent: "senior"},
+		{AfterFailures: 3, Action: EscalateBacklogItem},
+	}}
+	if got := e.Due(1); len(got) != 0 {
+		t.Errorf("expected no steps after 1 failure, got %+v", got)
+	}
+	if got := e.Due(3); len(got) != 2 || got[0].Action != EscalateReassign || got[1].Action != EscalateBacklogItem {
+		t.Errorf("expected reassign then backlog item after 3 failures, got %+v", got)
+	}
+}
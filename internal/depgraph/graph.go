@@ -0,0 +1,288 @@
+// Package depgraph models backlog items and their blocking dependencies as a
+// directed graph: it detects cycles, extracts the subgraph gating an item,
+// finds the critical path and renders the graph as Graphviz DOT.
+package depgraph
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// ErrCycle is returned when the graph is not acyclic, so it has no
+// critical path.
+var ErrCycle = errors.New("dependency graph contains a cycle")
+
+// effortPoints sizes each effort estimate in relative points.
+var effortPoints = map[string]float64{
+	"xs": 1,
+	"s":  2,
+	"m":  3,
+	"l":  5,
+	"xl": 8,
+}
+
+// TokensPerPoint converts an item's estimated tokens to effort points when
+// it has no effort estimate.
+const TokensPerPoint = 50000
+
+// Effort returns the effort points of work left on item: its effort
+// estimate, else its estimated tokens, else a medium item. Done and
+// cancelled items have none left.
+func Effort(item *store.BacklogItem) float64 {
+	switch item.Status {
+	case store.BacklogStatusDone, store.BacklogStatusCancelled:
+		return 0
+	}
+	if p, ok := effortPoints[strings.ToLower(item.EffortEstimate)]; ok {
+		return p
+	}
+	if item.EstimatedTokens != nil && *item.EstimatedTokens > 0 {
+		p := float64(*item.EstimatedTokens) / TokensPerPoint
+		if p < 1 {
+			p = 1
+		}
+		return p
+	}
+	return effortPoints["m"]
+}
+
+// FindCycle reports whether adding a dependency where blocker blocks
+// blocked would close a cycle among deps. If so it returns the cycle as
+// item IDs, starting and ending with blocker.
+func FindCycle(deps []*store.BacklogDependency, blocker, blocked uuid.UUID) []uuid.UUID {
+	if blocker == blocked {
+		return []uuid.UUID{blocker, blocker}
+	}
+	blocks := make(map[uuid.UUID][]uuid.UUID)
+	for _, d := range deps {
+		blocks[d.BlockerID] = append(blocks[d.BlockerID], d.BlockedID)
+	}
+
+	// Search from blocked for a path back to blocker.
+	prev := map[uuid.UUID]uuid.UUID{blocked: blocker}
+	queue := []uuid.UUID{blocked}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == blocker {
+			cycle := []uuid.UUID{blocker}
+			for id != blocked {
+				id = prev[id]
+				cycle = append(cycle, id)
+			}
+			cycle = append(cycle, blocker)
+			for i, j := 1, len(cycle)-2; i < j; i, j = i+1, j-1 {
+				cycle[i], cycle[j] = cycle[j], cycle[i]
+			}
+			return cycle
+		}
+		for _, next := range blocks[id] {
+			if _, seen := prev[next]; !seen {
+				prev[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// Graph is a set of backlog items and the dependencies between them.
+type Graph struct {
+	Items map[uuid.UUID]*store.BacklogItem
+	Deps  []*store.BacklogDependency
+}
+
+// New builds a graph from items and deps, dropping dependencies on items
+// not in items.
+func New(items []*store.BacklogItem, deps []*store.BacklogDependency) *Graph {
+	g := &Graph{Items: make(map[uuid.UUID]*store.BacklogItem, len(items))}
+	for _, item := range items {
+		g.Items[item.ID] = item
+	}
+	for _, d := range deps {
+		if g.Items[d.BlockerID] != nil && g.Items[d.BlockedID] != nil {
+			g.Deps = append(g.Deps, d)
+		}
+	}
+	return g
+}
+
+// Subgraph returns the part of g that gates root: root, its descendants by
+// parent, and every item they transitively depend on.
+func (g *Graph) Subgraph(root uuid.UUID) *Graph {
+	children := make(map[uuid.UUID][]uuid.UUID)
+	for id, item := range g.Items {
+		if item.ParentID != nil {
+			children[*item.ParentID] = append(children[*item.ParentID], id)
+		}
+	}
+	blockers := make(map[uuid.UUID][]uuid.UUID)
+	for _, d := range g.Deps {
+		blockers[d.BlockedID] = append(blockers[d.BlockedID], d.BlockerID)
+	}
+
+	keep := make(map[uuid.UUID]bool)
+	var walk func(id uuid.UUID, descend bool)
+	walk = func(id uuid.UUID, descend bool) {
+		if keep[id] || g.Items[id] == nil {
+			return
+		}
+		keep[id] = true
+		if descend {
+			for _, c := range children[id] {
+				walk(c, true)
+			}
+		}
+		for _, b := range blockers[id] {
+			walk(b, false)
+		}
+	}
+	walk(root, true)
+
+	sub := &Graph{Items: make(map[uuid.UUID]*store.BacklogItem, len(keep))}
+	for id := range keep {
+		sub.Items[id] = g.Items[id]
+	}
+	for _, d := range g.Deps {
+		if keep[d.BlockerID] && keep[d.BlockedID] {
+			sub.Deps = append(sub.Deps, d)
+		}
+	}
+	return sub
+}
+
+// Path is a chain of dependent items, blockers first.
+type Path struct {
+	Items           []uuid.UUID `json:"items"`
+	Effort          float64     `json:"effort"`
+	EstimatedTokens int64       `json:"estimated_tokens"`
+}
+
+// CriticalPath returns the chain of dependencies with the most effort left,
+// which bounds how soon the last item in g can finish. It returns ErrCycle
+// if g has a cycle.
+func (g *Graph) CriticalPath() (Path, error) {
+	indegree := make(map[uuid.UUID]int, len(g.Items))
+	blocks := make(map[uuid.UUID][]uuid.UUID)
+	for _, d := range g.Deps {
+		blocks[d.BlockerID] = append(blocks[d.BlockerID], d.BlockedID)
+		indegree[d.BlockedID]++
+	}
+
+	// Kahn's algorithm, visiting ready items in a stable order.
+	var ready []uuid.UUID
+	for _, id := range g.IDs() {
+		if indegree[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	dist := make(map[uuid.UUID]float64, len(g.Items))
+	prev := make(map[uuid.UUID]uuid.UUID)
+	var end uuid.UUID
+	visited := 0
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		visited++
+		dist[id] += Effort(g.Items[id])
+		if visited == 1 || dist[id] > dist[end] {
+			end = id
+		}
+		for _, next := range blocks[id] {
+			if _, ok := prev[next]; !ok || dist[id] > dist[next] {
+				dist[next] = dist[id]
+				prev[next] = id
+			}
+			indegree[next]--
+			if indegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	if visited < len(g.Items) {
+		return Path{}, ErrCycle
+	}
+	if visited == 0 {
+		return Path{Items: []uuid.UUID{}}, nil
+	}
+
+	p := Path{Effort: dist[end]}
+	for id, ok := end, true; ok; id, ok = prev[id] {
+		p.Items = append(p.Items, id)
+		if t := g.Items[id].EstimatedTokens; t != nil {
+			p.EstimatedTokens += *t
+		}
+	}
+	for i, j := 0, len(p.Items)-1; i < j; i, j = i+1, j-1 {
+		p.Items[i], p.Items[j] = p.Items[j], p.Items[i]
+	}
+	return p, nil
+}
+
+// DOT renders g in Graphviz DOT. Items and dependencies on critical are
+// highlighted and resolved dependencies are dashed.
+func (g *Graph) DOT(critical Path) string {
+	onPath := make(map[uuid.UUID]int, len(critical.Items))
+	for i, id := range critical.Items {
+		onPath[id] = i + 1
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph backlog {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, id := range g.IDs() {
+		item := g.Items[id]
+		label := item.Title + "\n" + string(item.Status)
+		if item.EffortEstimate != "" {
+			label += ", " + item.EffortEstimate
+		}
+		fmt.Fprintf(&sb, "\t%q [label=%s", id.String(), dotString(label))
+		if onPath[id] > 0 {
+			sb.WriteString(", color=red, penwidth=2")
+		}
+		sb.WriteString("];\n")
+	}
+	for _, d := range g.Deps {
+		fmt.Fprintf(&sb, "\t%q -> %q", d.BlockerID.String(), d.BlockedID.String())
+		var attrs []string
+		if d.ResolvedAt != nil {
+			attrs = append(attrs, "style=dashed")
+		}
+		if i := onPath[d.BlockerID]; i > 0 && onPath[d.BlockedID] == i+1 {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		if len(attrs) > 0 {
+			sb.WriteString(" [" + strings.Join(attrs, ", ") + "]")
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// IDs returns g's item IDs, oldest item first.
+func (g *Graph) IDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(g.Items))
+	for id := range g.Items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := g.Items[ids[i]], g.Items[ids[j]]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return ids
+}
+
+// dotString quotes s as a DOT string, keeping newlines as line breaks.
+func dotString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,156 @@
+package depgraph
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// newItems creates items with the given effort estimates, created in order.
+func newItems(efforts ...string) []*store.BacklogItem {
+	base := time.Now()
+	var items []*store.BacklogItem
+	for i, e := range efforts {
+		items = append(items, &store.BacklogItem{
+			ID:             uuid.New(),
+			Title:          "item " + string(rune('A'+i)),
+			Status:         store.BacklogStatusBacklog,
+			EffortEstimate: e,
+			CreatedAt:      base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return items
+}
+
+func dep(blocker, blocked *store.BacklogItem) *store.BacklogDependency {
+	return &store.BacklogDependency{ID: uuid.New(), BlockerID: blocker.ID, BlockedID: blocked.ID}
+}
+
+func TestEffort(t *testing.T) {
+	tokens := int64(200000)
+	tests := []struct {
+		item *store.BacklogItem
+		want float64
+	}{
+		{&store.BacklogItem{EffortEstimate: "XL"}, 8},
+		{&store.BacklogItem{EffortEstimate: "s", EstimatedTokens: &tokens}, 2},
+		{&store.BacklogItem{EstimatedTokens: &tokens}, 4},
+		{&store.BacklogItem{}, 3},
+		{&store.BacklogItem{EffortEstimate: "xl", Status: store.BacklogStatusDone}, 0},
+	}
+	for _, tt := range tests {
+		if got := Effort(tt.item); got != tt.want {
+			t.Errorf("Effort(%q, %v) = %v, want %v", tt.item.EffortEstimate, tt.item.EstimatedTokens, got, tt.want)
+		}
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	items := newItems("m", "m", "m")
+	a, b, c := items[0], items[1], items[2]
+	deps := []*store.BacklogDependency{dep(a, b), dep(b, c)}
+
+	if cycle := FindCycle(deps, a.ID, c.ID); cycle != nil {
+		t.Errorf("expected a shortcut edge to be acyclic, got %v", cycle)
+	}
+	cycle := FindCycle(deps, c.ID, a.ID)
+	want := []uuid.UUID{c.ID, a.ID, b.ID, c.ID}
+	if len(cycle) != len(want) {
+		t.Fatalf("expected cycle %v, got %v", want, cycle)
+	}
+	for i := range want {
+		if cycle[i] != want[i] {
+			t.Fatalf("expected cycle %v, got %v", want, cycle)
+		}
+	}
+	if cycle := FindCycle(nil, a.ID, a.ID); len(cycle) != 2 {
+		t.Errorf("expected a self-dependency to be a cycle, got %v", cycle)
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	// A -> B -> D and A -> C -> D; C is the longer branch. E stands alone.
+	items := newItems("s", "s", "xl", "m", "xs")
+	a, b, c, d := items[0], items[1], items[2], items[3]
+	tokens := int64(1000)
+	c.EstimatedTokens = &tokens
+	g := New(items, []*store.BacklogDependency{dep(a, b), dep(a, c), dep(b, d), dep(c, d)})
+
+	p, err := g.CriticalPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uuid.UUID{a.ID, c.ID, d.ID}
+	if len(p.Items) != len(want) || p.Items[0] != want[0] || p.Items[1] != want[1] || p.Items[2] != want[2] {
+		t.Fatalf("expected path A, C, D, got %v", p.Items)
+	}
+	if p.Effort != 13 || p.EstimatedTokens != 1000 {
+		t.Errorf("expected effort 13 and 1000 tokens, got %v and %d", p.Effort, p.EstimatedTokens)
+	}
+
+	// Finishing C moves the critical path to B.
+	c.Status = store.BacklogStatusDone
+	p, _ = g.CriticalPath()
+	if len(p.Items) != 3 || p.Items[1] != b.ID || p.Effort != 7 {
+		t.Errorf("expected path A, B, D with effort 7, got %v (%v)", p.Items, p.Effort)
+	}
+}
+
+func TestCriticalPathCycle(t *testing.T) {
+	items := newItems("m", "m")
+	g := New(items, []*store.BacklogDependency{dep(items[0], items[1]), dep(items[1], items[0])})
+	if _, err := g.CriticalPath(); !errors.Is(err, ErrCycle) {
+		t.Errorf("expected ErrCycle, got %v", err)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	// The epic's child is blocked by A, which is blocked by B. C depends on
+	// the epic and D is unrelated.
+	items := newItems("m", "m", "m", "m", "m", "m")
+	epic, child, a, b, c, d := items[0], items[1], items[2], items[3], items[4], items[5]
+	child.ParentID = &epic.ID
+	g := New(items, []*store.BacklogDependency{dep(a, child), dep(b, a), dep(epic, c), dep(d, c)})
+
+	sub := g.Subgraph(epic.ID)
+	for _, item := range []*store.BacklogItem{epic, child, a, b} {
+		if sub.Items[item.ID] == nil {
+			t.Errorf("expected %s in the subgraph", item.Title)
+		}
+	}
+	for _, item := range []*store.BacklogItem{c, d} {
+		if sub.Items[item.ID] != nil {
+			t.Errorf("expected %s left out of the subgraph", item.Title)
+		}
+	}
+	if len(sub.Deps) != 2 {
+		t.Errorf("expected the two dependencies among kept items, got %d", len(sub.Deps))
+	}
+}
+
+func TestDOT(t *testing.T) {
+	items := newItems("s", "m")
+	items[0].Title = `Fix "quoted" bug`
+	now := time.Now()
+	resolved := dep(items[0], items[1])
+	resolved.ResolvedAt = &now
+	g := New(items, []*store.BacklogDependency{resolved})
+	p, _ := g.CriticalPath()
+
+	out := g.DOT(p)
+	if !strings.HasPrefix(out, "digraph backlog {") {
+		t.Errorf("expected a digraph, got %s", out)
+	}
+	if !strings.Contains(out, `label="Fix \"quoted\" bug\nbacklog, s"`) {
+		t.Errorf("expected an escaped label, got %s", out)
+	}
+	edge := `"` + items[0].ID.String() + `" -> "` + items[1].ID.String() + `" [style=dashed, color=red, penwidth=2];`
+	if !strings.Contains(out, edge) {
+		t.Errorf("expected a dashed critical edge, got %s", out)
+	}
+}
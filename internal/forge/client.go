@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +18,10 @@ type Persona struct {
 	Name         string   `json:"name"`
 	Type         string   `json:"type"`
 	Capabilities []string `json:"capabilities"`
+
+	// MaxConcurrent is the agent's own concurrency limit from its
+	// max_concurrent section; zero means use the broker default.
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
 }
 
 // ModelTierStats holds effectiveness metrics for a single model tier,
@@ -130,17 +135,18 @@ func (c *HTTPClient) fetchPersonas(ctx context.Context) ([]Persona, error) {
 		p := Persona{ID: item.ID, Slug: item.Slug, Name: item.Name, Type: item.Type}
 
 		// Fetch latest version (try version 2 first since we added capabilities as v2, fallback to 1)
-		caps := c.fetchCapabilities(ctx, item.Slug)
-		p.Capabilities = caps
+		c.fetchSections(ctx, &p)
 		personas = append(personas, p)
 	}
 	return personas, nil
 }
 
-func (c *HTTPClient) fetchCapabilities(ctx context.Context, slug string) []string {
+// fetchSections fills in the persona's capabilities and concurrency limit
+// from its latest prompt version.
+func (c *HTTPClient) fetchSections(ctx context.Context, p *Persona) {
 	// Try versions in descending order (most recent first)
 	for v := 10; v >= 1; v-- {
-		url := fmt.Sprintf("%s/api/v1/prompts/%s/versions/%d", c.baseURL, slug, v)
+		url := fmt.Sprintf("%s/api/v1/prompts/%s/versions/%d", c.baseURL, p.Slug, v)
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			continue
@@ -160,13 +166,20 @@ func (c *HTTPClient) fetchCapabilities(ctx context.Context, slug string) []strin
 			continue
 		}
 
+		found := false
 		for _, s := range ver.Content.Sections {
-			if s.ID == "capabilities" {
-				return ParseCapabilities(s.Content)
+			switch s.ID {
+			case "capabilities":
+				p.Capabilities = ParseCapabilities(s.Content)
+				found = true
+			case "max_concurrent":
+				p.MaxConcurrent = ParseMaxConcurrent(s.Content)
 			}
 		}
+		if found {
+			return
+		}
 	}
-	return nil
 }
 
 func (c *HTTPClient) GetAgentsByCapability(ctx context.Context, scope string) ([]Persona, error) {
@@ -241,3 +254,13 @@ func ParseCapabilities(content string) []string {
 	}
 	return caps
 }
+
+// ParseMaxConcurrent parses a max_concurrent section. Anything other than a
+// positive integer yields zero (no persona-specific limit).
+func ParseMaxConcurrent(content string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(content))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -2,6 +2,14 @@ package hermes
 
 import "time"
 
+// OriginField marks the progress and heartbeat events the API publishes
+// after saving the report itself, so the broker's subscriber does not save
+// them again.
+const (
+	OriginField = "origin"
+	OriginAPI   = "api"
+)
+
 type TaskRequestEvent struct {
 	Owner                string                 `json:"owner"`
 	Title                string                 `json:"title"`
@@ -12,6 +20,13 @@ type TaskRequestEvent struct {
 	TimeoutSeconds       int                    `json:"timeout_seconds,omitempty"`
 	MaxRetries           int                    `json:"max_retries,omitempty"`
 	Source               string                 `json:"source,omitempty"`
+	Preemptible          bool                   `json:"preemptible,omitempty"`
+	ParentTaskID         string                 `json:"parent_task_id,omitempty"`
+	PreferredAgents      []string               `json:"preferred_agents,omitempty"`
+	RequiredAgent        string                 `json:"required_agent,omitempty"`
+	ExcludedAgents       []string               `json:"excluded_agents,omitempty"`
+	AffinityGroup        string                 `json:"affinity_group,omitempty"`
+	Deadline             *time.Time             `json:"deadline,omitempty"`
 }
 
 type TaskAssignedEvent struct {
@@ -24,10 +39,13 @@ type TaskCompletedEvent struct {
 	Result map[string]interface{} `json:"result,omitempty"`
 }
 
+// TaskFailedEvent reports a failed attempt. FailureClass is one of the
+// store.Failure* classes, or empty when the agent did not classify it.
 type TaskFailedEvent struct {
 	TaskID        string `json:"task_id"`
 	Error         string `json:"error"`
 	RetryEligible bool   `json:"retry_eligible"`
+	FailureClass  string `json:"failure_class,omitempty"`
 }
 
 type TaskTimeoutEvent struct {
@@ -35,6 +53,30 @@ type TaskTimeoutEvent struct {
 	RetryCount int    `json:"retry_count"`
 	MaxRetries int    `json:"max_retries"`
 	TimedOutIn string `json:"timed_out_in_state"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// TaskSLAEvent is published when a task is estimated to miss its deadline
+// (sla.at_risk) and when the deadline passes unmet (sla.missed).
+type TaskSLAEvent struct {
+	TaskID              string     `json:"task_id"`
+	Owner               string     `json:"owner"`
+	Status              string     `json:"status"`
+	AssignedAgent       string     `json:"assigned_agent,omitempty"`
+	Deadline            time.Time  `json:"deadline"`
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty"`
+}
+
+// TaskEscalatedEvent is published for each escalation step applied to a
+// failing task. BacklogItemID is set for create_backlog_item.
+type TaskEscalatedEvent struct {
+	TaskID        string   `json:"task_id"`
+	Action        string   `json:"action"`
+	Failures      int      `json:"failures"`
+	ModelTier     string   `json:"model_tier,omitempty"`
+	Capabilities  []string `json:"capabilities,omitempty"`
+	Agent         string   `json:"agent,omitempty"`
+	BacklogItemID string   `json:"backlog_item_id,omitempty"`
 }
 
 type StatsEvent struct {
@@ -184,3 +226,67 @@ type AutonomyRevokedEvent struct {
 	Reason          string `json:"reason"`
 	CorrectionsIn10 int    `json:"corrections_in_10"`
 }
+
+// BudgetThresholdEvent is published when a budget's usage crosses 80% or
+// 100% of a limit in the current period.
+type BudgetThresholdEvent struct {
+	BudgetID    string    `json:"budget_id"`
+	Scope       string    `json:"scope"`
+	Subject     string    `json:"subject"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	Threshold   int       `json:"threshold"`
+	Fraction    float64   `json:"fraction"`
+	Tasks       int       `json:"tasks"`
+	Tokens      int64     `json:"tokens"`
+	CostUSD     float64   `json:"cost_usd"`
+	Action      string    `json:"action"`
+}
+
+// BacklogRescoredEvent summarises a periodic rescoring pass that moved items
+// significantly in the backlog ranking.
+type BacklogRescoredEvent struct {
+	ItemsScored  int               `json:"items_scored"`
+	ItemsChanged int               `json:"items_changed"`
+	Moves        []BacklogRankMove `json:"moves"`
+}
+
+// BacklogRankMove is one item's move in the backlog ranking. Rank 1 is the
+// highest priority.
+type BacklogRankMove struct {
+	ItemID        string   `json:"item_id"`
+	Title         string   `json:"title"`
+	PreviousScore *float64 `json:"previous_score,omitempty"`
+	NewScore      float64  `json:"new_score"`
+	PreviousRank  int      `json:"previous_rank"`
+	NewRank       int      `json:"new_rank"`
+}
+
+// BacklogBulkAppliedEvent summarises one bulk action applied to a set of
+// backlog items. Per-item changes are recorded as overrides.
+type BacklogBulkAppliedEvent struct {
+	Action       string   `json:"action"`
+	ItemIDs      []string `json:"item_ids"`
+	ItemsChanged int      `json:"items_changed"`
+	OverriddenBy string   `json:"overridden_by"`
+	Reason       string   `json:"reason,omitempty"`
+}
+
+// BacklogImportedEvent summarises one backlog import. Per-item changes are
+// recorded in the change history.
+type BacklogImportedEvent struct {
+	Format       string   `json:"format"`
+	ItemIDs      []string `json:"item_ids"`
+	ItemsCreated int      `json:"items_created"`
+	ItemsUpdated int      `json:"items_updated"`
+	Dependencies int      `json:"dependencies"`
+}
+
+// IterationItemsEvent lists the backlog items committed to or uncommitted
+// from an iteration. Planned is set when the auto-planner picked them.
+type IterationItemsEvent struct {
+	IterationID string   `json:"iteration_id"`
+	ItemIDs     []string `json:"item_ids"`
+	Actor       string   `json:"actor"`
+	Planned     bool     `json:"planned,omitempty"`
+}
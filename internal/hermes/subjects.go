@@ -19,8 +19,17 @@ func SubjectTaskTimeout(taskID string) string     { return "swarm.task." + taskI
 func SubjectTaskRetry(taskID string) string       { return "swarm.task." + taskID + ".retry" }
 func SubjectTaskDLQ(taskID string) string         { return "swarm.task." + taskID + ".dlq" }
 func SubjectTaskReassigned(taskID string) string  { return "swarm.task." + taskID + ".reassigned" }
+func SubjectTaskReclaimed(taskID string) string   { return "swarm.task." + taskID + ".reclaimed" }
+func SubjectTaskAcked(taskID string) string       { return "swarm.task." + taskID + ".acked" }
+func SubjectTaskWaking(taskID string) string      { return "swarm.task." + taskID + ".waking" }
+func SubjectTaskWakeTimeout(taskID string) string { return "swarm.task." + taskID + ".wake_timeout" }
 func SubjectTaskProgress(taskID string) string    { return "swarm.task." + taskID + ".progress" }
+func SubjectTaskHeartbeat(taskID string) string   { return "swarm.task." + taskID + ".heartbeat" }
 func SubjectTaskUnmatched(taskID string) string   { return "swarm.task." + taskID + ".unmatched" }
+func SubjectTaskPreempt(taskID string) string     { return "swarm.task." + taskID + ".preempt" }
+func SubjectTaskSLAAtRisk(taskID string) string   { return "swarm.task." + taskID + ".sla.at_risk" }
+func SubjectTaskSLAMissed(taskID string) string   { return "swarm.task." + taskID + ".sla.missed" }
+func SubjectTaskEscalated(taskID string) string   { return "swarm.task." + taskID + ".escalated" }
 
 func SubjectDispatchAssigned(taskID string) string  { return "swarm.dispatch." + taskID + ".assigned" }
 func SubjectDispatchCompleted(taskID string) string { return "swarm.dispatch." + taskID + ".completed" }
@@ -34,9 +43,23 @@ func SubjectBacklogPlanned(itemID string) string   { return "swarm.backlog." + i
 func SubjectBacklogExecuting(itemID string) string { return "swarm.backlog." + itemID + ".executing" }
 func SubjectBacklogCompleted(itemID string) string { return "swarm.backlog." + itemID + ".completed" }
 func SubjectBacklogBlocked(itemID string) string   { return "swarm.backlog." + itemID + ".blocked" }
+func SubjectBacklogUnblocked(itemID string) string { return "swarm.backlog." + itemID + ".unblocked" }
 func SubjectBacklogParked(itemID string) string    { return "swarm.backlog." + itemID + ".parked" }
 func SubjectBacklogCancelled(itemID string) string { return "swarm.backlog." + itemID + ".cancelled" }
 
+// SubjectBacklogRescored carries the summary of a periodic rescoring pass.
+func SubjectBacklogRescored() string { return "swarm.backlog.rescored" }
+
+// SubjectBacklogBulkApplied carries the summary of a bulk backlog operation.
+func SubjectBacklogBulkApplied() string { return "swarm.backlog.bulk.applied" }
+
+// SubjectBacklogImported carries the summary of a backlog import.
+func SubjectBacklogImported() string { return "swarm.backlog.imported" }
+
+// Iteration subjects
+func SubjectIterationCommitted(id string) string   { return "swarm.iteration." + id + ".committed" }
+func SubjectIterationUncommitted(id string) string { return "swarm.iteration." + id + ".uncommitted" }
+
 // Stage lifecycle subjects
 func SubjectStageAdvanced(itemID string) string  { return "swarm.dispatch." + itemID + ".stage.advanced" }
 func SubjectGateSatisfied(itemID string) string   { return "swarm.dispatch." + itemID + ".gate.satisfied" }
@@ -51,6 +74,9 @@ func SubjectGateChangesRequested(itemID string) string { return "swarm.dispatch.
 func SubjectItemCompleted(itemID string) string      { return "swarm.dispatch." + itemID + ".item.completed" }
 func SubjectItemBlocked(itemID string) string        { return "swarm.dispatch." + itemID + ".item.blocked" }
 
+// Budget subjects
+func SubjectBudgetThreshold(budgetID string) string { return "swarm.budget." + budgetID + ".threshold" }
+
 // Autonomy graduation subjects
 func SubjectAutonomyGraduated() string { return "swarm.dispatch.autonomy.graduated" }
 func SubjectAutonomyRevoked() string   { return "swarm.dispatch.autonomy.revoked" }
@@ -0,0 +1,164 @@
+// Package hierarchy rolls backlog items up their ParentID links, so an epic
+// or story reports the progress, size, priority and state of the work
+// beneath it.
+package hierarchy
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// statusRank orders statuses from least to most progressed. The worst
+// status of a tree is the lowest ranked among its leaves.
+var statusRank = map[store.BacklogStatus]int{
+	store.BacklogStatusBlocked:     0,
+	store.BacklogStatusBacklog:     1,
+	store.BacklogStatusReady:       2,
+	store.BacklogStatusInDiscovery: 3,
+	store.BacklogStatusPlanned:     4,
+	store.BacklogStatusInProgress:  5,
+	store.BacklogStatusReview:      6,
+	store.BacklogStatusDone:        7,
+	store.BacklogStatusCancelled:   8,
+}
+
+// Worse returns whichever of a and b is less progressed. Cancelled ranks
+// last, so it is only the worst status when nothing else is left.
+func Worse(a, b store.BacklogStatus) store.BacklogStatus {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	if statusRank[b] < statusRank[a] {
+		return b
+	}
+	return a
+}
+
+// Rollup aggregates the leaves beneath a node: the items with no children
+// of their own. Cancelled leaves are left out of the counts and tokens.
+type Rollup struct {
+	Total           int                 `json:"total"`
+	Done            int                 `json:"done"`
+	PercentByCount  float64             `json:"percent_by_count"`
+	PercentByTokens float64             `json:"percent_by_tokens"`
+	EstimatedTokens int64               `json:"estimated_tokens"`
+	PriorityScore   *float64            `json:"priority_score,omitempty"`
+	WorstStatus     store.BacklogStatus `json:"worst_status"`
+
+	doneTokens int64
+}
+
+// Node is a backlog item with its children and their roll-up.
+type Node struct {
+	Item     *store.BacklogItem `json:"item"`
+	Rollup   Rollup             `json:"rollup"`
+	Children []*Node            `json:"children,omitempty"`
+}
+
+// Build nests descendants under root by ParentID and computes each node's
+// roll-up. Items that do not descend from root are ignored.
+func Build(root *store.BacklogItem, descendants []*store.BacklogItem) *Node {
+	children := make(map[uuid.UUID][]*store.BacklogItem)
+	for _, item := range descendants {
+		if item.ParentID != nil && item.ID != root.ID {
+			children[*item.ParentID] = append(children[*item.ParentID], item)
+		}
+	}
+	seen := make(map[uuid.UUID]bool)
+	return build(root, children, seen)
+}
+
+func build(item *store.BacklogItem, children map[uuid.UUID][]*store.BacklogItem, seen map[uuid.UUID]bool) *Node {
+	seen[item.ID] = true
+	n := &Node{Item: item}
+
+	kids := children[item.ID]
+	sort.Slice(kids, func(i, j int) bool {
+		if !kids[i].CreatedAt.Equal(kids[j].CreatedAt) {
+			return kids[i].CreatedAt.Before(kids[j].CreatedAt)
+		}
+		return kids[i].ID.String() < kids[j].ID.String()
+	})
+	for _, c := range kids {
+		if seen[c.ID] {
+			continue
+		}
+		child := build(c, children, seen)
+		n.Children = append(n.Children, child)
+		n.Rollup.add(child.Rollup)
+	}
+	if len(n.Children) == 0 {
+		n.Rollup = leaf(item)
+	}
+	n.Rollup.percentages()
+	return n
+}
+
+func leaf(item *store.BacklogItem) Rollup {
+	r := Rollup{WorstStatus: item.Status}
+	if item.Status == store.BacklogStatusCancelled {
+		return r
+	}
+	r.Total = 1
+	if item.EstimatedTokens != nil {
+		r.EstimatedTokens = *item.EstimatedTokens
+	}
+	if item.Status == store.BacklogStatusDone {
+		r.Done = 1
+		r.doneTokens = r.EstimatedTokens
+	} else {
+		r.PriorityScore = item.PriorityScore
+	}
+	return r
+}
+
+func (r *Rollup) add(c Rollup) {
+	r.Total += c.Total
+	r.Done += c.Done
+	r.EstimatedTokens += c.EstimatedTokens
+	r.doneTokens += c.doneTokens
+	r.WorstStatus = Worse(r.WorstStatus, c.WorstStatus)
+	if c.PriorityScore != nil && (r.PriorityScore == nil || *c.PriorityScore > *r.PriorityScore) {
+		r.PriorityScore = c.PriorityScore
+	}
+}
+
+// percentages fills in progress from the counts. Without any token
+// estimates, progress by tokens falls back to progress by count.
+func (r *Rollup) percentages() {
+	if r.Total > 0 {
+		r.PercentByCount = 100 * float64(r.Done) / float64(r.Total)
+	}
+	r.PercentByTokens = r.PercentByCount
+	if r.EstimatedTokens > 0 {
+		r.PercentByTokens = 100 * float64(r.doneTokens) / float64(r.EstimatedTokens)
+	}
+}
+
+// Complete reports whether a parent with children is finished: every child
+// is done or cancelled, and at least one is done.
+func Complete(children []*store.BacklogItem) bool {
+	var counts store.ChildCounts
+	for _, c := range children {
+		switch c.Status {
+		case store.BacklogStatusDone:
+			counts.Done++
+		case store.BacklogStatusCancelled:
+			counts.Cancelled++
+		default:
+			counts.Open++
+		}
+	}
+	return CountsComplete(counts)
+}
+
+// CountsComplete is Complete for children already tallied by status.
+func CountsComplete(c store.ChildCounts) bool {
+	return c.Open == 0 && c.Done > 0
+}
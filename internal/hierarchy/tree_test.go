@@ -0,0 +1,103 @@
+package hierarchy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+var created = time.Now()
+
+// newItem creates an item under parent, created after the previous one.
+func newItem(parent *store.BacklogItem, status store.BacklogStatus, tokens int64) *store.BacklogItem {
+	created = created.Add(time.Second)
+	item := &store.BacklogItem{ID: uuid.New(), Status: status, CreatedAt: created}
+	if parent != nil {
+		item.ParentID = &parent.ID
+	}
+	if tokens > 0 {
+		item.EstimatedTokens = &tokens
+	}
+	return item
+}
+
+func TestBuild(t *testing.T) {
+	// epic -> story (done task, blocked task), done task, cancelled task
+	epic := newItem(nil, store.BacklogStatusInProgress, 999)
+	story := newItem(epic, store.BacklogStatusInProgress, 0)
+	a := newItem(story, store.BacklogStatusDone, 1000)
+	b := newItem(story, store.BacklogStatusBlocked, 3000)
+	c := newItem(epic, store.BacklogStatusDone, 0)
+	d := newItem(epic, store.BacklogStatusCancelled, 5000)
+	score := 0.7
+	b.PriorityScore = &score
+	unrelated := newItem(newItem(nil, store.BacklogStatusReady, 0), store.BacklogStatusReady, 0)
+
+	tree := Build(epic, []*store.BacklogItem{story, a, b, c, d, unrelated})
+	if len(tree.Children) != 3 || tree.Children[0].Item != story || len(tree.Children[0].Children) != 2 {
+		t.Fatalf("unexpected tree shape: %+v", tree)
+	}
+
+	r := tree.Rollup
+	if r.Total != 3 || r.Done != 2 {
+		t.Errorf("expected 2 of 3 leaves done, got %d of %d", r.Done, r.Total)
+	}
+	if r.EstimatedTokens != 4000 {
+		t.Errorf("expected 4000 rolled-up tokens, got %d", r.EstimatedTokens)
+	}
+	if r.PercentByTokens != 25 {
+		t.Errorf("expected 25%% by tokens, got %v", r.PercentByTokens)
+	}
+	if r.WorstStatus != store.BacklogStatusBlocked {
+		t.Errorf("expected worst status blocked, got %s", r.WorstStatus)
+	}
+	if r.PriorityScore == nil || *r.PriorityScore != 0.7 {
+		t.Errorf("expected the open leaf's priority score, got %v", r.PriorityScore)
+	}
+
+	s := tree.Children[0].Rollup
+	if s.PercentByCount != 50 || s.PercentByTokens != 25 {
+		t.Errorf("expected the story 50%% by count and 25%% by tokens, got %v and %v", s.PercentByCount, s.PercentByTokens)
+	}
+	if cr := tree.Children[1].Rollup; cr.PercentByTokens != 100 {
+		t.Errorf("expected an unestimated done leaf 100%% by tokens, got %v", cr.PercentByTokens)
+	}
+}
+
+func TestWorse(t *testing.T) {
+	tests := []struct {
+		a, b, want store.BacklogStatus
+	}{
+		{store.BacklogStatusDone, store.BacklogStatusInProgress, store.BacklogStatusInProgress},
+		{store.BacklogStatusBlocked, store.BacklogStatusBacklog, store.BacklogStatusBlocked},
+		{store.BacklogStatusCancelled, store.BacklogStatusDone, store.BacklogStatusDone},
+		{"", store.BacklogStatusReview, store.BacklogStatusReview},
+	}
+	for _, tt := range tests {
+		if got := Worse(tt.a, tt.b); got != tt.want {
+			t.Errorf("Worse(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	done := newItem(nil, store.BacklogStatusDone, 0)
+	cancelled := newItem(nil, store.BacklogStatusCancelled, 0)
+	open := newItem(nil, store.BacklogStatusReview, 0)
+
+	if !Complete([]*store.BacklogItem{done, cancelled}) {
+		t.Error("expected done and cancelled children to complete the parent")
+	}
+	if Complete([]*store.BacklogItem{done, open}) {
+		t.Error("expected an open child to keep the parent open")
+	}
+	if Complete([]*store.BacklogItem{cancelled}) {
+		t.Error("expected only cancelled children to leave the parent alone")
+	}
+	if Complete(nil) {
+		t.Error("expected a childless parent to stay open")
+	}
+}
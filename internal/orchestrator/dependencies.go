@@ -0,0 +1,163 @@
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hierarchy"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// ItemClosed applies a backlog item being done or cancelled to the items
+// around it: the items it was blocking are released, and with
+// hierarchy.auto_complete its parent is completed once all of the parent's
+// children are closed.
+func (o *Orchestrator) ItemClosed(ctx context.Context, item *store.BacklogItem) {
+	o.releaseDependents(ctx, item)
+	if o.cfg.Hierarchy.AutoComplete {
+		o.completeParent(ctx, item)
+	}
+}
+
+// releaseDependents resolves item's dependencies and rescores each item it
+// was blocking. A blocked item left with no unresolved blockers moves back
+// to ready and is published as unblocked. When item was cancelled and
+// dependencies.on_cancel is cascade, the blocked items are cancelled
+// instead, along with everything they block in turn.
+func (o *Orchestrator) releaseDependents(ctx context.Context, item *store.BacklogItem) {
+	deps, err := o.store.GetDependenciesForItem(ctx, item.ID)
+	if err != nil {
+		o.logger.Error("failed to load dependencies", "item_id", item.ID, "error", err)
+		return
+	}
+	var blocked []*store.BacklogItem
+	for _, d := range deps {
+		if d.BlockerID != item.ID || d.ResolvedAt != nil {
+			continue
+		}
+		b, err := o.store.GetBacklogItem(ctx, d.BlockedID)
+		if err != nil || b == nil {
+			continue
+		}
+		blocked = append(blocked, b)
+	}
+
+	if err := o.store.ResolveDependenciesForBlocker(ctx, item.ID); err != nil {
+		o.logger.Error("failed to resolve dependencies", "item_id", item.ID, "error", err)
+		return
+	}
+
+	cascade := item.Status == store.BacklogStatusCancelled && o.cfg.Dependencies.OnCancel == config.OnCancelCascade
+	medianTokens, _ := o.store.GetMedianEstimatedTokens(ctx)
+	for _, b := range blocked {
+		switch b.Status {
+		case store.BacklogStatusDone, store.BacklogStatusCancelled:
+			continue
+		}
+		if cascade {
+			o.cascadeCancel(ctx, b, item)
+			continue
+		}
+		o.release(ctx, b, medianTokens)
+	}
+}
+
+// release rescores b after one of its blockers closed, and unblocks it if
+// that was the last one.
+func (o *Orchestrator) release(ctx context.Context, b *store.BacklogItem, medianTokens int64) {
+	hasBlockers, err := o.store.HasUnresolvedBlockers(ctx, b.ID)
+	if err != nil {
+		o.logger.Error("failed to check blockers", "item_id", b.ID, "error", err)
+		return
+	}
+	score := o.scorer.ScoreItem(b, hasBlockers, medianTokens)
+	b.PriorityScore = &score
+
+	// Items blocked by a failed stage task stay blocked until a human
+	// clears the failure.
+	_, failed := b.Metadata["blocked_reason"]
+	if !hasBlockers && b.Status == store.BacklogStatusBlocked && !failed {
+		b.Status = store.BacklogStatusReady
+	}
+	if err := o.store.UpdateBacklogItem(ctx, b); err != nil {
+		o.logger.Error("failed to update unblocked item", "item_id", b.ID, "error", err)
+		return
+	}
+	if hasBlockers {
+		return
+	}
+
+	o.logger.Info("backlog item unblocked", "item_id", b.ID, "status", b.Status)
+	if o.hermes != nil {
+		_ = o.hermes.Publish(hermes.SubjectBacklogUnblocked(b.ID.String()), hermes.BacklogItemEvent{
+			ItemID: b.ID.String(),
+			Status: string(b.Status),
+			Title:  b.Title,
+		})
+	}
+}
+
+// cascadeCancel cancels b because blocker was cancelled, then closes b in
+// turn.
+func (o *Orchestrator) cascadeCancel(ctx context.Context, b, blocker *store.BacklogItem) {
+	b.Status = store.BacklogStatusCancelled
+	if b.Metadata == nil {
+		b.Metadata = map[string]interface{}{}
+	}
+	b.Metadata["cancelled_by"] = blocker.ID.String()
+	if err := o.store.UpdateBacklogItem(ctx, b); err != nil {
+		o.logger.Error("failed to cancel blocked item", "item_id", b.ID, "error", err)
+		return
+	}
+
+	o.logger.Info("backlog item cancelled with its blocker", "item_id", b.ID, "blocker_id", blocker.ID)
+	if o.hermes != nil {
+		_ = o.hermes.Publish(hermes.SubjectBacklogCancelled(b.ID.String()), hermes.BacklogItemEvent{
+			ItemID: b.ID.String(),
+			Status: string(b.Status),
+			Title:  b.Title,
+		})
+	}
+	o.ItemClosed(ctx, b)
+}
+
+// completeParent marks item's parent done once every child of the parent
+// is done or cancelled, then closes the parent in turn.
+func (o *Orchestrator) completeParent(ctx context.Context, item *store.BacklogItem) {
+	if item.ParentID == nil {
+		return
+	}
+	parent, err := o.store.GetBacklogItem(ctx, *item.ParentID)
+	if err != nil || parent == nil {
+		return
+	}
+	switch parent.Status {
+	case store.BacklogStatusDone, store.BacklogStatusCancelled:
+		return
+	}
+	counts, err := o.store.CountBacklogChildren(ctx, parent.ID)
+	if err != nil {
+		o.logger.Error("failed to count children", "item_id", parent.ID, "error", err)
+		return
+	}
+	if !hierarchy.CountsComplete(counts) {
+		return
+	}
+
+	parent.Status = store.BacklogStatusDone
+	if err := o.store.UpdateBacklogItem(ctx, parent); err != nil {
+		o.logger.Error("failed to complete parent item", "item_id", parent.ID, "error", err)
+		return
+	}
+
+	o.logger.Info("backlog item completed with its children", "item_id", parent.ID)
+	if o.hermes != nil {
+		_ = o.hermes.Publish(hermes.SubjectBacklogCompleted(parent.ID.String()), hermes.BacklogItemEvent{
+			ItemID: parent.ID.String(),
+			Status: string(parent.Status),
+			Title:  parent.Title,
+		})
+	}
+	o.ItemClosed(ctx, parent)
+}
@@ -0,0 +1,303 @@
+// Package orchestrator bridges the backlog stage engine and the task broker:
+// backlog stages configured for dispatch are run as swarm tasks, and the
+// outcome of those tasks is fed back into the item's stage gates. It also
+// releases the items a backlog item blocks once that item is closed, and
+// completes parents whose children are all closed.
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/budget"
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/scoring"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// TaskSource is the source recorded on tasks dispatched for backlog stages.
+const TaskSource = "backlog"
+
+// Task defaults for dispatched stages.
+const (
+	stageTaskTimeoutSeconds = 300
+	stageTaskMaxRetries     = 3
+)
+
+// Orchestrator dispatches backlog stages as tasks and applies their results.
+// It is shared by the API, which advances stages, and the broker, which sees
+// tasks complete and fail.
+type Orchestrator struct {
+	store  store.Store
+	hermes hermes.Client
+	scorer *scoring.BacklogScorer
+	cfg    *config.Config
+	logger *slog.Logger
+}
+
+func New(s store.Store, h hermes.Client, cfg *config.Config, logger *slog.Logger) *Orchestrator {
+	scorer := scoring.NewConfiguredBacklogScorer(cfg.Scoring)
+	return &Orchestrator{store: s, hermes: h, scorer: scorer, cfg: cfg, logger: logger}
+}
+
+// Advance moves item past its current stage once that stage's gate is met:
+// to the next stage, which is then dispatched, or to done after the last.
+func (o *Orchestrator) Advance(ctx context.Context, item *store.BacklogItem) {
+	if item.StageIndex >= len(item.StageTemplate)-1 {
+		item.Status = store.BacklogStatusDone
+		_ = o.store.UpdateBacklogItem(ctx, item)
+
+		if o.hermes != nil {
+			_ = o.hermes.Publish(hermes.SubjectItemCompleted(item.ID.String()), hermes.ItemCompletedEvent{
+				ItemID:          item.ID.String(),
+				Title:           item.Title,
+				StagesCompleted: len(item.StageTemplate),
+				TotalDurationMs: time.Since(item.CreatedAt).Milliseconds(),
+			})
+		}
+		o.ItemClosed(ctx, item)
+		return
+	}
+
+	previousStage := item.CurrentStage
+	item.StageIndex++
+	item.CurrentStage = item.StageTemplate[item.StageIndex]
+
+	if err := o.store.UpdateBacklogItem(ctx, item); err != nil {
+		o.logger.Error("failed to advance backlog item", "item_id", item.ID, "error", err)
+		return
+	}
+
+	if o.hermes != nil {
+		_ = o.hermes.Publish(hermes.SubjectStageAdvanced(item.ID.String()), hermes.StageAdvancedEvent{
+			ItemID:     item.ID.String(),
+			ItemTitle:  item.Title,
+			FromStage:  previousStage,
+			ToStage:    item.CurrentStage,
+			StageIndex: item.StageIndex,
+			Tier:       item.ModelTier,
+		})
+	}
+
+	if _, err := o.StageEntered(ctx, item); err != nil {
+		o.logger.Error("failed to dispatch backlog stage", "item_id", item.ID, "stage", item.CurrentStage, "error", err)
+	}
+}
+
+// StageEntered creates a task for item's current stage when the orchestrator
+// is enabled and the stage is configured for dispatch. It returns nil when
+// no task is needed, including when the stage already has an active task.
+func (o *Orchestrator) StageEntered(ctx context.Context, item *store.BacklogItem) (*store.Task, error) {
+	if !o.cfg.Orchestrator.Enabled {
+		return nil, nil
+	}
+	caps, ok := o.cfg.Orchestrator.Stages[item.CurrentStage]
+	if !ok {
+		return nil, nil
+	}
+
+	existing, err := o.store.ListTasks(ctx, store.TaskFilter{BacklogItemID: &item.ID})
+	if err != nil {
+		return nil, fmt.Errorf("list stage tasks: %w", err)
+	}
+	for _, t := range existing {
+		if t.BacklogStage != item.CurrentStage {
+			continue
+		}
+		switch t.Status {
+		case store.StatusPending, store.StatusAssigned, store.StatusInProgress:
+			return nil, nil
+		}
+	}
+
+	owner := item.AssignedTo
+	if owner == "" {
+		owner = "system"
+	}
+	id := item.ID
+	task := &store.Task{
+		Owner:                owner,
+		Title:                item.CurrentStage + ": " + item.Title,
+		Description:          item.Description,
+		RequiredCapabilities: caps,
+		Status:               store.StatusPending,
+		Source:               TaskSource,
+		Labels:               item.Labels,
+		OneWayDoor:           item.OneWayDoor,
+		ModelTier:            item.ModelTier,
+		MinModelTier:         item.ModelTier,
+		TimeoutSeconds:       stageTaskTimeoutSeconds,
+		MaxRetries:           stageTaskMaxRetries,
+		RetryEligible:        true,
+		Metadata: map[string]interface{}{
+			"backlog_item_id": item.ID.String(),
+			"stage":           item.CurrentStage,
+		},
+		BacklogItemID: &id,
+		BacklogStage:  item.CurrentStage,
+	}
+
+	d, err := budget.CheckCreate(ctx, o.store, o.hermes, o.logger, task)
+	if err != nil {
+		o.logger.Warn("budget check failed", "error", err)
+	} else if d.Action == store.BudgetActionReject {
+		o.block(ctx, item, fmt.Sprintf("%s task rejected by budget", item.CurrentStage))
+		return nil, nil
+	}
+
+	if err := o.store.CreateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("create stage task: %w", err)
+	}
+	if o.hermes != nil {
+		_ = o.hermes.Publish(hermes.SubjectTaskCreated(task.ID.String()), task)
+	}
+	o.logger.Info("backlog stage dispatched", "item_id", item.ID, "stage", item.CurrentStage, "task_id", task.ID)
+	return task, nil
+}
+
+// TaskCompleted submits a completed stage task's result as evidence for the
+// unsatisfied criteria of its stage gate. Items on a tier with auto-approve
+// have the criteria satisfied too, advancing the item once the gate is met.
+func (o *Orchestrator) TaskCompleted(ctx context.Context, task *store.Task) {
+	item := o.stageItem(ctx, task)
+	if item == nil {
+		return
+	}
+
+	criteria, err := o.store.GetGateStatus(ctx, item.ID, task.BacklogStage)
+	if err != nil {
+		o.logger.Error("failed to load stage gate", "item_id", item.ID, "stage", task.BacklogStage, "error", err)
+		return
+	}
+	submittedBy := task.AssignedAgent
+	if submittedBy == "" {
+		submittedBy = "dispatch"
+	}
+	for _, c := range criteria {
+		if c.Satisfied {
+			continue
+		}
+		evidence := taskEvidence(task, c.Criterion)
+		if err := o.store.SubmitEvidence(ctx, item.ID, task.BacklogStage, c.Criterion, evidence, submittedBy); err != nil {
+			o.logger.Error("failed to submit stage evidence", "item_id", item.ID, "criterion", c.Criterion, "error", err)
+			continue
+		}
+		o.publishEvidence(ctx, item, task.BacklogStage, c.Criterion, evidence, submittedBy)
+	}
+
+	if !o.autoApprove(ctx, item) {
+		return
+	}
+	for _, c := range criteria {
+		if !c.Satisfied {
+			_ = o.store.SatisfyCriterion(ctx, item.ID, task.BacklogStage, c.Criterion, "auto-approved")
+		}
+	}
+	if met, _ := o.store.AllCriteriaMet(ctx, item.ID, task.BacklogStage); met {
+		o.Advance(ctx, item)
+	}
+}
+
+// TaskFailed marks a stage task's backlog item blocked with the task's error.
+// It is called once the task has no retries left.
+func (o *Orchestrator) TaskFailed(ctx context.Context, task *store.Task) {
+	item := o.stageItem(ctx, task)
+	if item == nil {
+		return
+	}
+	reason := task.Error
+	if reason == "" {
+		reason = fmt.Sprintf("%s task %s failed", task.BacklogStage, task.ID)
+	}
+	o.block(ctx, item, reason)
+}
+
+// stageItem returns the backlog item task was dispatched for, or nil when
+// task is not a stage task or the item has since moved to another stage.
+func (o *Orchestrator) stageItem(ctx context.Context, task *store.Task) *store.BacklogItem {
+	if task.BacklogItemID == nil {
+		return nil
+	}
+	item, err := o.store.GetBacklogItem(ctx, *task.BacklogItemID)
+	if err != nil || item == nil {
+		return nil
+	}
+	if item.CurrentStage != task.BacklogStage {
+		return nil
+	}
+	return item
+}
+
+func (o *Orchestrator) autoApprove(ctx context.Context, item *store.BacklogItem) bool {
+	if item.ModelTier != "economy" {
+		return false
+	}
+	ac, err := o.store.GetAutonomyConfig(ctx, item.ModelTier)
+	return err == nil && ac != nil && ac.AutoApprove
+}
+
+func (o *Orchestrator) block(ctx context.Context, item *store.BacklogItem, reason string) {
+	item.Status = store.BacklogStatusBlocked
+	if item.Metadata == nil {
+		item.Metadata = map[string]interface{}{}
+	}
+	item.Metadata["blocked_reason"] = reason
+	if err := o.store.UpdateBacklogItem(ctx, item); err != nil {
+		o.logger.Error("failed to block backlog item", "item_id", item.ID, "error", err)
+		return
+	}
+	o.logger.Warn("backlog item blocked", "item_id", item.ID, "stage", item.CurrentStage, "reason", reason)
+	if o.hermes != nil {
+		_ = o.hermes.Publish(hermes.SubjectItemBlocked(item.ID.String()), hermes.ItemBlockedEvent{
+			ItemID:    item.ID.String(),
+			Reason:    reason,
+			BlockedBy: "dispatch",
+		})
+	}
+}
+
+func (o *Orchestrator) publishEvidence(ctx context.Context, item *store.BacklogItem, stage, criterion, evidence, submittedBy string) {
+	if o.hermes == nil {
+		return
+	}
+	all, _ := o.store.GetGateStatus(ctx, item.ID, stage)
+	var snapshot []hermes.GateEvidenceCriterion
+	for _, c := range all {
+		snapshot = append(snapshot, hermes.GateEvidenceCriterion{
+			Name:        c.Criterion,
+			Evidence:    c.Evidence,
+			HasEvidence: c.Evidence != "",
+		})
+	}
+	_ = o.hermes.Publish(hermes.SubjectGateEvidence(item.ID.String()), hermes.GateEvidenceEvent{
+		ItemID:      item.ID.String(),
+		ItemTitle:   item.Title,
+		ModelTier:   item.ModelTier,
+		Stage:       stage,
+		StageIndex:  item.StageIndex,
+		TotalStages: len(item.StageTemplate),
+		Criterion:   criterion,
+		Evidence:    evidence,
+		SubmittedBy: submittedBy,
+		AgentID:     submittedBy,
+		AllCriteria: snapshot,
+	})
+}
+
+// taskEvidence picks the evidence for criterion from a task result: a
+// per-criterion entry under "evidence", else the result's "summary", else a
+// reference to the task.
+func taskEvidence(task *store.Task, criterion string) string {
+	if ev, ok := task.Result["evidence"].(map[string]interface{}); ok {
+		if s, ok := ev[criterion].(string); ok && s != "" {
+			return s
+		}
+	}
+	if s, ok := task.Result["summary"].(string); ok && s != "" {
+		return s
+	}
+	return "completed by task " + task.ID.String()
+}
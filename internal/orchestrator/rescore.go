@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/hermes"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// RescoreReason is recorded on score changes made by periodic rescoring.
+const RescoreReason = "rescore"
+
+// scoreEpsilon is the smallest score change worth saving.
+const scoreEpsilon = 1e-4
+
+// Rescore recomputes the priority score of every open backlog item as of
+// now, aging urgency as configured in scoring.backlog_rescore. Changed scores
+// are saved and recorded in the item's score history. If any item moves
+// significant_rank_change or more places in the ranking, a summary of the
+// moves is published.
+func (o *Orchestrator) Rescore(ctx context.Context, now time.Time) error {
+	items, err := o.store.ListOpenBacklogItems(ctx)
+	if err != nil {
+		return fmt.Errorf("list open items: %w", err)
+	}
+	medianTokens, _ := o.store.GetMedianEstimatedTokens(ctx)
+	blocked, err := o.store.ListBlockedBacklogItemIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("list blocked items: %w", err)
+	}
+
+	before := rank(items)
+	previous := make(map[uuid.UUID]*float64, len(items))
+	changed := 0
+	for _, item := range items {
+		previous[item.ID] = item.PriorityScore
+		score := o.scorer.ScoreAt(item, blocked[item.ID], medianTokens, now)
+		prev := item.PriorityScore
+		if prev != nil && math.Abs(*prev-score) < scoreEpsilon {
+			continue
+		}
+
+		// Only the score is written, and only if the item is unchanged since
+		// it was listed, so a concurrent edit is never overwritten. An item
+		// edited meanwhile was scored by that edit and is left until the
+		// next rescore.
+		if err := o.store.UpdateBacklogItemScore(ctx, item, score); err != nil {
+			if errors.Is(err, store.ErrBacklogItemChanged) {
+				o.logger.Info("backlog item changed during rescore, skipped", "item_id", item.ID)
+			} else {
+				o.logger.Error("failed to save rescored item", "item_id", item.ID, "error", err)
+			}
+			item.PriorityScore = prev
+			continue
+		}
+		changed++
+		if err := o.store.CreateScoreChange(ctx, &store.BacklogScoreChange{
+			BacklogItemID: item.ID,
+			PreviousScore: prev,
+			NewScore:      score,
+			Reason:        RescoreReason,
+		}); err != nil {
+			o.logger.Error("failed to record score change", "item_id", item.ID, "error", err)
+		}
+	}
+
+	threshold := o.cfg.Scoring.BacklogRescore.SignificantRankChange
+	if threshold < 1 {
+		threshold = 1
+	}
+	after := rank(items)
+	var moves []hermes.BacklogRankMove
+	for _, item := range items {
+		from, to := before[item.ID], after[item.ID]
+		if from-to < threshold && to-from < threshold {
+			continue
+		}
+		moves = append(moves, hermes.BacklogRankMove{
+			ItemID:        item.ID.String(),
+			Title:         item.Title,
+			PreviousScore: previous[item.ID],
+			NewScore:      *item.PriorityScore,
+			PreviousRank:  from,
+			NewRank:       to,
+		})
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].NewRank < moves[j].NewRank })
+
+	o.logger.Info("backlog rescored", "items", len(items), "changed", changed, "moved", len(moves))
+	if len(moves) > 0 && o.hermes != nil {
+		_ = o.hermes.Publish(hermes.SubjectBacklogRescored(), hermes.BacklogRescoredEvent{
+			ItemsScored:  len(items),
+			ItemsChanged: changed,
+			Moves:        moves,
+		})
+	}
+	return nil
+}
+
+// rank returns each item's 1-based position when ordered as the backlog is
+// listed: highest score first, unscored last, oldest first among ties.
+func rank(items []*store.BacklogItem) map[uuid.UUID]int {
+	sorted := make([]*store.BacklogItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].PriorityScore, sorted[j].PriorityScore
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && *a != *b {
+			return *a > *b
+		}
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	ranks := make(map[uuid.UUID]int, len(sorted))
+	for i, item := range sorted {
+		ranks[item.ID] = i + 1
+	}
+	return ranks
+}
@@ -0,0 +1,242 @@
+// Package planning fills iterations from the backlog and tracks their
+// progress: it sizes items in an iteration's capacity unit, picks ready
+// items by priority within capacity, and derives burndown from the items'
+// status history.
+package planning
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/depgraph"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// Reasons an item was left out of a plan.
+const (
+	SkipCapacity = "capacity" // it does not fit in the capacity left
+	SkipBlocked  = "blocked"  // a blocker is neither closed nor in the iteration
+)
+
+// Cost returns the capacity item takes in unit. In points it is the item's
+// effort; in tokens it is its estimated tokens, else defaultTokens, else
+// its effort converted to tokens. Closed items are sized as if open, so
+// completing an item does not shrink the iteration's scope.
+func Cost(item *store.BacklogItem, unit string, defaultTokens int64) float64 {
+	open := *item
+	open.Status = store.BacklogStatusReady
+	if unit == store.CapacityPoints {
+		return depgraph.Effort(&open)
+	}
+	if item.EstimatedTokens != nil && *item.EstimatedTokens > 0 {
+		return float64(*item.EstimatedTokens)
+	}
+	if defaultTokens > 0 {
+		return float64(defaultTokens)
+	}
+	return depgraph.Effort(&open) * depgraph.TokensPerPoint
+}
+
+// Options configures Plan.
+type Options struct {
+	Capacity float64
+	Unit     string
+	// MaxDomainShare caps the share of capacity one domain may take while
+	// other domains have items that fit. 0 or 1 disables the cap.
+	MaxDomainShare float64
+	// DefaultTokens sizes items without estimated tokens; see Cost.
+	DefaultTokens int64
+}
+
+// Skip is a candidate left out of a plan.
+type Skip struct {
+	Item   *store.BacklogItem
+	Reason string
+}
+
+// Result is the outcome of Plan.
+type Result struct {
+	Items   []*store.BacklogItem // picked, in the order they were picked
+	Load    float64              // capacity used, including committed items
+	Skipped []Skip
+}
+
+// Plan fills an iteration holding committed from candidates. It picks the
+// highest-priority candidate that fits, over and over, until none does.
+// A candidate fits when its cost fits in the capacity left, each of its
+// unresolved blockers in deps is closed or in the iteration, and its
+// domain stays within MaxDomainShare of the capacity. Once no candidate
+// fits, the domain cap is lifted so capacity no other domain could use is
+// not left idle.
+func Plan(committed, candidates []*store.BacklogItem, deps []*store.BacklogDependency, opts Options) Result {
+	in := make(map[uuid.UUID]bool)
+	domainLoad := make(map[string]float64)
+	var res Result
+	for _, item := range committed {
+		in[item.ID] = true
+		c := Cost(item, opts.Unit, opts.DefaultTokens)
+		res.Load += c
+		domainLoad[item.Domain] += c
+	}
+
+	blockers := make(map[uuid.UUID][]uuid.UUID)
+	for _, d := range deps {
+		if d.ResolvedAt == nil {
+			blockers[d.BlockedID] = append(blockers[d.BlockedID], d.BlockerID)
+		}
+	}
+	// Blockers outside the iteration may be closed.
+	known := make(map[uuid.UUID]*store.BacklogItem)
+	for _, item := range committed {
+		known[item.ID] = item
+	}
+	for _, item := range candidates {
+		known[item.ID] = item
+	}
+	blocked := func(item *store.BacklogItem) bool {
+		for _, id := range blockers[item.ID] {
+			if in[id] {
+				continue
+			}
+			if b := known[id]; b != nil && closed(b) {
+				continue
+			}
+			return true
+		}
+		return false
+	}
+
+	queue := make([]*store.BacklogItem, 0, len(candidates))
+	for _, item := range candidates {
+		if !in[item.ID] {
+			queue = append(queue, item)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		si, sj := score(queue[i]), score(queue[j])
+		if si != sj {
+			return si > sj
+		}
+		return queue[i].CreatedAt.Before(queue[j].CreatedAt)
+	})
+
+	capped := opts.MaxDomainShare > 0 && opts.MaxDomainShare < 1
+	for {
+		picked := -1
+		for i, item := range queue {
+			c := Cost(item, opts.Unit, opts.DefaultTokens)
+			if res.Load+c > opts.Capacity || blocked(item) {
+				continue
+			}
+			if capped && domainLoad[item.Domain]+c > opts.MaxDomainShare*opts.Capacity {
+				continue
+			}
+			picked = i
+			res.Items = append(res.Items, item)
+			res.Load += c
+			domainLoad[item.Domain] += c
+			in[item.ID] = true
+			break
+		}
+		if picked >= 0 {
+			queue = append(queue[:picked], queue[picked+1:]...)
+			continue
+		}
+		if !capped {
+			break
+		}
+		capped = false
+	}
+
+	for _, item := range queue {
+		reason := SkipBlocked
+		if res.Load+Cost(item, opts.Unit, opts.DefaultTokens) > opts.Capacity {
+			reason = SkipCapacity
+		}
+		res.Skipped = append(res.Skipped, Skip{Item: item, Reason: reason})
+	}
+	return res
+}
+
+func closed(item *store.BacklogItem) bool {
+	return item.Status == store.BacklogStatusDone || item.Status == store.BacklogStatusCancelled
+}
+
+func score(item *store.BacklogItem) float64 {
+	if item.PriorityScore == nil {
+		return -1
+	}
+	return *item.PriorityScore
+}
+
+// Tracked is an item committed to an iteration, for Burndown.
+type Tracked struct {
+	Item        *store.BacklogItem
+	CommittedAt time.Time
+	Cost        float64
+	// Changes holds the item's changes since the iteration started, newest
+	// first.
+	Changes []*store.ChangeRecord
+}
+
+// BurndownPoint is an iteration's progress at a point in time, in its
+// capacity unit.
+type BurndownPoint struct {
+	Date      time.Time `json:"date"`
+	Scope     float64   `json:"scope"`
+	Completed float64   `json:"completed"`
+	Remaining float64   `json:"remaining"`
+	Ideal     float64   `json:"ideal"`
+}
+
+// Burndown returns the iteration's progress at its start, at each day
+// after, and at its end or now, whichever is earlier. An item counts
+// towards scope from when it was committed, or the start if earlier, until
+// it is cancelled; it counts as completed while it is done. Ideal falls in
+// a straight line from the scope at the start to zero at the end.
+func Burndown(it *store.Iteration, items []Tracked, now time.Time) ([]BurndownPoint, error) {
+	end := it.EndsAt
+	if now.Before(end) {
+		end = now
+	}
+	if end.Before(it.StartsAt) {
+		return []BurndownPoint{}, nil
+	}
+	var times []time.Time
+	for t := it.StartsAt; t.Before(end); t = t.Add(24 * time.Hour) {
+		times = append(times, t)
+	}
+	times = append(times, end)
+
+	length := it.EndsAt.Sub(it.StartsAt).Seconds()
+	points := make([]BurndownPoint, 0, len(times))
+	for _, t := range times {
+		p := BurndownPoint{Date: t}
+		for _, tr := range items {
+			if tr.CommittedAt.After(t) {
+				continue
+			}
+			item, err := store.AsOf(tr.Item, tr.Changes, t)
+			if err != nil {
+				return nil, err
+			}
+			if item == nil || item.Status == store.BacklogStatusCancelled {
+				continue
+			}
+			p.Scope += tr.Cost
+			if item.Status == store.BacklogStatusDone {
+				p.Completed += tr.Cost
+			}
+		}
+		p.Remaining = p.Scope - p.Completed
+		if len(points) > 0 && length > 0 {
+			p.Ideal = points[0].Scope * it.EndsAt.Sub(t).Seconds() / length
+		} else {
+			p.Ideal = p.Scope
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
@@ -0,0 +1,184 @@
+package planning
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func newItem(title, domain, effort string, score float64) *store.BacklogItem {
+	return &store.BacklogItem{
+		ID:             uuid.New(),
+		Title:          title,
+		Domain:         domain,
+		Status:         store.BacklogStatusReady,
+		EffortEstimate: effort,
+		PriorityScore:  &score,
+	}
+}
+
+func titles(items []*store.BacklogItem) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Title)
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCost(t *testing.T) {
+	tokens := int64(120000)
+	tests := []struct {
+		item          *store.BacklogItem
+		unit          string
+		defaultTokens int64
+		want          float64
+	}{
+		{&store.BacklogItem{EffortEstimate: "l", Status: store.BacklogStatusDone}, store.CapacityPoints, 0, 5},
+		{&store.BacklogItem{EstimatedTokens: &tokens}, store.CapacityPoints, 0, 2.4},
+		{&store.BacklogItem{EstimatedTokens: &tokens}, store.CapacityTokens, 30000, 120000},
+		{&store.BacklogItem{EffortEstimate: "s"}, store.CapacityTokens, 30000, 30000},
+		{&store.BacklogItem{EffortEstimate: "s"}, store.CapacityTokens, 0, 100000},
+	}
+	for _, tt := range tests {
+		if got := Cost(tt.item, tt.unit, tt.defaultTokens); got != tt.want {
+			t.Errorf("Cost(%q, %v, %s) = %v, want %v", tt.item.EffortEstimate, tt.item.EstimatedTokens, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestPlanCapacityAndPriority(t *testing.T) {
+	a := newItem("A", "", "l", 0.9)       // 5
+	b := newItem("B", "", "m", 0.8)       // 3
+	c := newItem("C", "", "s", 0.7)       // 2
+	d := newItem("D", "", "xs", 0.1)      // 1
+	committed := newItem("X", "", "s", 0) // 2
+
+	res := Plan([]*store.BacklogItem{committed}, []*store.BacklogItem{d, c, b, a, committed}, nil,
+		Options{Capacity: 10, Unit: store.CapacityPoints})
+	if got := titles(res.Items); !equal(got, []string{"A", "B"}) {
+		t.Errorf("picked %v, want [A B]", got)
+	}
+	if res.Load != 10 {
+		t.Errorf("load = %v, want 10", res.Load)
+	}
+	if len(res.Skipped) != 2 || res.Skipped[0].Item != c || res.Skipped[0].Reason != SkipCapacity {
+		t.Errorf("skipped %+v, want C and D for capacity", res.Skipped)
+	}
+}
+
+func TestPlanDependencies(t *testing.T) {
+	blocker := newItem("blocker", "", "s", 0.2)
+	blocked := newItem("blocked", "", "s", 0.9)
+	outside := newItem("outside", "", "s", 0.8)
+	stuck := newItem("stuck", "", "s", 0.7)
+	resolvedAt := time.Now()
+	deps := []*store.BacklogDependency{
+		{BlockerID: blocker.ID, BlockedID: blocked.ID},
+		{BlockerID: uuid.New(), BlockedID: stuck.ID},
+		{BlockerID: uuid.New(), BlockedID: outside.ID, ResolvedAt: &resolvedAt},
+	}
+
+	res := Plan(nil, []*store.BacklogItem{blocker, blocked, outside, stuck}, deps,
+		Options{Capacity: 100, Unit: store.CapacityPoints})
+	// The blocked item follows its blocker in, ahead of lower-priority items.
+	if got := titles(res.Items); !equal(got, []string{"outside", "blocker", "blocked"}) {
+		t.Errorf("picked %v, want [outside blocker blocked]", got)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0].Item != stuck || res.Skipped[0].Reason != SkipBlocked {
+		t.Errorf("skipped %+v, want stuck as blocked", res.Skipped)
+	}
+}
+
+func TestPlanDomainShare(t *testing.T) {
+	ops1 := newItem("ops1", "ops", "m", 0.9)
+	ops2 := newItem("ops2", "ops", "m", 0.8)
+	ops3 := newItem("ops3", "ops", "m", 0.7)
+	web := newItem("web", "web", "m", 0.1)
+
+	opts := Options{Capacity: 12, Unit: store.CapacityPoints, MaxDomainShare: 0.5}
+	res := Plan(nil, []*store.BacklogItem{ops1, ops2, ops3, web}, nil, opts)
+	// ops fills its half, web gets in, then the cap lifts to fill the rest.
+	if got := titles(res.Items); !equal(got, []string{"ops1", "ops2", "web", "ops3"}) {
+		t.Errorf("picked %v, want [ops1 ops2 web ops3]", got)
+	}
+
+	opts.MaxDomainShare = 0
+	res = Plan(nil, []*store.BacklogItem{ops1, ops2, ops3, web}, nil, opts)
+	if got := titles(res.Items); !equal(got, []string{"ops1", "ops2", "ops3", "web"}) {
+		t.Errorf("uncapped picked %v, want [ops1 ops2 ops3 web]", got)
+	}
+}
+
+func TestBurndown(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	it := &store.Iteration{StartsAt: start, EndsAt: start.Add(4 * 24 * time.Hour)}
+
+	done := newItem("done", "", "s", 0)
+	cancelled := newItem("cancelled", "", "s", 0)
+	late := newItem("late", "", "s", 0)
+	open := newItem("open", "", "s", 0)
+
+	// change returns the change record for item's status moving from
+	// before to after at at.
+	change := func(item *store.BacklogItem, before, after store.BacklogStatus, at time.Time) *store.ChangeRecord {
+		old, cur := *item, *item
+		old.Status, cur.Status = before, after
+		c := store.NewChangeRecord(context.Background(), store.EntityBacklogItem, item.ID, store.ChangeUpdated, &old, &cur)
+		c.CreatedAt = at
+		return c
+	}
+	done.Status = store.BacklogStatusDone
+	cancelled.Status = store.BacklogStatusCancelled
+	items := []Tracked{
+		{Item: done, CommittedAt: start.Add(-time.Hour), Cost: 2, Changes: []*store.ChangeRecord{
+			change(done, store.BacklogStatusInProgress, store.BacklogStatusDone, start.Add(36*time.Hour)),
+			change(done, store.BacklogStatusReady, store.BacklogStatusInProgress, start.Add(12*time.Hour)),
+		}},
+		{Item: cancelled, CommittedAt: start, Cost: 2, Changes: []*store.ChangeRecord{
+			change(cancelled, store.BacklogStatusReady, store.BacklogStatusCancelled, start.Add(60*time.Hour)),
+		}},
+		{Item: late, CommittedAt: start.Add(30 * time.Hour), Cost: 2},
+		{Item: open, CommittedAt: start, Cost: 2},
+	}
+
+	points, err := Burndown(it, items, start.Add(3*24*time.Hour+time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BurndownPoint{
+		{Scope: 6, Completed: 0, Remaining: 6, Ideal: 6},
+		{Scope: 6, Completed: 0, Remaining: 6, Ideal: 4.5},
+		{Scope: 8, Completed: 2, Remaining: 6, Ideal: 3},
+		{Scope: 6, Completed: 2, Remaining: 4, Ideal: 1.5},
+		{Scope: 6, Completed: 2, Remaining: 4, Ideal: 1.4375},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d: %+v", len(points), len(want), points)
+	}
+	for i, p := range points {
+		w := want[i]
+		if p.Scope != w.Scope || p.Completed != w.Completed || p.Remaining != w.Remaining || p.Ideal != w.Ideal {
+			t.Errorf("point %d (%s) = %+v, want %+v", i, p.Date, p, w)
+		}
+	}
+
+	if points, _ := Burndown(it, items, start.Add(-time.Hour)); len(points) != 0 {
+		t.Errorf("burndown before the start = %+v, want none", points)
+	}
+}
@@ -0,0 +1,197 @@
+// Package schedule parses cron expressions and plans the runs of recurring
+// task schedules.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week. Fields accept *, lists, ranges and steps, and
+// month and weekday names. The @hourly, @daily, @weekly, @monthly and
+// @yearly shorthands are also accepted.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// As in Vixie cron, when both day fields are restricted a day matches
+	// either; when one starts with * only the other applies.
+	domStar, dowStar bool
+}
+
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type bounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteBounds = bounds{name: "minute", min: 0, max: 59}
+	hourBounds   = bounds{name: "hour", min: 0, max: 23}
+	domBounds    = bounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = bounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day of week allows 7 for Sunday, folded onto 0 after parsing.
+	dowBounds = bounds{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// searchLimit bounds how far Next looks ahead, so expressions such as
+// "0 0 30 2 *" that never fire terminate.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// ParseCron parses expr. It rejects expressions that never fire.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if s, ok := shorthands[strings.ToLower(expr)]; ok {
+		expr = s
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	c := &Cron{
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}
+	var err error
+	if c.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+
+	if c.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	return c, nil
+}
+
+// parseField parses one comma-separated field into a bitset.
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step := b.min, b.max, 1
+
+		rng := part
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid %s step in %q", b.name, part)
+			}
+			rng, step = part[:i], n
+		}
+
+		if rng != "*" {
+			if i := strings.IndexByte(rng, '-'); i >= 0 {
+				var err error
+				if lo, err = b.value(rng[:i]); err != nil {
+					return 0, err
+				}
+				if hi, err = b.value(rng[i+1:]); err != nil {
+					return 0, err
+				}
+			} else {
+				v, err := b.value(rng)
+				if err != nil {
+					return 0, err
+				}
+				lo, hi = v, v
+				if step > 1 {
+					hi = b.max
+				}
+			}
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid %s range %q", b.name, part)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (b bounds) value(s string) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("invalid %s %q", b.name, s)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t, in t's location, that matches the
+// expression, or the zero time if there is none within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(searchLimit)
+
+	for t.Before(end) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			next := t.Add(time.Minute)
+			if next.Hour() == t.Hour() && next.Minute() < t.Minute() {
+				// The clock went back an hour; don't fire twice in the
+				// repeated hour.
+				next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			}
+			t = next
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
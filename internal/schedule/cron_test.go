@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, loc *time.Location, s string) time.Time {
+	t.Helper()
+	v, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	if err != nil {
+		t.Fatalf("parse %q: %v", s, err)
+	}
+	return v
+}
+
+func TestCronNext(t *testing.T) {
+	cases := []struct {
+		expr, after, want string
+	}{
+		{"*/15 * * * *", "2026-03-02 10:07", "2026-03-02 10:15"},
+		{"0 3 * * *", "2026-03-02 03:00", "2026-03-03 03:00"},
+		{"@hourly", "2026-03-02 10:59", "2026-03-02 11:00"},
+		{"30 9 * * mon-fri", "2026-03-06 10:00", "2026-03-09 09:30"}, // Friday -> Monday
+		{"0 0 1 jan,jul *", "2026-03-02 00:00", "2026-07-01 00:00"},
+		{"0 12 * * 7", "2026-03-02 00:00", "2026-03-08 12:00"}, // 7 is Sunday
+		{"5-10/5 * * * *", "2026-03-02 10:06", "2026-03-02 10:10"},
+		// Both day fields restricted: either matches.
+		{"0 0 15 * fri", "2026-03-02 00:00", "2026-03-06 00:00"},
+		{"0 0 29 2 *", "2026-03-02 00:00", "2028-02-29 00:00"},
+	}
+	for _, tc := range cases {
+		c, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		got := c.Next(mustTime(t, time.UTC, tc.after))
+		if want := mustTime(t, time.UTC, tc.want); !got.Equal(want) {
+			t.Errorf("%s after %s: expected %s, got %s", tc.expr, tc.after, want, got)
+		}
+	}
+}
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"*/0 * * * *", "5-1 * * * *", "* * * foo *", "0 0 30 2 *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestCronNextAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("tzdata unavailable")
+	}
+	c, _ := ParseCron("30 1 * * *")
+
+	// 01:30 does not exist on 29 March 2026; the next run is the day after.
+	got := c.Next(mustTime(t, loc, "2026-03-28 02:00"))
+	if want := mustTime(t, loc, "2026-03-30 01:30"); !got.Equal(want) {
+		t.Errorf("spring forward: expected %s, got %s", want, got)
+	}
+
+	// 01:30 happens twice on 25 October 2026; it runs once.
+	first := c.Next(mustTime(t, loc, "2026-10-25 00:00"))
+	second := c.Next(first)
+	if second.Day() != 26 {
+		t.Errorf("fall back: expected one run on the 25th, got %s then %s", first, second)
+	}
+}
@@ -0,0 +1,146 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// Location loads the IANA time zone name, defaulting to UTC.
+func Location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// NextRun returns when expr next fires after after, in timezone.
+func NextRun(expr, timezone string, after time.Time) (time.Time, error) {
+	c, err := ParseCron(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := Location(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.Next(after.In(loc)), nil
+}
+
+// Plan is what a schedule should do at a point in time.
+type Plan struct {
+	Runs   []time.Time // scheduled times to create tasks for, oldest first
+	Missed int         // due runs dropped by the catch-up policy
+	Next   time.Time   // the next run after now
+}
+
+// PlanRuns works out which of s's runs from NextRunAt up to now should
+// create tasks. Runs no more than cfg.MissedAfterMs late always do. Later
+// runs were missed, typically while Dispatch was down, and are handled by
+// the schedule's catch-up policy: none drops them, latest runs only the most
+// recent when nothing is on time, and all runs up to cfg.MaxCatchUp of them.
+func PlanRuns(s *store.Schedule, now time.Time, cfg config.SchedulesConfig) (Plan, error) {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return Plan{}, err
+	}
+	loc, err := Location(s.Timezone)
+	if err != nil {
+		return Plan{}, err
+	}
+	now = now.In(loc)
+	if s.NextRunAt.After(now) {
+		return Plan{Next: s.NextRunAt}, nil
+	}
+
+	window := cfg.MaxCatchUp
+	if window < 1 {
+		window = 1
+	}
+	var due []time.Time
+	total := 0
+	for t := s.NextRunAt.In(loc); !t.IsZero() && !t.After(now); t = c.Next(t) {
+		total++
+		due = append(due, t)
+		if len(due) > window {
+			due = due[1:]
+		}
+	}
+
+	grace := time.Duration(cfg.MissedAfterMs) * time.Millisecond
+	late := len(due)
+	for late > 0 && now.Sub(due[late-1]) <= grace {
+		late--
+	}
+	onTime := due[late:]
+
+	plan := Plan{Next: c.Next(now)}
+	switch catchUp(s, cfg) {
+	case config.CatchUpNone:
+		plan.Runs = onTime
+	case config.CatchUpAll:
+		plan.Runs = due
+	default:
+		plan.Runs = onTime
+		if len(onTime) == 0 && late > 0 {
+			plan.Runs = due[late-1 : late]
+		}
+	}
+	plan.Missed = total - len(plan.Runs)
+	return plan, nil
+}
+
+func catchUp(s *store.Schedule, cfg config.SchedulesConfig) string {
+	if s.CatchUp != "" {
+		return s.CatchUp
+	}
+	return cfg.CatchUp
+}
+
+// NewTask builds the task for s's run at the given time. The task's source
+// names the schedule and its metadata records the schedule and run time.
+func NewTask(s *store.Schedule, at time.Time) *store.Task {
+	tpl := s.Template
+	metadata := make(map[string]interface{}, len(tpl.Metadata)+3)
+	for k, v := range tpl.Metadata {
+		metadata[k] = v
+	}
+	metadata["schedule_id"] = s.ID.String()
+	metadata["schedule_name"] = s.Name
+	metadata["scheduled_for"] = at.UTC().Format(time.RFC3339)
+
+	task := &store.Task{
+		Title:                tpl.Title,
+		Description:          tpl.Description,
+		Owner:                tpl.Owner,
+		RequiredCapabilities: tpl.RequiredCapabilities,
+		Priority:             tpl.Priority,
+		Status:               store.StatusPending,
+		Metadata:             metadata,
+		TimeoutSeconds:       tpl.TimeoutSeconds,
+		MaxRetries:           tpl.MaxRetries,
+		Source:               s.Source(),
+		RetryEligible:        true,
+		Preemptible:          tpl.Preemptible,
+		PreferredAgents:      tpl.PreferredAgents,
+		RequiredAgent:        tpl.RequiredAgent,
+		ExcludedAgents:       tpl.ExcludedAgents,
+		AffinityGroup:        tpl.AffinityGroup,
+	}
+	if task.Owner == "" {
+		task.Owner = "system"
+	}
+	if task.TimeoutSeconds == 0 {
+		task.TimeoutSeconds = 300
+	}
+	if task.MaxRetries == 0 {
+		task.MaxRetries = 3
+	}
+	return task
+}
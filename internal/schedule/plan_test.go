@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func planConfig(catchUp string) config.SchedulesConfig {
+	return config.SchedulesConfig{MissedAfterMs: 300000, CatchUp: catchUp, MaxCatchUp: 3}
+}
+
+func TestPlanRunsOnTime(t *testing.T) {
+	s := &store.Schedule{Cron: "0 * * * *", NextRunAt: mustTime(t, time.UTC, "2026-03-02 10:00")}
+	now := mustTime(t, time.UTC, "2026-03-02 10:01")
+
+	plan, err := PlanRuns(s, now, planConfig(config.CatchUpNone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Runs) != 1 || !plan.Runs[0].Equal(s.NextRunAt) || plan.Missed != 0 {
+		t.Errorf("expected the on-time run, got %+v", plan)
+	}
+	if want := mustTime(t, time.UTC, "2026-03-02 11:00"); !plan.Next.Equal(want) {
+		t.Errorf("expected next run %s, got %s", want, plan.Next)
+	}
+}
+
+func TestPlanRunsNotDue(t *testing.T) {
+	s := &store.Schedule{Cron: "0 * * * *", NextRunAt: mustTime(t, time.UTC, "2026-03-02 11:00")}
+
+	plan, _ := PlanRuns(s, mustTime(t, time.UTC, "2026-03-02 10:30"), planConfig(config.CatchUpAll))
+	if len(plan.Runs) != 0 || !plan.Next.Equal(s.NextRunAt) {
+		t.Errorf("expected nothing due, got %+v", plan)
+	}
+}
+
+func TestPlanRunsCatchUp(t *testing.T) {
+	// Down from 04:00 to 09:30: runs at 04:00..09:00 were missed.
+	s := &store.Schedule{Cron: "0 * * * *", NextRunAt: mustTime(t, time.UTC, "2026-03-02 04:00")}
+	now := mustTime(t, time.UTC, "2026-03-02 09:30")
+
+	cases := []struct {
+		catchUp string
+		runs    []string
+		missed  int
+	}{
+		{config.CatchUpNone, nil, 6},
+		{config.CatchUpLatest, []string{"09:00"}, 5},
+		{config.CatchUpAll, []string{"07:00", "08:00", "09:00"}, 3}, // capped at MaxCatchUp
+	}
+	for _, tc := range cases {
+		plan, err := PlanRuns(s, now, planConfig(tc.catchUp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Runs) != len(tc.runs) || plan.Missed != tc.missed {
+			t.Errorf("%s: expected runs %v and %d missed, got %+v", tc.catchUp, tc.runs, tc.missed, plan)
+			continue
+		}
+		for i, r := range tc.runs {
+			if want := mustTime(t, time.UTC, "2026-03-02 "+r); !plan.Runs[i].Equal(want) {
+				t.Errorf("%s: run %d expected %s, got %s", tc.catchUp, i, want, plan.Runs[i])
+			}
+		}
+	}
+
+	// A schedule's own policy overrides the configured default.
+	s.CatchUp = config.CatchUpNone
+	if plan, _ := PlanRuns(s, now, planConfig(config.CatchUpAll)); len(plan.Runs) != 0 {
+		t.Errorf("expected schedule override to drop missed runs, got %+v", plan)
+	}
+}
+
+func TestPlanRunsInTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("tzdata unavailable")
+	}
+	s := &store.Schedule{Cron: "0 3 * * *", Timezone: "America/New_York", NextRunAt: mustTime(t, loc, "2026-03-02 03:00")}
+
+	plan, _ := PlanRuns(s, mustTime(t, loc, "2026-03-02 03:00"), planConfig(config.CatchUpNone))
+	if want := mustTime(t, loc, "2026-03-03 03:00"); !plan.Next.Equal(want) {
+		t.Errorf("expected next run %s, got %s", want, plan.Next)
+	}
+}
+
+func TestNewTaskFromTemplate(t *testing.T) {
+	s := &store.Schedule{
+		ID:   uuid.New(),
+		Name: "nightly-audit",
+		Template: store.TaskTemplate{
+			Title:                "Dependency audit",
+			RequiredCapabilities: []string{"security"},
+			Metadata:             map[string]interface{}{"repo": "dispatch"},
+		},
+	}
+	at := mustTime(t, time.UTC, "2026-03-02 03:00")
+
+	task := NewTask(s, at)
+	if task.Source != "schedule:nightly-audit" || task.Owner != "system" || task.TimeoutSeconds != 300 || task.MaxRetries != 3 {
+		t.Errorf("unexpected task: %+v", task)
+	}
+	if task.Metadata["schedule_id"] != s.ID.String() || task.Metadata["scheduled_for"] != "2026-03-02T03:00:00Z" || task.Metadata["repo"] != "dispatch" {
+		t.Errorf("unexpected metadata: %v", task.Metadata)
+	}
+	if _, ok := s.Template.Metadata["schedule_id"]; ok {
+		t.Error("template metadata must not be modified")
+	}
+}
@@ -0,0 +1,122 @@
+package scoring
+
+import "math"
+
+// PriorityWeight scales a candidate score by task priority (0-10) so that
+// batch assignment favours urgent tasks the way greedy ordering does.
+// Priority 0 weighs 1.0 and priority 10 weighs 2.0.
+func PriorityWeight(priority int) float64 {
+	if priority < 0 {
+		priority = 0
+	}
+	if priority > 10 {
+		priority = 10
+	}
+	return 1 + float64(priority)/10
+}
+
+// MaxWeightAssignment solves the rectangular assignment problem: each row is
+// matched to at most one column and each column to at most one row so the
+// sum of weights is maximised. Cells set to math.Inf(-1) are forbidden.
+// Weights must otherwise be non-negative. Returns the column chosen for each
+// row, or -1 if the row is left unassigned.
+//
+// Every row gets a private zero-weight "unassigned" column, so a row is
+// never forced into a forbidden cell. Hungarian algorithm, O(n²·m).
+func MaxWeightAssignment(weights [][]float64) []int {
+	n := len(weights)
+	if n == 0 {
+		return nil
+	}
+	cols := 0
+	maxW := 0.0
+	for _, row := range weights {
+		if len(row) > cols {
+			cols = len(row)
+		}
+		for _, w := range row {
+			if !math.IsInf(w, -1) && w > maxW {
+				maxW = w
+			}
+		}
+	}
+	m := cols + n
+	forbidden := (maxW + 1) * float64(n+1)
+
+	cost := func(i, j int) float64 {
+		switch {
+		case j >= cols:
+			if j-cols == i {
+				return maxW
+			}
+			return forbidden
+		case j >= len(weights[i]) || math.IsInf(weights[i][j], -1):
+			return forbidden
+		default:
+			return maxW - weights[i][j]
+		}
+	}
+
+	// Potentials and matching, 1-indexed with 0 as a sentinel.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		used := make([]bool, m+1)
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost(i0-1, j-1) - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	out := make([]int, n)
+	for i := range out {
+		out[i] = -1
+	}
+	for j := 1; j <= cols; j++ {
+		if i := p[j]; i != 0 && cost(i-1, j-1) < forbidden {
+			out[i-1] = j - 1
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+func assignmentTotal(weights [][]float64, cols []int) float64 {
+	total := 0.0
+	for i, j := range cols {
+		if j >= 0 {
+			total += weights[i][j]
+		}
+	}
+	return total
+}
+
+func TestMaxWeightAssignmentBeatsGreedy(t *testing.T) {
+	// Row 0 slightly prefers column 0, but row 1 can only use column 0.
+	forbid := math.Inf(-1)
+	weights := [][]float64{
+		{0.9, 0.8},
+		{0.7, forbid},
+	}
+	got := MaxWeightAssignment(weights)
+	if got[0] != 1 || got[1] != 0 {
+		t.Fatalf("expected [1 0], got %v", got)
+	}
+	if total := assignmentTotal(weights, got); math.Abs(total-1.5) > 1e-9 {
+		t.Errorf("expected total 1.5, got %f", total)
+	}
+}
+
+func TestMaxWeightAssignmentLeavesForbiddenRowsUnassigned(t *testing.T) {
+	forbid := math.Inf(-1)
+	weights := [][]float64{
+		{0.5},
+		{forbid},
+		{0.9},
+	}
+	got := MaxWeightAssignment(weights)
+	if got[1] != -1 {
+		t.Errorf("expected forbidden row unassigned, got %d", got[1])
+	}
+	if got[2] != 0 || got[0] != -1 {
+		t.Errorf("expected the single column to go to the heavier row, got %v", got)
+	}
+}
+
+func TestMaxWeightAssignmentMoreColumnsThanRows(t *testing.T) {
+	weights := [][]float64{
+		{0.1, 0.2, 0.9},
+		{0.8, 0.3, 0.95},
+	}
+	got := MaxWeightAssignment(weights)
+	if total := assignmentTotal(weights, got); math.Abs(total-1.7) > 1e-9 {
+		t.Errorf("expected optimal total 1.7, got %f (%v)", total, got)
+	}
+}
+
+func TestMaxWeightAssignmentEmpty(t *testing.T) {
+	if got := MaxWeightAssignment(nil); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
+
+func TestPriorityWeight(t *testing.T) {
+	tests := []struct {
+		priority int
+		want     float64
+	}{
+		{-3, 1.0},
+		{0, 1.0},
+		{5, 1.5},
+		{10, 2.0},
+		{42, 2.0},
+	}
+	for _, tt := range tests {
+		if got := PriorityWeight(tt.priority); got != tt.want {
+			t.Errorf("PriorityWeight(%d) = %f, want %f", tt.priority, got, tt.want)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package scoring
 import (
 	"fmt"
 	"math"
+	"time"
 
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
 
@@ -44,10 +46,43 @@ func (w BacklogWeightSet) Validate() error {
 }
 
 // BacklogScoringContext bundles all inputs needed to score a single backlog item.
+// With Aging set, urgency is aged to Now.
 type BacklogScoringContext struct {
 	Item              *store.BacklogItem
 	HasUnresolvedDeps bool
 	MedianTokens      int64
+	Now               time.Time
+	Aging             *UrgencyAging
+}
+
+// UrgencyAging raises a backlog item's urgency as time passes.
+type UrgencyAging struct {
+	GrowthPerDay float64       // added per day since the item was created
+	DueWindow    time.Duration // urgency rises to 1 over this window before the due date
+}
+
+// Urgency returns item's urgency at now: its own urgency, or 0.5 when unset,
+// plus GrowthPerDay for each day since it was created. Within DueWindow of
+// its due date urgency rises linearly to 1, and stays 1 once overdue.
+func (a UrgencyAging) Urgency(item *store.BacklogItem, now time.Time) float64 {
+	u := 0.5
+	if item.Urgency != nil {
+		u = *item.Urgency
+	}
+	if age := now.Sub(item.CreatedAt); age > 0 && !item.CreatedAt.IsZero() {
+		u += a.GrowthPerDay * age.Hours() / 24
+	}
+	if item.DueDate != nil && a.DueWindow > 0 {
+		left := item.DueDate.Sub(now)
+		if left <= 0 {
+			return 1
+		}
+		if left < a.DueWindow {
+			base := clamp(u, 0, 1)
+			u = math.Max(u, base+(1-base)*(1-float64(left)/float64(a.DueWindow)))
+		}
+	}
+	return clamp(u, 0, 1)
 }
 
 // BacklogScoringResult captures the scoring output for a single backlog item.
@@ -59,6 +94,7 @@ type BacklogScoringResult struct {
 // BacklogScorer scores backlog items using 4 weighted factors.
 type BacklogScorer struct {
 	weights BacklogWeightSet
+	aging   *UrgencyAging // nil leaves urgency unaged in ScoreItem
 }
 
 // NewBacklogScorer creates a BacklogScorer with the given weights.
@@ -66,6 +102,23 @@ func NewBacklogScorer(weights BacklogWeightSet) *BacklogScorer {
 	return &BacklogScorer{weights: weights}
 }
 
+// NewConfiguredBacklogScorer creates a BacklogScorer with the configured
+// backlog weights whose ScoreItem ages urgency as in
+// scoring.backlog_rescore, so every write scores items as rescoring does.
+func NewConfiguredBacklogScorer(cfg config.ScoringConfig) *BacklogScorer {
+	s := NewBacklogScorer(BacklogWeightSet{
+		BusinessImpact:      cfg.BacklogWeights.BusinessImpact,
+		DependencyReadiness: cfg.BacklogWeights.DependencyReadiness,
+		Urgency:             cfg.BacklogWeights.Urgency,
+		CostEfficiency:      cfg.BacklogWeights.CostEfficiency,
+	})
+	s.aging = &UrgencyAging{
+		GrowthPerDay: cfg.BacklogRescore.UrgencyGrowthPerDay,
+		DueWindow:    time.Duration(cfg.BacklogRescore.DueWindowHours) * time.Hour,
+	}
+	return s
+}
+
 // Score computes the 4-factor priority score for a backlog item.
 func (s *BacklogScorer) Score(ctx *BacklogScoringContext) BacklogScoringResult {
 	factors := []FactorResult{
@@ -96,11 +149,20 @@ func (s *BacklogScorer) Score(ctx *BacklogScoringContext) BacklogScoringResult {
 }
 
 // ScoreItem is a callback-compatible helper for use with store.BacklogDiscoveryComplete.
+// It scores item as of now.
 func (s *BacklogScorer) ScoreItem(item *store.BacklogItem, hasUnresolvedDeps bool, medianTokens int64) float64 {
+	return s.ScoreAt(item, hasUnresolvedDeps, medianTokens, time.Now())
+}
+
+// ScoreAt scores item as of now, aging its urgency if the scorer was
+// configured to.
+func (s *BacklogScorer) ScoreAt(item *store.BacklogItem, hasUnresolvedDeps bool, medianTokens int64, now time.Time) float64 {
 	ctx := &BacklogScoringContext{
 		Item:              item,
 		HasUnresolvedDeps: hasUnresolvedDeps,
 		MedianTokens:      medianTokens,
+		Now:               now,
+		Aging:             s.aging,
 	}
 	result := s.Score(ctx)
 	return result.TotalScore
@@ -144,8 +206,16 @@ func (s *BacklogScorer) dependencyReadiness(ctx *BacklogScoringContext) FactorRe
 	}
 }
 
-// urgency is a passthrough from item.Urgency.
+// urgency is a passthrough from item.Urgency, aged when the context has aging.
 func (s *BacklogScorer) urgency(ctx *BacklogScoringContext) FactorResult {
+	if ctx.Aging != nil {
+		return FactorResult{
+			Name:      "urgency",
+			Score:     ctx.Aging.Urgency(ctx.Item, ctx.Now),
+			Available: true,
+			Reason:    "aged from item urgency and due date",
+		}
+	}
 	if ctx.Item.Urgency != nil {
 		return FactorResult{
 			Name:      "urgency",
@@ -3,7 +3,9 @@ package scoring
 import (
 	"math"
 	"testing"
+	"time"
 
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
 	"github.com/MikeSquared-Agency/Dispatch/internal/store"
 )
 
@@ -182,3 +184,54 @@ func TestScoreItemCallback(t *testing.T) {
 		t.Errorf("expected score in (0, 1.0], got %f", score)
 	}
 }
+
+func TestUrgencyAging(t *testing.T) {
+	now := time.Now()
+	aging := UrgencyAging{GrowthPerDay: 0.01, DueWindow: 10 * 24 * time.Hour}
+	base := 0.4
+	due := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	tests := []struct {
+		name string
+		item *store.BacklogItem
+		want float64
+	}{
+		{"new", &store.BacklogItem{Urgency: &base, CreatedAt: now}, 0.4},
+		{"ten days old", &store.BacklogItem{Urgency: &base, CreatedAt: now.Add(-240 * time.Hour)}, 0.5},
+		{"unset urgency", &store.BacklogItem{CreatedAt: now.Add(-240 * time.Hour)}, 0.6},
+		{"capped", &store.BacklogItem{Urgency: &base, CreatedAt: now.Add(-1000 * 24 * time.Hour)}, 1},
+		{"due outside window", &store.BacklogItem{Urgency: &base, CreatedAt: now, DueDate: due(20 * 24 * time.Hour)}, 0.4},
+		{"halfway through window", &store.BacklogItem{Urgency: &base, CreatedAt: now, DueDate: due(5 * 24 * time.Hour)}, 0.7},
+		{"overdue", &store.BacklogItem{Urgency: &base, CreatedAt: now, DueDate: due(-time.Hour)}, 1},
+	}
+	for _, tt := range tests {
+		if got := aging.Urgency(tt.item, now); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: expected urgency %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// Aging only applies when the context asks for it.
+	scorer := NewBacklogScorer(DefaultBacklogWeights())
+	old := &store.BacklogItem{Urgency: &base, CreatedAt: now.Add(-240 * time.Hour)}
+	plain := scorer.Score(&BacklogScoringContext{Item: old})
+	aged := scorer.Score(&BacklogScoringContext{Item: old, Now: now, Aging: &aging})
+	if math.Abs(aged.TotalScore-plain.TotalScore-0.25*0.1) > 1e-9 {
+		t.Errorf("expected aging to add 0.1 urgency at weight 0.25, got %v -> %v", plain.TotalScore, aged.TotalScore)
+	}
+}
+
+func TestConfiguredScorerAgesOnWrite(t *testing.T) {
+	cfg := config.ScoringConfig{
+		BacklogWeights: config.BacklogScoringWeights{Urgency: 1},
+		BacklogRescore: config.BacklogRescoreConfig{UrgencyGrowthPerDay: 0.01, DueWindowHours: 24},
+	}
+	scorer := NewConfiguredBacklogScorer(cfg)
+	base := 0.4
+	old := &store.BacklogItem{Urgency: &base, CreatedAt: time.Now().Add(-240 * time.Hour)}
+
+	// Scoring an item on create or update ages it the same way a rescore
+	// would, so the two never disagree about an unchanged item.
+	if got := scorer.ScoreItem(old, false, 0); math.Abs(got-0.5) > 1e-6 {
+		t.Errorf("expected ten days of aging on write, got %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package scoring
 
 import (
+	"fmt"
 	"math"
 	"strings"
 
@@ -24,13 +25,36 @@ type TaskContext struct {
 	Task            *store.Task
 	Persona         forge.Persona
 	AgentState      *warren.AgentState
-	ActiveTaskCount int
+	ActiveTaskCount int // concurrency slots in use, see TaskSlots
 	MaxConcurrent   int
+	TaskSlots       int // slots this task takes; zero means one
+
+	// WarmContext explains why this agent has warm context for the task (a
+	// preferred agent, or the last to run its affinity group), or is empty.
+	// PrefersOtherAgents is set when some other candidate does.
+	WarmContext        string
+	PrefersOtherAgents bool
 
 	// Optional enrichment — nil means unavailable, factor uses default 0.5
 	AgentAvgDuration *float64
 	AgentAvgCost     *float64
 	AgentTrustLevel  *float64
+	// AgentFaultRate is the share of the agent's attempts that failed
+	// through its own fault.
+	AgentFaultRate *float64
+}
+
+// slots is the number of concurrency slots the task takes, never more than
+// the agent's whole limit so a heavy task can still run on a small agent.
+func (tc *TaskContext) slots() int {
+	n := tc.TaskSlots
+	if n < 1 {
+		n = 1
+	}
+	if tc.MaxConcurrent > 0 && n > tc.MaxConcurrent {
+		n = tc.MaxConcurrent
+	}
+	return n
 }
 
 // --- Individual factor calculators ---
@@ -63,7 +87,7 @@ func AvailabilityFactor(tc *TaskContext) FactorResult {
 	case "sleeping":
 		return FactorResult{Name: "availability", Score: 0.6, Available: true, Reason: "sleeping (wake penalty)"}
 	case "busy":
-		if tc.MaxConcurrent > 0 && tc.ActiveTaskCount >= tc.MaxConcurrent {
+		if tc.MaxConcurrent > 0 && tc.ActiveTaskCount+tc.slots() > tc.MaxConcurrent {
 			return FactorResult{Name: "availability", Score: 0.0, Available: true, Reason: "at max concurrency"}
 		}
 		load := float64(tc.ActiveTaskCount) / float64(tc.MaxConcurrent)
@@ -75,18 +99,22 @@ func AvailabilityFactor(tc *TaskContext) FactorResult {
 }
 
 // RiskFitFactor maps agent trust level vs task risk using the Shu/Ha/Ri matrix.
-// trust and risk are both 0.0–1.0.
+// trust and risk are both 0.0–1.0. Trust is discounted by the agent's
+// agent-fault failure rate.
 func RiskFitFactor(tc *TaskContext) FactorResult {
 	trust := 0.5 // default
 	if tc.AgentTrustLevel != nil {
 		trust = *tc.AgentTrustLevel
 	}
+	if tc.AgentFaultRate != nil {
+		trust *= 1.0 - clamp(*tc.AgentFaultRate, 0, 1)
+	}
 	risk := 0.5 // default
 	if tc.Task.RiskScore != nil {
 		risk = *tc.Task.RiskScore
 	}
 
-	available := tc.AgentTrustLevel != nil || tc.Task.RiskScore != nil
+	available := tc.AgentTrustLevel != nil || tc.Task.RiskScore != nil || tc.AgentFaultRate != nil
 
 	// Higher trust + lower risk = better fit
 	// Low trust + high risk = poor fit
@@ -97,6 +125,9 @@ func RiskFitFactor(tc *TaskContext) FactorResult {
 	if available {
 		reason = "trust/risk evaluated"
 	}
+	if tc.AgentFaultRate != nil && *tc.AgentFaultRate > 0 {
+		reason += fmt.Sprintf(", %.0f%% agent-fault failures", *tc.AgentFaultRate*100)
+	}
 	return FactorResult{Name: "risk_fit", Score: score, Available: available, Reason: reason}
 }
 
@@ -189,8 +220,16 @@ func DurationFitFactor(tc *TaskContext) FactorResult {
 	return FactorResult{Name: "duration_fit", Score: score, Available: true, Reason: "from history"}
 }
 
-// ContextualityFitFactor is a passthrough from task metadata.
+// ContextualityFitFactor favours agents with warm context for the task. When
+// the task names no preferred agent or affinity group it is a passthrough
+// from task metadata.
 func ContextualityFitFactor(tc *TaskContext) FactorResult {
+	if tc.WarmContext != "" {
+		return FactorResult{Name: "contextuality", Score: 1.0, Available: true, Reason: tc.WarmContext}
+	}
+	if tc.PrefersOtherAgents {
+		return FactorResult{Name: "contextuality", Score: 0.0, Available: true, Reason: "no warm context"}
+	}
 	if tc.Task.ContextualityScore != nil {
 		return FactorResult{Name: "contextuality", Score: clamp(*tc.Task.ContextualityScore, 0, 1), Available: true, Reason: "from metadata"}
 	}
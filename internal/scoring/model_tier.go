@@ -12,13 +12,23 @@ import (
 type ModelTier struct {
 	Name          string
 	Models        []string
-	RoutingMethod string // "cold_start" or "learned"
+	RoutingMethod string // "cold_start", "learned" or "escalation"
 }
 
 // DeriveModelTier selects the appropriate model tier for a task.
 // When hasLearnedData is false (cold start), static rules are used.
 // When true, the scoring engine route derives tier from complexity/risk/reversibility.
+// A task escalated to a minimum tier never gets a cheaper one.
 func DeriveModelTier(task *store.Task, cfg config.ModelRoutingConfig, hasLearnedData bool) ModelTier {
+	tier := deriveModelTier(task, cfg, hasLearnedData)
+	if task.MinModelTier != "" && tierRank(tier.Name, cfg.Tiers) < tierRank(task.MinModelTier, cfg.Tiers) {
+		tier = tierByName(task.MinModelTier, cfg.Tiers)
+		tier.RoutingMethod = "escalation"
+	}
+	return tier
+}
+
+func deriveModelTier(task *store.Task, cfg config.ModelRoutingConfig, hasLearnedData bool) ModelTier {
 	if !cfg.Enabled {
 		tier := tierByName(cfg.DefaultTier, cfg.Tiers)
 		tier.RoutingMethod = "cold_start"
@@ -62,6 +72,16 @@ func DeriveModelTier(task *store.Task, cfg config.ModelRoutingConfig, hasLearned
 	return tier
 }
 
+// CheapestTier returns the first configured tier, which is the cheapest by
+// convention. It is used when an exhausted budget downgrades a task.
+func CheapestTier(cfg config.ModelRoutingConfig) ModelTier {
+	if len(cfg.Tiers) == 0 {
+		return ModelTier{Name: cfg.DefaultTier, RoutingMethod: "budget"}
+	}
+	t := cfg.Tiers[0]
+	return ModelTier{Name: t.Name, Models: t.Models, RoutingMethod: "budget"}
+}
+
 // ColdStartRoute applies static rules to determine a model tier without historical data.
 // Returns nil if no rule matches.
 func ColdStartRoute(task *store.Task, rules []config.ColdStartRule) *ModelTier {
@@ -149,6 +169,17 @@ func tierByName(name string, tiers []config.ModelTierDef) ModelTier {
 	return ModelTier{Name: name}
 }
 
+// tierRank is the tier's position in tiers, cheapest first, or -1 if it is
+// not configured.
+func tierRank(name string, tiers []config.ModelTierDef) int {
+	for i, t := range tiers {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func hasAnyLabel(taskLabels, ruleLabels []string) bool {
 	for _, tl := range taskLabels {
 		for _, rl := range ruleLabels {
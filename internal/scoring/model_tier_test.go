@@ -389,3 +389,36 @@ func TestApplyEffectivenessSafetyNet_BoundaryStandard(t *testing.T) {
 		t.Fatal("exactly at threshold should not promote (must be > 0.8)")
 	}
 }
+
+func TestCheapestTier(t *testing.T) {
+	tier := CheapestTier(testConfig())
+	if tier.Name != "economy" {
+		t.Errorf("expected economy, got %s", tier.Name)
+	}
+	if tier.RoutingMethod != "budget" {
+		t.Errorf("expected budget routing method, got %s", tier.RoutingMethod)
+	}
+}
+
+func TestDeriveModelTier_MinModelTierFloor(t *testing.T) {
+	cfg := testConfig()
+	task := &store.Task{
+		Labels:       []string{"config"},
+		FilePatterns: []string{"app.yaml"},
+		MinModelTier: "premium",
+	}
+	tier := DeriveModelTier(task, cfg, false)
+	if tier.Name != "premium" {
+		t.Errorf("expected premium floor, got %s", tier.Name)
+	}
+	if tier.RoutingMethod != "escalation" {
+		t.Errorf("expected routing_method escalation, got %s", tier.RoutingMethod)
+	}
+
+	// A floor below the derived tier leaves it alone.
+	task = &store.Task{Labels: []string{"architecture"}, MinModelTier: "economy"}
+	tier = DeriveModelTier(task, cfg, false)
+	if tier.Name != "premium" || tier.RoutingMethod != "cold_start" {
+		t.Errorf("expected premium via cold_start, got %s via %s", tier.Name, tier.RoutingMethod)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"log/slog"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/MikeSquared-Agency/Dispatch/internal/forge"
@@ -97,6 +98,61 @@ func TestAvailabilityFactor(t *testing.T) {
 	}
 }
 
+func TestAvailabilityFactorWeightedSlots(t *testing.T) {
+	tc := &TaskContext{
+		Task:            &store.Task{},
+		AgentState:      &warren.AgentState{Status: "busy"},
+		ActiveTaskCount: 2,
+		MaxConcurrent:   4,
+		TaskSlots:       3,
+	}
+	if r := AvailabilityFactor(tc); r.Score != 0 {
+		t.Errorf("expected heavy task not to fit, got %f", r.Score)
+	}
+	tc.TaskSlots = 2
+	if r := AvailabilityFactor(tc); r.Score == 0 {
+		t.Error("expected two-slot task to fit")
+	}
+
+	// A task heavier than the whole limit still fits an idle agent.
+	tc.ActiveTaskCount, tc.MaxConcurrent, tc.TaskSlots = 0, 1, 3
+	if r := AvailabilityFactor(tc); r.Score == 0 {
+		t.Error("expected oversized task to fit an idle agent")
+	}
+}
+
+func TestContextualityFitFactorWarmContext(t *testing.T) {
+	ctxScore := 0.3
+	tc := &TaskContext{Task: &store.Task{ContextualityScore: &ctxScore}}
+	if r := ContextualityFitFactor(tc); r.Score != 0.3 || r.Reason != "from metadata" {
+		t.Errorf("expected metadata passthrough, got %+v", r)
+	}
+
+	tc.WarmContext = "preferred agent"
+	if r := ContextualityFitFactor(tc); r.Score != 1.0 || r.Reason != "preferred agent" {
+		t.Errorf("expected warm agent to score 1.0, got %+v", r)
+	}
+
+	tc.WarmContext, tc.PrefersOtherAgents = "", true
+	if r := ContextualityFitFactor(tc); r.Score != 0 || !r.Available {
+		t.Errorf("expected cold agent to score 0, got %+v", r)
+	}
+}
+
+func TestRiskFitFactorAgentFaultRate(t *testing.T) {
+	tc := &TaskContext{Task: &store.Task{RiskScore: float64Ptr(0)}, AgentTrustLevel: float64Ptr(0.8)}
+	clean := RiskFitFactor(tc)
+
+	tc.AgentFaultRate = float64Ptr(0.25)
+	faulty := RiskFitFactor(tc)
+	if faulty.Score >= clean.Score || math.Abs(faulty.Score-0.6) > 1e-9 {
+		t.Errorf("expected fault rate to discount trust to 0.6, got %f (clean %f)", faulty.Score, clean.Score)
+	}
+	if !strings.Contains(faulty.Reason, "25% agent-fault failures") {
+		t.Errorf("expected fault rate in reason, got %q", faulty.Reason)
+	}
+}
+
 func TestFastPathEligible(t *testing.T) {
 	t.Run("positive", func(t *testing.T) {
 		tc := &TaskContext{
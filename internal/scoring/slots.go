@@ -0,0 +1,20 @@
+package scoring
+
+import (
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+// TaskSlots returns how many agent concurrency slots task takes: the larger
+// of its duration-class weight and its heavy-complexity weight, and at
+// least one.
+func TaskSlots(task *store.Task, cfg config.SlotConfig) int {
+	n := 1
+	if w := cfg.ByDurationClass[task.DurationClass]; w > n {
+		n = w
+	}
+	if cfg.HeavyComplexity > 0 && task.ComplexityScore != nil && *task.ComplexityScore >= cfg.HeavyComplexity && cfg.HeavySlots > n {
+		n = cfg.HeavySlots
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/MikeSquared-Agency/Dispatch/internal/config"
+	"github.com/MikeSquared-Agency/Dispatch/internal/store"
+)
+
+func TestTaskSlots(t *testing.T) {
+	cfg := config.SlotConfig{
+		ByDurationClass: map[string]int{"long": 2},
+		HeavyComplexity: 0.8,
+		HeavySlots:      3,
+	}
+	tests := []struct {
+		name string
+		task *store.Task
+		want int
+	}{
+		{"default", &store.Task{}, 1},
+		{"long", &store.Task{DurationClass: "long"}, 2},
+		{"complex", &store.Task{ComplexityScore: float64Ptr(0.9)}, 3},
+		{"long and simple", &store.Task{DurationClass: "long", ComplexityScore: float64Ptr(0.2)}, 2},
+		{"long and complex", &store.Task{DurationClass: "long", ComplexityScore: float64Ptr(0.8)}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TaskSlots(tt.task, cfg); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if got := TaskSlots(&store.Task{DurationClass: "long"}, config.SlotConfig{}); got != 1 {
+		t.Errorf("expected one slot without config, got %d", got)
+	}
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Backlog list sort orders. Items without a value for the sort key come
+// last, and ties go to the oldest item.
+const (
+	BacklogSortPriority  = "priority"  // highest priority score first (default)
+	BacklogSortCreated   = "created"   // newest first
+	BacklogSortUpdated   = "updated"   // most recently updated first
+	BacklogSortDueDate   = "due_date"  // soonest due first
+	BacklogSortRelevance = "relevance" // best full-text match first; needs a query
+)
+
+// ErrInvalidCursor is returned for a cursor that cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ValidBacklogSort reports whether sort is a known backlog sort order.
+// The empty string sorts by priority.
+func ValidBacklogSort(sort string) bool {
+	switch sort {
+	case "", BacklogSortPriority, BacklogSortCreated, BacklogSortUpdated, BacklogSortDueDate, BacklogSortRelevance:
+		return true
+	}
+	return false
+}
+
+// BacklogCursor marks a position in a sorted backlog list: the sort key,
+// creation time and ID of the last item on the previous page. Listing after
+// it continues from the next item even as other items change.
+type BacklogCursor struct {
+	Sort      string     `json:"s"`
+	Score     *float64   `json:"p,omitempty"` // priority sort key
+	Rank      *float64   `json:"r,omitempty"` // relevance sort key
+	Time      *time.Time `json:"t,omitempty"` // created, updated or due_date sort key
+	CreatedAt time.Time  `json:"c"`
+	ID        uuid.UUID  `json:"i"`
+}
+
+// NewBacklogCursor returns the cursor after item in a list sorted by sort.
+func NewBacklogCursor(sort string, item *BacklogItem) *BacklogCursor {
+	if sort == "" {
+		sort = BacklogSortPriority
+	}
+	c := &BacklogCursor{Sort: sort, CreatedAt: item.CreatedAt, ID: item.ID}
+	switch sort {
+	case BacklogSortPriority:
+		c.Score = item.PriorityScore
+	case BacklogSortCreated:
+		c.Time = &item.CreatedAt
+	case BacklogSortUpdated:
+		c.Time = &item.UpdatedAt
+	case BacklogSortDueDate:
+		c.Time = item.DueDate
+	case BacklogSortRelevance:
+		c.Rank = item.SearchRank
+	}
+	return c
+}
+
+// ByRelevance reports whether f lists items by how well they match its
+// query. Without a query, relevance falls back to priority.
+func (f BacklogFilter) ByRelevance() bool {
+	return f.Sort == BacklogSortRelevance && f.Query != ""
+}
+
+// Encode returns c as an opaque URL-safe string.
+func (c *BacklogCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBacklogCursor parses a cursor returned by Encode.
+func DecodeBacklogCursor(s string) (*BacklogCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := &BacklogCursor{}
+	if err := json.Unmarshal(b, c); err != nil || c.ID == uuid.Nil || !ValidBacklogSort(c.Sort) {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
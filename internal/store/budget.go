@@ -0,0 +1,71 @@
+package store
+
+import "time"
+
+// PeriodStart returns the start of the budget period containing now. Periods
+// are calendar days or months in UTC.
+func (b *Budget) PeriodStart(now time.Time) time.Time {
+	now = now.UTC()
+	if b.Period == BudgetPeriodMonth {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Applies reports whether the budget covers task.
+func (b *Budget) Applies(task *Task) bool {
+	switch b.Scope {
+	case BudgetScopeOwner:
+		return task.Owner == b.Subject
+	case BudgetScopeSource:
+		return task.Source == b.Subject
+	}
+	return false
+}
+
+// Fraction returns the largest share of any limit that usage has consumed,
+// counting created tasks against MaxTasks.
+func (b *Budget) Fraction(u BudgetUsage) float64 {
+	f := 0.0
+	if b.MaxTasks != nil && *b.MaxTasks > 0 {
+		f = max(f, float64(u.Tasks)/float64(*b.MaxTasks))
+	}
+	if b.MaxTokens != nil && *b.MaxTokens > 0 {
+		f = max(f, float64(u.Tokens)/float64(*b.MaxTokens))
+	}
+	if b.MaxCostUSD != nil && *b.MaxCostUSD > 0 {
+		f = max(f, u.CostUSD / *b.MaxCostUSD)
+	}
+	return f
+}
+
+// Exceeded returns the limits ("tasks", "tokens", "cost_usd") that taking on
+// task would break. taskCount is the number of tasks already counted against
+// MaxTasks: created tasks at creation time, dispatched tasks at assignment.
+// Token and cost limits are checked against the task's estimates and are
+// exceeded once usage reaches the limit even if the task has no estimate.
+func (b *Budget) Exceeded(taskCount int, u BudgetUsage, task *Task) []string {
+	var out []string
+	if b.MaxTasks != nil && taskCount+1 > *b.MaxTasks {
+		out = append(out, "tasks")
+	}
+	if b.MaxTokens != nil {
+		var est int64
+		if task.CostEstimateTokens != nil {
+			est = *task.CostEstimateTokens
+		}
+		if u.Tokens >= *b.MaxTokens || u.Tokens+est > *b.MaxTokens {
+			out = append(out, "tokens")
+		}
+	}
+	if b.MaxCostUSD != nil {
+		var est float64
+		if task.CostEstimateUSD != nil {
+			est = *task.CostEstimateUSD
+		}
+		if u.CostUSD >= *b.MaxCostUSD || u.CostUSD+est > *b.MaxCostUSD {
+			out = append(out, "cost_usd")
+		}
+	}
+	return out
+}
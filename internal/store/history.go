@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entity types recorded in the change history.
+const (
+	EntityBacklogItem = "backlog_item"
+	EntityTask        = "task"
+)
+
+// Change actions.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// SystemActor is recorded for changes made without an actor in the context,
+// such as the broker's own writes.
+const SystemActor = "system"
+
+// untrackedFields are left out of the change history: identity and
+// timestamps the database maintains, fields every heartbeat rewrites, and
+// the search rank a relevance list adds.
+var untrackedFields = map[string]bool{
+	"id":                true,
+	"task_id":           true,
+	"created_at":        true,
+	"updated_at":        true,
+	"last_heartbeat_at": true,
+	"lease_expires_at":  true,
+	"progress":          true,
+	"search_rank":       true,
+}
+
+// unversionedFields are untracked fields that change over an entity's
+// life. Their past values are unknown, so AsOf leaves them out.
+var unversionedFields = []string{"updated_at", "last_heartbeat_at", "lease_expires_at", "progress", "search_rank"}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx whose writes are recorded as made by
+// actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor writes made with ctx are recorded as,
+// or SystemActor if none was set.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// FieldChange is one field's value before and after a change, as JSON. A
+// missing value is null.
+type FieldChange struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+// ChangeRecord is one write to a backlog item or task: who made it, when,
+// and the fields it changed, keyed by their JSON names.
+type ChangeRecord struct {
+	ID         uuid.UUID              `json:"id"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   uuid.UUID              `json:"entity_id"`
+	Action     string                 `json:"action"`
+	Actor      string                 `json:"actor"`
+	Changes    map[string]FieldChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type ChangeHistoryFilter struct {
+	EntityType string
+	EntityID   uuid.UUID
+	Since      *time.Time // only changes made after this time
+	Limit      int
+}
+
+// NewChangeRecord diffs before and after, either of which may be nil, and
+// returns the change made by the actor in ctx. It returns nil when no
+// tracked field changed.
+func NewChangeRecord(ctx context.Context, entityType string, entityID uuid.UUID, action string, before, after interface{}) *ChangeRecord {
+	changes := diffFields(fields(before), fields(after))
+	if len(changes) == 0 {
+		return nil
+	}
+	return &ChangeRecord{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      ActorFromContext(ctx),
+		Changes:    changes,
+	}
+}
+
+// fields returns v's JSON fields. A nil v has none.
+func fields(v interface{}) map[string]json.RawMessage {
+	out := map[string]json.RawMessage{}
+	if v == nil {
+		return out
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return out
+	}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+func diffFields(before, after map[string]json.RawMessage) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	for k, old := range before {
+		if !untrackedFields[k] && string(old) != string(after[k]) {
+			changes[k] = FieldChange{Old: old, New: after[k]}
+		}
+	}
+	for k, v := range after {
+		if _, ok := before[k]; !ok && !untrackedFields[k] {
+			changes[k] = FieldChange{New: v}
+		}
+	}
+	return changes
+}
+
+// AsOf returns current as it was at time at, by undoing the changes made
+// after it. changes must include every change made after at, newest first.
+// It returns nil if the entity was created after at. Fields without history,
+// such as updated_at and a task's progress, heartbeat and lease, are zero.
+func AsOf[T any](current *T, changes []*ChangeRecord, at time.Time) (*T, error) {
+	state := fields(current)
+	for _, c := range changes {
+		if !c.CreatedAt.After(at) {
+			continue
+		}
+		if c.Action == ChangeCreated {
+			return nil, nil
+		}
+		for k, fc := range c.Changes {
+			if fc.Old == nil {
+				delete(state, k)
+				continue
+			}
+			state[k] = fc.Old
+		}
+	}
+	for _, k := range unversionedFields {
+		delete(state, k)
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("marshal state: %w", err)
+	}
+	out := new(T)
+	if err := json.Unmarshal(b, out); err != nil {
+		return nil, fmt.Errorf("unmarshal state: %w", err)
+	}
+	return out, nil
+}
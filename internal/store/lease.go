@@ -0,0 +1,63 @@
+package store
+
+import "time"
+
+// Acknowledge records that the assigned agent has received the task and opens
+// a lease of timeout_seconds. It returns false if the task was already acked.
+func (t *Task) Acknowledge(now time.Time) bool {
+	if t.AckedAt != nil {
+		return false
+	}
+	t.AckedAt = &now
+	expires := now.Add(time.Duration(t.TimeoutSeconds) * time.Second)
+	t.LeaseExpiresAt = &expires
+	return true
+}
+
+// ClearAssignment returns the task to an unowned state ready for
+// re-assignment, dropping the previous agent's ack, lease and liveness.
+func (t *Task) ClearAssignment() {
+	t.Status = StatusPending
+	t.AssignedAgent = ""
+	t.AssignedAt = nil
+	t.StartedAt = nil
+	t.AckedAt = nil
+	t.LeaseExpiresAt = nil
+	t.LastHeartbeatAt = nil
+	t.SubState = ""
+}
+
+// TaskGuard is what a guarded write expects the stored task to still be.
+// Empty fields are not checked.
+type TaskGuard struct {
+	Statuses      []TaskStatus
+	AssignedAgent string
+	SubState      string
+}
+
+// Running guards a write to a task assigned to or being worked on by agent.
+func Running(agent string) TaskGuard {
+	return TaskGuard{Statuses: []TaskStatus{StatusAssigned, StatusInProgress}, AssignedAgent: agent}
+}
+
+// Matches reports whether t is as the guard expects.
+func (g TaskGuard) Matches(t *Task) bool {
+	if len(g.Statuses) > 0 {
+		found := false
+		for _, s := range g.Statuses {
+			found = found || t.Status == s
+		}
+		if !found {
+			return false
+		}
+	}
+	if g.AssignedAgent != "" && t.AssignedAgent != g.AssignedAgent {
+		return false
+	}
+	return g.SubState == "" || t.SubState == g.SubState
+}
+
+// Waking guards a write to a task still waiting for agent to wake.
+func Waking(agent string) TaskGuard {
+	return TaskGuard{Statuses: []TaskStatus{StatusAssigned}, AssignedAgent: agent, SubState: SubStateWaking}
+}
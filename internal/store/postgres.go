@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -48,26 +49,42 @@ const taskColumns = `task_id, title, description, owner, required_capabilities,
 	duration_class, contextuality_score, subjectivity_score,
 	fast_path, pareto_frontier, alternative_decompositions,
 	labels, file_patterns, one_way_door,
-	recommended_model, model_tier, routing_method, runtime`
+	recommended_model, model_tier, routing_method, runtime,
+	progress, last_heartbeat_at,
+	acked_at, lease_expires_at, sub_state, preemptible,
+	preferred_agents, required_agent, excluded_agents, affinity_group,
+	deadline, sla_at_risk_at, deadline_missed_at, min_model_tier, failure_class,
+	backlog_item_id, backlog_stage`
 
 func (s *PostgresStore) CreateTask(ctx context.Context, task *Task) error {
 	resultJSON, _ := json.Marshal(task.Result)
 	metadataJSON, _ := json.Marshal(task.Metadata)
 
-	return s.pool.QueryRow(ctx, `
-		INSERT INTO swarm_tasks (title, description, owner, required_capabilities,
-			status, timeout_seconds, max_retries, retry_eligible,
-			priority, source, parent_task_id, result, metadata,
-			scoring_version, fast_path,
-			labels, file_patterns, one_way_door)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
-		RETURNING task_id, created_at, updated_at`,
-		task.Title, task.Description, task.Owner, task.RequiredCapabilities,
-		task.Status, task.TimeoutSeconds, task.MaxRetries, task.RetryEligible,
-		task.Priority, task.Source, task.ParentTaskID, resultJSON, metadataJSON,
-		task.ScoringVersion, task.FastPath,
-		task.Labels, task.FilePatterns, task.OneWayDoor,
-	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO swarm_tasks (title, description, owner, required_capabilities,
+				status, timeout_seconds, max_retries, retry_eligible,
+				priority, source, parent_task_id, result, metadata,
+				scoring_version, fast_path,
+				labels, file_patterns, one_way_door, preemptible,
+				preferred_agents, required_agent, excluded_agents, affinity_group, deadline,
+				min_model_tier, backlog_item_id, backlog_stage)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+				$20, $21, $22, $23, $24, $25, $26, $27)
+			RETURNING task_id, created_at, updated_at`,
+			task.Title, task.Description, task.Owner, task.RequiredCapabilities,
+			task.Status, task.TimeoutSeconds, task.MaxRetries, task.RetryEligible,
+			task.Priority, task.Source, task.ParentTaskID, resultJSON, metadataJSON,
+			task.ScoringVersion, task.FastPath,
+			task.Labels, task.FilePatterns, task.OneWayDoor, task.Preemptible,
+			task.PreferredAgents, task.RequiredAgent, task.ExcludedAgents, task.AffinityGroup, task.Deadline,
+			task.MinModelTier, task.BacklogItemID, task.BacklogStage,
+		).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, NewChangeRecord(ctx, EntityTask, task.ID, ChangeCreated, nil, task))
+	})
 }
 
 func (s *PostgresStore) GetTask(ctx context.Context, id uuid.UUID) (*Task, error) {
@@ -82,6 +99,8 @@ func (s *PostgresStore) GetTask(ctx context.Context, id uuid.UUID) (*Task, error
 	var scoringVersion sql.NullInt32
 	var fastPath, oneWayDoor sql.NullBool
 	var recommendedModel, modelTier, routingMethod, runtime sql.NullString
+	var progressJSON []byte
+	var subState sql.NullString
 	err := s.pool.QueryRow(ctx, `
 		SELECT `+taskColumns+`
 		FROM swarm_tasks WHERE task_id = $1`, id,
@@ -100,6 +119,11 @@ func (s *PostgresStore) GetTask(ctx context.Context, id uuid.UUID) (*Task, error
 		&fastPath, &paretoFrontierJSON, &altDecompJSON,
 		&t.Labels, &t.FilePatterns, &oneWayDoor,
 		&recommendedModel, &modelTier, &routingMethod, &runtime,
+		&progressJSON, &t.LastHeartbeatAt,
+		&t.AckedAt, &t.LeaseExpiresAt, &subState, &t.Preemptible,
+		&t.PreferredAgents, &t.RequiredAgent, &t.ExcludedAgents, &t.AffinityGroup,
+		&t.Deadline, &t.SLAAtRiskAt, &t.DeadlineMissedAt, &t.MinModelTier, &t.FailureClass,
+		&t.BacklogItemID, &t.BacklogStage,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -123,6 +147,10 @@ func (s *PostgresStore) GetTask(ctx context.Context, id uuid.UUID) (*Task, error
 		oversightLevel, scoringFactorsJSON, scoringVersion, complexity, uncertainty,
 		durationClass, contextuality, subjectivity, fastPath, paretoFrontierJSON, altDecompJSON)
 	applyModelRoutingFields(t, oneWayDoor, recommendedModel, modelTier, routingMethod, runtime)
+	if progressJSON != nil {
+		_ = json.Unmarshal(progressJSON, &t.Progress)
+	}
+	t.SubState = subState.String
 	return t, nil
 }
 
@@ -151,6 +179,11 @@ func (s *PostgresStore) ListTasks(ctx context.Context, filter TaskFilter) ([]*Ta
 		query += fmt.Sprintf(" AND source = $%d", n)
 		args = append(args, filter.Source)
 	}
+	if filter.BacklogItemID != nil {
+		n++
+		query += fmt.Sprintf(" AND backlog_item_id = $%d", n)
+		args = append(args, *filter.BacklogItemID)
+	}
 
 	query += " ORDER BY priority DESC, created_at ASC"
 
@@ -211,47 +244,148 @@ func (s *PostgresStore) GetActiveTasks(ctx context.Context) ([]*Task, error) {
 	return scanTasks(rows)
 }
 
+// GetDeadlineTasks returns the unfinished tasks with a deadline whose miss
+// has not been recorded yet.
+func (s *PostgresStore) GetDeadlineTasks(ctx context.Context) ([]*Task, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+taskColumns+`
+		FROM swarm_tasks
+		WHERE deadline IS NOT NULL AND deadline_missed_at IS NULL
+			AND status IN ('pending', 'assigned', 'in_progress')
+		ORDER BY deadline`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTasks(rows)
+}
+
+// GetAffinityGroupAgent returns the agent most recently assigned a task in
+// group, or "" if none has been.
+func (s *PostgresStore) GetAffinityGroupAgent(ctx context.Context, group string) (string, error) {
+	var agent string
+	err := s.pool.QueryRow(ctx, `
+		SELECT assigned_agent FROM swarm_tasks
+		WHERE affinity_group = $1 AND assigned_agent IS NOT NULL AND assigned_agent <> ''
+		ORDER BY assigned_at DESC NULLS LAST
+		LIMIT 1`, group,
+	).Scan(&agent)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get affinity group agent: %w", err)
+	}
+	return agent, nil
+}
+
+// ErrTaskChanged is returned by UpdateTaskGuarded when the stored task no
+// longer matches the guard.
+var ErrTaskChanged = errors.New("task changed concurrently")
+
 func (s *PostgresStore) UpdateTask(ctx context.Context, task *Task) error {
+	return s.updateTask(ctx, task, nil)
+}
+
+// UpdateTaskGuarded writes task like UpdateTask, but only if the stored row
+// still matches guard. Otherwise it fails with ErrTaskChanged and writes
+// nothing.
+func (s *PostgresStore) UpdateTaskGuarded(ctx context.Context, task *Task, guard TaskGuard) error {
+	return s.updateTask(ctx, task, &guard)
+}
+
+func (s *PostgresStore) updateTask(ctx context.Context, task *Task, guard *TaskGuard) error {
 	resultJSON, _ := json.Marshal(task.Result)
 	metadataJSON, _ := json.Marshal(task.Metadata)
 	scoringFactorsJSON, _ := json.Marshal(task.ScoringFactors)
 	paretoFrontierJSON, _ := json.Marshal(task.ParetoFrontier)
 	altDecompJSON, _ := json.Marshal(task.AlternativeDecompositions)
+	progressJSON, _ := json.Marshal(task.Progress)
 
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `SELECT `+taskColumns+` FROM swarm_tasks WHERE task_id = $1 FOR UPDATE`, task.ID)
+		if err != nil {
+			return fmt.Errorf("lock task: %w", err)
+		}
+		defer rows.Close()
+		locked, err := scanTasks(rows)
+		if err != nil {
+			return err
+		}
+		if len(locked) == 0 {
+			if guard != nil {
+				return ErrTaskChanged
+			}
+			return nil
+		}
+		if guard != nil && !guard.Matches(locked[0]) {
+			return ErrTaskChanged
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE swarm_tasks SET
+				title = $2, description = $3, owner = $4, required_capabilities = $5,
+				status = $6, assigned_agent = $7,
+				assigned_at = $8, started_at = $9, completed_at = $10,
+				result = $11, error = $12,
+				retry_count = $13, max_retries = $14, retry_eligible = $15,
+				timeout_seconds = $16,
+				priority = $17, source = $18, parent_task_id = $19, metadata = $20,
+				risk_score = $21, cost_estimate_tokens = $22, cost_estimate_usd = $23,
+				verifiability_score = $24, reversibility_score = $25, oversight_level = $26,
+				scoring_factors = $27, scoring_version = $28, complexity_score = $29, uncertainty_score = $30,
+				duration_class = $31, contextuality_score = $32, subjectivity_score = $33,
+				fast_path = $34, pareto_frontier = $35, alternative_decompositions = $36,
+				labels = $37, file_patterns = $38, one_way_door = $39,
+				recommended_model = $40, model_tier = $41, routing_method = $42, runtime = $43,
+				progress = $44, last_heartbeat_at = $45,
+				acked_at = $46, lease_expires_at = $47, sub_state = $48, preemptible = $49,
+				preferred_agents = $50, required_agent = $51, excluded_agents = $52, affinity_group = $53,
+				deadline = $54, sla_at_risk_at = $55, deadline_missed_at = $56, min_model_tier = $57,
+				failure_class = $58
+			WHERE task_id = $1`,
+			task.ID, task.Title, task.Description, task.Owner, task.RequiredCapabilities,
+			task.Status, task.AssignedAgent,
+			task.AssignedAt, task.StartedAt, task.CompletedAt,
+			resultJSON, task.Error,
+			task.RetryCount, task.MaxRetries, task.RetryEligible,
+			task.TimeoutSeconds,
+			task.Priority, task.Source, task.ParentTaskID, metadataJSON,
+			task.RiskScore, task.CostEstimateTokens, task.CostEstimateUSD,
+			task.VerifiabilityScore, task.ReversibilityScore, nullString(task.OversightLevel),
+			scoringFactorsJSON, task.ScoringVersion, task.ComplexityScore, task.UncertaintyScore,
+			nullString(task.DurationClass), task.ContextualityScore, task.SubjectivityScore,
+			task.FastPath, paretoFrontierJSON, altDecompJSON,
+			task.Labels, task.FilePatterns, task.OneWayDoor,
+			nullString(task.RecommendedModel), nullString(task.ModelTier),
+			nullString(task.RoutingMethod), nullString(task.Runtime),
+			progressJSON, task.LastHeartbeatAt,
+			task.AckedAt, task.LeaseExpiresAt, nullString(task.SubState), task.Preemptible,
+			task.PreferredAgents, task.RequiredAgent, task.ExcludedAgents, task.AffinityGroup,
+			task.Deadline, task.SLAAtRiskAt, task.DeadlineMissedAt, task.MinModelTier,
+			task.FailureClass,
+		)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, NewChangeRecord(ctx, EntityTask, task.ID, ChangeUpdated, locked[0], task))
+	})
+}
+
+// UpdateTaskLiveness writes a running task's progress, heartbeat and lease.
+// Every heartbeat rewrites them and the change history leaves them out, so
+// unlike UpdateTask it neither locks nor diffs the row.
+func (s *PostgresStore) UpdateTaskLiveness(ctx context.Context, task *Task) error {
+	progressJSON, _ := json.Marshal(task.Progress)
 	_, err := s.pool.Exec(ctx, `
-		UPDATE swarm_tasks SET
-			title = $2, description = $3, owner = $4, required_capabilities = $5,
-			status = $6, assigned_agent = $7,
-			assigned_at = $8, started_at = $9, completed_at = $10,
-			result = $11, error = $12,
-			retry_count = $13, max_retries = $14, retry_eligible = $15,
-			timeout_seconds = $16,
-			priority = $17, source = $18, parent_task_id = $19, metadata = $20,
-			risk_score = $21, cost_estimate_tokens = $22, cost_estimate_usd = $23,
-			verifiability_score = $24, reversibility_score = $25, oversight_level = $26,
-			scoring_factors = $27, scoring_version = $28, complexity_score = $29, uncertainty_score = $30,
-			duration_class = $31, contextuality_score = $32, subjectivity_score = $33,
-			fast_path = $34, pareto_frontier = $35, alternative_decompositions = $36,
-			labels = $37, file_patterns = $38, one_way_door = $39,
-			recommended_model = $40, model_tier = $41, routing_method = $42, runtime = $43
-		WHERE task_id = $1`,
-		task.ID, task.Title, task.Description, task.Owner, task.RequiredCapabilities,
-		task.Status, task.AssignedAgent,
-		task.AssignedAt, task.StartedAt, task.CompletedAt,
-		resultJSON, task.Error,
-		task.RetryCount, task.MaxRetries, task.RetryEligible,
-		task.TimeoutSeconds,
-		task.Priority, task.Source, task.ParentTaskID, metadataJSON,
-		task.RiskScore, task.CostEstimateTokens, task.CostEstimateUSD,
-		task.VerifiabilityScore, task.ReversibilityScore, nullString(task.OversightLevel),
-		scoringFactorsJSON, task.ScoringVersion, task.ComplexityScore, task.UncertaintyScore,
-		nullString(task.DurationClass), task.ContextualityScore, task.SubjectivityScore,
-		task.FastPath, paretoFrontierJSON, altDecompJSON,
-		task.Labels, task.FilePatterns, task.OneWayDoor,
-		nullString(task.RecommendedModel), nullString(task.ModelTier),
-		nullString(task.RoutingMethod), nullString(task.Runtime),
+		UPDATE swarm_tasks SET progress = $2, last_heartbeat_at = $3, lease_expires_at = $4
+		WHERE task_id = $1 AND status IN ('assigned', 'in_progress')`,
+		task.ID, progressJSON, task.LastHeartbeatAt, task.LeaseExpiresAt,
 	)
-	return err
+	if err != nil {
+		return fmt.Errorf("update task liveness: %w", err)
+	}
+	return nil
 }
 
 func (s *PostgresStore) CreateTaskEvent(ctx context.Context, event *TaskEvent) error {
@@ -303,6 +437,38 @@ func (s *PostgresStore) GetStats(ctx context.Context) (*TaskStats, error) {
 	return stats, err
 }
 
+// GetSLAStats returns deadline attainment per owner. A task counts once it
+// has finished or its deadline has passed; it met its SLA if it completed by
+// the deadline.
+func (s *PostgresStore) GetSLAStats(ctx context.Context) ([]*OwnerSLA, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT owner,
+			COUNT(*) FILTER (WHERE met),
+			COUNT(*) FILTER (WHERE NOT met)
+		FROM (
+			SELECT owner, (status = 'completed' AND completed_at <= deadline) AS met
+			FROM swarm_tasks
+			WHERE deadline IS NOT NULL AND (completed_at IS NOT NULL OR deadline <= NOW())
+		) t
+		GROUP BY owner
+		ORDER BY owner`)
+	if err != nil {
+		return nil, fmt.Errorf("query sla stats: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*OwnerSLA
+	for rows.Next() {
+		o := &OwnerSLA{}
+		if err := rows.Scan(&o.Owner, &o.Met, &o.Missed); err != nil {
+			return nil, fmt.Errorf("scan sla stats: %w", err)
+		}
+		o.Attainment = float64(o.Met) / float64(o.Met+o.Missed)
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
 func scanTasks(rows pgx.Rows) ([]*Task, error) {
 	var tasks []*Task
 	for rows.Next() {
@@ -317,6 +483,8 @@ func scanTasks(rows pgx.Rows) ([]*Task, error) {
 		var scoringVersion sql.NullInt32
 		var fastPath, oneWayDoor sql.NullBool
 		var recommendedModel, modelTier, routingMethod, runtime sql.NullString
+		var progressJSON []byte
+		var subState sql.NullString
 		if err := rows.Scan(
 			&t.ID, &t.Title, &t.Description, &t.Owner, &t.RequiredCapabilities,
 			&t.Status, &assignedAgent,
@@ -332,6 +500,11 @@ func scanTasks(rows pgx.Rows) ([]*Task, error) {
 			&fastPath, &paretoFrontierJSON, &altDecompJSON,
 			&t.Labels, &t.FilePatterns, &oneWayDoor,
 			&recommendedModel, &modelTier, &routingMethod, &runtime,
+			&progressJSON, &t.LastHeartbeatAt,
+			&t.AckedAt, &t.LeaseExpiresAt, &subState, &t.Preemptible,
+			&t.PreferredAgents, &t.RequiredAgent, &t.ExcludedAgents, &t.AffinityGroup,
+			&t.Deadline, &t.SLAAtRiskAt, &t.DeadlineMissedAt, &t.MinModelTier, &t.FailureClass,
+			&t.BacklogItemID, &t.BacklogStage,
 		); err != nil {
 			return nil, err
 		}
@@ -351,6 +524,10 @@ func scanTasks(rows pgx.Rows) ([]*Task, error) {
 			oversightLevel, scoringFactorsJSON, scoringVersion, complexity, uncertainty,
 			durationClass, contextuality, subjectivity, fastPath, paretoFrontierJSON, altDecompJSON)
 		applyModelRoutingFields(t, oneWayDoor, recommendedModel, modelTier, routingMethod, runtime)
+		if progressJSON != nil {
+			_ = json.Unmarshal(progressJSON, &t.Progress)
+		}
+		t.SubState = subState.String
 		tasks = append(tasks, t)
 	}
 	return tasks, rows.Err()
@@ -450,11 +627,11 @@ func nullString(s string) sql.NullString {
 func (s *PostgresStore) CreateAgentTaskHistory(ctx context.Context, h *AgentTaskHistory) error {
 	return s.pool.QueryRow(ctx, `
 		INSERT INTO agent_task_history (agent_slug, task_id, started_at, completed_at,
-			duration_seconds, tokens_used, cost_usd, success)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			duration_seconds, tokens_used, cost_usd, success, failure_class)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at`,
 		h.AgentSlug, h.TaskID, h.StartedAt, h.CompletedAt,
-		h.DurationSeconds, h.TokensUsed, h.CostUSD, h.Success,
+		h.DurationSeconds, h.TokensUsed, h.CostUSD, h.Success, nullString(h.FailureClass),
 	).Scan(&h.ID, &h.CreatedAt)
 }
 
@@ -464,7 +641,7 @@ func (s *PostgresStore) GetAgentTaskHistory(ctx context.Context, agentSlug strin
 	}
 	rows, err := s.pool.Query(ctx, `
 		SELECT id, agent_slug, task_id, started_at, completed_at,
-			duration_seconds, tokens_used, cost_usd, success, created_at
+			duration_seconds, tokens_used, cost_usd, success, COALESCE(failure_class, ''), created_at
 		FROM agent_task_history WHERE agent_slug = $1
 		ORDER BY created_at DESC LIMIT $2`, agentSlug, limit)
 	if err != nil {
@@ -476,7 +653,7 @@ func (s *PostgresStore) GetAgentTaskHistory(ctx context.Context, agentSlug strin
 	for rows.Next() {
 		h := &AgentTaskHistory{}
 		if err := rows.Scan(&h.ID, &h.AgentSlug, &h.TaskID, &h.StartedAt, &h.CompletedAt,
-			&h.DurationSeconds, &h.TokensUsed, &h.CostUSD, &h.Success, &h.CreatedAt); err != nil {
+			&h.DurationSeconds, &h.TokensUsed, &h.CostUSD, &h.Success, &h.FailureClass, &h.CreatedAt); err != nil {
 			return nil, err
 		}
 		history = append(history, h)
@@ -484,6 +661,30 @@ func (s *PostgresStore) GetAgentTaskHistory(ctx context.Context, agentSlug strin
 	return history, rows.Err()
 }
 
+func (s *PostgresStore) GetAgentFailureStats(ctx context.Context, agentSlug string) (*AgentFailureStats, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT COALESCE(failure_class, ''), COUNT(*) FROM agent_task_history
+		WHERE agent_slug = $1 GROUP BY 1`, agentSlug)
+	if err != nil {
+		return nil, fmt.Errorf("get agent failure stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &AgentFailureStats{Failures: make(map[string]int)}
+	for rows.Next() {
+		var class string
+		var n int
+		if err := rows.Scan(&class, &n); err != nil {
+			return nil, fmt.Errorf("scan agent failure stats: %w", err)
+		}
+		stats.Attempts += n
+		if class != "" {
+			stats.Failures[class] = n
+		}
+	}
+	return stats, rows.Err()
+}
+
 func (s *PostgresStore) GetAgentAvgDuration(ctx context.Context, agentSlug string) (*float64, error) {
 	var avg sql.NullFloat64
 	err := s.pool.QueryRow(ctx, `
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -16,13 +17,13 @@ const backlogItemColumns = `id, title, description, item_type, status, domain, a
 	model_tier, labels, one_way_door,
 	stage_template, current_stage, stage_index,
 	discovery_assessment,
-	source, metadata, pr_url, branch_name, created_at, updated_at`
+	source, metadata, pr_url, branch_name, due_date, external_id, created_at, updated_at`
 
 func scanBacklogItem(row pgx.Row) (*BacklogItem, error) {
 	item := &BacklogItem{}
 	var description, domain, assignedTo, effortEstimate sql.NullString
 	var scoresSource, modelTier, source sql.NullString
-	var prURL, branchName sql.NullString
+	var prURL, branchName, externalID sql.NullString
 	var currentStage sql.NullString
 	var impact, urgency, priorityScore sql.NullFloat64
 	var estimatedTokens sql.NullInt64
@@ -37,7 +38,7 @@ func scanBacklogItem(row pgx.Row) (*BacklogItem, error) {
 		&modelTier, &item.Labels, &oneWayDoor,
 		&item.StageTemplate, &currentStage, &item.StageIndex,
 		&discoveryJSON,
-		&source, &metadataJSON, &prURL, &branchName, &item.CreatedAt, &item.UpdatedAt,
+		&source, &metadataJSON, &prURL, &branchName, &item.DueDate, &externalID, &item.CreatedAt, &item.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -57,6 +58,7 @@ func scanBacklogItem(row pgx.Row) (*BacklogItem, error) {
 	if branchName.Valid {
 		item.BranchName = branchName.String
 	}
+	item.ExternalID = externalID.String
 	return item, nil
 }
 
@@ -66,7 +68,7 @@ func scanBacklogItems(rows pgx.Rows) ([]*BacklogItem, error) {
 		item := &BacklogItem{}
 		var description, domain, assignedTo, effortEstimate sql.NullString
 		var scoresSource, modelTier, source sql.NullString
-		var prURL, branchName sql.NullString
+		var prURL, branchName, externalID sql.NullString
 		var currentStage sql.NullString
 		var impact, urgency, priorityScore sql.NullFloat64
 		var estimatedTokens sql.NullInt64
@@ -81,7 +83,7 @@ func scanBacklogItems(rows pgx.Rows) ([]*BacklogItem, error) {
 			&modelTier, &item.Labels, &oneWayDoor,
 			&item.StageTemplate, &currentStage, &item.StageIndex,
 			&discoveryJSON,
-			&source, &metadataJSON, &prURL, &branchName, &item.CreatedAt, &item.UpdatedAt,
+			&source, &metadataJSON, &prURL, &branchName, &item.DueDate, &externalID, &item.CreatedAt, &item.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -94,6 +96,7 @@ func scanBacklogItems(rows pgx.Rows) ([]*BacklogItem, error) {
 			scoresSource, modelTier, source,
 			impact, urgency, priorityScore, estimatedTokens,
 			oneWayDoor, discoveryJSON, metadataJSON)
+		item.ExternalID = externalID.String
 		items = append(items, item)
 	}
 	return items, rows.Err()
@@ -152,26 +155,42 @@ func applyBacklogNullables(item *BacklogItem,
 }
 
 func (s *PostgresStore) CreateBacklogItem(ctx context.Context, item *BacklogItem) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return insertBacklogItem(ctx, tx, item)
+	})
+}
+
+// insertBacklogItem inserts item within tx and records its creation. An item
+// without an ID is given a new one.
+func insertBacklogItem(ctx context.Context, tx pgx.Tx, item *BacklogItem) error {
+	if item.ID == uuid.Nil {
+		item.ID = uuid.New()
+	}
 	discoveryJSON, _ := json.Marshal(item.DiscoveryAssessment)
 	metadataJSON, _ := json.Marshal(item.Metadata)
 
-	return s.pool.QueryRow(ctx, `
-		INSERT INTO backlog_items (title, description, item_type, status, domain, assigned_to, parent_id,
+	err := tx.QueryRow(ctx, `
+		INSERT INTO backlog_items (id, title, description, item_type, status, domain, assigned_to, parent_id,
 			impact, urgency, estimated_tokens, effort_estimate,
 			priority_score, scores_source,
 			model_tier, labels, one_way_door,
 			stage_template, current_stage, stage_index,
-			discovery_assessment, source, metadata, pr_url, branch_name)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
-		RETURNING id, created_at, updated_at`,
-		item.Title, nullString(item.Description), item.ItemType, item.Status,
+			discovery_assessment, source, metadata, pr_url, branch_name, due_date, external_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
+		RETURNING created_at, updated_at`,
+		item.ID, item.Title, nullString(item.Description), item.ItemType, item.Status,
 		nullString(item.Domain), nullString(item.AssignedTo), item.ParentID,
 		item.Impact, item.Urgency, item.EstimatedTokens, nullString(item.EffortEstimate),
 		item.PriorityScore, nullString(item.ScoresSource),
 		nullString(item.ModelTier), item.Labels, item.OneWayDoor,
 		item.StageTemplate, nullString(item.CurrentStage), item.StageIndex,
 		discoveryJSON, nullString(item.Source), metadataJSON, nullString(item.PRURL), nullString(item.BranchName),
-	).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+		item.DueDate, nullString(item.ExternalID),
+	).Scan(&item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return recordChange(ctx, tx, NewChangeRecord(ctx, EntityBacklogItem, item.ID, ChangeCreated, nil, item))
 }
 
 func (s *PostgresStore) GetBacklogItem(ctx context.Context, id uuid.UUID) (*BacklogItem, error) {
@@ -183,91 +202,326 @@ func (s *PostgresStore) GetBacklogItem(ctx context.Context, id uuid.UUID) (*Back
 	return item, err
 }
 
+func (s *PostgresStore) GetBacklogItemByExternalID(ctx context.Context, externalID string) (*BacklogItem, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+backlogItemColumns+` FROM backlog_items WHERE external_id = $1`, externalID)
+	item, err := scanBacklogItem(row)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+// GetBacklogItems returns the items with the given IDs, in no particular
+// order. IDs with no item are skipped.
+func (s *PostgresStore) GetBacklogItems(ctx context.Context, ids []uuid.UUID) ([]*BacklogItem, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.pool.Query(ctx, `SELECT `+backlogItemColumns+` FROM backlog_items WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("query backlog items: %w", err)
+	}
+	defer rows.Close()
+	return scanBacklogItems(rows)
+}
+
+// ListBacklogSubtree returns rootID's item and all its descendants in one
+// recursive query, oldest first. It returns nil when the root does not
+// exist.
+func (s *PostgresStore) ListBacklogSubtree(ctx context.Context, rootID uuid.UUID) ([]*BacklogItem, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM backlog_items WHERE id = $1
+			UNION
+			SELECT c.id FROM backlog_items c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT `+backlogItemColumns+` FROM backlog_items
+		WHERE id IN (SELECT id FROM subtree)
+		ORDER BY created_at`, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("query backlog subtree: %w", err)
+	}
+	defer rows.Close()
+	return scanBacklogItems(rows)
+}
+
 func (s *PostgresStore) ListBacklogItems(ctx context.Context, filter BacklogFilter) ([]*BacklogItem, error) {
-	query := `SELECT ` + backlogItemColumns + ` FROM backlog_items WHERE 1=1`
+	query, args := backlogListQuery(filter)
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !filter.ByRelevance() {
+		return scanBacklogItems(rows)
+	}
+
+	// Relevance lists carry each item's rank as a last column.
+	var items []*BacklogItem
+	for rows.Next() {
+		var rank float32
+		item, err := scanBacklogItem(extraColumnsRow{Rows: rows, after: []interface{}{&rank}})
+		if err != nil {
+			return nil, err
+		}
+		r := float64(rank)
+		item.SearchRank = &r
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// extraColumnsRow scans a row that has other columns before or after a
+// backlog item's, such as a search rank.
+type extraColumnsRow struct {
+	pgx.Rows
+	before, after []interface{}
+}
+
+func (r extraColumnsRow) Scan(dest ...interface{}) error {
+	all := make([]interface{}, 0, len(r.before)+len(dest)+len(r.after))
+	all = append(append(append(all, r.before...), dest...), r.after...)
+	return r.Rows.Scan(all...)
+}
+
+// backlogSortColumns maps each sort order to its column and direction.
+var backlogSortColumns = map[string]struct {
+	column string
+	desc   bool
+}{
+	BacklogSortPriority: {"priority_score", true},
+	BacklogSortCreated:  {"created_at", true},
+	BacklogSortUpdated:  {"updated_at", true},
+	BacklogSortDueDate:  {"due_date", false},
+}
+
+// backlogListQuery builds the query and arguments for ListBacklogItems.
+func backlogListQuery(filter BacklogFilter) (string, []interface{}) {
 	args := []interface{}{}
-	n := 0
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// A relevance list selects each item's rank after its columns, for the
+	// next page's cursor.
+	columns := backlogItemColumns
+	var tsquery, rank string
+	if filter.Query != "" {
+		tsquery = "websearch_to_tsquery('english', " + arg(filter.Query) + ")"
+		rank = "ts_rank(search_vector, " + tsquery + ")"
+	}
+	if filter.ByRelevance() {
+		columns += ", " + rank
+	}
+	query := `SELECT ` + columns + ` FROM backlog_items WHERE 1=1`
 
 	if filter.Status != nil {
-		n++
-		query += fmt.Sprintf(" AND status = $%d", n)
-		args = append(args, string(*filter.Status))
+		query += " AND status = " + arg(string(*filter.Status))
 	}
 	if filter.Domain != "" {
-		n++
-		query += fmt.Sprintf(" AND domain = $%d", n)
-		args = append(args, filter.Domain)
+		query += " AND domain = " + arg(filter.Domain)
 	}
 	if filter.AssignedTo != "" {
-		n++
-		query += fmt.Sprintf(" AND assigned_to = $%d", n)
-		args = append(args, filter.AssignedTo)
+		query += " AND assigned_to = " + arg(filter.AssignedTo)
 	}
 	if filter.ItemType != "" {
-		n++
-		query += fmt.Sprintf(" AND item_type = $%d", n)
-		args = append(args, filter.ItemType)
+		query += " AND item_type = " + arg(filter.ItemType)
 	}
 	if filter.ParentID != nil {
-		n++
-		query += fmt.Sprintf(" AND parent_id = $%d", n)
-		args = append(args, *filter.ParentID)
+		query += " AND parent_id = " + arg(*filter.ParentID)
+	}
+	if tsquery != "" {
+		query += " AND search_vector @@ " + tsquery
+	}
+	if len(filter.Labels) > 0 {
+		op := "&&"
+		if filter.AllLabels {
+			op = "@>"
+		}
+		query += " AND labels " + op + " " + arg(filter.Labels)
+	}
+	if filter.ModelTier != "" {
+		query += " AND model_tier = " + arg(filter.ModelTier)
+	}
+	if filter.MinScore != nil {
+		query += " AND priority_score >= " + arg(*filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		query += " AND priority_score <= " + arg(*filter.MaxScore)
+	}
+	if filter.OneWayDoor != nil {
+		query += " AND one_way_door = " + arg(*filter.OneWayDoor)
+	}
+	if filter.CreatedAfter != nil {
+		query += " AND created_at >= " + arg(*filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query += " AND created_at < " + arg(*filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		query += " AND updated_at >= " + arg(*filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		query += " AND updated_at < " + arg(*filter.UpdatedBefore)
+	}
+	if filter.HasBlockers != nil {
+		blocked := `EXISTS (SELECT 1 FROM backlog_dependencies d
+			WHERE d.blocked_id = backlog_items.id AND d.resolved_at IS NULL)`
+		if !*filter.HasBlockers {
+			blocked = "NOT " + blocked
+		}
+		query += " AND " + blocked
+	}
+
+	sort, ok := backlogSortColumns[filter.Sort]
+	if !ok {
+		sort = backlogSortColumns[BacklogSortPriority]
+	}
+	dir, after := "ASC", ">"
+	if sort.desc {
+		dir, after = "DESC", "<"
 	}
 
-	query += " ORDER BY priority_score DESC NULLS LAST, created_at ASC"
+	// Keyset pagination: rows strictly after the cursor in sort order.
+	// Items without a sort key come last; every match has a rank.
+	if c := filter.Cursor; c != nil && filter.ByRelevance() {
+		var key float64
+		if c.Rank != nil {
+			key = *c.Rank
+		}
+		k := arg(key)
+		tie := "(created_at, id) > (" + arg(c.CreatedAt) + ", " + arg(c.ID) + ")"
+		query += fmt.Sprintf(" AND (%[1]s < %[2]s OR (%[1]s = %[2]s AND %[3]s))", rank, k, tie)
+	} else if c != nil {
+		var key interface{}
+		switch {
+		case c.Score != nil:
+			key = *c.Score
+		case c.Time != nil:
+			key = *c.Time
+		}
+		if key == nil {
+			tie := "(created_at, id) > (" + arg(c.CreatedAt) + ", " + arg(c.ID) + ")"
+			query += fmt.Sprintf(" AND (%s IS NULL AND %s)", sort.column, tie)
+		} else {
+			k := arg(key)
+			tie := "(created_at, id) > (" + arg(c.CreatedAt) + ", " + arg(c.ID) + ")"
+			query += fmt.Sprintf(" AND (%[1]s %[2]s %[3]s OR %[1]s IS NULL OR (%[1]s = %[3]s AND %[4]s))",
+				sort.column, after, k, tie)
+		}
+	}
+
+	if filter.ByRelevance() {
+		query += " ORDER BY " + rank + " DESC, created_at ASC, id ASC"
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s NULLS LAST, created_at ASC, id ASC", sort.column, dir)
+	}
 
 	limit := filter.Limit
 	if limit <= 0 {
 		limit = 100
 	}
-	n++
-	query += fmt.Sprintf(" LIMIT $%d", n)
-	args = append(args, limit)
+	query += " LIMIT " + arg(limit)
 
-	if filter.Offset > 0 {
-		n++
-		query += fmt.Sprintf(" OFFSET $%d", n)
-		args = append(args, filter.Offset)
+	if filter.Offset > 0 && filter.Cursor == nil {
+		query += " OFFSET " + arg(filter.Offset)
 	}
+	return query, args
+}
 
-	rows, err := s.pool.Query(ctx, query, args...)
+// UpdateBacklogItemScore sets item's priority score and nothing else. It
+// fails with ErrBacklogItemChanged if the item was written since it was read
+// at item.UpdatedAt, so a concurrent edit is never reverted. The write keeps
+// updated_at and leaves no change history; rescores are recorded in the
+// score history instead. On success item carries the new score.
+func (s *PostgresStore) UpdateBacklogItemScore(ctx context.Context, item *BacklogItem, score float64) error {
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT set_config('dispatch.keep_updated_at', 'on', true)`); err != nil {
+			return fmt.Errorf("update item %s score: %w", item.ID, err)
+		}
+		tag, err := tx.Exec(ctx, `
+			UPDATE backlog_items SET priority_score = $2
+			WHERE id = $1 AND updated_at = $3`,
+			item.ID, score, item.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("update item %s score: %w", item.ID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("update item %s score: %w", item.ID, ErrBacklogItemChanged)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-	return scanBacklogItems(rows)
+	item.PriorityScore = &score
+	return nil
 }
 
 func (s *PostgresStore) UpdateBacklogItem(ctx context.Context, item *BacklogItem) error {
 	discoveryJSON, _ := json.Marshal(item.DiscoveryAssessment)
 	metadataJSON, _ := json.Marshal(item.Metadata)
 
-	_, err := s.pool.Exec(ctx, `
-		UPDATE backlog_items SET
-			title = $2, description = $3, item_type = $4, status = $5,
-			domain = $6, assigned_to = $7, parent_id = $8,
-			impact = $9, urgency = $10, estimated_tokens = $11, effort_estimate = $12,
-			priority_score = $13, scores_source = $14,
-			model_tier = $15, labels = $16, one_way_door = $17,
-			stage_template = $18, current_stage = $19, stage_index = $20,
-			discovery_assessment = $21, source = $22, metadata = $23,
-			pr_url = $24, branch_name = $25
-		WHERE id = $1`,
-		item.ID, item.Title, nullString(item.Description), item.ItemType, item.Status,
-		nullString(item.Domain), nullString(item.AssignedTo), item.ParentID,
-		item.Impact, item.Urgency, item.EstimatedTokens, nullString(item.EffortEstimate),
-		item.PriorityScore, nullString(item.ScoresSource),
-		nullString(item.ModelTier), item.Labels, item.OneWayDoor,
-		item.StageTemplate, nullString(item.CurrentStage), item.StageIndex,
-		discoveryJSON, nullString(item.Source), metadataJSON,
-		nullString(item.PRURL), nullString(item.BranchName),
-	)
-	return err
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		before, err := lockBacklogItem(ctx, tx, item.ID)
+		if err != nil || before == nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE backlog_items SET
+				title = $2, description = $3, item_type = $4, status = $5,
+				domain = $6, assigned_to = $7, parent_id = $8,
+				impact = $9, urgency = $10, estimated_tokens = $11, effort_estimate = $12,
+				priority_score = $13, scores_source = $14,
+				model_tier = $15, labels = $16, one_way_door = $17,
+				stage_template = $18, current_stage = $19, stage_index = $20,
+				discovery_assessment = $21, source = $22, metadata = $23,
+				pr_url = $24, branch_name = $25, due_date = $26, external_id = $27
+			WHERE id = $1`,
+			item.ID, item.Title, nullString(item.Description), item.ItemType, item.Status,
+			nullString(item.Domain), nullString(item.AssignedTo), item.ParentID,
+			item.Impact, item.Urgency, item.EstimatedTokens, nullString(item.EffortEstimate),
+			item.PriorityScore, nullString(item.ScoresSource),
+			nullString(item.ModelTier), item.Labels, item.OneWayDoor,
+			item.StageTemplate, nullString(item.CurrentStage), item.StageIndex,
+			discoveryJSON, nullString(item.Source), metadataJSON,
+			nullString(item.PRURL), nullString(item.BranchName), item.DueDate, nullString(item.ExternalID),
+		)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, NewChangeRecord(ctx, EntityBacklogItem, item.ID, ChangeUpdated, before, item))
+	})
 }
 
 func (s *PostgresStore) DeleteBacklogItem(ctx context.Context, id uuid.UUID) error {
-	_, err := s.pool.Exec(ctx, `DELETE FROM backlog_items WHERE id = $1`, id)
-	return err
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		before, err := lockBacklogItem(ctx, tx, id)
+		if err != nil || before == nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM backlog_items WHERE id = $1`, id); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, NewChangeRecord(ctx, EntityBacklogItem, id, ChangeDeleted, before, nil))
+	})
+}
+
+// lockBacklogItem reads an item for update within tx, so its history can be
+// recorded against what the write replaces. It returns nil if there is no
+// such item.
+func lockBacklogItem(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*BacklogItem, error) {
+	item, err := scanBacklogItem(tx.QueryRow(ctx, `SELECT `+backlogItemColumns+` FROM backlog_items WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock item: %w", err)
+	}
+	return item, nil
 }
 
 func (s *PostgresStore) GetNextBacklogItems(ctx context.Context, limit int) ([]*BacklogItem, error) {
@@ -287,6 +541,77 @@ func (s *PostgresStore) GetNextBacklogItems(ctx context.Context, limit int) ([]*
 	return scanBacklogItems(rows)
 }
 
+// ListOpenBacklogItems returns every item that is not done or cancelled.
+// CountBacklogChildren tallies parentID's children by status in one query.
+func (s *PostgresStore) CountBacklogChildren(ctx context.Context, parentID uuid.UUID) (ChildCounts, error) {
+	var c ChildCounts
+	err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status NOT IN ('done', 'cancelled')),
+			COUNT(*) FILTER (WHERE status = 'done'),
+			COUNT(*) FILTER (WHERE status = 'cancelled')
+		FROM backlog_items
+		WHERE parent_id = $1`, parentID,
+	).Scan(&c.Open, &c.Done, &c.Cancelled)
+	if err != nil {
+		return ChildCounts{}, fmt.Errorf("count children: %w", err)
+	}
+	return c, nil
+}
+
+func (s *PostgresStore) ListOpenBacklogItems(ctx context.Context) ([]*BacklogItem, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+backlogItemColumns+`
+		FROM backlog_items
+		WHERE status NOT IN ('done', 'cancelled')
+		ORDER BY priority_score DESC NULLS LAST, created_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("list open backlog items: %w", err)
+	}
+	defer rows.Close()
+	return scanBacklogItems(rows)
+}
+
+// --- Score history ---
+
+func (s *PostgresStore) CreateScoreChange(ctx context.Context, c *BacklogScoreChange) error {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO backlog_score_history (backlog_item_id, previous_score, new_score, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		c.BacklogItemID, c.PreviousScore, c.NewScore, c.Reason,
+	).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create score change: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListScoreHistory(ctx context.Context, itemID uuid.UUID, limit int) ([]*BacklogScoreChange, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, backlog_item_id, previous_score, new_score, reason, created_at
+		FROM backlog_score_history
+		WHERE backlog_item_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, itemID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list score history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*BacklogScoreChange
+	for rows.Next() {
+		c := &BacklogScoreChange{}
+		if err := rows.Scan(&c.ID, &c.BacklogItemID, &c.PreviousScore, &c.NewScore, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan score change: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
 // --- Dependencies ---
 
 func (s *PostgresStore) CreateDependency(ctx context.Context, dep *BacklogDependency) error {
@@ -298,6 +623,39 @@ func (s *PostgresStore) CreateDependency(ctx context.Context, dep *BacklogDepend
 	).Scan(&dep.ID, &dep.CreatedAt)
 }
 
+// dependencyLockKey is the transaction-level advisory lock that serializes
+// checked dependency inserts.
+const dependencyLockKey = 0x64657073 // "deps"
+
+// CreateDependencyChecked adds dep once check passes against the existing
+// dependencies. The check and insert run in one transaction holding an
+// advisory lock, so two concurrent additions cannot form a cycle that
+// neither saw.
+func (s *PostgresStore) CreateDependencyChecked(ctx context.Context, dep *BacklogDependency, check DependencyCheck) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, dependencyLockKey); err != nil {
+			return fmt.Errorf("lock dependencies: %w", err)
+		}
+		rows, err := tx.Query(ctx, `SELECT `+dependencyColumns+` FROM backlog_dependencies ORDER BY created_at ASC`)
+		if err != nil {
+			return fmt.Errorf("query dependencies: %w", err)
+		}
+		existing, err := scanDependencies(rows)
+		if err != nil {
+			return err
+		}
+		if err := check(existing); err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, `
+			INSERT INTO backlog_dependencies (blocker_id, blocked_id)
+			VALUES ($1, $2)
+			RETURNING id, created_at`,
+			dep.BlockerID, dep.BlockedID,
+		).Scan(&dep.ID, &dep.CreatedAt)
+	})
+}
+
 func (s *PostgresStore) DeleteDependency(ctx context.Context, id uuid.UUID) error {
 	_, err := s.pool.Exec(ctx, `DELETE FROM backlog_dependencies WHERE id = $1`, id)
 	return err
@@ -325,6 +683,31 @@ func (s *PostgresStore) GetDependenciesForItem(ctx context.Context, itemID uuid.
 	return deps, rows.Err()
 }
 
+const dependencyColumns = `id, blocker_id, blocked_id, resolved_at, created_at`
+
+// ListDependencies returns every dependency, resolved or not.
+func (s *PostgresStore) ListDependencies(ctx context.Context) ([]*BacklogDependency, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+dependencyColumns+` FROM backlog_dependencies ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query dependencies: %w", err)
+	}
+	return scanDependencies(rows)
+}
+
+// scanDependencies reads dependencyColumns rows and closes them.
+func scanDependencies(rows pgx.Rows) ([]*BacklogDependency, error) {
+	defer rows.Close()
+	var deps []*BacklogDependency
+	for rows.Next() {
+		d := &BacklogDependency{}
+		if err := rows.Scan(&d.ID, &d.BlockerID, &d.BlockedID, &d.ResolvedAt, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan dependency: %w", err)
+		}
+		deps = append(deps, d)
+	}
+	return deps, rows.Err()
+}
+
 func (s *PostgresStore) HasUnresolvedBlockers(ctx context.Context, itemID uuid.UUID) (bool, error) {
 	var count int
 	err := s.pool.QueryRow(ctx, `
@@ -334,6 +717,27 @@ func (s *PostgresStore) HasUnresolvedBlockers(ctx context.Context, itemID uuid.U
 	return count > 0, err
 }
 
+// ListBlockedBacklogItemIDs returns the items that have an unresolved
+// blocker.
+func (s *PostgresStore) ListBlockedBacklogItemIDs(ctx context.Context) (map[uuid.UUID]bool, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT DISTINCT blocked_id FROM backlog_dependencies
+		WHERE resolved_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("query blocked items: %w", err)
+	}
+	defer rows.Close()
+	blocked := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan blocked item: %w", err)
+		}
+		blocked[id] = true
+	}
+	return blocked, rows.Err()
+}
+
 func (s *PostgresStore) ResolveDependenciesForBlocker(ctx context.Context, blockerID uuid.UUID) error {
 	_, err := s.pool.Exec(ctx, `
 		UPDATE backlog_dependencies SET resolved_at = NOW()
@@ -353,6 +757,31 @@ func (s *PostgresStore) CreateOverride(ctx context.Context, o *DispatchOverride)
 	).Scan(&o.ID, &o.CreatedAt)
 }
 
+func (s *PostgresStore) GetOverridesForTask(ctx context.Context, taskID uuid.UUID) ([]*DispatchOverride, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, backlog_item_id, task_id, override_type, previous_value, new_value, reason, overridden_by, created_at
+		FROM dispatch_overrides
+		WHERE task_id = $1
+		ORDER BY created_at ASC`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("query overrides for task: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*DispatchOverride
+	for rows.Next() {
+		o := &DispatchOverride{}
+		var prev, reason sql.NullString
+		if err := rows.Scan(&o.ID, &o.BacklogItemID, &o.TaskID, &o.OverrideType, &prev, &o.NewValue, &reason, &o.OverriddenBy, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan override: %w", err)
+		}
+		o.PreviousValue = prev.String
+		o.Reason = reason.String
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
 // --- Autonomy ---
 
 func (s *PostgresStore) CreateAutonomyEvent(ctx context.Context, e *AutonomyEvent) error {
@@ -418,6 +847,7 @@ func (s *PostgresStore) BacklogDiscoveryComplete(ctx context.Context, itemID uui
 	if err != nil {
 		return nil, fmt.Errorf("lock item: %w", err)
 	}
+	before := *item
 
 	result := &BacklogDiscoveryCompleteResult{}
 
@@ -525,7 +955,7 @@ func (s *PostgresStore) BacklogDiscoveryComplete(ctx context.Context, itemID uui
 			model_tier = $15, labels = $16, one_way_door = $17,
 			stage_template = $18, current_stage = $19, stage_index = $20,
 			discovery_assessment = $21, source = $22, metadata = $23,
-			pr_url = $24, branch_name = $25
+			pr_url = $24, branch_name = $25, due_date = $26, external_id = $27
 		WHERE id = $1`,
 		item.ID, item.Title, nullString(item.Description), item.ItemType, item.Status,
 		nullString(item.Domain), nullString(item.AssignedTo), item.ParentID,
@@ -534,11 +964,14 @@ func (s *PostgresStore) BacklogDiscoveryComplete(ctx context.Context, itemID uui
 		nullString(item.ModelTier), item.Labels, item.OneWayDoor,
 		item.StageTemplate, nullString(item.CurrentStage), item.StageIndex,
 		discoveryJSON, nullString(item.Source), metadataJSON,
-		nullString(item.PRURL), nullString(item.BranchName),
+		nullString(item.PRURL), nullString(item.BranchName), item.DueDate, nullString(item.ExternalID),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("update item: %w", err)
 	}
+	if err := recordChange(ctx, tx, NewChangeRecord(ctx, EntityBacklogItem, itemID, ChangeUpdated, &before, item)); err != nil {
+		return nil, err
+	}
 
 	// 9. Create discovered subtasks
 	for _, sub := range req.Subtasks {
@@ -593,6 +1026,9 @@ func (s *PostgresStore) BacklogDiscoveryComplete(ctx context.Context, itemID uui
 		if err != nil {
 			return nil, fmt.Errorf("create subtask: %w", err)
 		}
+		if err := recordChange(ctx, tx, NewChangeRecord(ctx, EntityBacklogItem, subtask.ID, ChangeCreated, nil, subtask)); err != nil {
+			return nil, err
+		}
 		result.CreatedSubtasks = append(result.CreatedSubtasks, subtask)
 	}
 
@@ -616,6 +1052,128 @@ func (s *PostgresStore) hasUnresolvedBlockersTx(ctx context.Context, tx pgx.Tx,
 	return count > 0, err
 }
 
+// --- Bulk Update (transactional) ---
+
+// ErrBacklogItemChanged is returned by BulkUpdateBacklogItems,
+// ImportBacklog and UpdateBacklogItemScore when an item was updated by
+// someone else after it was read.
+var ErrBacklogItemChanged = errors.New("backlog item changed concurrently")
+
+func (s *PostgresStore) BulkUpdateBacklogItems(ctx context.Context, items []*BacklogItem, overrides []*DispatchOverride) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, item := range items {
+		// Only update the item as it was read; updated_at moves on any
+		// other write.
+		before, err := lockBacklogItem(ctx, tx, item.ID)
+		if err != nil {
+			return err
+		}
+		if before == nil || !before.UpdatedAt.Equal(item.UpdatedAt) {
+			return fmt.Errorf("update item %s: %w", item.ID, ErrBacklogItemChanged)
+		}
+
+		if err := updateBacklogItemTx(ctx, tx, before, item); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range overrides {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO dispatch_overrides (backlog_item_id, task_id, override_type, previous_value, new_value, reason, overridden_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at`,
+			o.BacklogItemID, o.TaskID, o.OverrideType,
+			nullString(o.PreviousValue), o.NewValue, nullString(o.Reason), o.OverriddenBy,
+		).Scan(&o.ID, &o.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("insert override: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// updateBacklogItemTx writes item over before within tx and records the
+// change. item.UpdatedAt is set to the new update time.
+func updateBacklogItemTx(ctx context.Context, tx pgx.Tx, before, item *BacklogItem) error {
+	discoveryJSON, _ := json.Marshal(item.DiscoveryAssessment)
+	metadataJSON, _ := json.Marshal(item.Metadata)
+
+	err := tx.QueryRow(ctx, `
+		UPDATE backlog_items SET
+			title = $2, description = $3, item_type = $4, status = $5,
+			domain = $6, assigned_to = $7, parent_id = $8,
+			impact = $9, urgency = $10, estimated_tokens = $11, effort_estimate = $12,
+			priority_score = $13, scores_source = $14,
+			model_tier = $15, labels = $16, one_way_door = $17,
+			stage_template = $18, current_stage = $19, stage_index = $20,
+			discovery_assessment = $21, source = $22, metadata = $23,
+			pr_url = $24, branch_name = $25, due_date = $26, external_id = $27
+		WHERE id = $1
+		RETURNING updated_at`,
+		item.ID, item.Title, nullString(item.Description), item.ItemType, item.Status,
+		nullString(item.Domain), nullString(item.AssignedTo), item.ParentID,
+		item.Impact, item.Urgency, item.EstimatedTokens, nullString(item.EffortEstimate),
+		item.PriorityScore, nullString(item.ScoresSource),
+		nullString(item.ModelTier), item.Labels, item.OneWayDoor,
+		item.StageTemplate, nullString(item.CurrentStage), item.StageIndex,
+		discoveryJSON, nullString(item.Source), metadataJSON,
+		nullString(item.PRURL), nullString(item.BranchName), item.DueDate, nullString(item.ExternalID),
+	).Scan(&item.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("update item %s: %w", item.ID, err)
+	}
+	return recordChange(ctx, tx, NewChangeRecord(ctx, EntityBacklogItem, item.ID, ChangeUpdated, before, item))
+}
+
+// --- Import (transactional) ---
+
+// ImportBacklog applies an import in one transaction: it creates the new
+// items in order, updates the existing ones, and adds the dependencies. An
+// update fails with ErrBacklogItemChanged if its item was written since it
+// was read. Dependencies that already exist are skipped.
+func (s *PostgresStore) ImportBacklog(ctx context.Context, imp *BacklogImport) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		for _, item := range imp.Create {
+			if err := insertBacklogItem(ctx, tx, item); err != nil {
+				return fmt.Errorf("create item %q: %w", item.Title, err)
+			}
+		}
+		for _, item := range imp.Update {
+			before, err := lockBacklogItem(ctx, tx, item.ID)
+			if err != nil {
+				return err
+			}
+			if before == nil || !before.UpdatedAt.Equal(item.UpdatedAt) {
+				return fmt.Errorf("update item %s: %w", item.ID, ErrBacklogItemChanged)
+			}
+			if err := updateBacklogItemTx(ctx, tx, before, item); err != nil {
+				return err
+			}
+		}
+		for _, d := range imp.Dependencies {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO backlog_dependencies (blocker_id, blocked_id, resolved_at)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (blocker_id, blocked_id) DO NOTHING`,
+				d.BlockerID, d.BlockedID, d.ResolvedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("insert dependency: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
 // --- Stage Engine ---
 
 func (s *PostgresStore) InitStages(ctx context.Context, itemID uuid.UUID, template []string) error {
@@ -623,12 +1181,23 @@ func (s *PostgresStore) InitStages(ctx context.Context, itemID uuid.UUID, templa
 	if len(template) > 0 {
 		currentStage = template[0]
 	}
-	_, err := s.pool.Exec(ctx, `
-		UPDATE backlog_items SET stage_template = $2, current_stage = $3, stage_index = 0
-		WHERE id = $1`,
-		itemID, template, nullString(currentStage),
-	)
-	return err
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		before, err := lockBacklogItem(ctx, tx, itemID)
+		if err != nil || before == nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE backlog_items SET stage_template = $2, current_stage = $3, stage_index = 0
+			WHERE id = $1`,
+			itemID, template, nullString(currentStage),
+		)
+		if err != nil {
+			return err
+		}
+		after := *before
+		after.StageTemplate, after.CurrentStage, after.StageIndex = template, currentStage, 0
+		return recordChange(ctx, tx, NewChangeRecord(ctx, EntityBacklogItem, itemID, ChangeUpdated, before, &after))
+	})
 }
 
 func (s *PostgresStore) GetCurrentStage(ctx context.Context, itemID uuid.UUID) (string, int, error) {
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const budgetColumns = `id, scope, subject, period, max_tasks, max_tokens, max_cost_usd, action,
+	alerted_threshold, alerted_period_start, created_at, updated_at`
+
+// UpsertBudget creates the budget or replaces the limits and action of the
+// existing budget for the same scope, subject and period.
+func (s *PostgresStore) UpsertBudget(ctx context.Context, b *Budget) error {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO dispatch_budgets (scope, subject, period, max_tasks, max_tokens, max_cost_usd, action)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (scope, subject, period) DO UPDATE SET
+			max_tasks = EXCLUDED.max_tasks,
+			max_tokens = EXCLUDED.max_tokens,
+			max_cost_usd = EXCLUDED.max_cost_usd,
+			action = EXCLUDED.action,
+			updated_at = NOW()
+		RETURNING id, alerted_threshold, alerted_period_start, created_at, updated_at`,
+		b.Scope, b.Subject, b.Period, b.MaxTasks, b.MaxTokens, b.MaxCostUSD, b.Action,
+	).Scan(&b.ID, &b.AlertedThreshold, &b.AlertedPeriodStart, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("upsert budget: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListBudgets(ctx context.Context) ([]*Budget, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+budgetColumns+`
+		FROM dispatch_budgets
+		ORDER BY scope, subject, period`)
+	if err != nil {
+		return nil, fmt.Errorf("query budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*Budget
+	for rows.Next() {
+		b := &Budget{}
+		if err := rows.Scan(&b.ID, &b.Scope, &b.Subject, &b.Period, &b.MaxTasks, &b.MaxTokens, &b.MaxCostUSD, &b.Action,
+			&b.AlertedThreshold, &b.AlertedPeriodStart, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan budget: %w", err)
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (s *PostgresStore) DeleteBudget(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM dispatch_budgets WHERE id = $1`, id)
+	return err
+}
+
+// GetBudgetUsage totals what an owner or source has consumed since the given
+// time: tasks created, assignments made, and tokens and USD recorded for
+// work completed.
+func (s *PostgresStore) GetBudgetUsage(ctx context.Context, scope, subject string, since time.Time) (*BudgetUsage, error) {
+	var col string
+	switch scope {
+	case BudgetScopeOwner:
+		col = "owner"
+	case BudgetScopeSource:
+		col = "source"
+	default:
+		return nil, fmt.Errorf("unknown budget scope %q", scope)
+	}
+
+	u := &BudgetUsage{}
+	err := s.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM swarm_tasks WHERE `+col+` = $1 AND created_at >= $2),
+			(SELECT COUNT(*) FROM swarm_task_events e JOIN swarm_tasks t ON t.task_id = e.task_id
+				WHERE t.`+col+` = $1 AND e.event = 'assigned' AND e.created_at >= $2),
+			(SELECT COALESCE(SUM(h.tokens_used), 0) FROM agent_task_history h JOIN swarm_tasks t ON t.task_id = h.task_id
+				WHERE t.`+col+` = $1 AND h.completed_at >= $2),
+			(SELECT COALESCE(SUM(h.cost_usd), 0) FROM agent_task_history h JOIN swarm_tasks t ON t.task_id = h.task_id
+				WHERE t.`+col+` = $1 AND h.completed_at >= $2)`,
+		subject, since,
+	).Scan(&u.Tasks, &u.Dispatched, &u.Tokens, &u.CostUSD)
+	if err != nil {
+		return nil, fmt.Errorf("query budget usage: %w", err)
+	}
+	return u, nil
+}
+
+// MarkBudgetAlerted records the highest threshold alerted in the period that
+// starts at periodStart.
+func (s *PostgresStore) MarkBudgetAlerted(ctx context.Context, id uuid.UUID, periodStart time.Time, threshold int) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE dispatch_budgets SET alerted_threshold = $2, alerted_period_start = $3
+		WHERE id = $1`, id, threshold, periodStart)
+	return err
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// recordChange saves c within tx. A nil c, for a write that changed no
+// tracked field, is skipped.
+func recordChange(ctx context.Context, tx pgx.Tx, c *ChangeRecord) error {
+	if c == nil {
+		return nil
+	}
+	changesJSON, _ := json.Marshal(c.Changes)
+	err := tx.QueryRow(ctx, `
+		INSERT INTO change_history (entity_type, entity_id, action, actor, changes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		c.EntityType, c.EntityID, c.Action, c.Actor, changesJSON,
+	).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("record %s change: %w", c.EntityType, err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListChangeHistory(ctx context.Context, filter ChangeHistoryFilter) ([]*ChangeRecord, error) {
+	query := `
+		SELECT id, entity_type, entity_id, action, actor, changes, created_at
+		FROM change_history
+		WHERE entity_type = $1 AND entity_id = $2`
+	args := []interface{}{filter.EntityType, filter.EntityID}
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		query += fmt.Sprintf(" AND created_at > $%d", len(args))
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query change history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*ChangeRecord
+	for rows.Next() {
+		c := &ChangeRecord{}
+		var changesJSON []byte
+		if err := rows.Scan(&c.ID, &c.EntityType, &c.EntityID, &c.Action, &c.Actor, &changesJSON, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan change: %w", err)
+		}
+		_ = json.Unmarshal(changesJSON, &c.Changes)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
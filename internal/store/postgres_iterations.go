@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const iterationColumns = `id, name, starts_at, ends_at, capacity, capacity_unit, created_at, updated_at`
+
+func (s *PostgresStore) CreateIteration(ctx context.Context, it *Iteration) error {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO iterations (name, starts_at, ends_at, capacity, capacity_unit)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`,
+		it.Name, it.StartsAt, it.EndsAt, it.Capacity, it.CapacityUnit,
+	).Scan(&it.ID, &it.CreatedAt, &it.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create iteration: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetIteration(ctx context.Context, id uuid.UUID) (*Iteration, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+iterationColumns+` FROM iterations WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query iteration: %w", err)
+	}
+	defer rows.Close()
+	out, err := scanIterations(rows)
+	if err != nil || len(out) == 0 {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (s *PostgresStore) ListIterations(ctx context.Context) ([]*Iteration, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+iterationColumns+` FROM iterations ORDER BY starts_at DESC, name`)
+	if err != nil {
+		return nil, fmt.Errorf("query iterations: %w", err)
+	}
+	defer rows.Close()
+	return scanIterations(rows)
+}
+
+func (s *PostgresStore) DeleteIteration(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM iterations WHERE id = $1`, id)
+	return err
+}
+
+func scanIterations(rows pgx.Rows) ([]*Iteration, error) {
+	var out []*Iteration
+	for rows.Next() {
+		it := &Iteration{}
+		if err := rows.Scan(&it.ID, &it.Name, &it.StartsAt, &it.EndsAt, &it.Capacity, &it.CapacityUnit, &it.CreatedAt, &it.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan iteration: %w", err)
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// IterationConflictError is returned by CommitIterationItems for an item
+// already committed to another open iteration.
+type IterationConflictError struct {
+	ItemID    uuid.UUID
+	Iteration *Iteration
+}
+
+func (e *IterationConflictError) Error() string {
+	return fmt.Sprintf("item %s is committed to iteration %q", e.ItemID, e.Iteration.Name)
+}
+
+// iterationLockKey is the transaction-level advisory lock that serializes
+// iteration commits, so two iterations cannot take the same item at once.
+const iterationLockKey = 0x69746572 // "iter"
+
+// openCommitmentsQuery selects the items committed to open iterations other
+// than $1, each with its iteration.
+const openCommitmentsQuery = `
+	SELECT ii.backlog_item_id,
+		i.id, i.name, i.starts_at, i.ends_at, i.capacity, i.capacity_unit, i.created_at, i.updated_at
+	FROM iteration_items ii
+	JOIN iterations i ON i.id = ii.iteration_id
+	WHERE ii.iteration_id <> $1 AND i.ends_at > NOW()`
+
+func (s *PostgresStore) ListOpenCommitments(ctx context.Context, exceptID uuid.UUID) (map[uuid.UUID]*Iteration, error) {
+	rows, err := s.pool.Query(ctx, openCommitmentsQuery, exceptID)
+	if err != nil {
+		return nil, fmt.Errorf("query open commitments: %w", err)
+	}
+	defer rows.Close()
+	return scanCommitments(rows)
+}
+
+func scanCommitments(rows pgx.Rows) (map[uuid.UUID]*Iteration, error) {
+	out := make(map[uuid.UUID]*Iteration)
+	byID := make(map[uuid.UUID]*Iteration)
+	for rows.Next() {
+		var itemID uuid.UUID
+		it := &Iteration{}
+		if err := rows.Scan(&itemID, &it.ID, &it.Name, &it.StartsAt, &it.EndsAt, &it.Capacity, &it.CapacityUnit, &it.CreatedAt, &it.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan commitment: %w", err)
+		}
+		if seen := byID[it.ID]; seen != nil {
+			it = seen
+		} else {
+			byID[it.ID] = it
+		}
+		out[itemID] = it
+	}
+	return out, rows.Err()
+}
+
+// CommitIterationItems commits items in one transaction. Items already
+// committed to the iteration are left as they were. It fails with an
+// IterationConflictError, committing nothing, if any item is committed to
+// another open iteration.
+func (s *PostgresStore) CommitIterationItems(ctx context.Context, items []*IterationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.BacklogItemID)
+	}
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, iterationLockKey); err != nil {
+			return fmt.Errorf("lock iterations: %w", err)
+		}
+		rows, err := tx.Query(ctx, openCommitmentsQuery+` AND ii.backlog_item_id = ANY($2)`, items[0].IterationID, ids)
+		if err != nil {
+			return fmt.Errorf("query open commitments: %w", err)
+		}
+		elsewhere, err := scanCommitments(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if other := elsewhere[id]; other != nil {
+				return &IterationConflictError{ItemID: id, Iteration: other}
+			}
+		}
+
+		for _, item := range items {
+			err := tx.QueryRow(ctx, `
+				INSERT INTO iteration_items (iteration_id, backlog_item_id, committed_by)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (iteration_id, backlog_item_id) DO UPDATE SET committed_by = iteration_items.committed_by
+				RETURNING committed_by, committed_at`,
+				item.IterationID, item.BacklogItemID, item.CommittedBy,
+			).Scan(&item.CommittedBy, &item.CommittedAt)
+			if err != nil {
+				return fmt.Errorf("commit item %s: %w", item.BacklogItemID, err)
+			}
+		}
+		if _, err := tx.Exec(ctx, `UPDATE iterations SET updated_at = NOW() WHERE id = $1`, items[0].IterationID); err != nil {
+			return fmt.Errorf("touch iteration: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *PostgresStore) UncommitIterationItems(ctx context.Context, iterationID uuid.UUID, itemIDs []uuid.UUID) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM iteration_items WHERE iteration_id = $1 AND backlog_item_id = ANY($2)`,
+			iterationID, itemIDs,
+		); err != nil {
+			return fmt.Errorf("uncommit items: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE iterations SET updated_at = NOW() WHERE id = $1`, iterationID); err != nil {
+			return fmt.Errorf("touch iteration: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListIterationItems returns the items committed to an iteration, with
+// their backlog items, in the order they were committed.
+func (s *PostgresStore) ListIterationItems(ctx context.Context, iterationID uuid.UUID) ([]*IterationItem, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT ii.iteration_id, ii.backlog_item_id, ii.committed_by, ii.committed_at, `+backlogItemColumns+`
+		FROM iteration_items ii
+		JOIN backlog_items ON backlog_items.id = ii.backlog_item_id
+		WHERE ii.iteration_id = $1
+		ORDER BY ii.committed_at, ii.backlog_item_id`, iterationID)
+	if err != nil {
+		return nil, fmt.Errorf("query iteration items: %w", err)
+	}
+	defer rows.Close()
+	var out []*IterationItem
+	for rows.Next() {
+		c := &IterationItem{}
+		item, err := scanBacklogItem(extraColumnsRow{Rows: rows, before: []interface{}{&c.IterationID, &c.BacklogItemID, &c.CommittedBy, &c.CommittedAt}})
+		if err != nil {
+			return nil, fmt.Errorf("scan iteration item: %w", err)
+		}
+		c.Item = item
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
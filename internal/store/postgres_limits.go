@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetAgentLimit creates or replaces the concurrency override for an agent.
+func (s *PostgresStore) SetAgentLimit(ctx context.Context, l *AgentLimit) error {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO agent_concurrency_limits (agent_slug, max_concurrent)
+		VALUES ($1, $2)
+		ON CONFLICT (agent_slug) DO UPDATE SET
+			max_concurrent = EXCLUDED.max_concurrent,
+			updated_at = NOW()
+		RETURNING updated_at`,
+		l.AgentSlug, l.MaxConcurrent,
+	).Scan(&l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("set agent limit: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListAgentLimits(ctx context.Context) ([]*AgentLimit, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT agent_slug, max_concurrent, updated_at
+		FROM agent_concurrency_limits
+		ORDER BY agent_slug`)
+	if err != nil {
+		return nil, fmt.Errorf("query agent limits: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*AgentLimit
+	for rows.Next() {
+		l := &AgentLimit{}
+		if err := rows.Scan(&l.AgentSlug, &l.MaxConcurrent, &l.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan agent limit: %w", err)
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func (s *PostgresStore) DeleteAgentLimit(ctx context.Context, agentSlug string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM agent_concurrency_limits WHERE agent_slug = $1`, agentSlug)
+	return err
+}
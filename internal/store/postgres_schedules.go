@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const scheduleColumns = `id, name, cron, timezone, template, overlap, catch_up, paused,
+	next_run_at, queued_run_at, last_run_at, last_task_id, skipped_runs, missed_runs,
+	created_at, updated_at`
+
+func (s *PostgresStore) CreateSchedule(ctx context.Context, sc *Schedule) error {
+	templateJSON, _ := json.Marshal(sc.Template)
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO dispatch_schedules (name, cron, timezone, template, overlap, catch_up, paused, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`,
+		sc.Name, sc.Cron, sc.Timezone, templateJSON, sc.Overlap, sc.CatchUp, sc.Paused, sc.NextRunAt,
+	).Scan(&sc.ID, &sc.CreatedAt, &sc.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create schedule: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetSchedule(ctx context.Context, id uuid.UUID) (*Schedule, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM dispatch_schedules WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query schedule: %w", err)
+	}
+	defer rows.Close()
+	out, err := scanSchedules(rows)
+	if err != nil || len(out) == 0 {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (s *PostgresStore) ListSchedules(ctx context.Context) ([]*Schedule, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM dispatch_schedules
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("query schedules: %w", err)
+	}
+	defer rows.Close()
+	return scanSchedules(rows)
+}
+
+func (s *PostgresStore) UpdateSchedule(ctx context.Context, sc *Schedule) error {
+	templateJSON, _ := json.Marshal(sc.Template)
+	err := s.pool.QueryRow(ctx, `
+		UPDATE dispatch_schedules SET
+			name = $2, cron = $3, timezone = $4, template = $5, overlap = $6, catch_up = $7, paused = $8,
+			next_run_at = $9, queued_run_at = $10, last_run_at = $11, last_task_id = $12,
+			skipped_runs = $13, missed_runs = $14, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		sc.ID, sc.Name, sc.Cron, sc.Timezone, templateJSON, sc.Overlap, sc.CatchUp, sc.Paused,
+		sc.NextRunAt, sc.QueuedRunAt, sc.LastRunAt, sc.LastTaskID,
+		sc.SkippedRuns, sc.MissedRuns,
+	).Scan(&sc.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("update schedule: %w", err)
+	}
+	return nil
+}
+
+// ErrScheduleChanged is returned by UpdateScheduleRun when the schedule was
+// updated by someone else after it was read.
+var ErrScheduleChanged = errors.New("schedule changed concurrently")
+
+// UpdateScheduleRun writes the scheduler's run state: the next and queued
+// runs, the last run and task, and the counters. The definition is left as
+// it is, and the write fails with ErrScheduleChanged if the schedule was
+// updated since it was read at sc.UpdatedAt.
+func (s *PostgresStore) UpdateScheduleRun(ctx context.Context, sc *Schedule) error {
+	err := s.pool.QueryRow(ctx, `
+		UPDATE dispatch_schedules SET
+			next_run_at = $2, queued_run_at = $3, last_run_at = $4, last_task_id = $5,
+			skipped_runs = $6, missed_runs = $7, updated_at = NOW()
+		WHERE id = $1 AND updated_at = $8
+		RETURNING updated_at`,
+		sc.ID, sc.NextRunAt, sc.QueuedRunAt, sc.LastRunAt, sc.LastTaskID,
+		sc.SkippedRuns, sc.MissedRuns, sc.UpdatedAt,
+	).Scan(&sc.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("update schedule %s run: %w", sc.ID, ErrScheduleChanged)
+	}
+	if err != nil {
+		return fmt.Errorf("update schedule run: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM dispatch_schedules WHERE id = $1`, id)
+	return err
+}
+
+// GetDueSchedules returns the unpaused schedules with a run due at now or
+// a run held by the queue overlap policy.
+func (s *PostgresStore) GetDueSchedules(ctx context.Context, now time.Time) ([]*Schedule, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM dispatch_schedules
+		WHERE NOT paused AND (next_run_at <= $1 OR queued_run_at IS NOT NULL)
+		ORDER BY next_run_at`, now)
+	if err != nil {
+		return nil, fmt.Errorf("query due schedules: %w", err)
+	}
+	defer rows.Close()
+	return scanSchedules(rows)
+}
+
+func scanSchedules(rows pgx.Rows) ([]*Schedule, error) {
+	var out []*Schedule
+	for rows.Next() {
+		sc := &Schedule{}
+		var templateJSON []byte
+		if err := rows.Scan(&sc.ID, &sc.Name, &sc.Cron, &sc.Timezone, &templateJSON, &sc.Overlap, &sc.CatchUp, &sc.Paused,
+			&sc.NextRunAt, &sc.QueuedRunAt, &sc.LastRunAt, &sc.LastTaskID, &sc.SkippedRuns, &sc.MissedRuns,
+			&sc.CreatedAt, &sc.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan schedule: %w", err)
+		}
+		if err := json.Unmarshal(templateJSON, &sc.Template); err != nil {
+			return nil, fmt.Errorf("decode schedule template: %w", err)
+		}
+		out = append(out, sc)
+	}
+	return out, rows.Err()
+}
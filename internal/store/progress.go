@@ -0,0 +1,72 @@
+package store
+
+import "time"
+
+// TaskProgress is the latest structured progress snapshot reported by the
+// agent working a task. It is stored on the task row so callers do not need
+// to scan the event log.
+type TaskProgress struct {
+	Percent    *float64  `json:"percent,omitempty"`
+	Stage      string    `json:"stage,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	ETASeconds *int      `json:"eta_seconds,omitempty"`
+	AgentID    string    `json:"agent_id,omitempty"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// ParseProgress builds a TaskProgress from a loosely typed progress payload.
+// It accepts the typed fields (percent, stage, message, eta_seconds) and the
+// legacy "progress" fraction and "detail" fields older agents still send.
+// Returns nil if the payload carries no progress information.
+func ParseProgress(payload map[string]interface{}, agentID string, at time.Time) *TaskProgress {
+	if payload == nil {
+		return nil
+	}
+	p := &TaskProgress{AgentID: agentID, ReportedAt: at}
+	found := false
+
+	if v, ok := payload["percent"].(float64); ok {
+		pct := clampPercent(v)
+		p.Percent = &pct
+		found = true
+	} else if v, ok := payload["progress"].(float64); ok {
+		// Legacy fraction in [0,1]; larger values are already percentages.
+		if v <= 1 {
+			v *= 100
+		}
+		pct := clampPercent(v)
+		p.Percent = &pct
+		found = true
+	}
+	if v, ok := payload["stage"].(string); ok && v != "" {
+		p.Stage = v
+		found = true
+	}
+	if v, ok := payload["message"].(string); ok && v != "" {
+		p.Message = v
+		found = true
+	} else if v, ok := payload["detail"].(string); ok && v != "" {
+		p.Message = v
+		found = true
+	}
+	if v, ok := payload["eta_seconds"].(float64); ok && v >= 0 {
+		eta := int(v)
+		p.ETASeconds = &eta
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return p
+}
+
+func clampPercent(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 100 {
+		return 100
+	}
+	return v
+}
@@ -18,6 +18,28 @@ const (
 	StatusTimedOut   TaskStatus = "timed_out"
 )
 
+// Failure classes an agent may report when it fails a task.
+const (
+	FailureTransient  = "transient"              // may succeed if simply retried
+	FailureAgentFault = "agent_fault"            // the agent itself misbehaved
+	FailureTaskSpec   = "task_spec"              // the task cannot be done as specified
+	FailureDependency = "dependency_unavailable" // a service the task needs is down
+	FailureQuota      = "quota"                  // the agent ran out of quota or rate limit
+)
+
+// ValidFailureClass reports whether c is a known failure class.
+func ValidFailureClass(c string) bool {
+	switch c {
+	case FailureTransient, FailureAgentFault, FailureTaskSpec, FailureDependency, FailureQuota:
+		return true
+	}
+	return false
+}
+
+// SubStateWaking marks an assigned task whose agent is being woken. The
+// assignment is not announced until the agent reports ready.
+const SubStateWaking = "waking"
+
 type Task struct {
 	ID                   uuid.UUID              `json:"task_id"`
 	Title                string                 `json:"title"`
@@ -37,8 +59,9 @@ type Task struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 
 	// Result
-	Result map[string]interface{} `json:"result,omitempty"`
-	Error  string                 `json:"error,omitempty"`
+	Result       map[string]interface{} `json:"result,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	FailureClass string                 `json:"failure_class,omitempty"`
 
 	// Retry
 	RetryCount    int  `json:"retry_count"`
@@ -80,15 +103,54 @@ type Task struct {
 	ModelTier        string   `json:"model_tier,omitempty"`
 	RoutingMethod    string   `json:"routing_method,omitempty"`
 	Runtime          string   `json:"runtime,omitempty"`
+
+	// Liveness
+	Progress        *TaskProgress `json:"progress,omitempty"`
+	LastHeartbeatAt *time.Time    `json:"last_heartbeat_at,omitempty"`
+
+	// Lease
+	AckedAt        *time.Time `json:"acked_at,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+
+	// SubState refines Status while a task is assigned (e.g. "waking").
+	SubState string `json:"sub_state,omitempty"`
+
+	// Preemptible tasks may be checkpointed and requeued to make room for
+	// urgent work.
+	Preemptible bool `json:"preemptible"`
+
+	// Placement. RequiredAgent pins the task and ExcludedAgents are never
+	// used; PreferredAgents and the agent that last ran a task in the same
+	// AffinityGroup are favoured through the contextuality factor.
+	PreferredAgents []string `json:"preferred_agents,omitempty"`
+	RequiredAgent   string   `json:"required_agent,omitempty"`
+	ExcludedAgents  []string `json:"excluded_agents,omitempty"`
+	AffinityGroup   string   `json:"affinity_group,omitempty"`
+
+	// SLA. Deadline is when the task must be done by; the broker records
+	// when it first estimated the task would miss it and when it did.
+	Deadline         *time.Time `json:"deadline,omitempty"`
+	SLAAtRiskAt      *time.Time `json:"sla_at_risk_at,omitempty"`
+	DeadlineMissedAt *time.Time `json:"deadline_missed_at,omitempty"`
+
+	// MinModelTier is the cheapest model tier the task may be routed to,
+	// raised by escalation after repeated failures.
+	MinModelTier string `json:"min_model_tier,omitempty"`
+
+	// BacklogItemID and BacklogStage link a task dispatched for a backlog
+	// item's stage back to the item.
+	BacklogItemID *uuid.UUID `json:"backlog_item_id,omitempty"`
+	BacklogStage  string     `json:"backlog_stage,omitempty"`
 }
 
 type TaskFilter struct {
-	Status *TaskStatus
-	Owner  string
-	Agent  string
-	Source string
-	Limit  int
-	Offset int
+	Status        *TaskStatus
+	Owner         string
+	Agent         string
+	Source        string
+	BacklogItemID *uuid.UUID
+	Limit         int
+	Offset        int
 }
 
 type TaskEvent struct {
@@ -108,6 +170,15 @@ type TaskStats struct {
 	AvgCompletionMs float64 `json:"avg_completion_ms"`
 }
 
+// OwnerSLA is one owner's deadline attainment: tasks that completed by
+// their deadline against those that finished late, failed or are overdue.
+type OwnerSLA struct {
+	Owner      string  `json:"owner"`
+	Met        int     `json:"met"`
+	Missed     int     `json:"missed"`
+	Attainment float64 `json:"attainment"` // met / (met + missed)
+}
+
 // --- Stage templates ---
 
 var StageTemplates = map[string][]string{
@@ -149,6 +220,16 @@ const (
 	BacklogStatusCancelled   BacklogStatus = "cancelled"
 )
 
+// ValidBacklogStatus reports whether s is a known backlog status.
+func ValidBacklogStatus(s BacklogStatus) bool {
+	switch s {
+	case BacklogStatusBacklog, BacklogStatusReady, BacklogStatusInDiscovery, BacklogStatusPlanned,
+		BacklogStatusInProgress, BacklogStatusReview, BacklogStatusBlocked, BacklogStatusDone, BacklogStatusCancelled:
+		return true
+	}
+	return false
+}
+
 type BacklogItem struct {
 	ID          uuid.UUID     `json:"id"`
 	Title       string        `json:"title"`
@@ -186,6 +267,13 @@ type BacklogItem struct {
 	PRURL      string `json:"pr_url,omitempty"`
 	BranchName string `json:"branch_name,omitempty"`
 
+	// DueDate raises the item's urgency as it approaches.
+	DueDate *time.Time `json:"due_date,omitempty"`
+
+	// ExternalID identifies the item outside Dispatch, such as in an
+	// imported file. Imports update the item with a matching ExternalID.
+	ExternalID string `json:"external_id,omitempty"`
+
 	// Metadata
 	Source    string                 `json:"source,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
@@ -193,6 +281,10 @@ type BacklogItem struct {
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// SearchRank is how well the item matched a full-text query, set only
+	// when a list is sorted by relevance.
+	SearchRank *float64 `json:"search_rank,omitempty"`
 }
 
 type BacklogFilter struct {
@@ -203,6 +295,30 @@ type BacklogFilter struct {
 	ParentID *uuid.UUID
 	Limit    int
 	Offset   int
+
+	// Query is a full-text search over title and description, in web
+	// search syntax: quoted phrases, "or" and -excluded words.
+	Query string
+	// Labels matches items with any of the labels, or all of them with
+	// AllLabels.
+	Labels        []string
+	AllLabels     bool
+	ModelTier     string
+	MinScore      *float64
+	MaxScore      *float64
+	OneWayDoor    *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// HasBlockers matches items with (true) or without (false) unresolved
+	// blockers.
+	HasBlockers *bool
+
+	// Sort is one of the BacklogSort orders. With Cursor, listing resumes
+	// after the cursor's item instead of skipping Offset items.
+	Sort   string
+	Cursor *BacklogCursor
 }
 
 type BacklogDependency struct {
@@ -213,6 +329,26 @@ type BacklogDependency struct {
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
+// BacklogImport is a planned import. Create items are inserted in order, so
+// a parent must come before its children; they may carry their IDs already
+// so that Update items and Dependencies can refer to them. Update items
+// must carry the UpdatedAt they were read with.
+type BacklogImport struct {
+	Create       []*BacklogItem
+	Update       []*BacklogItem
+	Dependencies []*BacklogDependency
+}
+
+// BacklogScoreChange records a backlog item's priority score changing.
+type BacklogScoreChange struct {
+	ID            uuid.UUID `json:"id"`
+	BacklogItemID uuid.UUID `json:"backlog_item_id"`
+	PreviousScore *float64  `json:"previous_score,omitempty"`
+	NewScore      float64   `json:"new_score"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
 type DispatchOverride struct {
 	ID            uuid.UUID  `json:"id"`
 	BacklogItemID *uuid.UUID `json:"backlog_item_id,omitempty"`
@@ -285,6 +421,17 @@ type ScoreFn func(item *BacklogItem, hasUnresolvedDeps bool, medianTokens int64)
 // TierFn derives a model tier from a backlog item.
 type TierFn func(item *BacklogItem) string
 
+// ChildCounts tallies a backlog item's children by status.
+type ChildCounts struct {
+	Open      int // neither done nor cancelled
+	Done      int
+	Cancelled int
+}
+
+// DependencyCheck vets a new dependency against every existing one. A
+// non-nil error stops the dependency being added and is returned as is.
+type DependencyCheck func(existing []*BacklogDependency) error
+
 type AgentTaskHistory struct {
 	ID              uuid.UUID  `json:"id"`
 	AgentSlug       string     `json:"agent_slug"`
@@ -295,48 +442,247 @@ type AgentTaskHistory struct {
 	TokensUsed      *int64     `json:"tokens_used,omitempty"`
 	CostUSD         *float64   `json:"cost_usd,omitempty"`
 	Success         *bool      `json:"success,omitempty"`
+	FailureClass    string     `json:"failure_class,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 }
 
+// AgentFailureStats counts an agent's recorded attempts and its failures by
+// failure class. Failures reported without a class are not counted by class.
+type AgentFailureStats struct {
+	Attempts int            `json:"attempts"`
+	Failures map[string]int `json:"failures"`
+}
+
+// FaultRate is the share of attempts that failed through the agent's own
+// fault.
+func (a *AgentFailureStats) FaultRate() float64 {
+	if a.Attempts == 0 {
+		return 0
+	}
+	return float64(a.Failures[FailureAgentFault]) / float64(a.Attempts)
+}
+
+// Budget scopes, periods and exhaustion actions.
+const (
+	BudgetScopeOwner  = "owner"
+	BudgetScopeSource = "source"
+
+	BudgetPeriodDay   = "day"
+	BudgetPeriodMonth = "month"
+
+	BudgetActionReject    = "reject"
+	BudgetActionQueue     = "queue"
+	BudgetActionDowngrade = "downgrade"
+)
+
+// Budget limits the tasks, tokens or USD an owner or source may consume per
+// day or month. Nil limits are not enforced.
+type Budget struct {
+	ID                 uuid.UUID  `json:"id"`
+	Scope              string     `json:"scope"`
+	Subject            string     `json:"subject"`
+	Period             string     `json:"period"`
+	MaxTasks           *int       `json:"max_tasks,omitempty"`
+	MaxTokens          *int64     `json:"max_tokens,omitempty"`
+	MaxCostUSD         *float64   `json:"max_cost_usd,omitempty"`
+	Action             string     `json:"action"`
+	AlertedThreshold   int        `json:"alerted_threshold"`
+	AlertedPeriodStart *time.Time `json:"alerted_period_start,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// BudgetUsage is what a budget's owner or source has consumed in the current
+// period. Tasks counts tasks created; Dispatched counts assignments; tokens
+// and cost come from completed work recorded in agent_task_history.
+type BudgetUsage struct {
+	Tasks      int     `json:"tasks"`
+	Dispatched int     `json:"dispatched"`
+	Tokens     int64   `json:"tokens"`
+	CostUSD    float64 `json:"cost_usd"`
+}
+
+// AgentLimit is an admin override of an agent's concurrency limit. It takes
+// precedence over the persona's own limit and the configured default.
+type AgentLimit struct {
+	AgentSlug     string    `json:"agent_slug"`
+	MaxConcurrent int       `json:"max_concurrent"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Overlap policies decide what a schedule does when a run comes due while
+// the task from its previous run is still active.
+const (
+	OverlapSkip  = "skip"  // drop the run
+	OverlapQueue = "queue" // hold one run until the previous task finishes
+	OverlapAllow = "allow" // run anyway
+)
+
+// TaskTemplate is the task a schedule creates on each run. It mirrors the
+// task creation request.
+type TaskTemplate struct {
+	Title                string                 `json:"title"`
+	Description          string                 `json:"description,omitempty"`
+	Owner                string                 `json:"owner,omitempty"`
+	RequiredCapabilities []string               `json:"required_capabilities,omitempty"`
+	Priority             int                    `json:"priority,omitempty"`
+	Metadata             map[string]interface{} `json:"metadata,omitempty"`
+	TimeoutSeconds       int                    `json:"timeout_seconds,omitempty"`
+	MaxRetries           int                    `json:"max_retries,omitempty"`
+	Preemptible          bool                   `json:"preemptible,omitempty"`
+	PreferredAgents      []string               `json:"preferred_agents,omitempty"`
+	RequiredAgent        string                 `json:"required_agent,omitempty"`
+	ExcludedAgents       []string               `json:"excluded_agents,omitempty"`
+	AffinityGroup        string                 `json:"affinity_group,omitempty"`
+}
+
+// Schedule creates a task from Template each time Cron fires in Timezone.
+type Schedule struct {
+	ID       uuid.UUID    `json:"id"`
+	Name     string       `json:"name"`
+	Cron     string       `json:"cron"`
+	Timezone string       `json:"timezone"`
+	Template TaskTemplate `json:"task"`
+	Overlap  string       `json:"overlap"`
+	CatchUp  string       `json:"catch_up,omitempty"` // empty uses the configured default
+	Paused   bool         `json:"paused"`
+
+	NextRunAt   time.Time  `json:"next_run_at"`
+	QueuedRunAt *time.Time `json:"queued_run_at,omitempty"` // run held by the queue overlap policy
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	LastTaskID  *uuid.UUID `json:"last_task_id,omitempty"`
+	SkippedRuns int        `json:"skipped_runs"` // dropped by the overlap policy
+	MissedRuns  int        `json:"missed_runs"`  // dropped by the catch-up policy
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Source is the task source of tasks created by the schedule.
+func (s *Schedule) Source() string {
+	return "schedule:" + s.Name
+}
+
+// Iteration capacity units.
+const (
+	CapacityTokens = "tokens" // estimated tokens
+	CapacityPoints = "points" // effort points
+)
+
+// Iteration is a planning horizon: backlog items committed to be done
+// between StartsAt and EndsAt, within Capacity.
+type Iteration struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	Capacity     float64   `json:"capacity"`
+	CapacityUnit string    `json:"capacity_unit"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Open reports whether the iteration has not ended by now.
+func (it *Iteration) Open(now time.Time) bool {
+	return now.Before(it.EndsAt)
+}
+
+// IterationItem is a backlog item committed to an iteration.
+type IterationItem struct {
+	IterationID   uuid.UUID `json:"iteration_id"`
+	BacklogItemID uuid.UUID `json:"backlog_item_id"`
+	CommittedBy   string    `json:"committed_by"`
+	CommittedAt   time.Time `json:"committed_at"`
+
+	// Item is the committed backlog item, as ListIterationItems loads it.
+	Item *BacklogItem `json:"-"`
+}
+
 type Store interface {
 	CreateTask(ctx context.Context, task *Task) error
 	GetTask(ctx context.Context, id uuid.UUID) (*Task, error)
 	ListTasks(ctx context.Context, filter TaskFilter) ([]*Task, error)
 	UpdateTask(ctx context.Context, task *Task) error
+	// UpdateTaskGuarded writes task only if the stored task still matches
+	// guard, failing with ErrTaskChanged otherwise.
+	UpdateTaskGuarded(ctx context.Context, task *Task, guard TaskGuard) error
+	// UpdateTaskLiveness writes only the task's progress, heartbeat and
+	// lease, skipping the change history. Tasks that are no longer assigned
+	// or in progress are left alone.
+	UpdateTaskLiveness(ctx context.Context, task *Task) error
 
 	GetPendingTasks(ctx context.Context) ([]*Task, error)
 	GetActiveTasksForAgent(ctx context.Context, agentID string) ([]*Task, error)
 	GetActiveTasks(ctx context.Context) ([]*Task, error)
+	GetAffinityGroupAgent(ctx context.Context, group string) (string, error)
+	GetDeadlineTasks(ctx context.Context) ([]*Task, error)
 
 	CreateTaskEvent(ctx context.Context, event *TaskEvent) error
 	GetTaskEvents(ctx context.Context, taskID uuid.UUID) ([]*TaskEvent, error)
 
 	GetStats(ctx context.Context) (*TaskStats, error)
+	GetSLAStats(ctx context.Context) ([]*OwnerSLA, error)
 
 	CreateAgentTaskHistory(ctx context.Context, h *AgentTaskHistory) error
 	GetAgentTaskHistory(ctx context.Context, agentSlug string, limit int) ([]*AgentTaskHistory, error)
+	GetAgentFailureStats(ctx context.Context, agentSlug string) (*AgentFailureStats, error)
 	GetAgentAvgDuration(ctx context.Context, agentSlug string) (*float64, error)
 	GetAgentAvgCost(ctx context.Context, agentSlug string) (*float64, error)
 
 	GetTrustScore(ctx context.Context, agentSlug, category, severity string) (float64, error)
 
+	// Budgets
+	UpsertBudget(ctx context.Context, b *Budget) error
+	ListBudgets(ctx context.Context) ([]*Budget, error)
+	DeleteBudget(ctx context.Context, id uuid.UUID) error
+	GetBudgetUsage(ctx context.Context, scope, subject string, since time.Time) (*BudgetUsage, error)
+	MarkBudgetAlerted(ctx context.Context, id uuid.UUID, periodStart time.Time, threshold int) error
+
+	// Agent concurrency overrides
+	SetAgentLimit(ctx context.Context, l *AgentLimit) error
+	ListAgentLimits(ctx context.Context) ([]*AgentLimit, error)
+	DeleteAgentLimit(ctx context.Context, agentSlug string) error
+
+	// Schedules
+	CreateSchedule(ctx context.Context, s *Schedule) error
+	GetSchedule(ctx context.Context, id uuid.UUID) (*Schedule, error)
+	ListSchedules(ctx context.Context) ([]*Schedule, error)
+	UpdateSchedule(ctx context.Context, s *Schedule) error
+	UpdateScheduleRun(ctx context.Context, s *Schedule) error
+	DeleteSchedule(ctx context.Context, id uuid.UUID) error
+	GetDueSchedules(ctx context.Context, now time.Time) ([]*Schedule, error)
+
 	// Backlog
 	CreateBacklogItem(ctx context.Context, item *BacklogItem) error
 	GetBacklogItem(ctx context.Context, id uuid.UUID) (*BacklogItem, error)
+	GetBacklogItemByExternalID(ctx context.Context, externalID string) (*BacklogItem, error)
+	GetBacklogItems(ctx context.Context, ids []uuid.UUID) ([]*BacklogItem, error)
+	ListBacklogSubtree(ctx context.Context, rootID uuid.UUID) ([]*BacklogItem, error)
 	ListBacklogItems(ctx context.Context, filter BacklogFilter) ([]*BacklogItem, error)
 	UpdateBacklogItem(ctx context.Context, item *BacklogItem) error
+	UpdateBacklogItemScore(ctx context.Context, item *BacklogItem, score float64) error
 	DeleteBacklogItem(ctx context.Context, id uuid.UUID) error
 	GetNextBacklogItems(ctx context.Context, limit int) ([]*BacklogItem, error)
+	ListOpenBacklogItems(ctx context.Context) ([]*BacklogItem, error)
+	CountBacklogChildren(ctx context.Context, parentID uuid.UUID) (ChildCounts, error)
+
+	// Score history
+	CreateScoreChange(ctx context.Context, c *BacklogScoreChange) error
+	ListScoreHistory(ctx context.Context, itemID uuid.UUID, limit int) ([]*BacklogScoreChange, error)
 
 	// Dependencies
 	CreateDependency(ctx context.Context, dep *BacklogDependency) error
+	CreateDependencyChecked(ctx context.Context, dep *BacklogDependency, check DependencyCheck) error
 	DeleteDependency(ctx context.Context, id uuid.UUID) error
 	GetDependenciesForItem(ctx context.Context, itemID uuid.UUID) ([]*BacklogDependency, error)
+	ListDependencies(ctx context.Context) ([]*BacklogDependency, error)
 	HasUnresolvedBlockers(ctx context.Context, itemID uuid.UUID) (bool, error)
+	ListBlockedBacklogItemIDs(ctx context.Context) (map[uuid.UUID]bool, error)
 	ResolveDependenciesForBlocker(ctx context.Context, blockerID uuid.UUID) error
 
 	// Overrides
 	CreateOverride(ctx context.Context, o *DispatchOverride) error
+	GetOverridesForTask(ctx context.Context, taskID uuid.UUID) ([]*DispatchOverride, error)
 
 	// Autonomy
 	CreateAutonomyEvent(ctx context.Context, e *AutonomyEvent) error
@@ -345,6 +691,27 @@ type Store interface {
 	// Discovery (transactional)
 	BacklogDiscoveryComplete(ctx context.Context, itemID uuid.UUID, req *BacklogDiscoveryCompleteRequest, scoreFn ScoreFn, tierFn TierFn) (*BacklogDiscoveryCompleteResult, error)
 
+	// Bulk update (transactional)
+	BulkUpdateBacklogItems(ctx context.Context, items []*BacklogItem, overrides []*DispatchOverride) error
+
+	// Iterations
+	CreateIteration(ctx context.Context, it *Iteration) error
+	GetIteration(ctx context.Context, id uuid.UUID) (*Iteration, error)
+	ListIterations(ctx context.Context) ([]*Iteration, error)
+	DeleteIteration(ctx context.Context, id uuid.UUID) error
+	CommitIterationItems(ctx context.Context, items []*IterationItem) error
+	UncommitIterationItems(ctx context.Context, iterationID uuid.UUID, itemIDs []uuid.UUID) error
+	ListIterationItems(ctx context.Context, iterationID uuid.UUID) ([]*IterationItem, error)
+	// ListOpenCommitments returns, by backlog item, the open iteration
+	// other than exceptID that each item is committed to.
+	ListOpenCommitments(ctx context.Context, exceptID uuid.UUID) (map[uuid.UUID]*Iteration, error)
+
+	// Import (transactional)
+	ImportBacklog(ctx context.Context, imp *BacklogImport) error
+
+	// Change history, recorded by every backlog item and task write
+	ListChangeHistory(ctx context.Context, filter ChangeHistoryFilter) ([]*ChangeRecord, error)
+
 	// Stage operations
 	InitStages(ctx context.Context, itemID uuid.UUID, template []string) error
 	GetCurrentStage(ctx context.Context, itemID uuid.UUID) (string, int, error)
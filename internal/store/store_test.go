@@ -1,7 +1,12 @@
 package store
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestTaskStatusValues(t *testing.T) {
@@ -42,3 +47,264 @@ func TestTaskFields(t *testing.T) {
 		t.Error("expected source to be set")
 	}
 }
+
+func TestParseProgressTyped(t *testing.T) {
+	now := time.Now()
+	p := ParseProgress(map[string]interface{}{
+		"percent":     55.0,
+		"stage":       "build",
+		"message":     "compiling",
+		"eta_seconds": 30.0,
+	}, "nova", now)
+	if p == nil {
+		t.Fatal("expected progress")
+	}
+	if *p.Percent != 55 || p.Stage != "build" || p.Message != "compiling" || *p.ETASeconds != 30 {
+		t.Errorf("unexpected progress %+v", p)
+	}
+	if p.AgentID != "nova" || !p.ReportedAt.Equal(now) {
+		t.Errorf("expected agent and timestamp recorded, got %+v", p)
+	}
+}
+
+func TestParseProgressLegacyFields(t *testing.T) {
+	p := ParseProgress(map[string]interface{}{"progress": 0.5, "detail": "halfway"}, "", time.Now())
+	if p == nil || p.Percent == nil || *p.Percent != 50 {
+		t.Fatalf("expected legacy fraction converted to 50%%, got %+v", p)
+	}
+	if p.Message != "halfway" {
+		t.Errorf("expected detail used as message, got %q", p.Message)
+	}
+
+	p = ParseProgress(map[string]interface{}{"percent": 150.0}, "", time.Now())
+	if *p.Percent != 100 {
+		t.Errorf("expected percent clamped to 100, got %f", *p.Percent)
+	}
+}
+
+func TestParseProgressEmpty(t *testing.T) {
+	if p := ParseProgress(nil, "", time.Now()); p != nil {
+		t.Errorf("expected nil for nil payload, got %+v", p)
+	}
+	if p := ParseProgress(map[string]interface{}{"task_id": "x"}, "", time.Now()); p != nil {
+		t.Errorf("expected nil without progress fields, got %+v", p)
+	}
+}
+
+func TestBudgetPeriodStart(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	day := &Budget{Period: BudgetPeriodDay}
+	if got := day.PeriodStart(now); !got.Equal(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected day start %v", got)
+	}
+	month := &Budget{Period: BudgetPeriodMonth}
+	if got := month.PeriodStart(now); !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected month start %v", got)
+	}
+}
+
+func TestBudgetExceededAndFraction(t *testing.T) {
+	maxTasks, maxTokens, maxCost := 10, int64(1000), 5.0
+	b := &Budget{Scope: BudgetScopeOwner, Subject: "mike-d", MaxTasks: &maxTasks, MaxTokens: &maxTokens, MaxCostUSD: &maxCost}
+	est := int64(300)
+	task := &Task{Owner: "mike-d", CostEstimateTokens: &est}
+
+	if !b.Applies(task) || b.Applies(&Task{Owner: "kai"}) {
+		t.Error("expected budget to apply to its owner only")
+	}
+	if got := b.Exceeded(9, BudgetUsage{Tokens: 700}, task); len(got) != 0 {
+		t.Errorf("expected no limits exceeded, got %v", got)
+	}
+	got := b.Exceeded(10, BudgetUsage{Tokens: 701, CostUSD: 5}, task)
+	if len(got) != 3 || got[0] != "tasks" || got[1] != "tokens" || got[2] != "cost_usd" {
+		t.Errorf("expected all limits exceeded, got %v", got)
+	}
+	if f := b.Fraction(BudgetUsage{Tasks: 2, Tokens: 800, CostUSD: 1}); f != 0.8 {
+		t.Errorf("expected fraction 0.8, got %f", f)
+	}
+}
+
+func TestBacklogCursorRoundTrip(t *testing.T) {
+	score := 0.42
+	item := &BacklogItem{ID: uuid.New(), PriorityScore: &score, CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+
+	c, err := DecodeBacklogCursor(NewBacklogCursor("", item).Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Sort != BacklogSortPriority || c.Score == nil || *c.Score != score || c.ID != item.ID || !c.CreatedAt.Equal(item.CreatedAt) {
+		t.Errorf("cursor did not round-trip: %+v", c)
+	}
+
+	// An item without a due date has no sort key.
+	c, _ = DecodeBacklogCursor(NewBacklogCursor(BacklogSortDueDate, item).Encode())
+	if c == nil || c.Time != nil || c.Score != nil {
+		t.Errorf("expected a due date cursor without a key, got %+v", c)
+	}
+
+	for _, bad := range []string{"", "not base64!", "e30"} {
+		if _, err := DecodeBacklogCursor(bad); err != ErrInvalidCursor {
+			t.Errorf("DecodeBacklogCursor(%q): expected ErrInvalidCursor, got %v", bad, err)
+		}
+	}
+}
+
+func TestBacklogListQuery(t *testing.T) {
+	yes := true
+	min := 0.5
+	query, args := backlogListQuery(BacklogFilter{
+		Query:       "auth -oauth",
+		Labels:      []string{"backend", "security"},
+		AllLabels:   true,
+		MinScore:    &min,
+		HasBlockers: &yes,
+		Sort:        BacklogSortUpdated,
+		Limit:       20,
+		Offset:      40,
+	})
+	for _, want := range []string{
+		"search_vector @@ websearch_to_tsquery('english', $1)",
+		"labels @> $2",
+		"priority_score >= $3",
+		"AND EXISTS (SELECT 1 FROM backlog_dependencies",
+		"ORDER BY updated_at DESC NULLS LAST, created_at ASC, id ASC LIMIT $4 OFFSET $5",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in %s", want, query)
+		}
+	}
+	if len(args) != 5 {
+		t.Errorf("expected 5 args, got %d", len(args))
+	}
+
+	// A cursor replaces the offset with a keyset predicate.
+	score := 0.7
+	cursor := NewBacklogCursor(BacklogSortPriority, &BacklogItem{ID: uuid.New(), PriorityScore: &score})
+	query, _ = backlogListQuery(BacklogFilter{Cursor: cursor, Offset: 40})
+	want := "AND (priority_score < $1 OR priority_score IS NULL OR (priority_score = $1 AND (created_at, id) > ($2, $3)))"
+	if !strings.Contains(query, want) || strings.Contains(query, "OFFSET") {
+		t.Errorf("expected a keyset predicate without offset, got %s", query)
+	}
+
+	// Relevance ranks by the query, selecting the rank for the cursor.
+	rank := 0.06
+	cursor = NewBacklogCursor(BacklogSortRelevance, &BacklogItem{ID: uuid.New(), SearchRank: &rank})
+	query, args = backlogListQuery(BacklogFilter{Query: "auth", Sort: BacklogSortRelevance, Cursor: cursor})
+	tsRank := "ts_rank(search_vector, websearch_to_tsquery('english', $1))"
+	for _, want := range []string{
+		"updated_at, " + tsRank + " FROM backlog_items",
+		"AND (" + tsRank + " < $2 OR (" + tsRank + " = $2 AND (created_at, id) > ($3, $4)))",
+		"ORDER BY " + tsRank + " DESC, created_at ASC, id ASC LIMIT $5",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in %s", want, query)
+		}
+	}
+	if args[1] != rank {
+		t.Errorf("expected the cursor's rank as the key, got %v", args[1])
+	}
+
+	// Without a query there is nothing to rank, so relevance falls back to
+	// priority.
+	query, _ = backlogListQuery(BacklogFilter{Sort: BacklogSortRelevance})
+	if strings.Contains(query, "ts_rank") || !strings.Contains(query, "ORDER BY priority_score DESC") {
+		t.Errorf("expected relevance without a query to sort by priority, got %s", query)
+	}
+
+	cursor = NewBacklogCursor(BacklogSortPriority, &BacklogItem{ID: uuid.New()})
+	query, _ = backlogListQuery(BacklogFilter{Cursor: cursor})
+	if !strings.Contains(query, "AND (priority_score IS NULL AND (created_at, id) > ($1, $2))") {
+		t.Errorf("expected an unscored cursor to continue among unscored items, got %s", query)
+	}
+}
+
+func TestNewChangeRecord(t *testing.T) {
+	ctx := WithActor(context.Background(), "alice")
+	impact := 0.4
+	before := &BacklogItem{ID: uuid.New(), Title: "Old", Status: BacklogStatusReady, Impact: &impact, UpdatedAt: time.Now()}
+	after := *before
+	after.Title = "New"
+	after.Impact = nil
+	after.Labels = []string{"infra"}
+	after.UpdatedAt = time.Now().Add(time.Second)
+
+	c := NewChangeRecord(ctx, EntityBacklogItem, before.ID, ChangeUpdated, before, &after)
+	if c == nil || c.Actor != "alice" || c.EntityID != before.ID {
+		t.Fatalf("unexpected change record: %+v", c)
+	}
+	if len(c.Changes) != 3 {
+		t.Errorf("expected title, impact and labels to change, got %v", c.Changes)
+	}
+	if fc := c.Changes["title"]; string(fc.Old) != `"Old"` || string(fc.New) != `"New"` {
+		t.Errorf("unexpected title change: %s -> %s", fc.Old, fc.New)
+	}
+	if fc := c.Changes["impact"]; string(fc.Old) != "0.4" || fc.New != nil {
+		t.Errorf("expected impact cleared, got %s -> %s", fc.Old, fc.New)
+	}
+
+	// Untracked fields alone are not a change.
+	touched := *before
+	touched.UpdatedAt = time.Now().Add(time.Minute)
+	rank := 0.1
+	touched.SearchRank = &rank
+	if c := NewChangeRecord(context.Background(), EntityBacklogItem, before.ID, ChangeUpdated, before, &touched); c != nil {
+		t.Errorf("expected no change for updated_at and search_rank alone, got %v", c.Changes)
+	}
+	if c := NewChangeRecord(context.Background(), EntityTask, uuid.New(), ChangeCreated, nil, &Task{Title: "T"}); c == nil || c.Actor != SystemActor {
+		t.Errorf("expected a created record by the system actor, got %+v", c)
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	v1 := &BacklogItem{ID: id, Title: "Draft", Status: BacklogStatusBacklog}
+	v2 := &BacklogItem{ID: id, Title: "Draft", Status: BacklogStatusReady, AssignedTo: "bob"}
+	v3 := &BacklogItem{ID: id, Title: "Final", Status: BacklogStatusReady, AssignedTo: "bob"}
+
+	record := func(action string, before, after *BacklogItem, at time.Time) *ChangeRecord {
+		c := NewChangeRecord(context.Background(), EntityBacklogItem, id, action, before, after)
+		c.CreatedAt = at
+		return c
+	}
+	changes := []*ChangeRecord{
+		record(ChangeUpdated, v2, v3, created.Add(2*time.Hour)),
+		record(ChangeUpdated, v1, v2, created.Add(time.Hour)),
+		record(ChangeCreated, nil, v1, created),
+	}
+
+	tests := []struct {
+		at   time.Time
+		want *BacklogItem
+	}{
+		{created.Add(3 * time.Hour), v3},
+		{created.Add(90 * time.Minute), v2},
+		{created.Add(time.Minute), v1},
+		{created.Add(-time.Minute), nil},
+	}
+	for _, tt := range tests {
+		got, err := AsOf(v3, changes, tt.at)
+		if err != nil {
+			t.Fatalf("AsOf(%s): %v", tt.at, err)
+		}
+		if tt.want == nil {
+			if got != nil {
+				t.Errorf("AsOf(%s) = %+v, want nil before creation", tt.at, got)
+			}
+			continue
+		}
+		if got == nil || got.Title != tt.want.Title || got.Status != tt.want.Status || got.AssignedTo != tt.want.AssignedTo || got.ID != id {
+			t.Errorf("AsOf(%s) = %+v, want %+v", tt.at, got, tt.want)
+		}
+	}
+	// Fields without history are not reported with their current values.
+	now := time.Now()
+	task := &Task{ID: id, Title: "Build", LastHeartbeatAt: &now, LeaseExpiresAt: &now, Progress: &TaskProgress{Stage: "tests"}, UpdatedAt: now}
+	past, err := AsOf(task, nil, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if past.Title != "Build" || past.LastHeartbeatAt != nil || past.LeaseExpiresAt != nil || past.Progress != nil || !past.UpdatedAt.IsZero() {
+		t.Errorf("expected unversioned fields zeroed, got %+v", past)
+	}
+}
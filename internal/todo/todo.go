@@ -0,0 +1,242 @@
+// Package todo reads and writes backlog items as a Markdown TODO list: one
+// checkbox per item under a heading per domain, with an emoji for its
+// priority.
+//
+//	## Infrastructure
+//	- [ ] 🔴 Rotate the database credentials <!-- id: todo-rotate-creds -->
+//	- [x] Move CI to the new runners
+package todo
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// priorities maps each priority emoji to the urgency it stands for, most
+// urgent first.
+var priorities = []struct {
+	emoji   string
+	urgency float64
+}{
+	{"🔴", 0.95}, // P0
+	{"🟠", 0.75}, // P1
+	{"🟡", 0.50}, // P2
+	{"🟢", 0.25}, // P3
+}
+
+// PersonalSections are the sections of a personal TODO.md that are not
+// backlog work.
+var PersonalSections = []string{"personal", "career", "health", "growth"}
+
+// idComment carries an item's external ID through an edit of the file.
+var idComment = regexp.MustCompile(`\s*<!--\s*id:\s*(\S+?)\s*-->`)
+
+// Item is one checkbox from a TODO list.
+type Item struct {
+	Title      string
+	Section    string // the heading the item is under, lower-cased
+	Domain     string // derived from Section
+	Done       bool
+	Urgency    *float64 // from the priority emoji, if any
+	ExternalID string   // from the id comment, else derived from Title
+}
+
+// Parse reads the checkbox items from a TODO list. Items without an id
+// comment are given an ID derived from their title, so parsing the same
+// list again gives the same IDs.
+func Parse(r io.Reader) ([]Item, error) {
+	var items []Item
+	var section string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "#") {
+			section = strings.ToLower(strings.TrimSpace(strings.TrimLeft(line, "#")))
+			continue
+		}
+
+		var text string
+		var done bool
+		switch {
+		case strings.HasPrefix(line, "- [ ] "):
+			text = strings.TrimPrefix(line, "- [ ] ")
+		case strings.HasPrefix(line, "- [x] "), strings.HasPrefix(line, "- [X] "):
+			text, done = line[len("- [x] "):], true
+		default:
+			continue
+		}
+
+		item := Item{Section: section, Domain: DeriveDomain(section), Done: done}
+		if m := idComment.FindStringSubmatch(text); m != nil {
+			item.ExternalID = m[1]
+			text = idComment.ReplaceAllString(text, "")
+		}
+		for _, p := range priorities {
+			if strings.Contains(text, p.emoji) {
+				u := p.urgency
+				item.Urgency = &u
+				text = strings.ReplaceAll(text, p.emoji, "")
+				break
+			}
+		}
+		item.Title = strings.TrimSpace(text)
+		if item.Title == "" {
+			continue
+		}
+		if item.ExternalID == "" {
+			item.ExternalID = "todo-" + slug(item.Title)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read todo list: %w", err)
+	}
+	return items, nil
+}
+
+// WithoutSections drops the items under any section whose heading contains
+// one of sections.
+func WithoutSections(items []Item, sections []string) []Item {
+	var out []Item
+	for _, item := range items {
+		skip := false
+		for _, s := range sections {
+			if strings.Contains(item.Section, strings.ToLower(s)) {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// DeriveDomain maps a section heading to the backlog domain its items
+// belong to. Unrecognised headings are their own domain.
+func DeriveDomain(section string) string {
+	section = strings.ToLower(section)
+	switch {
+	case strings.Contains(section, "infra"):
+		return "infrastructure"
+	case strings.Contains(section, "product"):
+		return "product"
+	case strings.Contains(section, "ops") || strings.Contains(section, "operation"):
+		return "operations"
+	case strings.Contains(section, "research"):
+		return "research"
+	case strings.Contains(section, "agent"):
+		return "agents"
+	case strings.Contains(section, "api"):
+		return "api"
+	case strings.Contains(section, "security"):
+		return "security"
+	default:
+		return section
+	}
+}
+
+// Write writes items as a TODO list that Parse reads back. Items without a
+// domain come first, then a section per domain in the order the domains
+// first appear. Urgency is written as the nearest priority emoji.
+func Write(w io.Writer, items []Item) error {
+	var domains []string
+	byDomain := make(map[string][]Item)
+	for _, item := range items {
+		if _, ok := byDomain[item.Domain]; !ok && item.Domain != "" {
+			domains = append(domains, item.Domain)
+		}
+		byDomain[item.Domain] = append(byDomain[item.Domain], item)
+	}
+
+	bw := bufio.NewWriter(w)
+	first := true
+	writeSection := func(heading string, items []Item) {
+		if heading != "" {
+			if !first {
+				bw.WriteString("\n")
+			}
+			fmt.Fprintf(bw, "## %s\n\n", heading)
+		}
+		first = false
+		for _, item := range items {
+			writeItem(bw, item)
+		}
+	}
+	if len(byDomain[""]) > 0 {
+		writeSection("", byDomain[""])
+	}
+	for _, d := range domains {
+		writeSection(d, byDomain[d])
+	}
+	return bw.Flush()
+}
+
+func writeItem(w *bufio.Writer, item Item) {
+	box := "[ ]"
+	if item.Done {
+		box = "[x]"
+	}
+	fmt.Fprintf(w, "- %s ", box)
+	if item.Urgency != nil {
+		fmt.Fprintf(w, "%s ", emoji(*item.Urgency))
+	}
+	w.WriteString(item.Title)
+	if item.ExternalID != "" {
+		fmt.Fprintf(w, " <!-- id: %s -->", item.ExternalID)
+	}
+	w.WriteString("\n")
+}
+
+// emoji returns the priority emoji whose urgency is nearest to urgency.
+func emoji(urgency float64) string {
+	best := priorities[0]
+	for _, p := range priorities[1:] {
+		if abs(urgency-p.urgency) < abs(urgency-best.urgency) {
+			best = p
+		}
+	}
+	return best.emoji
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// slug reduces title to lower-case letters and digits separated by single
+// dashes, at most 60 characters long. A title with neither is hashed
+// instead.
+func slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		h := fnv.New32a()
+		h.Write([]byte(title))
+		return fmt.Sprintf("%08x", h.Sum32())
+	}
+	return b.String()
+}
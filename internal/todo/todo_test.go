@@ -0,0 +1,99 @@
+package todo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const list = `# TODO
+
+## Infrastructure
+- [ ] 🔴 Rotate the database credentials
+- [x] Move CI to the new runners <!-- id: ci-runners -->
+- not a checkbox
+
+## Personal / Career
+- [ ] 🟢 Update CV
+
+## Agent Platform
+- [ ] 🟡 Retry failed handoffs
+- [X] 🟠
+`
+
+func TestParse(t *testing.T) {
+	items, err := Parse(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("parsed %d items, want 4: %+v", len(items), items)
+	}
+
+	rotate := items[0]
+	if rotate.Title != "Rotate the database credentials" || rotate.Domain != "infrastructure" || rotate.Done {
+		t.Errorf("unexpected item: %+v", rotate)
+	}
+	if rotate.Urgency == nil || *rotate.Urgency != 0.95 {
+		t.Errorf("urgency = %v, want 0.95", rotate.Urgency)
+	}
+	if rotate.ExternalID != "todo-rotate-the-database-credentials" {
+		t.Errorf("derived external id = %q", rotate.ExternalID)
+	}
+
+	ci := items[1]
+	if ci.Title != "Move CI to the new runners" || !ci.Done || ci.Urgency != nil || ci.ExternalID != "ci-runners" {
+		t.Errorf("unexpected item: %+v", ci)
+	}
+	if items[3].Domain != "agents" || *items[3].Urgency != 0.50 {
+		t.Errorf("unexpected item: %+v", items[3])
+	}
+
+	work := WithoutSections(items, PersonalSections)
+	if len(work) != 3 {
+		t.Fatalf("%d items left without personal sections, want 3", len(work))
+	}
+	for _, item := range work {
+		if item.Title == "Update CV" {
+			t.Error("personal item kept")
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	high, low := 0.9, 0.3
+	items := []Item{
+		{Title: "Loose end", ExternalID: "loose"},
+		{Title: "Harden the API", Domain: "security", Urgency: &high, ExternalID: "api-hardening"},
+		{Title: "Write runbook", Domain: "operations", Done: true, Urgency: &low, ExternalID: "runbook"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, items); err != nil {
+		t.Fatal(err)
+	}
+	want := "- [ ] Loose end <!-- id: loose -->\n" +
+		"\n## security\n\n" +
+		"- [ ] 🔴 Harden the API <!-- id: api-hardening -->\n" +
+		"\n## operations\n\n" +
+		"- [x] 🟢 Write runbook <!-- id: runbook -->\n"
+	if buf.String() != want {
+		t.Fatalf("wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 3 {
+		t.Fatalf("parsed %d items, want 3", len(parsed))
+	}
+	for i, item := range parsed {
+		if item.Title != items[i].Title || item.Domain != items[i].Domain || item.Done != items[i].Done || item.ExternalID != items[i].ExternalID {
+			t.Errorf("item %d = %+v, want %+v", i, item, items[i])
+		}
+	}
+	if *parsed[1].Urgency != 0.95 || *parsed[2].Urgency != 0.25 {
+		t.Errorf("urgencies not mapped to the nearest emoji: %v, %v", *parsed[1].Urgency, *parsed[2].Urgency)
+	}
+}
@@ -0,0 +1,5 @@
+-- 011_task_liveness.sql
+-- Structured progress snapshot and heartbeat tracking for liveness-based timeouts.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS progress JSONB;
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS last_heartbeat_at TIMESTAMPTZ;
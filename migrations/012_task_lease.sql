@@ -0,0 +1,5 @@
+-- 012_task_lease.sql
+-- Explicit acknowledgement and lease tracking for assigned tasks.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS acked_at TIMESTAMPTZ;
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- 013_task_sub_state.sql
+-- Sub-state for assigned tasks (e.g. 'waking' while the agent is woken).
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS sub_state TEXT;
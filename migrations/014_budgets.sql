@@ -0,0 +1,21 @@
+-- 014_budgets.sql
+-- Per-owner and per-source budgets on task count, tokens and USD.
+
+CREATE TABLE IF NOT EXISTS dispatch_budgets (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scope                TEXT NOT NULL,                 -- owner, source
+    subject              TEXT NOT NULL,                 -- owner or source name
+    period               TEXT NOT NULL,                 -- day, month (UTC)
+    max_tasks            INTEGER,
+    max_tokens           BIGINT,
+    max_cost_usd         DOUBLE PRECISION,
+    action               TEXT NOT NULL DEFAULT 'reject', -- reject, queue, downgrade
+    alerted_threshold    INTEGER NOT NULL DEFAULT 0,    -- highest threshold alerted this period
+    alerted_period_start TIMESTAMPTZ,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (scope, subject, period)
+);
+
+CREATE INDEX IF NOT EXISTS idx_swarm_tasks_owner_created ON swarm_tasks (owner, created_at);
+CREATE INDEX IF NOT EXISTS idx_swarm_tasks_source_created ON swarm_tasks (source, created_at);
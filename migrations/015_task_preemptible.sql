@@ -0,0 +1,4 @@
+-- 015_task_preemptible.sql
+-- Tasks flagged preemptible may be checkpointed and requeued for urgent work.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS preemptible BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,8 @@
+-- 016_agent_limits.sql
+-- Admin overrides for per-agent concurrency limits.
+
+CREATE TABLE IF NOT EXISTS agent_concurrency_limits (
+    agent_slug     TEXT PRIMARY KEY,
+    max_concurrent INTEGER NOT NULL CHECK (max_concurrent >= 0),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
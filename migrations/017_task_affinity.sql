@@ -0,0 +1,9 @@
+-- 017_task_affinity.sql
+-- Agent placement constraints: pinning, preferences, exclusions and affinity groups.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS preferred_agents TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS required_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS excluded_agents TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS affinity_group TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_swarm_tasks_affinity_group ON swarm_tasks (affinity_group, assigned_at) WHERE affinity_group <> '';
@@ -0,0 +1,23 @@
+-- 018_schedules.sql
+-- Recurring tasks materialised from a cron expression and a task template.
+
+CREATE TABLE IF NOT EXISTS dispatch_schedules (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name          TEXT NOT NULL UNIQUE,
+    cron          TEXT NOT NULL,
+    timezone      TEXT NOT NULL DEFAULT 'UTC',
+    template      JSONB NOT NULL,
+    overlap       TEXT NOT NULL DEFAULT 'skip',  -- skip, queue, allow
+    catch_up      TEXT NOT NULL DEFAULT '',      -- none, latest, all; '' uses config
+    paused        BOOLEAN NOT NULL DEFAULT false,
+    next_run_at   TIMESTAMPTZ NOT NULL,
+    queued_run_at TIMESTAMPTZ,
+    last_run_at   TIMESTAMPTZ,
+    last_task_id  UUID REFERENCES swarm_tasks(task_id) ON DELETE SET NULL,
+    skipped_runs  INTEGER NOT NULL DEFAULT 0,
+    missed_runs   INTEGER NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispatch_schedules_next_run ON dispatch_schedules (next_run_at) WHERE NOT paused;
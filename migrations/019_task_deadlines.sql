@@ -0,0 +1,8 @@
+-- 019_task_deadlines.sql
+-- Optional task deadlines, with when the broker flagged a task at risk and recorded a miss.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS deadline TIMESTAMPTZ;
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS sla_at_risk_at TIMESTAMPTZ;
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS deadline_missed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_swarm_tasks_deadline ON swarm_tasks (deadline) WHERE deadline IS NOT NULL;
@@ -0,0 +1,4 @@
+-- 020_task_escalation.sql
+-- Minimum model tier for tasks escalated after repeated failures.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS min_model_tier TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- 021_failure_classes.sql
+-- Structured failure classes on tasks and agent history.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS failure_class TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE agent_task_history ADD COLUMN IF NOT EXISTS failure_class TEXT;
+CREATE INDEX IF NOT EXISTS idx_agent_task_history_failure_class ON agent_task_history (agent_slug, failure_class);
@@ -0,0 +1,7 @@
+-- 022_backlog_tasks.sql
+-- Links swarm tasks dispatched for a backlog item's stage back to the item.
+
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS backlog_item_id UUID REFERENCES backlog_items(id) ON DELETE SET NULL;
+ALTER TABLE swarm_tasks ADD COLUMN IF NOT EXISTS backlog_stage TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_swarm_tasks_backlog_item ON swarm_tasks (backlog_item_id) WHERE backlog_item_id IS NOT NULL;
@@ -0,0 +1,15 @@
+-- 023_backlog_rescoring.sql
+-- Due dates for backlog items, and the score changes made by periodic rescoring.
+
+ALTER TABLE backlog_items ADD COLUMN IF NOT EXISTS due_date TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS backlog_score_history (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    backlog_item_id UUID NOT NULL REFERENCES backlog_items(id) ON DELETE CASCADE,
+    previous_score  DOUBLE PRECISION,
+    new_score       DOUBLE PRECISION NOT NULL,
+    reason          TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_backlog_score_history_item ON backlog_score_history (backlog_item_id, created_at DESC);
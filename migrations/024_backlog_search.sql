@@ -0,0 +1,17 @@
+-- 024_backlog_search.sql
+-- Full-text search over backlog items, and indexes for filtering and keyset pagination.
+
+ALTER TABLE backlog_items ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_backlog_items_search ON backlog_items USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_backlog_items_labels ON backlog_items USING GIN (labels);
+
+-- One index per sort order, matching ORDER BY <key> NULLS LAST, created_at, id.
+CREATE INDEX IF NOT EXISTS idx_backlog_items_sort_priority ON backlog_items (priority_score DESC NULLS LAST, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_backlog_items_sort_created ON backlog_items (created_at DESC, id);
+CREATE INDEX IF NOT EXISTS idx_backlog_items_sort_updated ON backlog_items (updated_at DESC NULLS LAST, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_backlog_items_sort_due ON backlog_items (due_date ASC NULLS LAST, created_at, id);
@@ -0,0 +1,16 @@
+-- 025_change_history.sql
+-- Field-level history of writes to backlog items and swarm tasks.
+
+CREATE TABLE IF NOT EXISTS change_history (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('backlog_item', 'task')),
+    entity_id   UUID NOT NULL,
+    action      TEXT NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    actor       TEXT NOT NULL,
+    changes     JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+-- clock_timestamp() keeps the changes made within one transaction in order.
+-- No foreign key: the history of a deleted item outlives it.
+CREATE INDEX IF NOT EXISTS idx_change_history_entity ON change_history (entity_type, entity_id, created_at DESC);
@@ -0,0 +1,7 @@
+-- 026_backlog_external_id.sql
+-- Identifies backlog items outside Dispatch, so re-importing a file updates
+-- the items it created instead of duplicating them.
+
+ALTER TABLE backlog_items ADD COLUMN IF NOT EXISTS external_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_backlog_items_external_id ON backlog_items (external_id) WHERE external_id IS NOT NULL;
@@ -0,0 +1,24 @@
+-- 027_iterations.sql
+-- Iterations: planning horizons with a capacity, and the backlog items committed to them.
+
+CREATE TABLE IF NOT EXISTS iterations (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name          TEXT NOT NULL,
+    starts_at     TIMESTAMPTZ NOT NULL,
+    ends_at       TIMESTAMPTZ NOT NULL,
+    capacity      DOUBLE PRECISION NOT NULL CHECK (capacity > 0),
+    capacity_unit TEXT NOT NULL CHECK (capacity_unit IN ('tokens', 'points')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS iteration_items (
+    iteration_id    UUID NOT NULL REFERENCES iterations(id) ON DELETE CASCADE,
+    backlog_item_id UUID NOT NULL REFERENCES backlog_items(id) ON DELETE CASCADE,
+    committed_by    TEXT NOT NULL,
+    committed_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (iteration_id, backlog_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_iteration_items_item ON iteration_items (backlog_item_id);
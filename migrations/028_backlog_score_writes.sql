@@ -0,0 +1,16 @@
+-- 028_backlog_score_writes.sql
+-- Lets a rescore write a backlog item's priority score without bumping
+-- updated_at, so aging scores neither looks like an edit nor conflicts with
+-- one. The writer sets dispatch.keep_updated_at for its transaction.
+
+CREATE OR REPLACE FUNCTION update_backlog_items_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF current_setting('dispatch.keep_updated_at', true) = 'on' THEN
+        NEW.updated_at = OLD.updated_at;
+    ELSE
+        NEW.updated_at = NOW();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;